@@ -0,0 +1,205 @@
+// Command smoketest runs an end-to-end check of a deployed stack through its HTTP
+// entry points: it creates two users, has one follow the other, posts as the
+// followed user and waits for the post to appear in the follower's timeline.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// smokeTest holds the endpoints and state shared by the steps
+type smokeTest struct {
+	client         *http.Client
+	gatewayURL     string
+	socialGraphURL string
+	timelineURL    string
+	timelineWait   time.Duration
+	pollInterval   time.Duration
+
+	// Filled in as steps succeed
+	followerID int64
+	authorID   int64
+	content    string
+}
+
+// step is one named stage of the smoke test
+type step struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+func main() {
+	gatewayURL := flag.String("gateway", "http://localhost:3000", "Gateway base URL (users and posts)")
+	socialGraphURL := flag.String("social-graph", "", "Social Graph base URL (defaults to -gateway)")
+	timelineURL := flag.String("timeline", "", "Timeline base URL (defaults to -gateway)")
+	requestTimeout := flag.Duration("request-timeout", 10*time.Second, "Timeout for each HTTP request")
+	timelineWait := flag.Duration("timeline-wait", 30*time.Second, "How long to wait for the post to reach the timeline")
+	pollInterval := flag.Duration("poll-interval", time.Second, "Delay between timeline reads while waiting")
+	flag.Parse()
+
+	t := &smokeTest{
+		client:         &http.Client{Timeout: *requestTimeout},
+		gatewayURL:     strings.TrimRight(*gatewayURL, "/"),
+		socialGraphURL: strings.TrimRight(*socialGraphURL, "/"),
+		timelineURL:    strings.TrimRight(*timelineURL, "/"),
+		timelineWait:   *timelineWait,
+		pollInterval:   *pollInterval,
+	}
+	if t.socialGraphURL == "" {
+		t.socialGraphURL = t.gatewayURL
+	}
+	if t.timelineURL == "" {
+		t.timelineURL = t.gatewayURL
+	}
+
+	// Unique per run so reruns against the same stack don't collide
+	runID := time.Now().UnixNano() % 1_000_000_000
+	followerName := fmt.Sprintf("smoke_f_%d", runID)
+	authorName := fmt.Sprintf("smoke_a_%d", runID)
+	t.content = fmt.Sprintf("smoke test post %d", runID)
+
+	steps := []step{
+		{"create follower user", func(ctx context.Context) error { return t.createUser(ctx, followerName, &t.followerID) }},
+		{"create author user", func(ctx context.Context) error { return t.createUser(ctx, authorName, &t.authorID) }},
+		{"follow author", t.follow},
+		{"create post", t.createPost},
+		{"post appears in timeline", t.waitForTimeline},
+	}
+
+	log.Printf("Running smoke test against gateway %s (social graph %s, timeline %s)",
+		t.gatewayURL, t.socialGraphURL, t.timelineURL)
+
+	start := time.Now()
+	for i, s := range steps {
+		stepStart := time.Now()
+		err := s.run(context.Background())
+		elapsed := time.Since(stepStart).Round(time.Millisecond)
+		if err != nil {
+			log.Printf("✗ [%d/%d] %s (%v): %v", i+1, len(steps), s.name, elapsed, err)
+			for _, skipped := range steps[i+1:] {
+				log.Printf("- skipped: %s", skipped.name)
+			}
+			log.Printf("\nSmoke test FAILED after %v", time.Since(start).Round(time.Millisecond))
+			os.Exit(1)
+		}
+		log.Printf("✓ [%d/%d] %s (%v)", i+1, len(steps), s.name, elapsed)
+	}
+
+	log.Printf("\n✓ Smoke test passed in %v", time.Since(start).Round(time.Millisecond))
+}
+
+// createUser registers a user through the gateway and stores its ID
+func (t *smokeTest) createUser(ctx context.Context, username string, userID *int64) error {
+	var resp struct {
+		UserID int64 `json:"user_id"`
+	}
+	if err := t.do(ctx, http.MethodPost, t.gatewayURL+"/api/users", map[string]string{"username": username}, http.StatusCreated, &resp); err != nil {
+		return err
+	}
+	if resp.UserID <= 0 {
+		return fmt.Errorf("response has no user_id")
+	}
+	*userID = resp.UserID
+	return nil
+}
+
+// follow makes the follower follow the author
+func (t *smokeTest) follow(ctx context.Context) error {
+	body := map[string]string{
+		"follower_user_id": fmt.Sprintf("%d", t.followerID),
+		"target_user_id":   fmt.Sprintf("%d", t.authorID),
+		"action":           "follow",
+	}
+	return t.do(ctx, http.MethodPost, t.socialGraphURL+"/api/social-graph/follow", body, http.StatusCreated, nil)
+}
+
+// createPost publishes the test post as the author
+func (t *smokeTest) createPost(ctx context.Context) error {
+	body := map[string]any{
+		"user_id": t.authorID,
+		"content": t.content,
+	}
+	return t.do(ctx, http.MethodPost, t.gatewayURL+"/api/posts", body, http.StatusOK, nil)
+}
+
+// waitForTimeline polls the follower's timeline until the post shows up
+// Push fan-out is asynchronous, so the post may take a few seconds to arrive
+func (t *smokeTest) waitForTimeline(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timelineWait)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/api/timeline/%d", t.timelineURL, t.followerID)
+	for {
+		var resp struct {
+			Timeline []struct {
+				AuthorID int64  `json:"author_id"`
+				Content  string `json:"content"`
+			} `json:"timeline"`
+		}
+		err := t.do(ctx, http.MethodGet, url, nil, http.StatusOK, &resp)
+		if err == nil {
+			for _, post := range resp.Timeline {
+				if post.AuthorID == t.authorID && post.Content == t.content {
+					return nil
+				}
+			}
+			err = fmt.Errorf("post not in timeline (%d entries)", len(resp.Timeline))
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up after %v: %w", t.timelineWait, err)
+		case <-time.After(t.pollInterval):
+		}
+	}
+}
+
+// do sends a JSON request, checks the status code and decodes the response into out
+func (t *smokeTest) do(ctx context.Context, method, url string, body any, wantStatus int, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s %s: failed to read response: %w", method, url, err)
+	}
+	if resp.StatusCode != wantStatus {
+		return fmt.Errorf("%s %s: got status %d, want %d: %s", method, url, resp.StatusCode, wantStatus, strings.TrimSpace(string(respBody)))
+	}
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("%s %s: failed to decode response: %w", method, url, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Package dynamotimeout bounds each DynamoDB operation, retries included, with its own
+// deadline. The SDK has no such setting: without one, a call made with a context that
+// has no deadline, such as a background worker's, can hang on a stalled connection.
+// Operations cut off this way fail with an *OperationTimeoutError and are counted, so
+// a service can report them on its health endpoint.
+package dynamotimeout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// operationTimeouts counts DynamoDB operations cut off by the operation timeout since startup
+var operationTimeouts atomic.Int64
+
+// OperationTimeouts returns the number of DynamoDB operations that timed out
+func OperationTimeouts() int64 {
+	return operationTimeouts.Load()
+}
+
+// errOperationDeadline marks a context cancelled by the operation timeout rather than the caller
+var errOperationDeadline = errors.New("dynamodb operation timeout")
+
+// OperationTimeoutError is returned when a DynamoDB operation, retries included, runs past
+// the operation timeout. The caller's own deadline or cancellation is reported as before.
+type OperationTimeoutError struct {
+	Operation string
+	Timeout   time.Duration
+	Err       error
+}
+
+func (e *OperationTimeoutError) Error() string {
+	return fmt.Sprintf("dynamodb %s timed out after %s: %v", e.Operation, e.Timeout, e.Err)
+}
+
+func (e *OperationTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// WithOperationTimeout bounds every operation of a client to timeout, so a hung call
+// cannot block a caller whose context has no deadline. A timeout of 0 adds no bound.
+func WithOperationTimeout(timeout time.Duration) func(*dynamodb.Options) {
+	return func(o *dynamodb.Options) {
+		if timeout <= 0 {
+			return
+		}
+		// Added last in the first step, once the operation name is known; the
+		// deadline still covers serialization, signing, every retry and the response
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Initialize.Add(operationTimeout(timeout), middleware.After)
+		})
+	}
+}
+
+func operationTimeout(timeout time.Duration) middleware.InitializeMiddleware {
+	return middleware.InitializeMiddlewareFunc("OperationTimeout", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+		ctx, cancel := context.WithTimeoutCause(ctx, timeout, errOperationDeadline)
+		defer cancel()
+
+		out, metadata, err := next.HandleInitialize(ctx, in)
+		if err != nil && errors.Is(context.Cause(ctx), errOperationDeadline) {
+			operationTimeouts.Add(1)
+			err = &OperationTimeoutError{Operation: awsmiddleware.GetOperationName(ctx), Timeout: timeout, Err: err}
+		}
+		return out, metadata, err
+	})
+}
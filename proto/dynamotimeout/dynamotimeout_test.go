@@ -0,0 +1,129 @@
+package dynamotimeout
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// newClient returns a client for a DynamoDB endpoint that answers after delay, or when
+// the request is abandoned. deadline receives the deadline each attempt is sent with.
+func newClient(t *testing.T, timeout, delay time.Duration, deadline *time.Time) *dynamodb.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+
+	// Records the context the request is finally sent with, after every other middleware
+	recordDeadline := middleware.FinalizeMiddlewareFunc("RecordDeadline", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		*deadline, _ = ctx.Deadline()
+		return next.HandleFinalize(ctx, in)
+	})
+	return dynamodb.New(dynamodb.Options{
+		Region:           "us-west-2",
+		BaseEndpoint:     aws.String(server.URL),
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+		APIOptions: []func(*middleware.Stack) error{func(stack *middleware.Stack) error {
+			return stack.Finalize.Add(recordDeadline, middleware.After)
+		}},
+	}, WithOperationTimeout(timeout))
+}
+
+func describeTable(client *dynamodb.Client, ctx context.Context) error {
+	_, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String("posts")})
+	return err
+}
+
+func TestDeadlineAppliedAndPropagated(t *testing.T) {
+	var deadline time.Time
+	client := newClient(t, time.Minute, 0, &deadline)
+
+	start := time.Now()
+	if err := describeTable(client, context.Background()); err != nil {
+		t.Fatalf("DescribeTable: %v", err)
+	}
+	if deadline.IsZero() {
+		t.Fatal("request sent without a deadline")
+	}
+	if got := deadline.Sub(start); got <= 0 || got > time.Minute+time.Second {
+		t.Errorf("request deadline %s after the call, want within the 1m operation timeout", got)
+	}
+
+	// A caller's earlier deadline is kept
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	callerDeadline, _ := ctx.Deadline()
+	if err := describeTable(client, ctx); err != nil {
+		t.Fatalf("DescribeTable: %v", err)
+	}
+	if !deadline.Equal(callerDeadline) {
+		t.Errorf("request deadline %s, want the caller's %s", deadline, callerDeadline)
+	}
+}
+
+func TestHungOperationTimesOut(t *testing.T) {
+	var deadline time.Time
+	client := newClient(t, 50*time.Millisecond, 2*time.Second, &deadline)
+	before := OperationTimeouts()
+
+	start := time.Now()
+	err := describeTable(client, context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("DescribeTable returned after %s, want about 50ms", elapsed)
+	}
+
+	var timeoutErr *OperationTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("error = %v, want *OperationTimeoutError", err)
+	}
+	if timeoutErr.Operation != "DescribeTable" || timeoutErr.Timeout != 50*time.Millisecond {
+		t.Errorf("error reports %s after %s, want DescribeTable after 50ms", timeoutErr.Operation, timeoutErr.Timeout)
+	}
+	if got := OperationTimeouts() - before; got != 1 {
+		t.Errorf("OperationTimeouts rose by %d, want 1", got)
+	}
+}
+
+func TestCallerDeadlineNotCountedAsTimeout(t *testing.T) {
+	var deadline time.Time
+	client := newClient(t, time.Minute, 2*time.Second, &deadline)
+	before := OperationTimeouts()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := describeTable(client, ctx)
+
+	var timeoutErr *OperationTimeoutError
+	if err == nil || errors.As(err, &timeoutErr) {
+		t.Fatalf("error = %v, want the caller's deadline reported as is", err)
+	}
+	if got := OperationTimeouts() - before; got != 0 {
+		t.Errorf("OperationTimeouts rose by %d, want 0", got)
+	}
+}
+
+func TestZeroTimeoutAddsNoDeadline(t *testing.T) {
+	var deadline time.Time
+	client := newClient(t, 0, 0, &deadline)
+	if err := describeTable(client, context.Background()); err != nil {
+		t.Fatalf("DescribeTable: %v", err)
+	}
+	if !deadline.IsZero() {
+		t.Errorf("request sent with deadline %s, want none", deadline)
+	}
+}
@@ -0,0 +1,55 @@
+// Package featureflags loads boolean feature toggles from FEATURE_<NAME> environment
+// variables. Flags are read once at startup into an immutable set, so a running service
+// never sees a toggle change; restart it to pick one up.
+package featureflags
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// envPrefix marks an environment variable as a feature flag
+const envPrefix = "FEATURE_"
+
+// Flags is an immutable set of feature toggles. The zero value has every feature disabled.
+type Flags struct {
+	enabled map[string]bool
+}
+
+// Load reads every FEATURE_<NAME>=<bool> variable from the environment.
+// Values that do not parse as a bool leave the feature disabled.
+func Load() Flags {
+	enabled := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, envPrefix) {
+			continue
+		}
+		if on, err := strconv.ParseBool(value); err == nil && on {
+			enabled[normalize(strings.TrimPrefix(key, envPrefix))] = true
+		}
+	}
+	return Flags{enabled: enabled}
+}
+
+// Enabled reports whether the named feature is on. Names are case-insensitive and
+// may use hyphens for underscores, so "self-follow" matches FEATURE_SELF_FOLLOW.
+func (f Flags) Enabled(name string) bool {
+	return f.enabled[normalize(name)]
+}
+
+// String lists the enabled features, for startup logging
+func (f Flags) String() string {
+	names := make([]string, 0, len(f.enabled))
+	for name := range f.enabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "[" + strings.Join(names, " ") + "]"
+}
+
+func normalize(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
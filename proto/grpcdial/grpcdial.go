@@ -0,0 +1,39 @@
+// Package grpcdial is the one place the gRPC keepalive contract between services is
+// defined. Servers enforce MinKeepaliveTime and close connections that ping more often,
+// so clients must validate their GRPC_KEEPALIVE_TIME against the same constant with
+// ValidateKeepalive at startup, and dial with Options.
+package grpcdial
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// MinKeepaliveTime is the shortest client ping interval servers accept;
+// pinging more often gets the connection closed with too_many_pings
+const MinKeepaliveTime = 10 * time.Second
+
+// Options returns the options shared by every outgoing gRPC client
+// Keepalive pings stop idle connections being silently dropped by NAT or load balancers
+func Options(keepaliveParams keepalive.ClientParameters) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithKeepaliveParams(keepaliveParams),
+	}
+}
+
+// ValidateKeepalive checks client keepalive settings, read from GRPC_KEEPALIVE_TIME and
+// GRPC_KEEPALIVE_TIMEOUT, against what the servers accept
+func ValidateKeepalive(params keepalive.ClientParameters) error {
+	if params.Time < MinKeepaliveTime {
+		return fmt.Errorf("invalid GRPC_KEEPALIVE_TIME %s: must be >= %s", params.Time, MinKeepaliveTime)
+	}
+	if params.Timeout <= 0 {
+		return fmt.Errorf("invalid GRPC_KEEPALIVE_TIMEOUT %s: must be > 0", params.Timeout)
+	}
+	return nil
+}
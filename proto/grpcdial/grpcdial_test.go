@@ -0,0 +1,28 @@
+package grpcdial
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/keepalive"
+)
+
+func TestValidateKeepalive(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  keepalive.ClientParameters
+		wantErr bool
+	}{
+		{name: "defaults", params: keepalive.ClientParameters{Time: 30 * time.Second, Timeout: 10 * time.Second}},
+		{name: "at the server minimum", params: keepalive.ClientParameters{Time: MinKeepaliveTime, Timeout: time.Second}},
+		{name: "pings too often", params: keepalive.ClientParameters{Time: MinKeepaliveTime - time.Millisecond, Timeout: time.Second}, wantErr: true},
+		{name: "no timeout", params: keepalive.ClientParameters{Time: time.Minute}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateKeepalive(tt.params); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateKeepalive(%+v) = %v, want error %t", tt.params, err, tt.wantErr)
+			}
+		})
+	}
+}
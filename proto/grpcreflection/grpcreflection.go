@@ -0,0 +1,38 @@
+// Package grpcreflection decides whether gRPC servers register the reflection service.
+// Reflection lets grpcurl list and call every method without the protos, which helps in
+// development but publishes the whole API schema, so outside dev it is off unless
+// enabled explicitly. Servers call Register once their own services are registered.
+package grpcreflection
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/reflection"
+)
+
+// envVar overrides the environment default when set to a bool
+const envVar = "GRPC_REFLECTION"
+
+// devEnvironments are the ENVIRONMENT values in which reflection is on by default.
+// An unset ENVIRONMENT counts as dev, matching the services' own default.
+var devEnvironments = map[string]bool{"": true, "dev": true, "development": true, "local": true}
+
+// Enabled reports whether reflection should be registered: GRPC_REFLECTION when it
+// parses as a bool, otherwise whether ENVIRONMENT is a dev environment
+func Enabled() bool {
+	if on, err := strconv.ParseBool(os.Getenv(envVar)); err == nil {
+		return on
+	}
+	return devEnvironments[strings.ToLower(os.Getenv("ENVIRONMENT"))]
+}
+
+// Register registers the reflection service on server if Enabled, and reports whether it did
+func Register(server reflection.GRPCServer) bool {
+	if !Enabled() {
+		return false
+	}
+	reflection.Register(server)
+	return true
+}
@@ -0,0 +1,91 @@
+// Package logthrottle rate-limits repeated log lines. During an outage the same error
+// can be hit on every request or poll; a Throttle writes the first few, drops the rest
+// for the interval, and reports how many it dropped on the next line it writes, so the
+// log still shows the error and its rate without burying everything else.
+package logthrottle
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Throttle writes at most burst lines per format string per interval. Lines over the
+// limit are dropped and counted, and the count is appended to the next line written for
+// that format. Lines are grouped by format, not by their formatted text, so one call
+// site counts as one message whatever its arguments.
+// A nil *Throttle is valid and writes every line, so callers need no special case when disabled.
+type Throttle struct {
+	burst    int
+	interval time.Duration
+	now      func() time.Time // Replaced in tests
+
+	mu      sync.Mutex
+	windows map[string]*window
+}
+
+type window struct {
+	start      time.Time
+	written    int
+	suppressed int // Dropped in this window
+	unreported int // Dropped in earlier windows, not yet mentioned in a written line
+}
+
+// New returns a throttle writing up to burst lines per format every interval,
+// or nil (no throttling) when burst or interval is not positive
+func New(burst int, interval time.Duration) *Throttle {
+	if burst <= 0 || interval <= 0 {
+		return nil
+	}
+	return &Throttle{
+		burst:    burst,
+		interval: interval,
+		now:      time.Now,
+		windows:  make(map[string]*window),
+	}
+}
+
+// Printf logs like log.Printf unless format has already been written burst times in
+// the current interval
+func (t *Throttle) Printf(format string, args ...any) {
+	if t == nil {
+		log.Printf(format, args...)
+		return
+	}
+
+	suppressed, ok := t.allow(format, t.now())
+	if !ok {
+		return
+	}
+	message := fmt.Sprintf(format, args...)
+	if suppressed > 0 {
+		message = fmt.Sprintf("%s (%d similar lines suppressed)", message, suppressed)
+	}
+	log.Print(message)
+}
+
+// allow reports whether a line for format may be written now, and how many lines for
+// it were suppressed since the last one written
+func (t *Throttle) allow(format string, now time.Time) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w, ok := t.windows[format]
+	if !ok {
+		w = &window{start: now}
+		t.windows[format] = w
+	}
+	if now.Sub(w.start) >= t.interval {
+		*w = window{start: now, unreported: w.unreported + w.suppressed}
+	}
+
+	if w.written >= t.burst {
+		w.suppressed++
+		return 0, false
+	}
+	w.written++
+	suppressed := w.unreported
+	w.unreported = 0
+	return suppressed, true
+}
@@ -0,0 +1,143 @@
+package logthrottle
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// captureLog returns the lines logged while fn runs
+func captureLog(t *testing.T, fn func()) []string {
+	t.Helper()
+	var buf bytes.Buffer
+	flags := log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+	}()
+	fn()
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+func newTestThrottle(burst int, interval time.Duration) (*Throttle, *time.Time) {
+	clock := time.Unix(1_700_000_000, 0)
+	th := New(burst, interval)
+	th.now = func() time.Time { return clock }
+	return th, &clock
+}
+
+func TestWindowBoundary(t *testing.T) {
+	th, clock := newTestThrottle(2, time.Minute)
+	start := *clock
+
+	tests := []struct {
+		after time.Duration
+		want  string // Empty when the line is dropped
+	}{
+		{after: 0, want: "error 1"},
+		{after: time.Second, want: "error 2"},
+		{after: 2 * time.Second, want: ""},
+		{after: time.Minute - time.Nanosecond, want: ""},
+		{after: time.Minute, want: "error 5 (2 similar lines suppressed)"},
+		{after: time.Minute + time.Second, want: "error 6"},
+		{after: time.Minute + 2*time.Second, want: ""},
+	}
+	for i, tt := range tests {
+		*clock = start.Add(tt.after)
+		got := captureLog(t, func() { th.Printf("error %d", i+1) })[0]
+		if got != tt.want {
+			t.Errorf("line %d after %s: logged %q, want %q", i+1, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestSuppressedCountCarriesAcrossQuietWindows(t *testing.T) {
+	th, clock := newTestThrottle(1, time.Minute)
+	captureLog(t, func() {
+		for i := 0; i < 4; i++ { // 1 written, 3 dropped
+			th.Printf("timeout")
+		}
+	})
+
+	// A whole window passes with no lines; the next line still reports the 3
+	*clock = clock.Add(3 * time.Minute)
+	lines := captureLog(t, func() {
+		th.Printf("timeout")
+		th.Printf("timeout")
+	})
+	if len(lines) != 1 || lines[0] != "timeout (3 similar lines suppressed)" {
+		t.Errorf("logged %q, want one line reporting 3 suppressed", lines)
+	}
+
+	// Reported once, then the count starts over
+	*clock = clock.Add(time.Minute)
+	lines = captureLog(t, func() { th.Printf("timeout") })
+	if lines[0] != "timeout (1 similar lines suppressed)" {
+		t.Errorf("logged %q, want 1 suppressed", lines[0])
+	}
+}
+
+func TestFormatsThrottledSeparately(t *testing.T) {
+	th, _ := newTestThrottle(1, time.Minute)
+	lines := captureLog(t, func() {
+		th.Printf("read failed for user %d", 1)
+		th.Printf("read failed for user %d", 2) // Same format: dropped
+		th.Printf("write failed for user %d", 1)
+	})
+	want := []string{"read failed for user 1", "write failed for user 1"}
+	if fmt.Sprint(lines) != fmt.Sprint(want) {
+		t.Errorf("logged %q, want %q", lines, want)
+	}
+}
+
+func TestNilThrottleWritesEverything(t *testing.T) {
+	th := New(0, time.Minute)
+	if th != nil {
+		t.Fatalf("New(0, 1m) = %v, want nil", th)
+	}
+	lines := captureLog(t, func() {
+		for i := 0; i < 3; i++ {
+			th.Printf("line %d", i)
+		}
+	})
+	if len(lines) != 3 {
+		t.Errorf("logged %d lines, want 3", len(lines))
+	}
+}
+
+func TestConcurrentCallers(t *testing.T) {
+	const burst, callers, perCaller = 5, 8, 100
+	th, clock := newTestThrottle(burst, time.Minute)
+
+	lines := captureLog(t, func() {
+		var wg sync.WaitGroup
+		for c := 0; c < callers; c++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < perCaller; i++ {
+					th.Printf("caller %d failed", c)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+	if len(lines) != burst {
+		t.Errorf("logged %d lines, want the burst of %d", len(lines), burst)
+	}
+
+	// Every dropped line is accounted for in the next window
+	*clock = clock.Add(time.Minute)
+	lines = captureLog(t, func() { th.Printf("caller %d failed", 0) })
+	want := fmt.Sprintf("caller 0 failed (%d similar lines suppressed)", callers*perCaller-burst)
+	if lines[0] != want {
+		t.Errorf("logged %q, want %q", lines[0], want)
+	}
+}
@@ -0,0 +1,132 @@
+// Package lru is a bounded least-recently-used cache whose entries also expire after a
+// TTL. The bound is a total weight: one per entry by default, or a per-value weight such
+// as a list's length, so a few large values cannot hold more memory than many small ones.
+// Every operation is O(1); nothing sweeps the whole cache.
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache maps keys to values, evicting the least recently used entries once the total
+// weight would exceed capacity. Entries past their TTL are never returned.
+// A nil *Cache is valid and caches nothing, so callers need no special case when disabled.
+type Cache[K comparable, V any] struct {
+	capacity int
+	ttl      time.Duration
+	weigh    func(V) int
+	now      func() time.Time // Replaced in tests
+
+	mu      sync.Mutex
+	order   *list.List // Front is most recently used
+	entries map[K]*list.Element
+	weight  int
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	weight    int
+	expiresAt time.Time
+}
+
+// New returns a cache of up to capacity entries, each kept for ttl,
+// or nil (caching disabled) when capacity or ttl is not positive
+func New[K comparable, V any](capacity int, ttl time.Duration) *Cache[K, V] {
+	return NewWeighted[K, V](capacity, ttl, func(V) int { return 1 })
+}
+
+// NewWeighted returns a cache whose entries' weights, as reported by weigh, add up to at
+// most capacity, or nil (caching disabled) when capacity or ttl is not positive.
+// A value weighing more than capacity on its own is not cached.
+func NewWeighted[K comparable, V any](capacity int, ttl time.Duration, weigh func(V) int) *Cache[K, V] {
+	if capacity <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &Cache[K, V]{
+		capacity: capacity,
+		ttl:      ttl,
+		weigh:    weigh,
+		now:      time.Now,
+		order:    list.New(),
+		entries:  make(map[K]*list.Element),
+	}
+}
+
+// Get returns the value cached for key and marks it recently used.
+// An expired entry is dropped and reported as missing.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	var zero V
+	if c == nil {
+		return zero, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	e := el.Value.(*entry[K, V])
+	if c.now().After(e.expiresAt) {
+		c.remove(el)
+		return zero, false
+	}
+	c.order.MoveToFront(el)
+	return e.value, true
+}
+
+// Set caches value for key for the TTL, evicting least recently used entries until it fits
+func (c *Cache[K, V]) Set(key K, value V) {
+	if c == nil {
+		return
+	}
+
+	weight := c.weigh(value)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	if weight > c.capacity {
+		return
+	}
+	for c.weight+weight > c.capacity {
+		c.remove(c.order.Back())
+	}
+	c.entries[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, weight: weight, expiresAt: c.now().Add(c.ttl)})
+	c.weight += weight
+}
+
+// Remove drops key from the cache
+func (c *Cache[K, V]) Remove(key K) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Len returns the number of entries held, including expired ones not yet dropped
+func (c *Cache[K, V]) Len() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// remove drops an entry. Callers must hold c.mu
+func (c *Cache[K, V]) remove(el *list.Element) {
+	e := c.order.Remove(el).(*entry[K, V])
+	delete(c.entries, e.key)
+	c.weight -= e.weight
+}
@@ -0,0 +1,161 @@
+package lru
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable clock for expiry tests
+type fakeClock struct{ t time.Time }
+
+func (f *fakeClock) now() time.Time { return f.t }
+
+func newTestCache(capacity int, ttl time.Duration) (*Cache[int, string], *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	c := New[int, string](capacity, ttl)
+	c.now = clock.now
+	return c, clock
+}
+
+func TestNewDisabled(t *testing.T) {
+	for _, tt := range []struct {
+		capacity int
+		ttl      time.Duration
+	}{
+		{capacity: 0, ttl: time.Minute},
+		{capacity: 10, ttl: 0},
+		{capacity: -1, ttl: -time.Second},
+	} {
+		c := New[int, string](tt.capacity, tt.ttl)
+		if c != nil {
+			t.Errorf("New(%d, %s) = %v, want nil", tt.capacity, tt.ttl, c)
+		}
+		// A nil cache caches nothing but is safe to use
+		c.Set(1, "a")
+		c.Remove(1)
+		if _, ok := c.Get(1); ok || c.Len() != 0 {
+			t.Errorf("nil cache returned an entry")
+		}
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	c, clock := newTestCache(10, time.Minute)
+	c.Set(1, "a")
+
+	tests := []struct {
+		after time.Duration
+		want  bool
+	}{
+		{after: 0, want: true},
+		{after: time.Minute, want: true},
+		{after: time.Minute + time.Nanosecond, want: false},
+	}
+	for _, tt := range tests {
+		clock.t = time.Unix(1_700_000_000, 0).Add(tt.after)
+		if _, ok := c.Get(1); ok != tt.want {
+			t.Errorf("Get after %s: found %t, want %t", tt.after, ok, tt.want)
+		}
+	}
+	if c.Len() != 0 {
+		t.Errorf("expired entry still held: Len = %d", c.Len())
+	}
+}
+
+func TestSetRefreshesExpiry(t *testing.T) {
+	c, clock := newTestCache(10, time.Minute)
+	c.Set(1, "a")
+	clock.t = clock.t.Add(50 * time.Second)
+	c.Set(1, "b")
+	clock.t = clock.t.Add(50 * time.Second)
+	if got, ok := c.Get(1); !ok || got != "b" {
+		t.Errorf("Get = %q, %t, want b, true", got, ok)
+	}
+}
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	c, _ := newTestCache(3, time.Minute)
+	c.Set(1, "a")
+	c.Set(2, "b")
+	c.Set(3, "c")
+	c.Get(1) // 2 is now the least recently used
+	c.Set(4, "d")
+
+	for key, want := range map[int]bool{1: true, 2: false, 3: true, 4: true} {
+		if _, ok := c.Get(key); ok != want {
+			t.Errorf("Get(%d) found %t, want %t", key, ok, want)
+		}
+	}
+	if c.Len() != 3 {
+		t.Errorf("Len = %d, want 3", c.Len())
+	}
+}
+
+func TestWeightedEviction(t *testing.T) {
+	c := NewWeighted[string, []int](10, time.Minute, func(v []int) int { return len(v) })
+
+	c.Set("small", make([]int, 2))
+	c.Set("medium", make([]int, 5))
+	c.Set("large", make([]int, 6)) // 13 > 10: evicts small, then medium
+	if _, ok := c.Get("small"); ok {
+		t.Error("small not evicted")
+	}
+	if _, ok := c.Get("medium"); ok {
+		t.Error("medium not evicted")
+	}
+	if _, ok := c.Get("large"); !ok {
+		t.Error("large not cached")
+	}
+
+	// Too heavy to cache at all; an older value for the key is dropped too
+	c.Set("large", make([]int, 11))
+	if _, ok := c.Get("large"); ok || c.Len() != 0 {
+		t.Errorf("oversized value cached: Len = %d", c.Len())
+	}
+
+	// Replacing a value re-weighs it
+	c.Set("a", make([]int, 4))
+	c.Set("b", make([]int, 4))
+	c.Set("a", make([]int, 6))
+	if _, ok := c.Get("b"); !ok {
+		t.Error("b evicted, but a's replacement fits alongside it")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	c, _ := newTestCache(2, time.Minute)
+	c.Set(1, "a")
+	c.Set(2, "b")
+	c.Remove(1)
+	c.Set(3, "c") // Fits in the freed slot without evicting 2
+	if _, ok := c.Get(2); !ok {
+		t.Error("2 evicted after a removal freed room")
+	}
+	if _, ok := c.Get(1); ok {
+		t.Error("1 still cached after Remove")
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	c := New[int, int](100, time.Minute)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := (w*1000 + i) % 250
+				c.Set(key, i)
+				c.Get(key)
+				if i%10 == 0 {
+					c.Remove(key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if c.Len() > 100 {
+		t.Errorf("Len = %d, want at most 100", c.Len())
+	}
+}
@@ -22,11 +22,12 @@ const (
 )
 
 type BatchGetPostsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserIds       []int64                `protobuf:"varint,1,rep,packed,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
-	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserIds         []int64                `protobuf:"varint,1,rep,packed,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	Limit           int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	BeforeTimestamp int64                  `protobuf:"varint,3,opt,name=before_timestamp,json=beforeTimestamp,proto3" json:"before_timestamp,omitempty"` // Optional: only posts created before this Unix time (0 = newest)
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *BatchGetPostsRequest) Reset() {
@@ -73,6 +74,13 @@ func (x *BatchGetPostsRequest) GetLimit() int32 {
 	return 0
 }
 
+func (x *BatchGetPostsRequest) GetBeforeTimestamp() int64 {
+	if x != nil {
+		return x.BeforeTimestamp
+	}
+	return 0
+}
+
 type BatchGetPostsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserPosts     map[int64]*PostList    `protobuf:"bytes,1,rep,name=user_posts,json=userPosts,proto3" json:"user_posts,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
@@ -125,6 +133,278 @@ func (x *BatchGetPostsResponse) GetErrorMessage() string {
 	return ""
 }
 
+type GetPostCountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPostCountRequest) Reset() {
+	*x = GetPostCountRequest{}
+	mi := &file_proto_post_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPostCountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPostCountRequest) ProtoMessage() {}
+
+func (x *GetPostCountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_post_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPostCountRequest.ProtoReflect.Descriptor instead.
+func (*GetPostCountRequest) Descriptor() ([]byte, []int) {
+	return file_proto_post_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetPostCountRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type GetPostCountResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	ErrorMessage  string                 `protobuf:"bytes,2,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPostCountResponse) Reset() {
+	*x = GetPostCountResponse{}
+	mi := &file_proto_post_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPostCountResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPostCountResponse) ProtoMessage() {}
+
+func (x *GetPostCountResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_post_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPostCountResponse.ProtoReflect.Descriptor instead.
+func (*GetPostCountResponse) Descriptor() ([]byte, []int) {
+	return file_proto_post_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetPostCountResponse) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *GetPostCountResponse) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+type GetWriteStrategyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWriteStrategyRequest) Reset() {
+	*x = GetWriteStrategyRequest{}
+	mi := &file_proto_post_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWriteStrategyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWriteStrategyRequest) ProtoMessage() {}
+
+func (x *GetWriteStrategyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_post_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWriteStrategyRequest.ProtoReflect.Descriptor instead.
+func (*GetWriteStrategyRequest) Descriptor() ([]byte, []int) {
+	return file_proto_post_proto_rawDescGZIP(), []int{4}
+}
+
+type GetWriteStrategyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Strategy      string                 `protobuf:"bytes,1,opt,name=strategy,proto3" json:"strategy,omitempty"` // push, pull or hybrid (POST_STRATEGY)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWriteStrategyResponse) Reset() {
+	*x = GetWriteStrategyResponse{}
+	mi := &file_proto_post_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWriteStrategyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWriteStrategyResponse) ProtoMessage() {}
+
+func (x *GetWriteStrategyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_post_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWriteStrategyResponse.ProtoReflect.Descriptor instead.
+func (*GetWriteStrategyResponse) Descriptor() ([]byte, []int) {
+	return file_proto_post_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetWriteStrategyResponse) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
+	}
+	return ""
+}
+
+type DeletePostRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PostId        int64                  `protobuf:"varint,1,opt,name=post_id,json=postId,proto3" json:"post_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePostRequest) Reset() {
+	*x = DeletePostRequest{}
+	mi := &file_proto_post_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePostRequest) ProtoMessage() {}
+
+func (x *DeletePostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_post_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePostRequest.ProtoReflect.Descriptor instead.
+func (*DeletePostRequest) Descriptor() ([]byte, []int) {
+	return file_proto_post_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeletePostRequest) GetPostId() int64 {
+	if x != nil {
+		return x.PostId
+	}
+	return 0
+}
+
+type DeletePostResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Found         bool                   `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"` // false when no post has post_id
+	ErrorMessage  string                 `protobuf:"bytes,2,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePostResponse) Reset() {
+	*x = DeletePostResponse{}
+	mi := &file_proto_post_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePostResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePostResponse) ProtoMessage() {}
+
+func (x *DeletePostResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_post_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePostResponse.ProtoReflect.Descriptor instead.
+func (*DeletePostResponse) Descriptor() ([]byte, []int) {
+	return file_proto_post_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeletePostResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *DeletePostResponse) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
 type PostList struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Posts         []*Post                `protobuf:"bytes,1,rep,name=posts,proto3" json:"posts,omitempty"`
@@ -134,7 +414,7 @@ type PostList struct {
 
 func (x *PostList) Reset() {
 	*x = PostList{}
-	mi := &file_proto_post_proto_msgTypes[2]
+	mi := &file_proto_post_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -146,7 +426,7 @@ func (x *PostList) String() string {
 func (*PostList) ProtoMessage() {}
 
 func (x *PostList) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_post_proto_msgTypes[2]
+	mi := &file_proto_post_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -159,7 +439,7 @@ func (x *PostList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PostList.ProtoReflect.Descriptor instead.
 func (*PostList) Descriptor() ([]byte, []int) {
-	return file_proto_post_proto_rawDescGZIP(), []int{2}
+	return file_proto_post_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *PostList) GetPosts() []*Post {
@@ -181,7 +461,7 @@ type Post struct {
 
 func (x *Post) Reset() {
 	*x = Post{}
-	mi := &file_proto_post_proto_msgTypes[3]
+	mi := &file_proto_post_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -193,7 +473,7 @@ func (x *Post) String() string {
 func (*Post) ProtoMessage() {}
 
 func (x *Post) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_post_proto_msgTypes[3]
+	mi := &file_proto_post_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -206,7 +486,7 @@ func (x *Post) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Post.ProtoReflect.Descriptor instead.
 func (*Post) Descriptor() ([]byte, []int) {
-	return file_proto_post_proto_rawDescGZIP(), []int{3}
+	return file_proto_post_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *Post) GetPostId() int64 {
@@ -241,17 +521,31 @@ var File_proto_post_proto protoreflect.FileDescriptor
 
 const file_proto_post_proto_rawDesc = "" +
 	"\n" +
-	"\x10proto/post.proto\x12\x04post\"G\n" +
+	"\x10proto/post.proto\x12\x04post\"r\n" +
 	"\x14BatchGetPostsRequest\x12\x19\n" +
 	"\buser_ids\x18\x01 \x03(\x03R\auserIds\x12\x14\n" +
-	"\x05limit\x18\x02 \x01(\x05R\x05limit\"\xd5\x01\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12)\n" +
+	"\x10before_timestamp\x18\x03 \x01(\x03R\x0fbeforeTimestamp\"\xd5\x01\n" +
 	"\x15BatchGetPostsResponse\x12I\n" +
 	"\n" +
 	"user_posts\x18\x01 \x03(\v2*.post.BatchGetPostsResponse.UserPostsEntryR\tuserPosts\x12#\n" +
 	"\rerror_message\x18\x02 \x01(\tR\ferrorMessage\x1aL\n" +
 	"\x0eUserPostsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\x03R\x03key\x12$\n" +
-	"\x05value\x18\x02 \x01(\v2\x0e.post.PostListR\x05value:\x028\x01\",\n" +
+	"\x05value\x18\x02 \x01(\v2\x0e.post.PostListR\x05value:\x028\x01\".\n" +
+	"\x13GetPostCountRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\"Q\n" +
+	"\x14GetPostCountResponse\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\x12#\n" +
+	"\rerror_message\x18\x02 \x01(\tR\ferrorMessage\"\x19\n" +
+	"\x17GetWriteStrategyRequest\"6\n" +
+	"\x18GetWriteStrategyResponse\x12\x1a\n" +
+	"\bstrategy\x18\x01 \x01(\tR\bstrategy\",\n" +
+	"\x11DeletePostRequest\x12\x17\n" +
+	"\apost_id\x18\x01 \x01(\x03R\x06postId\"O\n" +
+	"\x12DeletePostResponse\x12\x14\n" +
+	"\x05found\x18\x01 \x01(\bR\x05found\x12#\n" +
+	"\rerror_message\x18\x02 \x01(\tR\ferrorMessage\",\n" +
 	"\bPostList\x12 \n" +
 	"\x05posts\x18\x01 \x03(\v2\n" +
 	".post.PostR\x05posts\"p\n" +
@@ -259,9 +553,13 @@ const file_proto_post_proto_rawDesc = "" +
 	"\apost_id\x18\x01 \x01(\x03R\x06postId\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\x03R\x06userId\x12\x18\n" +
 	"\acontent\x18\x03 \x01(\tR\acontent\x12\x1c\n" +
-	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp2W\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp2\xb2\x02\n" +
 	"\vPostService\x12H\n" +
-	"\rBatchGetPosts\x12\x1a.post.BatchGetPostsRequest\x1a\x1b.post.BatchGetPostsResponseB\x1eZ\x1cgithub.com/cs6650/proto/postb\x06proto3"
+	"\rBatchGetPosts\x12\x1a.post.BatchGetPostsRequest\x1a\x1b.post.BatchGetPostsResponse\x12E\n" +
+	"\fGetPostCount\x12\x19.post.GetPostCountRequest\x1a\x1a.post.GetPostCountResponse\x12Q\n" +
+	"\x10GetWriteStrategy\x12\x1d.post.GetWriteStrategyRequest\x1a\x1e.post.GetWriteStrategyResponse\x12?\n" +
+	"\n" +
+	"DeletePost\x12\x17.post.DeletePostRequest\x1a\x18.post.DeletePostResponseB\x1eZ\x1cgithub.com/cs6650/proto/postb\x06proto3"
 
 var (
 	file_proto_post_proto_rawDescOnce sync.Once
@@ -275,25 +573,37 @@ func file_proto_post_proto_rawDescGZIP() []byte {
 	return file_proto_post_proto_rawDescData
 }
 
-var file_proto_post_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_proto_post_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
 var file_proto_post_proto_goTypes = []any{
-	(*BatchGetPostsRequest)(nil),  // 0: post.BatchGetPostsRequest
-	(*BatchGetPostsResponse)(nil), // 1: post.BatchGetPostsResponse
-	(*PostList)(nil),              // 2: post.PostList
-	(*Post)(nil),                  // 3: post.Post
-	nil,                           // 4: post.BatchGetPostsResponse.UserPostsEntry
+	(*BatchGetPostsRequest)(nil),     // 0: post.BatchGetPostsRequest
+	(*BatchGetPostsResponse)(nil),    // 1: post.BatchGetPostsResponse
+	(*GetPostCountRequest)(nil),      // 2: post.GetPostCountRequest
+	(*GetPostCountResponse)(nil),     // 3: post.GetPostCountResponse
+	(*GetWriteStrategyRequest)(nil),  // 4: post.GetWriteStrategyRequest
+	(*GetWriteStrategyResponse)(nil), // 5: post.GetWriteStrategyResponse
+	(*DeletePostRequest)(nil),        // 6: post.DeletePostRequest
+	(*DeletePostResponse)(nil),       // 7: post.DeletePostResponse
+	(*PostList)(nil),                 // 8: post.PostList
+	(*Post)(nil),                     // 9: post.Post
+	nil,                              // 10: post.BatchGetPostsResponse.UserPostsEntry
 }
 var file_proto_post_proto_depIdxs = []int32{
-	4, // 0: post.BatchGetPostsResponse.user_posts:type_name -> post.BatchGetPostsResponse.UserPostsEntry
-	3, // 1: post.PostList.posts:type_name -> post.Post
-	2, // 2: post.BatchGetPostsResponse.UserPostsEntry.value:type_name -> post.PostList
-	0, // 3: post.PostService.BatchGetPosts:input_type -> post.BatchGetPostsRequest
-	1, // 4: post.PostService.BatchGetPosts:output_type -> post.BatchGetPostsResponse
-	4, // [4:5] is the sub-list for method output_type
-	3, // [3:4] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	10, // 0: post.BatchGetPostsResponse.user_posts:type_name -> post.BatchGetPostsResponse.UserPostsEntry
+	9,  // 1: post.PostList.posts:type_name -> post.Post
+	8,  // 2: post.BatchGetPostsResponse.UserPostsEntry.value:type_name -> post.PostList
+	0,  // 3: post.PostService.BatchGetPosts:input_type -> post.BatchGetPostsRequest
+	2,  // 4: post.PostService.GetPostCount:input_type -> post.GetPostCountRequest
+	4,  // 5: post.PostService.GetWriteStrategy:input_type -> post.GetWriteStrategyRequest
+	6,  // 6: post.PostService.DeletePost:input_type -> post.DeletePostRequest
+	1,  // 7: post.PostService.BatchGetPosts:output_type -> post.BatchGetPostsResponse
+	3,  // 8: post.PostService.GetPostCount:output_type -> post.GetPostCountResponse
+	5,  // 9: post.PostService.GetWriteStrategy:output_type -> post.GetWriteStrategyResponse
+	7,  // 10: post.PostService.DeletePost:output_type -> post.DeletePostResponse
+	7,  // [7:11] is the sub-list for method output_type
+	3,  // [3:7] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_proto_post_proto_init() }
@@ -307,7 +617,7 @@ func file_proto_post_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_post_proto_rawDesc), len(file_proto_post_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   5,
+			NumMessages:   11,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,7 +19,10 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	PostService_BatchGetPosts_FullMethodName = "/post.PostService/BatchGetPosts"
+	PostService_BatchGetPosts_FullMethodName    = "/post.PostService/BatchGetPosts"
+	PostService_GetPostCount_FullMethodName     = "/post.PostService/GetPostCount"
+	PostService_GetWriteStrategy_FullMethodName = "/post.PostService/GetWriteStrategy"
+	PostService_DeletePost_FullMethodName       = "/post.PostService/DeletePost"
 )
 
 // PostServiceClient is the client API for PostService service.
@@ -27,6 +30,9 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type PostServiceClient interface {
 	BatchGetPosts(ctx context.Context, in *BatchGetPostsRequest, opts ...grpc.CallOption) (*BatchGetPostsResponse, error)
+	GetPostCount(ctx context.Context, in *GetPostCountRequest, opts ...grpc.CallOption) (*GetPostCountResponse, error)
+	GetWriteStrategy(ctx context.Context, in *GetWriteStrategyRequest, opts ...grpc.CallOption) (*GetWriteStrategyResponse, error)
+	DeletePost(ctx context.Context, in *DeletePostRequest, opts ...grpc.CallOption) (*DeletePostResponse, error)
 }
 
 type postServiceClient struct {
@@ -47,11 +53,44 @@ func (c *postServiceClient) BatchGetPosts(ctx context.Context, in *BatchGetPosts
 	return out, nil
 }
 
+func (c *postServiceClient) GetPostCount(ctx context.Context, in *GetPostCountRequest, opts ...grpc.CallOption) (*GetPostCountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetPostCountResponse)
+	err := c.cc.Invoke(ctx, PostService_GetPostCount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *postServiceClient) GetWriteStrategy(ctx context.Context, in *GetWriteStrategyRequest, opts ...grpc.CallOption) (*GetWriteStrategyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetWriteStrategyResponse)
+	err := c.cc.Invoke(ctx, PostService_GetWriteStrategy_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *postServiceClient) DeletePost(ctx context.Context, in *DeletePostRequest, opts ...grpc.CallOption) (*DeletePostResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeletePostResponse)
+	err := c.cc.Invoke(ctx, PostService_DeletePost_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PostServiceServer is the server API for PostService service.
 // All implementations must embed UnimplementedPostServiceServer
 // for forward compatibility.
 type PostServiceServer interface {
 	BatchGetPosts(context.Context, *BatchGetPostsRequest) (*BatchGetPostsResponse, error)
+	GetPostCount(context.Context, *GetPostCountRequest) (*GetPostCountResponse, error)
+	GetWriteStrategy(context.Context, *GetWriteStrategyRequest) (*GetWriteStrategyResponse, error)
+	DeletePost(context.Context, *DeletePostRequest) (*DeletePostResponse, error)
 	mustEmbedUnimplementedPostServiceServer()
 }
 
@@ -65,6 +104,15 @@ type UnimplementedPostServiceServer struct{}
 func (UnimplementedPostServiceServer) BatchGetPosts(context.Context, *BatchGetPostsRequest) (*BatchGetPostsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BatchGetPosts not implemented")
 }
+func (UnimplementedPostServiceServer) GetPostCount(context.Context, *GetPostCountRequest) (*GetPostCountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPostCount not implemented")
+}
+func (UnimplementedPostServiceServer) GetWriteStrategy(context.Context, *GetWriteStrategyRequest) (*GetWriteStrategyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWriteStrategy not implemented")
+}
+func (UnimplementedPostServiceServer) DeletePost(context.Context, *DeletePostRequest) (*DeletePostResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePost not implemented")
+}
 func (UnimplementedPostServiceServer) mustEmbedUnimplementedPostServiceServer() {}
 func (UnimplementedPostServiceServer) testEmbeddedByValue()                     {}
 
@@ -104,6 +152,60 @@ func _PostService_BatchGetPosts_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PostService_GetPostCount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPostCountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PostServiceServer).GetPostCount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PostService_GetPostCount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PostServiceServer).GetPostCount(ctx, req.(*GetPostCountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PostService_GetWriteStrategy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWriteStrategyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PostServiceServer).GetWriteStrategy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PostService_GetWriteStrategy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PostServiceServer).GetWriteStrategy(ctx, req.(*GetWriteStrategyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PostService_DeletePost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PostServiceServer).DeletePost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PostService_DeletePost_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PostServiceServer).DeletePost(ctx, req.(*DeletePostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PostService_ServiceDesc is the grpc.ServiceDesc for PostService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,6 +217,18 @@ var PostService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "BatchGetPosts",
 			Handler:    _PostService_BatchGetPosts_Handler,
 		},
+		{
+			MethodName: "GetPostCount",
+			Handler:    _PostService_GetPostCount_Handler,
+		},
+		{
+			MethodName: "GetWriteStrategy",
+			Handler:    _PostService_GetWriteStrategy_Handler,
+		},
+		{
+			MethodName: "DeletePost",
+			Handler:    _PostService_DeletePost_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/post.proto",
@@ -316,12 +316,15 @@ func (x *GetFollowersRequest) GetOffset() int32 {
 	return 0
 }
 
+// total_count is the user's overall follower count, not the length of this page;
+// page_size is the number of IDs in user_ids, and has_more refers to further pages
 type GetFollowersResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserIds       []int64                `protobuf:"varint,1,rep,packed,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`        // List of follower user IDs
-	TotalCount    int32                  `protobuf:"varint,2,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`      // Total follower count before pagination
-	HasMore       bool                   `protobuf:"varint,3,opt,name=has_more,json=hasMore,proto3" json:"has_more,omitempty"`               // Whether there are more results available
+	TotalCount    int32                  `protobuf:"varint,2,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`      // Overall follower count, independent of pagination
+	HasMore       bool                   `protobuf:"varint,3,opt,name=has_more,json=hasMore,proto3" json:"has_more,omitempty"`               // Whether more pages follow this one
 	ErrorMessage  string                 `protobuf:"bytes,4,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"` // Error message if request failed
+	PageSize      int32                  `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`            // Number of follower IDs returned in this page
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -384,6 +387,13 @@ func (x *GetFollowersResponse) GetErrorMessage() string {
 	return ""
 }
 
+func (x *GetFollowersResponse) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
 // GetFollowingList
 type GetFollowingListRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -489,6 +499,135 @@ func (x *GetFollowingListResponse) GetErrorMessage() string {
 	return ""
 }
 
+// GetFollowingIDs
+type GetFollowingIDsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // Required: ID of the user whose following IDs to retrieve
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`                 // Optional: Maximum number of IDs to return (default: all)
+	Offset        int32                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`               // Optional: Number of IDs to skip (default: 0)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFollowingIDsRequest) Reset() {
+	*x = GetFollowingIDsRequest{}
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFollowingIDsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFollowingIDsRequest) ProtoMessage() {}
+
+func (x *GetFollowingIDsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFollowingIDsRequest.ProtoReflect.Descriptor instead.
+func (*GetFollowingIDsRequest) Descriptor() ([]byte, []int) {
+	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetFollowingIDsRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *GetFollowingIDsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetFollowingIDsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type GetFollowingIDsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserIds       []int64                `protobuf:"varint,1,rep,packed,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`        // User IDs that the user follows, in follow order
+	ErrorCode     string                 `protobuf:"bytes,2,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`          // Error code if request failed
+	ErrorMessage  string                 `protobuf:"bytes,3,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"` // Error message if request failed
+	HasMore       bool                   `protobuf:"varint,4,opt,name=has_more,json=hasMore,proto3" json:"has_more,omitempty"`               // Whether IDs remain past this page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFollowingIDsResponse) Reset() {
+	*x = GetFollowingIDsResponse{}
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFollowingIDsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFollowingIDsResponse) ProtoMessage() {}
+
+func (x *GetFollowingIDsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFollowingIDsResponse.ProtoReflect.Descriptor instead.
+func (*GetFollowingIDsResponse) Descriptor() ([]byte, []int) {
+	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetFollowingIDsResponse) GetUserIds() []int64 {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+func (x *GetFollowingIDsResponse) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *GetFollowingIDsResponse) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *GetFollowingIDsResponse) GetHasMore() bool {
+	if x != nil {
+		return x.HasMore
+	}
+	return false
+}
+
 // GetFollowersCount
 type GetFollowersCountRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -499,7 +638,7 @@ type GetFollowersCountRequest struct {
 
 func (x *GetFollowersCountRequest) Reset() {
 	*x = GetFollowersCountRequest{}
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[8]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -511,7 +650,7 @@ func (x *GetFollowersCountRequest) String() string {
 func (*GetFollowersCountRequest) ProtoMessage() {}
 
 func (x *GetFollowersCountRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[8]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -524,7 +663,7 @@ func (x *GetFollowersCountRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetFollowersCountRequest.ProtoReflect.Descriptor instead.
 func (*GetFollowersCountRequest) Descriptor() ([]byte, []int) {
-	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{8}
+	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *GetFollowersCountRequest) GetUserId() int64 {
@@ -545,7 +684,7 @@ type GetFollowersCountResponse struct {
 
 func (x *GetFollowersCountResponse) Reset() {
 	*x = GetFollowersCountResponse{}
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[9]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -557,7 +696,7 @@ func (x *GetFollowersCountResponse) String() string {
 func (*GetFollowersCountResponse) ProtoMessage() {}
 
 func (x *GetFollowersCountResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[9]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -570,7 +709,7 @@ func (x *GetFollowersCountResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetFollowersCountResponse.ProtoReflect.Descriptor instead.
 func (*GetFollowersCountResponse) Descriptor() ([]byte, []int) {
-	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{9}
+	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *GetFollowersCountResponse) GetUserId() int64 {
@@ -604,7 +743,7 @@ type GetFollowingCountRequest struct {
 
 func (x *GetFollowingCountRequest) Reset() {
 	*x = GetFollowingCountRequest{}
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[10]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -616,7 +755,7 @@ func (x *GetFollowingCountRequest) String() string {
 func (*GetFollowingCountRequest) ProtoMessage() {}
 
 func (x *GetFollowingCountRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[10]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -629,7 +768,7 @@ func (x *GetFollowingCountRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetFollowingCountRequest.ProtoReflect.Descriptor instead.
 func (*GetFollowingCountRequest) Descriptor() ([]byte, []int) {
-	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{10}
+	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *GetFollowingCountRequest) GetUserId() int64 {
@@ -650,7 +789,7 @@ type GetFollowingCountResponse struct {
 
 func (x *GetFollowingCountResponse) Reset() {
 	*x = GetFollowingCountResponse{}
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[11]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -662,7 +801,7 @@ func (x *GetFollowingCountResponse) String() string {
 func (*GetFollowingCountResponse) ProtoMessage() {}
 
 func (x *GetFollowingCountResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[11]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -675,7 +814,7 @@ func (x *GetFollowingCountResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetFollowingCountResponse.ProtoReflect.Descriptor instead.
 func (*GetFollowingCountResponse) Descriptor() ([]byte, []int) {
-	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{11}
+	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *GetFollowingCountResponse) GetUserId() int64 {
@@ -710,7 +849,7 @@ type CheckFollowRelationshipRequest struct {
 
 func (x *CheckFollowRelationshipRequest) Reset() {
 	*x = CheckFollowRelationshipRequest{}
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[12]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -722,7 +861,7 @@ func (x *CheckFollowRelationshipRequest) String() string {
 func (*CheckFollowRelationshipRequest) ProtoMessage() {}
 
 func (x *CheckFollowRelationshipRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[12]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -735,7 +874,7 @@ func (x *CheckFollowRelationshipRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CheckFollowRelationshipRequest.ProtoReflect.Descriptor instead.
 func (*CheckFollowRelationshipRequest) Descriptor() ([]byte, []int) {
-	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{12}
+	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *CheckFollowRelationshipRequest) GetFollowerUserId() int64 {
@@ -756,13 +895,17 @@ type CheckFollowRelationshipResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	IsFollowing   bool                   `protobuf:"varint,1,opt,name=is_following,json=isFollowing,proto3" json:"is_following,omitempty"`
 	ErrorMessage  string                 `protobuf:"bytes,2,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	FollowedAt    int64                  `protobuf:"varint,3,opt,name=followed_at,json=followedAt,proto3" json:"followed_at,omitempty"`         // Unix time the follow was created; 0 if not following or not tracked
+	IsFollowedBy  bool                   `protobuf:"varint,4,opt,name=is_followed_by,json=isFollowedBy,proto3" json:"is_followed_by,omitempty"` // Target follows the follower back
+	IsMutual      bool                   `protobuf:"varint,5,opt,name=is_mutual,json=isMutual,proto3" json:"is_mutual,omitempty"`               // Both users follow each other
+	ErrorCode     string                 `protobuf:"bytes,6,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`             // Error code if request failed
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CheckFollowRelationshipResponse) Reset() {
 	*x = CheckFollowRelationshipResponse{}
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[13]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -774,7 +917,7 @@ func (x *CheckFollowRelationshipResponse) String() string {
 func (*CheckFollowRelationshipResponse) ProtoMessage() {}
 
 func (x *CheckFollowRelationshipResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[13]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -787,7 +930,7 @@ func (x *CheckFollowRelationshipResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CheckFollowRelationshipResponse.ProtoReflect.Descriptor instead.
 func (*CheckFollowRelationshipResponse) Descriptor() ([]byte, []int) {
-	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{13}
+	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *CheckFollowRelationshipResponse) GetIsFollowing() bool {
@@ -804,6 +947,147 @@ func (x *CheckFollowRelationshipResponse) GetErrorMessage() string {
 	return ""
 }
 
+func (x *CheckFollowRelationshipResponse) GetFollowedAt() int64 {
+	if x != nil {
+		return x.FollowedAt
+	}
+	return 0
+}
+
+func (x *CheckFollowRelationshipResponse) GetIsFollowedBy() bool {
+	if x != nil {
+		return x.IsFollowedBy
+	}
+	return false
+}
+
+func (x *CheckFollowRelationshipResponse) GetIsMutual() bool {
+	if x != nil {
+		return x.IsMutual
+	}
+	return false
+}
+
+func (x *CheckFollowRelationshipResponse) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+// BatchCheckFollowRelationship
+type BatchCheckFollowRelationshipRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	FollowerUserId int64                  `protobuf:"varint,1,opt,name=follower_user_id,json=followerUserId,proto3" json:"follower_user_id,omitempty"`
+	TargetUserIds  []int64                `protobuf:"varint,2,rep,packed,name=target_user_ids,json=targetUserIds,proto3" json:"target_user_ids,omitempty"` // At most 500 IDs
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *BatchCheckFollowRelationshipRequest) Reset() {
+	*x = BatchCheckFollowRelationshipRequest{}
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCheckFollowRelationshipRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCheckFollowRelationshipRequest) ProtoMessage() {}
+
+func (x *BatchCheckFollowRelationshipRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCheckFollowRelationshipRequest.ProtoReflect.Descriptor instead.
+func (*BatchCheckFollowRelationshipRequest) Descriptor() ([]byte, []int) {
+	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BatchCheckFollowRelationshipRequest) GetFollowerUserId() int64 {
+	if x != nil {
+		return x.FollowerUserId
+	}
+	return 0
+}
+
+func (x *BatchCheckFollowRelationshipRequest) GetTargetUserIds() []int64 {
+	if x != nil {
+		return x.TargetUserIds
+	}
+	return nil
+}
+
+type BatchCheckFollowRelationshipResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IsFollowing   map[int64]bool         `protobuf:"bytes,1,rep,name=is_following,json=isFollowing,proto3" json:"is_following,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // Keyed by target user ID; every requested ID is present
+	ErrorCode     string                 `protobuf:"bytes,2,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`                                                                                   // Error code if request failed
+	ErrorMessage  string                 `protobuf:"bytes,3,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`                                                                          // Error message if request failed
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCheckFollowRelationshipResponse) Reset() {
+	*x = BatchCheckFollowRelationshipResponse{}
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCheckFollowRelationshipResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCheckFollowRelationshipResponse) ProtoMessage() {}
+
+func (x *BatchCheckFollowRelationshipResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCheckFollowRelationshipResponse.ProtoReflect.Descriptor instead.
+func (*BatchCheckFollowRelationshipResponse) Descriptor() ([]byte, []int) {
+	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BatchCheckFollowRelationshipResponse) GetIsFollowing() map[int64]bool {
+	if x != nil {
+		return x.IsFollowing
+	}
+	return nil
+}
+
+func (x *BatchCheckFollowRelationshipResponse) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *BatchCheckFollowRelationshipResponse) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
 // BatchCreateFollowRelationships (for data generation)
 type BatchCreateFollowRelationshipsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -814,7 +1098,7 @@ type BatchCreateFollowRelationshipsRequest struct {
 
 func (x *BatchCreateFollowRelationshipsRequest) Reset() {
 	*x = BatchCreateFollowRelationshipsRequest{}
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[14]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -826,7 +1110,7 @@ func (x *BatchCreateFollowRelationshipsRequest) String() string {
 func (*BatchCreateFollowRelationshipsRequest) ProtoMessage() {}
 
 func (x *BatchCreateFollowRelationshipsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[14]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -839,7 +1123,7 @@ func (x *BatchCreateFollowRelationshipsRequest) ProtoReflect() protoreflect.Mess
 
 // Deprecated: Use BatchCreateFollowRelationshipsRequest.ProtoReflect.Descriptor instead.
 func (*BatchCreateFollowRelationshipsRequest) Descriptor() ([]byte, []int) {
-	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{14}
+	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *BatchCreateFollowRelationshipsRequest) GetRelationships() []*FollowRelationship {
@@ -859,7 +1143,7 @@ type FollowRelationship struct {
 
 func (x *FollowRelationship) Reset() {
 	*x = FollowRelationship{}
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[15]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -871,7 +1155,7 @@ func (x *FollowRelationship) String() string {
 func (*FollowRelationship) ProtoMessage() {}
 
 func (x *FollowRelationship) ProtoReflect() protoreflect.Message {
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[15]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -884,7 +1168,7 @@ func (x *FollowRelationship) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FollowRelationship.ProtoReflect.Descriptor instead.
 func (*FollowRelationship) Descriptor() ([]byte, []int) {
-	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{15}
+	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *FollowRelationship) GetFollowerUserId() int64 {
@@ -913,7 +1197,7 @@ type BatchCreateFollowRelationshipsResponse struct {
 
 func (x *BatchCreateFollowRelationshipsResponse) Reset() {
 	*x = BatchCreateFollowRelationshipsResponse{}
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[16]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -925,7 +1209,7 @@ func (x *BatchCreateFollowRelationshipsResponse) String() string {
 func (*BatchCreateFollowRelationshipsResponse) ProtoMessage() {}
 
 func (x *BatchCreateFollowRelationshipsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_social_graph_social_graph_service_proto_msgTypes[16]
+	mi := &file_social_graph_social_graph_service_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -938,7 +1222,7 @@ func (x *BatchCreateFollowRelationshipsResponse) ProtoReflect() protoreflect.Mes
 
 // Deprecated: Use BatchCreateFollowRelationshipsResponse.ProtoReflect.Descriptor instead.
 func (*BatchCreateFollowRelationshipsResponse) Descriptor() ([]byte, []int) {
-	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{16}
+	return file_social_graph_social_graph_service_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *BatchCreateFollowRelationshipsResponse) GetCreatedCount() int32 {
@@ -995,20 +1279,31 @@ const file_social_graph_social_graph_service_proto_rawDesc = "" +
 	"\x13GetFollowersRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x14\n" +
 	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
-	"\x06offset\x18\x03 \x01(\x05R\x06offset\"\x92\x01\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\"\xaf\x01\n" +
 	"\x14GetFollowersResponse\x12\x19\n" +
 	"\buser_ids\x18\x01 \x03(\x03R\auserIds\x12\x1f\n" +
 	"\vtotal_count\x18\x02 \x01(\x05R\n" +
 	"totalCount\x12\x19\n" +
 	"\bhas_more\x18\x03 \x01(\bR\ahasMore\x12#\n" +
-	"\rerror_message\x18\x04 \x01(\tR\ferrorMessage\"2\n" +
+	"\rerror_message\x18\x04 \x01(\tR\ferrorMessage\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\"2\n" +
 	"\x17GetFollowingListRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\x03R\x06userId\"\x8c\x01\n" +
 	"\x18GetFollowingListResponse\x12,\n" +
 	"\x12following_user_ids\x18\x01 \x03(\x03R\x10followingUserIds\x12\x1d\n" +
 	"\n" +
 	"error_code\x18\x02 \x01(\tR\terrorCode\x12#\n" +
-	"\rerror_message\x18\x03 \x01(\tR\ferrorMessage\"3\n" +
+	"\rerror_message\x18\x03 \x01(\tR\ferrorMessage\"_\n" +
+	"\x16GetFollowingIDsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\"\x93\x01\n" +
+	"\x17GetFollowingIDsResponse\x12\x19\n" +
+	"\buser_ids\x18\x01 \x03(\x03R\auserIds\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x02 \x01(\tR\terrorCode\x12#\n" +
+	"\rerror_message\x18\x03 \x01(\tR\ferrorMessage\x12\x19\n" +
+	"\bhas_more\x18\x04 \x01(\bR\ahasMore\"3\n" +
 	"\x18GetFollowersCountRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\x03R\x06userId\"\x82\x01\n" +
 	"\x19GetFollowersCountResponse\x12\x17\n" +
@@ -1023,10 +1318,27 @@ const file_social_graph_social_graph_service_proto_rawDesc = "" +
 	"\rerror_message\x18\x03 \x01(\tR\ferrorMessage\"p\n" +
 	"\x1eCheckFollowRelationshipRequest\x12(\n" +
 	"\x10follower_user_id\x18\x01 \x01(\x03R\x0efollowerUserId\x12$\n" +
-	"\x0etarget_user_id\x18\x02 \x01(\x03R\ftargetUserId\"i\n" +
+	"\x0etarget_user_id\x18\x02 \x01(\x03R\ftargetUserId\"\xec\x01\n" +
 	"\x1fCheckFollowRelationshipResponse\x12!\n" +
 	"\fis_following\x18\x01 \x01(\bR\visFollowing\x12#\n" +
-	"\rerror_message\x18\x02 \x01(\tR\ferrorMessage\"n\n" +
+	"\rerror_message\x18\x02 \x01(\tR\ferrorMessage\x12\x1f\n" +
+	"\vfollowed_at\x18\x03 \x01(\x03R\n" +
+	"followedAt\x12$\n" +
+	"\x0eis_followed_by\x18\x04 \x01(\bR\fisFollowedBy\x12\x1b\n" +
+	"\tis_mutual\x18\x05 \x01(\bR\bisMutual\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x06 \x01(\tR\terrorCode\"w\n" +
+	"#BatchCheckFollowRelationshipRequest\x12(\n" +
+	"\x10follower_user_id\x18\x01 \x01(\x03R\x0efollowerUserId\x12&\n" +
+	"\x0ftarget_user_ids\x18\x02 \x03(\x03R\rtargetUserIds\"\x91\x02\n" +
+	"$BatchCheckFollowRelationshipResponse\x12e\n" +
+	"\fis_following\x18\x01 \x03(\v2B.socialgraph.BatchCheckFollowRelationshipResponse.IsFollowingEntryR\visFollowing\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x02 \x01(\tR\terrorCode\x12#\n" +
+	"\rerror_message\x18\x03 \x01(\tR\ferrorMessage\x1a>\n" +
+	"\x10IsFollowingEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\bR\x05value:\x028\x01\"n\n" +
 	"%BatchCreateFollowRelationshipsRequest\x12E\n" +
 	"\rrelationships\x18\x01 \x03(\v2\x1f.socialgraph.FollowRelationshipR\rrelationships\"d\n" +
 	"\x12FollowRelationship\x12(\n" +
@@ -1036,16 +1348,18 @@ const file_social_graph_social_graph_service_proto_rawDesc = "" +
 	"\rcreated_count\x18\x01 \x01(\x05R\fcreatedCount\x12!\n" +
 	"\ffailed_count\x18\x02 \x01(\x05R\vfailedCount\x12\x18\n" +
 	"\asuccess\x18\x03 \x01(\bR\asuccess\x12#\n" +
-	"\rerror_message\x18\x04 \x01(\tR\ferrorMessage2\xb8\x06\n" +
+	"\rerror_message\x18\x04 \x01(\tR\ferrorMessage2\x9c\b\n" +
 	"\x12SocialGraphService\x12M\n" +
 	"\n" +
 	"FollowUser\x12\x1e.socialgraph.FollowUserRequest\x1a\x1f.socialgraph.FollowUserResponse\x12S\n" +
 	"\fUnfollowUser\x12 .socialgraph.UnfollowUserRequest\x1a!.socialgraph.UnfollowUserResponse\x12S\n" +
 	"\fGetFollowers\x12 .socialgraph.GetFollowersRequest\x1a!.socialgraph.GetFollowersResponse\x12_\n" +
-	"\x10GetFollowingList\x12$.socialgraph.GetFollowingListRequest\x1a%.socialgraph.GetFollowingListResponse\x12b\n" +
+	"\x10GetFollowingList\x12$.socialgraph.GetFollowingListRequest\x1a%.socialgraph.GetFollowingListResponse\x12\\\n" +
+	"\x0fGetFollowingIDs\x12#.socialgraph.GetFollowingIDsRequest\x1a$.socialgraph.GetFollowingIDsResponse\x12b\n" +
 	"\x11GetFollowersCount\x12%.socialgraph.GetFollowersCountRequest\x1a&.socialgraph.GetFollowersCountResponse\x12b\n" +
 	"\x11GetFollowingCount\x12%.socialgraph.GetFollowingCountRequest\x1a&.socialgraph.GetFollowingCountResponse\x12t\n" +
-	"\x17CheckFollowRelationship\x12+.socialgraph.CheckFollowRelationshipRequest\x1a,.socialgraph.CheckFollowRelationshipResponse\x12\x89\x01\n" +
+	"\x17CheckFollowRelationship\x12+.socialgraph.CheckFollowRelationshipRequest\x1a,.socialgraph.CheckFollowRelationshipResponse\x12\x83\x01\n" +
+	"\x1cBatchCheckFollowRelationship\x120.socialgraph.BatchCheckFollowRelationshipRequest\x1a1.socialgraph.BatchCheckFollowRelationshipResponse\x12\x89\x01\n" +
 	"\x1eBatchCreateFollowRelationships\x122.socialgraph.BatchCreateFollowRelationshipsRequest\x1a3.socialgraph.BatchCreateFollowRelationshipsResponseB&Z$github.com/cs6650/proto/social_graphb\x06proto3"
 
 var (
@@ -1060,7 +1374,7 @@ func file_social_graph_social_graph_service_proto_rawDescGZIP() []byte {
 	return file_social_graph_social_graph_service_proto_rawDescData
 }
 
-var file_social_graph_social_graph_service_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_social_graph_social_graph_service_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
 var file_social_graph_social_graph_service_proto_goTypes = []any{
 	(*FollowUserRequest)(nil),                      // 0: socialgraph.FollowUserRequest
 	(*FollowUserResponse)(nil),                     // 1: socialgraph.FollowUserResponse
@@ -1070,39 +1384,49 @@ var file_social_graph_social_graph_service_proto_goTypes = []any{
 	(*GetFollowersResponse)(nil),                   // 5: socialgraph.GetFollowersResponse
 	(*GetFollowingListRequest)(nil),                // 6: socialgraph.GetFollowingListRequest
 	(*GetFollowingListResponse)(nil),               // 7: socialgraph.GetFollowingListResponse
-	(*GetFollowersCountRequest)(nil),               // 8: socialgraph.GetFollowersCountRequest
-	(*GetFollowersCountResponse)(nil),              // 9: socialgraph.GetFollowersCountResponse
-	(*GetFollowingCountRequest)(nil),               // 10: socialgraph.GetFollowingCountRequest
-	(*GetFollowingCountResponse)(nil),              // 11: socialgraph.GetFollowingCountResponse
-	(*CheckFollowRelationshipRequest)(nil),         // 12: socialgraph.CheckFollowRelationshipRequest
-	(*CheckFollowRelationshipResponse)(nil),        // 13: socialgraph.CheckFollowRelationshipResponse
-	(*BatchCreateFollowRelationshipsRequest)(nil),  // 14: socialgraph.BatchCreateFollowRelationshipsRequest
-	(*FollowRelationship)(nil),                     // 15: socialgraph.FollowRelationship
-	(*BatchCreateFollowRelationshipsResponse)(nil), // 16: socialgraph.BatchCreateFollowRelationshipsResponse
+	(*GetFollowingIDsRequest)(nil),                 // 8: socialgraph.GetFollowingIDsRequest
+	(*GetFollowingIDsResponse)(nil),                // 9: socialgraph.GetFollowingIDsResponse
+	(*GetFollowersCountRequest)(nil),               // 10: socialgraph.GetFollowersCountRequest
+	(*GetFollowersCountResponse)(nil),              // 11: socialgraph.GetFollowersCountResponse
+	(*GetFollowingCountRequest)(nil),               // 12: socialgraph.GetFollowingCountRequest
+	(*GetFollowingCountResponse)(nil),              // 13: socialgraph.GetFollowingCountResponse
+	(*CheckFollowRelationshipRequest)(nil),         // 14: socialgraph.CheckFollowRelationshipRequest
+	(*CheckFollowRelationshipResponse)(nil),        // 15: socialgraph.CheckFollowRelationshipResponse
+	(*BatchCheckFollowRelationshipRequest)(nil),    // 16: socialgraph.BatchCheckFollowRelationshipRequest
+	(*BatchCheckFollowRelationshipResponse)(nil),   // 17: socialgraph.BatchCheckFollowRelationshipResponse
+	(*BatchCreateFollowRelationshipsRequest)(nil),  // 18: socialgraph.BatchCreateFollowRelationshipsRequest
+	(*FollowRelationship)(nil),                     // 19: socialgraph.FollowRelationship
+	(*BatchCreateFollowRelationshipsResponse)(nil), // 20: socialgraph.BatchCreateFollowRelationshipsResponse
+	nil, // 21: socialgraph.BatchCheckFollowRelationshipResponse.IsFollowingEntry
 }
 var file_social_graph_social_graph_service_proto_depIdxs = []int32{
-	15, // 0: socialgraph.BatchCreateFollowRelationshipsRequest.relationships:type_name -> socialgraph.FollowRelationship
-	0,  // 1: socialgraph.SocialGraphService.FollowUser:input_type -> socialgraph.FollowUserRequest
-	2,  // 2: socialgraph.SocialGraphService.UnfollowUser:input_type -> socialgraph.UnfollowUserRequest
-	4,  // 3: socialgraph.SocialGraphService.GetFollowers:input_type -> socialgraph.GetFollowersRequest
-	6,  // 4: socialgraph.SocialGraphService.GetFollowingList:input_type -> socialgraph.GetFollowingListRequest
-	8,  // 5: socialgraph.SocialGraphService.GetFollowersCount:input_type -> socialgraph.GetFollowersCountRequest
-	10, // 6: socialgraph.SocialGraphService.GetFollowingCount:input_type -> socialgraph.GetFollowingCountRequest
-	12, // 7: socialgraph.SocialGraphService.CheckFollowRelationship:input_type -> socialgraph.CheckFollowRelationshipRequest
-	14, // 8: socialgraph.SocialGraphService.BatchCreateFollowRelationships:input_type -> socialgraph.BatchCreateFollowRelationshipsRequest
-	1,  // 9: socialgraph.SocialGraphService.FollowUser:output_type -> socialgraph.FollowUserResponse
-	3,  // 10: socialgraph.SocialGraphService.UnfollowUser:output_type -> socialgraph.UnfollowUserResponse
-	5,  // 11: socialgraph.SocialGraphService.GetFollowers:output_type -> socialgraph.GetFollowersResponse
-	7,  // 12: socialgraph.SocialGraphService.GetFollowingList:output_type -> socialgraph.GetFollowingListResponse
-	9,  // 13: socialgraph.SocialGraphService.GetFollowersCount:output_type -> socialgraph.GetFollowersCountResponse
-	11, // 14: socialgraph.SocialGraphService.GetFollowingCount:output_type -> socialgraph.GetFollowingCountResponse
-	13, // 15: socialgraph.SocialGraphService.CheckFollowRelationship:output_type -> socialgraph.CheckFollowRelationshipResponse
-	16, // 16: socialgraph.SocialGraphService.BatchCreateFollowRelationships:output_type -> socialgraph.BatchCreateFollowRelationshipsResponse
-	9,  // [9:17] is the sub-list for method output_type
-	1,  // [1:9] is the sub-list for method input_type
-	1,  // [1:1] is the sub-list for extension type_name
-	1,  // [1:1] is the sub-list for extension extendee
-	0,  // [0:1] is the sub-list for field type_name
+	21, // 0: socialgraph.BatchCheckFollowRelationshipResponse.is_following:type_name -> socialgraph.BatchCheckFollowRelationshipResponse.IsFollowingEntry
+	19, // 1: socialgraph.BatchCreateFollowRelationshipsRequest.relationships:type_name -> socialgraph.FollowRelationship
+	0,  // 2: socialgraph.SocialGraphService.FollowUser:input_type -> socialgraph.FollowUserRequest
+	2,  // 3: socialgraph.SocialGraphService.UnfollowUser:input_type -> socialgraph.UnfollowUserRequest
+	4,  // 4: socialgraph.SocialGraphService.GetFollowers:input_type -> socialgraph.GetFollowersRequest
+	6,  // 5: socialgraph.SocialGraphService.GetFollowingList:input_type -> socialgraph.GetFollowingListRequest
+	8,  // 6: socialgraph.SocialGraphService.GetFollowingIDs:input_type -> socialgraph.GetFollowingIDsRequest
+	10, // 7: socialgraph.SocialGraphService.GetFollowersCount:input_type -> socialgraph.GetFollowersCountRequest
+	12, // 8: socialgraph.SocialGraphService.GetFollowingCount:input_type -> socialgraph.GetFollowingCountRequest
+	14, // 9: socialgraph.SocialGraphService.CheckFollowRelationship:input_type -> socialgraph.CheckFollowRelationshipRequest
+	16, // 10: socialgraph.SocialGraphService.BatchCheckFollowRelationship:input_type -> socialgraph.BatchCheckFollowRelationshipRequest
+	18, // 11: socialgraph.SocialGraphService.BatchCreateFollowRelationships:input_type -> socialgraph.BatchCreateFollowRelationshipsRequest
+	1,  // 12: socialgraph.SocialGraphService.FollowUser:output_type -> socialgraph.FollowUserResponse
+	3,  // 13: socialgraph.SocialGraphService.UnfollowUser:output_type -> socialgraph.UnfollowUserResponse
+	5,  // 14: socialgraph.SocialGraphService.GetFollowers:output_type -> socialgraph.GetFollowersResponse
+	7,  // 15: socialgraph.SocialGraphService.GetFollowingList:output_type -> socialgraph.GetFollowingListResponse
+	9,  // 16: socialgraph.SocialGraphService.GetFollowingIDs:output_type -> socialgraph.GetFollowingIDsResponse
+	11, // 17: socialgraph.SocialGraphService.GetFollowersCount:output_type -> socialgraph.GetFollowersCountResponse
+	13, // 18: socialgraph.SocialGraphService.GetFollowingCount:output_type -> socialgraph.GetFollowingCountResponse
+	15, // 19: socialgraph.SocialGraphService.CheckFollowRelationship:output_type -> socialgraph.CheckFollowRelationshipResponse
+	17, // 20: socialgraph.SocialGraphService.BatchCheckFollowRelationship:output_type -> socialgraph.BatchCheckFollowRelationshipResponse
+	20, // 21: socialgraph.SocialGraphService.BatchCreateFollowRelationships:output_type -> socialgraph.BatchCreateFollowRelationshipsResponse
+	12, // [12:22] is the sub-list for method output_type
+	2,  // [2:12] is the sub-list for method input_type
+	2,  // [2:2] is the sub-list for extension type_name
+	2,  // [2:2] is the sub-list for extension extendee
+	0,  // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_social_graph_social_graph_service_proto_init() }
@@ -1116,7 +1440,7 @@ func file_social_graph_social_graph_service_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_social_graph_social_graph_service_proto_rawDesc), len(file_social_graph_social_graph_service_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   17,
+			NumMessages:   22,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
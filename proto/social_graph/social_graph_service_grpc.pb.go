@@ -23,9 +23,11 @@ const (
 	SocialGraphService_UnfollowUser_FullMethodName                   = "/socialgraph.SocialGraphService/UnfollowUser"
 	SocialGraphService_GetFollowers_FullMethodName                   = "/socialgraph.SocialGraphService/GetFollowers"
 	SocialGraphService_GetFollowingList_FullMethodName               = "/socialgraph.SocialGraphService/GetFollowingList"
+	SocialGraphService_GetFollowingIDs_FullMethodName                = "/socialgraph.SocialGraphService/GetFollowingIDs"
 	SocialGraphService_GetFollowersCount_FullMethodName              = "/socialgraph.SocialGraphService/GetFollowersCount"
 	SocialGraphService_GetFollowingCount_FullMethodName              = "/socialgraph.SocialGraphService/GetFollowingCount"
 	SocialGraphService_CheckFollowRelationship_FullMethodName        = "/socialgraph.SocialGraphService/CheckFollowRelationship"
+	SocialGraphService_BatchCheckFollowRelationship_FullMethodName   = "/socialgraph.SocialGraphService/BatchCheckFollowRelationship"
 	SocialGraphService_BatchCreateFollowRelationships_FullMethodName = "/socialgraph.SocialGraphService/BatchCreateFollowRelationships"
 )
 
@@ -43,12 +45,16 @@ type SocialGraphServiceClient interface {
 	GetFollowers(ctx context.Context, in *GetFollowersRequest, opts ...grpc.CallOption) (*GetFollowersResponse, error)
 	// GetFollowingList retrieves the list of users that a specified user follows
 	GetFollowingList(ctx context.Context, in *GetFollowingListRequest, opts ...grpc.CallOption) (*GetFollowingListResponse, error)
+	// GetFollowingIDs retrieves only the IDs of users that a specified user follows (no counts or cursors)
+	GetFollowingIDs(ctx context.Context, in *GetFollowingIDsRequest, opts ...grpc.CallOption) (*GetFollowingIDsResponse, error)
 	// GetFollowersCount retrieves the follower count for a user
 	GetFollowersCount(ctx context.Context, in *GetFollowersCountRequest, opts ...grpc.CallOption) (*GetFollowersCountResponse, error)
 	// GetFollowingCount retrieves the following count for a user
 	GetFollowingCount(ctx context.Context, in *GetFollowingCountRequest, opts ...grpc.CallOption) (*GetFollowingCountResponse, error)
 	// CheckFollowRelationship checks if a follow relationship exists
 	CheckFollowRelationship(ctx context.Context, in *CheckFollowRelationshipRequest, opts ...grpc.CallOption) (*CheckFollowRelationshipResponse, error)
+	// BatchCheckFollowRelationship checks which of several users one user follows
+	BatchCheckFollowRelationship(ctx context.Context, in *BatchCheckFollowRelationshipRequest, opts ...grpc.CallOption) (*BatchCheckFollowRelationshipResponse, error)
 	// BatchCreateFollowRelationships creates multiple follow relationships (for data generation)
 	BatchCreateFollowRelationships(ctx context.Context, in *BatchCreateFollowRelationshipsRequest, opts ...grpc.CallOption) (*BatchCreateFollowRelationshipsResponse, error)
 }
@@ -101,6 +107,16 @@ func (c *socialGraphServiceClient) GetFollowingList(ctx context.Context, in *Get
 	return out, nil
 }
 
+func (c *socialGraphServiceClient) GetFollowingIDs(ctx context.Context, in *GetFollowingIDsRequest, opts ...grpc.CallOption) (*GetFollowingIDsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetFollowingIDsResponse)
+	err := c.cc.Invoke(ctx, SocialGraphService_GetFollowingIDs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *socialGraphServiceClient) GetFollowersCount(ctx context.Context, in *GetFollowersCountRequest, opts ...grpc.CallOption) (*GetFollowersCountResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetFollowersCountResponse)
@@ -131,6 +147,16 @@ func (c *socialGraphServiceClient) CheckFollowRelationship(ctx context.Context,
 	return out, nil
 }
 
+func (c *socialGraphServiceClient) BatchCheckFollowRelationship(ctx context.Context, in *BatchCheckFollowRelationshipRequest, opts ...grpc.CallOption) (*BatchCheckFollowRelationshipResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchCheckFollowRelationshipResponse)
+	err := c.cc.Invoke(ctx, SocialGraphService_BatchCheckFollowRelationship_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *socialGraphServiceClient) BatchCreateFollowRelationships(ctx context.Context, in *BatchCreateFollowRelationshipsRequest, opts ...grpc.CallOption) (*BatchCreateFollowRelationshipsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(BatchCreateFollowRelationshipsResponse)
@@ -155,12 +181,16 @@ type SocialGraphServiceServer interface {
 	GetFollowers(context.Context, *GetFollowersRequest) (*GetFollowersResponse, error)
 	// GetFollowingList retrieves the list of users that a specified user follows
 	GetFollowingList(context.Context, *GetFollowingListRequest) (*GetFollowingListResponse, error)
+	// GetFollowingIDs retrieves only the IDs of users that a specified user follows (no counts or cursors)
+	GetFollowingIDs(context.Context, *GetFollowingIDsRequest) (*GetFollowingIDsResponse, error)
 	// GetFollowersCount retrieves the follower count for a user
 	GetFollowersCount(context.Context, *GetFollowersCountRequest) (*GetFollowersCountResponse, error)
 	// GetFollowingCount retrieves the following count for a user
 	GetFollowingCount(context.Context, *GetFollowingCountRequest) (*GetFollowingCountResponse, error)
 	// CheckFollowRelationship checks if a follow relationship exists
 	CheckFollowRelationship(context.Context, *CheckFollowRelationshipRequest) (*CheckFollowRelationshipResponse, error)
+	// BatchCheckFollowRelationship checks which of several users one user follows
+	BatchCheckFollowRelationship(context.Context, *BatchCheckFollowRelationshipRequest) (*BatchCheckFollowRelationshipResponse, error)
 	// BatchCreateFollowRelationships creates multiple follow relationships (for data generation)
 	BatchCreateFollowRelationships(context.Context, *BatchCreateFollowRelationshipsRequest) (*BatchCreateFollowRelationshipsResponse, error)
 	mustEmbedUnimplementedSocialGraphServiceServer()
@@ -185,6 +215,9 @@ func (UnimplementedSocialGraphServiceServer) GetFollowers(context.Context, *GetF
 func (UnimplementedSocialGraphServiceServer) GetFollowingList(context.Context, *GetFollowingListRequest) (*GetFollowingListResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetFollowingList not implemented")
 }
+func (UnimplementedSocialGraphServiceServer) GetFollowingIDs(context.Context, *GetFollowingIDsRequest) (*GetFollowingIDsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFollowingIDs not implemented")
+}
 func (UnimplementedSocialGraphServiceServer) GetFollowersCount(context.Context, *GetFollowersCountRequest) (*GetFollowersCountResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetFollowersCount not implemented")
 }
@@ -194,6 +227,9 @@ func (UnimplementedSocialGraphServiceServer) GetFollowingCount(context.Context,
 func (UnimplementedSocialGraphServiceServer) CheckFollowRelationship(context.Context, *CheckFollowRelationshipRequest) (*CheckFollowRelationshipResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CheckFollowRelationship not implemented")
 }
+func (UnimplementedSocialGraphServiceServer) BatchCheckFollowRelationship(context.Context, *BatchCheckFollowRelationshipRequest) (*BatchCheckFollowRelationshipResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCheckFollowRelationship not implemented")
+}
 func (UnimplementedSocialGraphServiceServer) BatchCreateFollowRelationships(context.Context, *BatchCreateFollowRelationshipsRequest) (*BatchCreateFollowRelationshipsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BatchCreateFollowRelationships not implemented")
 }
@@ -290,6 +326,24 @@ func _SocialGraphService_GetFollowingList_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SocialGraphService_GetFollowingIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFollowingIDsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SocialGraphServiceServer).GetFollowingIDs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SocialGraphService_GetFollowingIDs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SocialGraphServiceServer).GetFollowingIDs(ctx, req.(*GetFollowingIDsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _SocialGraphService_GetFollowersCount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetFollowersCountRequest)
 	if err := dec(in); err != nil {
@@ -344,6 +398,24 @@ func _SocialGraphService_CheckFollowRelationship_Handler(srv interface{}, ctx co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SocialGraphService_BatchCheckFollowRelationship_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCheckFollowRelationshipRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SocialGraphServiceServer).BatchCheckFollowRelationship(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SocialGraphService_BatchCheckFollowRelationship_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SocialGraphServiceServer).BatchCheckFollowRelationship(ctx, req.(*BatchCheckFollowRelationshipRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _SocialGraphService_BatchCreateFollowRelationships_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(BatchCreateFollowRelationshipsRequest)
 	if err := dec(in); err != nil {
@@ -385,6 +457,10 @@ var SocialGraphService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetFollowingList",
 			Handler:    _SocialGraphService_GetFollowingList_Handler,
 		},
+		{
+			MethodName: "GetFollowingIDs",
+			Handler:    _SocialGraphService_GetFollowingIDs_Handler,
+		},
 		{
 			MethodName: "GetFollowersCount",
 			Handler:    _SocialGraphService_GetFollowersCount_Handler,
@@ -397,6 +473,10 @@ var SocialGraphService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CheckFollowRelationship",
 			Handler:    _SocialGraphService_CheckFollowRelationship_Handler,
 		},
+		{
+			MethodName: "BatchCheckFollowRelationship",
+			Handler:    _SocialGraphService_BatchCheckFollowRelationship_Handler,
+		},
 		{
 			MethodName: "BatchCreateFollowRelationships",
 			Handler:    _SocialGraphService_BatchCreateFollowRelationships_Handler,
@@ -0,0 +1,86 @@
+// Package usercache caches user_id → username lookups in front of the User Service.
+// Author names are read on every timeline and follower-list render but almost never
+// change, so a short TTL bounds how long a deleted user's name is still shown. Hit and
+// miss counts are kept for the services' health endpoints.
+package usercache
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/cs6650/proto/lru"
+)
+
+// Cache is an LRU of usernames whose entries also expire after a TTL.
+// A nil *Cache is valid and caches nothing, so callers need no special case when disabled.
+type Cache struct {
+	usernames *lru.Cache[int64, string]
+	hits      atomic.Int64
+	misses    atomic.Int64
+}
+
+// Stats is a snapshot of cache effectiveness, for health endpoints
+type Stats struct {
+	Enabled bool    `json:"enabled"`
+	Size    int     `json:"size"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+// New returns a cache holding up to capacity usernames for ttl each,
+// or nil (caching disabled) when capacity or ttl is not positive
+func New(capacity int, ttl time.Duration) *Cache {
+	if capacity <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &Cache{usernames: lru.New[int64, string](capacity, ttl)}
+}
+
+// Lookup returns the cached usernames among userIDs and the IDs that must be fetched
+func (c *Cache) Lookup(userIDs []int64) (map[int64]string, []int64) {
+	found := make(map[int64]string, len(userIDs))
+	if c == nil {
+		return found, userIDs
+	}
+
+	var misses []int64
+	for _, id := range userIDs {
+		if username, ok := c.usernames.Get(id); ok {
+			found[id] = username
+			c.hits.Add(1)
+		} else {
+			misses = append(misses, id)
+			c.misses.Add(1)
+		}
+	}
+	return found, misses
+}
+
+// Get returns the cached username for userID
+func (c *Cache) Get(userID int64) (string, bool) {
+	found, _ := c.Lookup([]int64{userID})
+	username, ok := found[userID]
+	return username, ok
+}
+
+// Set caches username for userID, evicting the least recently used entry when full
+func (c *Cache) Set(userID int64, username string) {
+	if c == nil {
+		return
+	}
+	c.usernames.Set(userID, username)
+}
+
+// Stats returns the current size and hit counters
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+
+	s := Stats{Enabled: true, Size: c.usernames.Len(), Hits: c.hits.Load(), Misses: c.misses.Load()}
+	if total := s.Hits + s.Misses; total > 0 {
+		s.HitRate = float64(s.Hits) / float64(total)
+	}
+	return s
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+)
+
+// tableNamePattern matches DynamoDB's table naming rules
+var tableNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,255}$`)
+
+type Config struct {
+	Env       string
+	AWSRegion string
+
+	// Post topic events arrive on QueueURL, a subscription of its own filtered to
+	// PostCreated and FeedWrite, and are recorded in TableName
+	QueueURL  string
+	TableName string
+
+	// Recorded events expire after EventTTL; 0 keeps them forever
+	EventTTL time.Duration
+}
+
+func Load() *Config {
+	return &Config{
+		Env:       getEnv("ENVIRONMENT", "dev"),
+		AWSRegion: getEnv("AWS_REGION", "us-west-2"),
+		QueueURL:  getEnv("ANALYTICS_QUEUE_URL", ""),
+		TableName: getEnv("ANALYTICS_TABLE_NAME", "fanout-analytics"),
+		EventTTL:  getEnvDuration("ANALYTICS_EVENT_TTL", 7*24*time.Hour),
+	}
+}
+
+// Validate rejects settings the service cannot run with
+func (c *Config) Validate() error {
+	if c.QueueURL == "" {
+		return fmt.Errorf("ANALYTICS_QUEUE_URL is required")
+	}
+	if !tableNamePattern.MatchString(c.TableName) {
+		return fmt.Errorf("invalid ANALYTICS_TABLE_NAME %q: must be 3-255 characters of letters, digits, '_', '.' or '-'", c.TableName)
+	}
+	if c.EventTTL < 0 {
+		return fmt.Errorf("invalid ANALYTICS_EVENT_TTL %s: must be >= 0", c.EventTTL)
+	}
+	return nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if durationVal, err := time.ParseDuration(value); err == nil {
+			return durationVal
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,190 @@
+// Package consumer records Post Service events for evaluating the fan-out strategies.
+// It reads its own SQS subscription to the post topic, so recording events never slows
+// timeline writes.
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamotypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/cs6650/proto/logthrottle"
+)
+
+// errorLogs throttles the failures logged on every poll while SQS or DynamoDB is down
+var errorLogs = logthrottle.New(5, time.Minute)
+
+// event is a message on the post topic: a FeedWrite fan-out message, or a PostCreated
+// event, which adds the path the Post Service took for the post
+type event struct {
+	models.SQSFeedMessage
+	Strategy      string `json:"strategy"`       // PostCreated only: push, pull, hybrid->push or hybrid->pull
+	FollowerCount *int32 `json:"follower_count"` // PostCreated only, when a hybrid decision looked it up
+}
+
+// Consumer polls the analytics queue and records one item per event, keyed by post_id
+// and event_id. A post has one "created" item holding the strategy chosen for it and,
+// when pushed, one "feed#<message ID>" item per fan-out message: its follower count is
+// the sum of their target_count. A redelivered message overwrites its own item rather
+// than counting twice.
+type Consumer struct {
+	sqsClient    *sqs.Client
+	dynamoClient *dynamodb.Client
+	queueURL     string
+	tableName    string
+	eventTTL     time.Duration // 0 keeps events forever
+
+	// Failed receives are retried after minBackoff, doubling up to maxBackoff
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	now        func() time.Time // Replaced in tests
+}
+
+func NewConsumer(sqsClient *sqs.Client, dynamoClient *dynamodb.Client, queueURL, tableName string, eventTTL time.Duration) *Consumer {
+	return &Consumer{
+		sqsClient:    sqsClient,
+		dynamoClient: dynamoClient,
+		queueURL:     queueURL,
+		tableName:    tableName,
+		eventTTL:     eventTTL,
+		minBackoff:   time.Second,
+		maxBackoff:   30 * time.Second,
+		now:          time.Now,
+	}
+}
+
+// Run polls until ctx is cancelled. Messages that fail to record are left for redelivery.
+func (c *Consumer) Run(ctx context.Context) error {
+	log.Println("Analytics consumer started, polling for messages...")
+
+	var backoff time.Duration
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Analytics consumer shutting down")
+			return nil
+		default:
+		}
+
+		result, err := c.sqsClient.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(c.queueURL),
+			MaxNumberOfMessages: int32(10),
+			WaitTimeSeconds:     int32(20), // Long polling
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			// Back off so an unreachable queue is not polled in a tight loop
+			backoff = c.nextBackoff(backoff)
+			errorLogs.Printf("Failed to receive analytics messages, retrying in %s: %v", backoff, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		backoff = 0
+
+		processed := make([]types.Message, 0, len(result.Messages))
+		for _, message := range result.Messages {
+			if err := c.record(ctx, message, c.now()); err != nil {
+				errorLogs.Printf("Failed to record message %s: %v", aws.ToString(message.MessageId), err)
+				continue
+			}
+			processed = append(processed, message)
+		}
+		if len(processed) > 0 {
+			c.deleteMessages(ctx, processed)
+		}
+	}
+}
+
+// nextBackoff returns the wait after a failed receive, given the wait after the
+// previous one (0 when the last receive succeeded)
+func (c *Consumer) nextBackoff(previous time.Duration) time.Duration {
+	return min(max(2*previous, c.minBackoff), c.maxBackoff)
+}
+
+// record writes the item for one message; events other than PostCreated and FeedWrite
+// are skipped
+func (c *Consumer) record(ctx context.Context, message types.Message, receivedAt time.Time) error {
+	var e event
+	if err := json.Unmarshal([]byte(aws.ToString(message.Body)), &e); err != nil {
+		return fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+	// Older publishers sent no post ID; such messages cannot be grouped by post
+	if e.PostID <= 0 {
+		return nil
+	}
+
+	item := map[string]dynamotypes.AttributeValue{
+		"post_id":       &dynamotypes.AttributeValueMemberN{Value: strconv.FormatInt(e.PostID, 10)},
+		"event_type":    &dynamotypes.AttributeValueMemberS{Value: e.EventType},
+		"author_id":     &dynamotypes.AttributeValueMemberN{Value: strconv.FormatInt(e.AuthorID, 10)},
+		"created_time":  &dynamotypes.AttributeValueMemberS{Value: e.CreatedTime.UTC().Format(time.RFC3339)},
+		"received_time": &dynamotypes.AttributeValueMemberS{Value: receivedAt.UTC().Format(time.RFC3339Nano)},
+	}
+	switch e.EventType {
+	case "PostCreated":
+		item["event_id"] = &dynamotypes.AttributeValueMemberS{Value: "created"}
+		item["strategy"] = &dynamotypes.AttributeValueMemberS{Value: e.Strategy}
+		if e.FollowerCount != nil {
+			item["follower_count"] = &dynamotypes.AttributeValueMemberN{Value: strconv.FormatInt(int64(*e.FollowerCount), 10)}
+		}
+	case "FeedWrite":
+		item["event_id"] = &dynamotypes.AttributeValueMemberS{Value: "feed#" + aws.ToString(message.MessageId)}
+		item["target_count"] = &dynamotypes.AttributeValueMemberN{Value: strconv.Itoa(len(e.TargetUserIDs))}
+		// Time from post creation to this consumer seeing the message: the SNS/SQS part of fan-out latency
+		item["delivery_lag_ms"] = &dynamotypes.AttributeValueMemberN{Value: strconv.FormatInt(receivedAt.Sub(e.CreatedTime).Milliseconds(), 10)}
+	default:
+		return nil
+	}
+	if c.eventTTL > 0 {
+		item["expires_at"] = &dynamotypes.AttributeValueMemberN{Value: strconv.FormatInt(receivedAt.Add(c.eventTTL).Unix(), 10)}
+	}
+
+	_, err := c.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write analytics event: %w", err)
+	}
+	return nil
+}
+
+// deleteMessages deletes recorded messages in a single batch call (a receive returns at
+// most 10, the DeleteMessageBatch limit). Failed deletes are redelivered and rewrite the
+// same items.
+func (c *Consumer) deleteMessages(ctx context.Context, messages []types.Message) {
+	entries := make([]types.DeleteMessageBatchRequestEntry, 0, len(messages))
+	for i, message := range messages {
+		entries = append(entries, types.DeleteMessageBatchRequestEntry{
+			Id:            aws.String(strconv.Itoa(i)),
+			ReceiptHandle: message.ReceiptHandle,
+		})
+	}
+
+	result, err := c.sqsClient.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String(c.queueURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		log.Printf("Failed to delete batch of %d analytics messages: %v", len(messages), err)
+		return
+	}
+	for _, failed := range result.Failed {
+		i, _ := strconv.Atoi(aws.ToString(failed.Id))
+		log.Printf("Failed to delete analytics message %s: %s %s", aws.ToString(messages[i].MessageId), aws.ToString(failed.Code), aws.ToString(failed.Message))
+	}
+}
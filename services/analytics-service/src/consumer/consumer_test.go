@@ -0,0 +1,182 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// fakeAWS serves SQS and DynamoDB. The first receive returns messages, later ones none,
+// or every receive fails when failReceives is set. Deleting a batch calls onDelete.
+type fakeAWS struct {
+	messages     []map[string]string
+	failReceives bool
+	onDelete     func()
+
+	mu       sync.Mutex
+	receives int
+	items    []map[string]map[string]string // PutItem items, as attribute -> type -> value
+	deleted  int
+}
+
+func (f *fakeAWS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	target := r.Header.Get("X-Amz-Target")
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch target {
+	case "AmazonSQS.ReceiveMessage":
+		f.receives++
+		if f.failReceives {
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, `{"__type":"InternalError","message":"unavailable"}`)
+			return
+		}
+		var messages []map[string]string
+		if f.receives == 1 {
+			messages = f.messages
+		}
+		json.NewEncoder(w).Encode(map[string]any{"Messages": messages})
+	case "AmazonSQS.DeleteMessageBatch":
+		var input struct{ Entries []json.RawMessage }
+		json.Unmarshal(body, &input)
+		f.deleted += len(input.Entries)
+		if f.onDelete != nil {
+			f.onDelete()
+		}
+		io.WriteString(w, `{}`)
+	case "DynamoDB_20120810.PutItem":
+		var input struct {
+			Item map[string]map[string]string
+		}
+		json.Unmarshal(body, &input)
+		f.items = append(f.items, input.Item)
+		io.WriteString(w, `{}`)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"__type":"UnknownOperationException"}`)
+	}
+}
+
+func newTestConsumer(t *testing.T, fake *fakeAWS) *Consumer {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	sqsClient := sqs.New(sqs.Options{
+		Region:                           "us-west-2",
+		BaseEndpoint:                     aws.String(server.URL),
+		Credentials:                      aws.AnonymousCredentials{},
+		DisableMessageChecksumValidation: true,
+		RetryMaxAttempts:                 1,
+	})
+	dynamoClient := dynamodb.New(dynamodb.Options{
+		Region:       "us-west-2",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials:  aws.AnonymousCredentials{},
+	})
+	return NewConsumer(sqsClient, dynamoClient, server.URL+"/queue", "fanout-analytics", time.Hour)
+}
+
+func message(id, body string) map[string]string {
+	return map[string]string{"MessageId": id, "ReceiptHandle": "r-" + id, "Body": body}
+}
+
+// PostCreated and FeedWrite messages are recorded under their post; every message,
+// recorded or skipped, is deleted
+func TestRunRecordsEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := &fakeAWS{
+		messages: []map[string]string{
+			message("m-1", `{"event_type":"PostCreated","post_id":7,"author_id":3,"created_time":"2026-01-01T00:00:00Z","strategy":"hybrid->pull","follower_count":5}`),
+			message("m-2", `{"event_type":"FeedWrite","post_id":8,"author_id":4,"target_user_ids":[1,2],"created_time":"2026-01-01T00:00:00Z"}`),
+			message("m-3", `{"event_type":"UserMentioned","post_id":7,"author_id":3}`),
+			message("m-4", `{"event_type":"FeedWrite","author_id":4,"target_user_ids":[1]}`), // No post ID
+		},
+		onDelete: cancel,
+	}
+	c := newTestConsumer(t, fake)
+	receivedAt := time.Date(2026, 1, 1, 0, 0, 2, 0, time.UTC)
+	c.now = func() time.Time { return receivedAt }
+
+	if err := c.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if fake.deleted != 4 {
+		t.Errorf("deleted %d messages, want 4", fake.deleted)
+	}
+	expiresAt := "1767229202" // receivedAt plus the hour TTL
+	want := []map[string]string{
+		{"post_id": "7", "event_id": "created", "event_type": "PostCreated", "author_id": "3", "strategy": "hybrid->pull", "follower_count": "5", "expires_at": expiresAt},
+		{"post_id": "8", "event_id": "feed#m-2", "event_type": "FeedWrite", "author_id": "4", "target_count": "2", "delivery_lag_ms": "2000", "expires_at": expiresAt},
+	}
+	if len(fake.items) != len(want) {
+		t.Fatalf("recorded %d items, want %d: %v", len(fake.items), len(want), fake.items)
+	}
+	for i, attributes := range want {
+		for name, value := range attributes {
+			if got := attributeValue(fake.items[i][name]); got != value {
+				t.Errorf("item %d: %s = %q, want %q", i, name, got, value)
+			}
+		}
+	}
+	if _, ok := fake.items[0]["target_count"]; ok {
+		t.Errorf("PostCreated item has a target_count")
+	}
+}
+
+// attributeValue returns the value of a DynamoDB JSON attribute, whatever its type
+func attributeValue(attribute map[string]string) string {
+	for _, value := range attribute {
+		return value
+	}
+	return ""
+}
+
+// While receives fail, the consumer backs off instead of polling in a tight loop
+func TestRunBacksOffFailedReceives(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	fake := &fakeAWS{failReceives: true}
+	c := newTestConsumer(t, fake)
+	c.minBackoff = 20 * time.Millisecond
+	c.maxBackoff = 80 * time.Millisecond
+
+	if err := c.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Waits of 20, 40, 80, 80... ms leave time for about six receives
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.receives < 2 || fake.receives > 8 {
+		t.Errorf("received %d times in 300ms, want 2-8", fake.receives)
+	}
+}
+
+// The first retry waits minBackoff and each later one twice as long, up to maxBackoff
+func TestBackoffDoubles(t *testing.T) {
+	c := &Consumer{minBackoff: time.Second, maxBackoff: 5 * time.Second}
+	var backoff time.Duration
+	var got []string
+	for range 5 {
+		backoff = c.nextBackoff(backoff)
+		got = append(got, backoff.String())
+	}
+	if want := "1s 2s 4s 5s 5s"; strings.Join(got, " ") != want {
+		t.Errorf("backoffs %s, want %s", strings.Join(got, " "), want)
+	}
+}
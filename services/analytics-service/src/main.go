@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/PCBZ/CS6650-Project/services/analytics-service/src/config"
+	"github.com/PCBZ/CS6650-Project/services/analytics-service/src/consumer"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+func main() {
+	// Load configuration
+	cfg := config.Load()
+	log.Printf("Loaded config: %+v", cfg)
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	log.Printf("Analytics Service starting - Environment: %s, Table: %s", cfg.Env, cfg.TableName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(cfg.AWSRegion))
+	if err != nil {
+		log.Fatalf("Failed to load AWS config: %v", err)
+	}
+
+	analyticsConsumer := consumer.NewConsumer(
+		sqs.NewFromConfig(awsCfg),
+		dynamodb.NewFromConfig(awsCfg),
+		cfg.QueueURL,
+		cfg.TableName,
+		cfg.EventTTL,
+	)
+
+	// Poll until SIGINT or SIGTERM; unrecorded messages are redelivered to the next task
+	consumeCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := analyticsConsumer.Run(consumeCtx); err != nil {
+		log.Printf("Analytics consumer failed: %v", err)
+		os.Exit(1)
+	}
+	log.Println("Analytics Service stopped")
+}
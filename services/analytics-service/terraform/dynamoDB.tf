@@ -0,0 +1,32 @@
+# ===================================
+# DynamoDB Table for Analytics Service
+# One item per post-created event and per fan-out message
+# ===================================
+
+resource "aws_dynamodb_table" "events" {
+  name         = "fanout-analytics-${var.service_name}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "post_id"
+  range_key    = "event_id"
+
+  attribute {
+    name = "post_id"
+    type = "N"
+  }
+
+  attribute {
+    name = "event_id"
+    type = "S"
+  }
+
+  # Events are for evaluating strategies over a test run, not kept forever
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
+
+  tags = {
+    Name    = "FanoutAnalytics-${var.service_name}"
+    Service = var.service_name
+  }
+}
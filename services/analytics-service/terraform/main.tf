@@ -0,0 +1,211 @@
+# Wire together modules: ecr, logging, and the queue, ECS service and DynamoDB table
+# of the analytics consumer. It serves no traffic, so it has no ALB target group or
+# Service Connect entry.
+
+module "ecr" {
+  source          = "./modules/ecr"
+  repository_name = var.ecr_repository_name
+}
+
+module "logging" {
+  source            = "./modules/logging"
+  service_name      = var.service_name
+  retention_in_days = var.log_retention_days
+}
+
+# SQS queue for the post-service events analytics records, apart from the timeline queue
+resource "aws_sqs_queue" "events" {
+  name                       = "${var.service_name}-${var.environment}-queue"
+  delay_seconds              = 0
+  max_message_size           = 262144  # 256 KB
+  message_retention_seconds  = 1209600 # 14 days
+  receive_wait_time_seconds  = 20      # Long polling
+  visibility_timeout_seconds = 30
+
+  tags = {
+    Name        = "${var.service_name}-${var.environment}-queue"
+    Environment = var.environment
+    Service     = var.service_name
+  }
+}
+
+# SQS Queue Policy to allow SNS to publish messages
+resource "aws_sqs_queue_policy" "events" {
+  queue_url = aws_sqs_queue.events.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect = "Allow"
+        Principal = {
+          Service = "sns.amazonaws.com"
+        }
+        Action   = "SQS:SendMessage"
+        Resource = aws_sqs_queue.events.arn
+        Condition = {
+          ArnEquals = {
+            "aws:SourceArn" = var.post_service_sns_topic_arn
+          }
+        }
+      }
+    ]
+  })
+}
+
+# Subscribe the queue to the post topic, for the two event types analytics records
+resource "aws_sns_topic_subscription" "events" {
+  topic_arn = var.post_service_sns_topic_arn
+  protocol  = "sqs"
+  endpoint  = aws_sqs_queue.events.arn
+
+  # Enable raw message delivery to avoid SNS wrapper
+  raw_message_delivery = true
+
+  filter_policy = jsonencode({
+    event_type = ["PostCreated", "FeedWrite"]
+  })
+}
+
+# Service-specific security group for ECS tasks: outbound only, to SQS and DynamoDB
+resource "aws_security_group" "app" {
+  name_prefix = "${var.service_name}-app-"
+  vpc_id      = var.vpc_id
+
+  egress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+    description = "Allow ${var.service_name} to access SQS and DynamoDB"
+  }
+
+  tags = {
+    Name    = "${var.service_name} Application Security Group"
+    Service = var.service_name
+  }
+}
+
+resource "aws_ecs_cluster" "main" {
+  name = var.service_name
+
+  tags = {
+    Name    = "${var.service_name} Cluster"
+    Service = var.service_name
+  }
+}
+
+resource "aws_ecs_task_definition" "app" {
+  family                   = var.service_name
+  network_mode             = "awsvpc"
+  requires_compatibilities = ["FARGATE"]
+  cpu                      = var.cpu
+  memory                   = var.memory
+  execution_role_arn       = var.execution_role_arn  # Innovation Sandbox with ISBStudent=true tag
+  task_role_arn            = var.task_role_arn       # Task role for DynamoDB/SQS access
+
+  runtime_platform {
+    operating_system_family = "LINUX"
+    cpu_architecture        = "X86_64"
+  }
+
+  container_definitions = jsonencode([
+    {
+      name  = var.service_name
+      image = "${module.ecr.repository_url}@${docker_registry_image.app.sha256_digest}"
+
+      environment = [
+        {
+          name  = "AWS_REGION"
+          value = var.aws_region
+        },
+        {
+          name  = "ANALYTICS_QUEUE_URL"
+          value = aws_sqs_queue.events.id
+        },
+        {
+          name  = "ANALYTICS_TABLE_NAME"
+          value = aws_dynamodb_table.events.name
+        },
+        {
+          name  = "ANALYTICS_EVENT_TTL"
+          value = var.event_ttl
+        },
+        {
+          name  = "ENVIRONMENT"
+          value = var.environment
+        }
+      ]
+
+      logConfiguration = {
+        logDriver = "awslogs"
+        options = {
+          "awslogs-group"         = module.logging.log_group_name
+          "awslogs-region"        = var.aws_region
+          "awslogs-stream-prefix" = "ecs"
+        }
+      }
+
+      essential = true
+    }
+  ])
+
+  tags = {
+    Name    = "${var.service_name} Task Definition"
+    Service = var.service_name
+  }
+}
+
+resource "aws_ecs_service" "app" {
+  name            = var.service_name
+  cluster         = aws_ecs_cluster.main.id
+  task_definition = aws_ecs_task_definition.app.arn
+  desired_count   = var.ecs_count
+  launch_type     = "FARGATE"
+
+  enable_execute_command = false
+  wait_for_steady_state  = false
+
+  network_configuration {
+    subnets          = var.public_subnet_ids
+    security_groups  = [aws_security_group.app.id]
+    assign_public_ip = true  # Reach SQS and DynamoDB through the IGW
+  }
+
+  # Ensure ECS service waits for Docker image to be pushed
+  depends_on = [docker_registry_image.app]
+
+  tags = {
+    Name    = "${var.service_name} Service"
+    Service = var.service_name
+  }
+}
+
+# Build & push the Go app image into ECR
+resource "docker_image" "app" {
+  name = "${module.ecr.repository_url}:latest"
+
+  build {
+    context     = "${path.module}/../../.."  # Project root (Dockerfile expects proto/)
+    dockerfile  = "services/analytics-service/Dockerfile"  # Path to Dockerfile from project root
+    platform    = "linux/amd64"  # Force x86_64 architecture for ECS Fargate
+    pull_parent = false
+    no_cache    = true
+    remove      = true
+  }
+
+  # Force rebuild on trigger changes
+  triggers = {
+    dockerfile_hash = filemd5("${path.module}/../Dockerfile")
+    src_hash        = sha1(join("", [for f in fileset("${path.module}/../src", "**/*.go") : filemd5("${path.module}/../src/${f}")]))
+    # Messages are decoded with the timeline service's models
+    models_hash     = sha1(join("", [for f in fileset("${path.module}/../../timeline-service/src/models", "*.go") : filemd5("${path.module}/../../timeline-service/src/models/${f}")]))
+  }
+}
+
+resource "docker_registry_image" "app" {
+  name = docker_image.app.name
+
+  # Ensure the image is built before pushing
+  depends_on = [docker_image.app]
+}
@@ -0,0 +1,4 @@
+output "repository_url" {
+  description = "The URL of the ECR repository"
+  value       = aws_ecr_repository.this.repository_url
+}
@@ -0,0 +1,4 @@
+variable "repository_name" {
+  description = "The name of the ECR repository"
+  type        = string
+}
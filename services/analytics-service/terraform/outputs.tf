@@ -0,0 +1,24 @@
+output "ecs_cluster_name" {
+  description = "Name of the created ECS cluster"
+  value       = aws_ecs_cluster.main.name
+}
+
+output "ecs_service_name" {
+  description = "Name of the running ECS service"
+  value       = aws_ecs_service.app.name
+}
+
+output "ecr_repository_url" {
+  description = "ECR repository URL"
+  value       = module.ecr.repository_url
+}
+
+output "queue_url" {
+  description = "URL of the analytics SQS queue"
+  value       = aws_sqs_queue.events.id
+}
+
+output "dynamodb_table_name" {
+  description = "DynamoDB table name for recorded events"
+  value       = aws_dynamodb_table.events.name
+}
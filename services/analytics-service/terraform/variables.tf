@@ -0,0 +1,76 @@
+# Region to deploy into
+variable "aws_region" {
+  type    = string
+  default = "us-west-2"
+}
+
+# Shared infrastructure values (passed from root terraform)
+variable "vpc_id" {
+  description = "VPC ID from shared infrastructure"
+  type        = string
+}
+
+variable "public_subnet_ids" {
+  description = "Public subnet IDs from shared infrastructure"
+  type        = list(string)
+}
+
+# ECR & ECS settings
+variable "ecr_repository_name" {
+  type    = string
+  default = "analytics-service"
+}
+
+variable "service_name" {
+  type    = string
+  default = "analytics-service"
+}
+
+variable "ecs_count" {
+  type    = number
+  default = 1
+}
+
+variable "cpu" {
+  type    = string
+  default = "256"
+}
+
+variable "memory" {
+  type    = string
+  default = "512"
+}
+
+# IAM Role for ECS Task Execution (Innovation Sandbox with ISBStudent tag)
+variable "execution_role_arn" {
+  description = "ARN of the ECS task execution role"
+  type        = string
+}
+
+variable "task_role_arn" {
+  description = "ARN of the ECS task role (for DynamoDB and SQS access)"
+  type        = string
+}
+
+variable "environment" {
+  description = "Environment name (dev, staging, prod)"
+  type        = string
+  default     = "dev"
+}
+
+variable "log_retention_days" {
+  type    = number
+  default = 7
+}
+
+# Analytics Service specific configuration
+variable "post_service_sns_topic_arn" {
+  description = "ARN of the post-service SNS topic whose events are recorded"
+  type        = string
+}
+
+variable "event_ttl" {
+  description = "How long recorded events are kept, as a Go duration; 0 keeps them forever"
+  type        = string
+  default     = "168h"
+}
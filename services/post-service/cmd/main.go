@@ -3,27 +3,37 @@ package main
 import (
 	"context"
 	"log"
+	"math/rand/v2"
 	"net"
 	"net/http"
-	"os"
 	"post-service/internal/client"
+	appConfig "post-service/internal/config"
 	"post-service/internal/handler"
+	"post-service/internal/idgen"
+	"post-service/internal/ratelimit"
 	"post-service/internal/repository"
 	"post-service/internal/service"
 	"sync"
 	"time"
 
+	"github.com/cs6650/proto/dynamotimeout"
+	"github.com/cs6650/proto/grpcdial"
+	"github.com/cs6650/proto/grpcreflection"
 	pb "github.com/cs6650/proto/post"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/keepalive"
 )
 
+// version is injected at build time via -ldflags "-X main.version=<version>"
+var version = "dev"
+
 // corsMiddleware handles CORS for requests from API Gateway
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -68,35 +78,107 @@ func main() {
 		log.Fatal("Failed to load AWS config: %w", err)
 	}
 
+	// Configuration
+	appCfg := appConfig.Load()
+	if err := appCfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
 	// Initialize AWS client
-	dynamoClient := dynamodb.NewFromConfig(cfg)
+	dynamoClient := dynamodb.NewFromConfig(cfg, dynamotimeout.WithOperationTimeout(appCfg.DynamoDBOperationTimeout))
 	snsClient := sns.NewFromConfig(cfg)
-
-	// Configuration
-	tableName := getEnv("DYNAMO_TABLE", "posts-table")
-	snsTopicARN := getEnv("SNS_TOPIC_ARN", "")
-	socialGraphURL := getEnv("SOCIAL_GRAPH_URL", "localhost:50052")
+	sqsClient := sqs.NewFromConfig(cfg)
+	log.Printf("DynamoDB Table: %s", appCfg.PostsTableName)
+	log.Printf("Post strategy: %s (push write-through: %t)", appCfg.PostStrategy, appCfg.PushWriteThrough)
+	log.Printf("Feature flags enabled: %s", appCfg.Features)
+	log.Printf("DynamoDB capacity mode: %s (batch workers: %d, fan-out concurrency: %d)",
+		appCfg.DynamoDBCapacityMode, appCfg.BatchWorkers(), appCfg.FanoutConcurrency())
 
 	//Initialize repository
-	postRepository := repository.NewPostRepository(dynamoClient, tableName)
+	postRepository := repository.NewPostRepository(dynamoClient, appCfg.PostsTableName, time.Duration(appCfg.PostTTLDays)*24*time.Hour, appCfg.BatchWorkers(), appCfg.UserIndexSortKey)
+	if appCfg.ValidateSchema {
+		if err := postRepository.ValidateUserIndex(context.Background(), appCfg.UserIndexSortKey); err != nil {
+			log.Fatalf("Posts table schema check failed: %v", err)
+		}
+		log.Printf("Posts table %s: user_id-index sorted by %s", appCfg.PostsTableName, appCfg.UserIndexSortKey)
+	}
 
 	//Initialize external service client
-	log.Printf("Initializing Social Graph client with endpoint: %s", socialGraphURL)
-	socialGraphClient, err := client.NewSocialGraphClient(socialGraphURL)
+	log.Printf("Initializing Social Graph client with endpoint: %s", appCfg.SocialGraphServiceEndpoint)
+	keepaliveParams := appCfg.KeepaliveParams()
+	socialGraphClient, err := client.NewSocialGraphClient(appCfg.SocialGraphServiceEndpoint, keepaliveParams)
 	if err != nil {
 		log.Fatalf("failed to create social graph client: %v", err)
 	}
 	defer socialGraphClient.Close()
 
 	//Initialize services
-	fanoutService := service.NewFanoutService(socialGraphClient, snsClient, snsTopicARN)
-	postService := service.NewPostService(postRepository, fanoutService)
+	fanoutService := service.NewFanoutService(socialGraphClient, snsClient, appCfg.SNSTopicARN, appCfg.PostStrategy, appCfg.FanoutConcurrency(), appCfg.FollowerCacheMaxIDs, appCfg.FollowerCacheTTL, appCfg.FanoutPublishAttempts, appCfg.FanoutRetryBaseDelay, sqsClient, appCfg.FanoutDLQURL)
+	if appCfg.FanoutDLQURL == "" {
+		log.Printf("FANOUT_DLQ_URL not set: fan-out messages that fail to publish will be dropped")
+	}
+
+	// Used for mentions and ?include_author= reads; it connects on first use
+	log.Printf("Initializing User Service client with endpoint: %s", appCfg.UserServiceEndpoint)
+	userClient, err := client.NewUserClient(appCfg.UserServiceEndpoint, keepaliveParams)
+	if err != nil {
+		log.Fatalf("failed to create user service client: %v", err)
+	}
+	defer userClient.Close()
+
+	var mentionService *service.MentionService
+	if appCfg.MaxMentionsPerPost > 0 {
+		mentionRepository := repository.NewMentionRepository(dynamoClient, appCfg.MentionsTableName)
+		mentionService = service.NewMentionService(userClient, mentionRepository, snsClient, appCfg.SNSTopicARN, appCfg.MaxMentionsPerPost)
+		log.Printf("Mentions: up to %d per post (table %s)", appCfg.MaxMentionsPerPost, appCfg.MentionsTableName)
+	}
+
+	// Post IDs embed the instance ID, so instances with distinct IDs never mint the same
+	// one. The random dev fallback can collide; the posts table rejects a taken ID then.
+	instanceID := appCfg.PostIDInstance
+	switch {
+	case instanceID >= 0:
+	case appCfg.PostIDLeaseTable != "":
+		lease, err := idgen.ClaimInstance(context.Background(), dynamoClient, appCfg.PostIDLeaseTable, appCfg.PostIDLeaseTTL)
+		if err != nil {
+			log.Fatalf("Failed to claim a post ID instance: %v", err)
+		}
+		// Exit rather than keep minting IDs another instance may now be minting too
+		go lease.KeepAlive(context.Background(), func(err error) { log.Fatalf("Stopping: %v", err) })
+		instanceID = lease.InstanceID
+		log.Printf("Claimed post ID instance %d from %s", instanceID, appCfg.PostIDLeaseTable)
+	default:
+		instanceID = rand.IntN(idgen.MaxInstanceID + 1)
+		log.Printf("POST_ID_INSTANCE not set, using random instance ID %d (dev only)", instanceID)
+	}
+	postIDs, err := idgen.NewGenerator(instanceID)
+	if err != nil {
+		log.Fatalf("Failed to create post ID generator: %v", err)
+	}
+
+	postService := service.NewPostService(postRepository, postIDs, fanoutService, mentionService, userClient, appCfg.PushMinFollowers, appCfg.HybridFollowerThreshold, appCfg.PushWriteThrough, appCfg.MaxContentLength, appCfg.Features.Enabled(appConfig.FeatureInlineFanout), appCfg.AnalyticsEvents, int32(appCfg.BatchGetPostsDefaultLimit), int32(appCfg.BatchGetPostsMaxLimit))
 
 	//Initialize gRPC Handler
-	grpcHandler := handler.NewGRPCHandler(postService)
+	grpcHandler := handler.NewGRPCHandler(postService, appCfg.PostStrategy)
+
+	//Initialize post creation rate limiter
+	var limiter ratelimit.Limiter
+	if appCfg.RateLimitPerMinute > 0 {
+		switch appCfg.RateLimitBackend {
+		case "dynamodb":
+			dynamoLimiter, err := ratelimit.NewDynamoDBLimiter(dynamoClient, appCfg.RateLimitTableName, appCfg.RateLimitPerMinute, time.Minute)
+			if err != nil {
+				log.Fatalf("Failed to create rate limiter: %v", err)
+			}
+			limiter = dynamoLimiter
+		default:
+			limiter = ratelimit.NewMemoryLimiter(appCfg.RateLimitPerMinute, time.Minute)
+		}
+		log.Printf("Post rate limit: %d/min per user (%s)", appCfg.RateLimitPerMinute, appCfg.RateLimitBackend)
+	}
 
 	//Initialize Post Handler
-	postHandler := handler.NewPostHandler(postService)
+	postHandler := handler.NewPostHandler(postService, limiter, appCfg.PostStrategy, version)
 
 	// Setup HTTP router
 	router := gin.Default()
@@ -106,10 +188,18 @@ func main() {
 	api := router.Group("/api")
 	{
 		api.POST("/posts", postHandler.ExecuteStrategy)
+		api.POST("/posts/batch", postHandler.BatchGetPosts)
+		api.GET("/posts/:post_id", postHandler.GetPost)
+		api.DELETE("/posts/:post_id", postHandler.DeletePost)
+		api.GET("/users/:user_id/posts", postHandler.GetUserPosts)
 		api.GET("/health", postHandler.Health)
 	}
 
 	router.POST("/posts", postHandler.ExecuteStrategy)
+	router.POST("/posts/batch", postHandler.BatchGetPosts)
+	router.GET("/posts/:post_id", postHandler.GetPost)
+	router.DELETE("/posts/:post_id", postHandler.DeletePost)
+	router.GET("/users/:user_id/posts", postHandler.GetUserPosts)
 	router.GET("/health", postHandler.Health)
 
 	var wg sync.WaitGroup
@@ -123,11 +213,19 @@ func main() {
 			log.Fatalf("failed to listen gRPC server: %v", err)
 		}
 
-		grpcServer := grpc.NewServer()
+		grpcServer := grpc.NewServer(
+			// Accept keepalive pings from clients, including on idle connections
+			grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+				MinTime:             grpcdial.MinKeepaliveTime,
+				PermitWithoutStream: true,
+			}),
+		)
 		pb.RegisterPostServiceServer(grpcServer, grpcHandler)
 
-		// Enable gRPC reflection for tools like grpcurl
-		reflection.Register(grpcServer)
+		// Reflection for grpcurl, on in dev; GRPC_REFLECTION overrides
+		if grpcreflection.Register(grpcServer) {
+			log.Println("gRPC reflection enabled")
+		}
 
 		log.Println("Post Service gRPC server running on :50053")
 		if err := grpcServer.Serve(lis); err != nil {
@@ -148,10 +246,3 @@ func main() {
 	wg.Wait()
 
 }
-
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
@@ -6,19 +6,25 @@ import (
 	"log"
 	"time"
 
+	"github.com/cs6650/proto/grpcdial"
+	"github.com/cs6650/proto/logthrottle"
 	pb "github.com/cs6650/proto/social_graph"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 )
 
+// retryLogs throttles retry logs, which every post writes while the Social Graph
+// Service is down
+var retryLogs = logthrottle.New(5, time.Minute)
+
 type SocialGraphClient struct {
 	client  pb.SocialGraphServiceClient
 	conn    *grpc.ClientConn
 	address string
 }
 
-func NewSocialGraphClient(address string) (*SocialGraphClient, error) {
+func NewSocialGraphClient(address string, keepaliveParams keepalive.ClientParameters) (*SocialGraphClient, error) {
 	log.Printf("Creating Social Graph Service client for %s (lazy connection)...", address)
 
 	// Use non-blocking connection - gRPC will connect when first RPC is made
@@ -26,8 +32,8 @@ func NewSocialGraphClient(address string) (*SocialGraphClient, error) {
 	// Remove WithBlock() to allow lazy connection
 	conn, err := grpc.Dial(
 		address,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		// No WithBlock() - connection will be established on first RPC call
+		grpcdial.Options(keepaliveParams)...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create gRPC client for %s: %w", address, err)
@@ -64,7 +70,7 @@ func (c *SocialGraphClient) GetFollowers(ctx context.Context, userID int64, limi
 
 			// Exponential backoff: 1s, 2s
 			backoff := time.Duration(1<<uint(i-1)) * time.Second
-			log.Printf("Retrying GetFollowers (attempt %d/%d) after %v...", i+1, maxRetries, backoff)
+			retryLogs.Printf("Retrying GetFollowers (attempt %d/%d) after %v...", i+1, maxRetries, backoff)
 			
 			select {
 			case <-time.After(backoff):
@@ -86,13 +92,55 @@ func (c *SocialGraphClient) GetFollowers(ctx context.Context, userID int64, limi
 		lastErr = err
 		// Log error but continue retrying
 		if i < maxRetries-1 {
-			log.Printf("GetFollowers failed (attempt %d/%d): %v", i+1, maxRetries, err)
+			retryLogs.Printf("GetFollowers failed (attempt %d/%d): %v", i+1, maxRetries, err)
 		}
 	}
 
 	return nil, fmt.Errorf("failed to get followers after %d attempts: %w", maxRetries, lastErr)
 }
 
+// GetFollowersCount returns a user's follower count without fetching a follower page.
+// The Social Graph Service reports lookup failures in ErrorMessage, returned here as errors.
+func (c *SocialGraphClient) GetFollowersCount(ctx context.Context, userID int64) (int32, error) {
+	callCtx := ctx
+	var cancel context.CancelFunc
+	if _, hasTimeout := ctx.Deadline(); !hasTimeout {
+		callCtx, cancel = context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+	}
+
+	// Same retry policy as GetFollowers
+	var lastErr error
+	maxRetries := 3
+	for i := 0; i < maxRetries; i++ {
+		if i > 0 {
+			backoff := time.Duration(1<<uint(i-1)) * time.Second
+			retryLogs.Printf("Retrying GetFollowersCount (attempt %d/%d) after %v...", i+1, maxRetries, backoff)
+
+			select {
+			case <-time.After(backoff):
+			case <-callCtx.Done():
+				return 0, fmt.Errorf("context cancelled during retry: %w", callCtx.Err())
+			}
+		}
+
+		resp, err := c.client.GetFollowersCount(callCtx, &pb.GetFollowersCountRequest{UserId: userID})
+		if err == nil && resp.ErrorMessage == "" {
+			return resp.FollowersCount, nil
+		}
+
+		if err == nil {
+			err = fmt.Errorf("social graph service: %s", resp.ErrorMessage)
+		}
+		lastErr = err
+		if i < maxRetries-1 {
+			retryLogs.Printf("GetFollowersCount failed (attempt %d/%d): %v", i+1, maxRetries, err)
+		}
+	}
+
+	return 0, fmt.Errorf("failed to get followers count after %d attempts: %w", maxRetries, lastErr)
+}
+
 func (c *SocialGraphClient) Close() {
     c.conn.Close()
 }
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/cs6650/proto/social_graph"
+	"google.golang.org/grpc"
+)
+
+// fakeSocialGraph answers GetFollowersCount and fails the test on a GetFollowers page fetch
+type fakeSocialGraph struct {
+	pb.SocialGraphServiceClient
+	t          *testing.T
+	counts     map[int64]int32
+	countCalls int
+}
+
+func (f *fakeSocialGraph) GetFollowersCount(ctx context.Context, req *pb.GetFollowersCountRequest, opts ...grpc.CallOption) (*pb.GetFollowersCountResponse, error) {
+	f.countCalls++
+	count, ok := f.counts[req.UserId]
+	if !ok {
+		return &pb.GetFollowersCountResponse{UserId: req.UserId, ErrorMessage: "user not found"}, nil
+	}
+	return &pb.GetFollowersCountResponse{UserId: req.UserId, FollowersCount: count}, nil
+}
+
+func (f *fakeSocialGraph) GetFollowers(ctx context.Context, req *pb.GetFollowersRequest, opts ...grpc.CallOption) (*pb.GetFollowersResponse, error) {
+	f.t.Errorf("GetFollowers called for user %d, want only the count RPC", req.UserId)
+	return &pb.GetFollowersResponse{}, nil
+}
+
+func TestGetFollowersCountUsesCountRPC(t *testing.T) {
+	fake := &fakeSocialGraph{t: t, counts: map[int64]int32{1: 0, 2: 9999, 3: 10000}}
+	c := &SocialGraphClient{client: fake}
+
+	for userID, want := range fake.counts {
+		got, err := c.GetFollowersCount(context.Background(), userID)
+		if err != nil {
+			t.Fatalf("GetFollowersCount(%d) error: %v", userID, err)
+		}
+		if got != want {
+			t.Errorf("GetFollowersCount(%d) = %d, want %d", userID, got, want)
+		}
+	}
+	if fake.countCalls != len(fake.counts) {
+		t.Errorf("count RPC called %d times, want %d", fake.countCalls, len(fake.counts))
+	}
+}
+
+// An error reported in the response is returned rather than read as a count of 0
+func TestGetFollowersCountReturnsServiceError(t *testing.T) {
+	fake := &fakeSocialGraph{t: t}
+	c := &SocialGraphClient{client: fake}
+
+	// The deadline ends the retries during the first backoff
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if count, err := c.GetFollowersCount(ctx, 42); err == nil {
+		t.Errorf("GetFollowersCount = %d, want error", count)
+	}
+}
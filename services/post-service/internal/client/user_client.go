@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	pb "github.com/cs6650/proto"
+	"github.com/cs6650/proto/grpcdial"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+type UserClient struct {
+	client  pb.UserServiceClient
+	conn    *grpc.ClientConn
+	address string
+}
+
+func NewUserClient(address string, keepaliveParams keepalive.ClientParameters) (*UserClient, error) {
+	log.Printf("Creating User Service client for %s (lazy connection)...", address)
+
+	// Non-blocking like the Social Graph client; the connection is made on first use
+	conn, err := grpc.Dial(address, grpcdial.Options(keepaliveParams)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client for %s: %w", address, err)
+	}
+
+	return &UserClient{
+		client:  pb.NewUserServiceClient(conn),
+		conn:    conn,
+		address: address,
+	}, nil
+}
+
+// GetUserIDByUsername resolves a username to its user ID
+// The bool result is false when no user has that username
+func (c *UserClient) GetUserIDByUsername(ctx context.Context, username string) (int64, bool, error) {
+	resp, err := c.client.GetUserByUsername(ctx, &pb.GetUserByUsernameRequest{Username: username})
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get user %q: %w", username, err)
+	}
+
+	switch resp.ErrorCode {
+	case "":
+		return resp.User.GetUserId(), true, nil
+	case "NOT_FOUND", "INVALID_ARGUMENT":
+		return 0, false, nil
+	default:
+		return 0, false, fmt.Errorf("failed to get user %q: %s", username, resp.ErrorMessage)
+	}
+}
+
+// GetUsernames returns the usernames of the given users in one BatchGetUserInfo call
+// Users that do not exist are left out of the result rather than reported as errors
+func (c *UserClient) GetUsernames(ctx context.Context, userIDs []int64) (map[int64]string, error) {
+	resp, err := c.client.BatchGetUserInfo(ctx, &pb.BatchGetUserInfoRequest{UserIds: userIDs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	if resp.ErrorCode != "" {
+		return nil, fmt.Errorf("failed to get users: %s", resp.ErrorMessage)
+	}
+
+	usernames := make(map[int64]string, len(resp.Users))
+	for userID, user := range resp.Users {
+		usernames[userID] = user.GetUsername()
+	}
+	return usernames, nil
+}
+
+func (c *UserClient) Close() {
+	c.conn.Close()
+}
@@ -0,0 +1,316 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"post-service/internal/idgen"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cs6650/proto/featureflags"
+	"github.com/cs6650/proto/grpcdial"
+	"google.golang.org/grpc/keepalive"
+)
+
+// tableNamePattern matches DynamoDB's table naming rules
+var tableNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,255}$`)
+
+// maxSNSContentLength leaves headroom under SNS's 256KB message limit for the
+// rest of the fan-out message (target user IDs, JSON escaping)
+const maxSNSContentLength = 128 * 1024
+
+// Concurrency presets for DYNAMODB_CAPACITY_MODE:
+//
+//	mode         batch read workers   push fan-out publishes in flight
+//	ondemand     50                   8
+//	provisioned  10                   2
+//
+// Batch read workers run the per-user queries behind BatchGetPosts. Each fan-out
+// publish becomes timeline writes downstream, so fewer in flight paces the write
+// rate for small provisioned tables that would otherwise throttle.
+var capacityModePresets = map[string]struct{ batchWorkers, fanoutConcurrency int }{
+	"ondemand":    {batchWorkers: 50, fanoutConcurrency: 8},
+	"provisioned": {batchWorkers: 10, fanoutConcurrency: 2},
+}
+
+// FeatureInlineFanout (FEATURE_INLINE_FANOUT) runs push fan-out before responding
+// instead of in the background, so followers can see the post once it is created
+const FeatureInlineFanout = "INLINE_FANOUT"
+
+type Config struct {
+	// DynamoDB
+	TablePrefix    string
+	PostsTableName string
+
+	// Sort key that user_id-index must have for newest-first reads; checked at
+	// startup against the live table unless ValidateSchema is false
+	UserIndexSortKey string
+	ValidateSchema   bool
+
+	// Days before a post expires from the posts table; 0 disables expiry. Expired posts
+	// are deleted, not archived. The default year outlives the Timeline Service's
+	// 30-day timeline copies, so a post is never listed in a timeline after it is gone.
+	PostTTLDays int
+
+	// Ceiling on each DynamoDB operation, retries included, whatever the caller's
+	// deadline; 0 disables it
+	DynamoDBOperationTimeout time.Duration
+
+	// Push mode also writes the canonical post to the posts table
+	PushWriteThrough bool
+
+	// Capacity mode of the DynamoDB tables (ondemand or provisioned); tunes batch concurrency
+	DynamoDBCapacityMode string
+
+	// SNS
+	SNSTopicARN string
+
+	// Maximum post content size in bytes, enforced for every transport
+	MaxContentLength int
+
+	// Push fan-out caches up to FollowerCacheMaxIDs follower IDs, across all authors, for
+	// FollowerCacheTTL, so bursts of posts reuse one follower walk; a size or TTL of 0 disables it
+	FollowerCacheMaxIDs int
+	FollowerCacheTTL  time.Duration
+
+	// Fan-out SNS publishes are tried FanoutPublishAttempts times, backing off from
+	// FanoutRetryBaseDelay; messages still failing go to the SQS queue at FanoutDLQURL
+	// for replay, or are dropped when it is empty
+	FanoutPublishAttempts int
+	FanoutRetryBaseDelay  time.Duration
+	FanoutDLQURL          string
+
+	// BatchGetPosts: posts per user when a request gives no limit, and the most it may ask for
+	BatchGetPostsDefaultLimit int
+	BatchGetPostsMaxLimit     int
+
+	// Instance ID embedded in post IDs, unique among running instances; -1 claims one
+	// from PostIDLeaseTable, or without a table picks one at random on startup, which is
+	// only unlikely, not guaranteed, to be unique, so it is allowed only when Env is dev
+	PostIDInstance int
+
+	// DynamoDB table instance IDs are leased from, each lease expiring PostIDLeaseTTL
+	// after its holder last renewed it
+	PostIDLeaseTable string
+	PostIDLeaseTTL   time.Duration
+
+	// Deployment environment (ENVIRONMENT); anything but dev requires POST_ID_INSTANCE
+	// or POST_ID_LEASE_TABLE
+	Env string
+
+	// Write strategy: push, pull or hybrid
+	PostStrategy string
+
+	// Hybrid strategy: authors with fewer followers than PushMinFollowers, or at least
+	// HybridFollowerThreshold, are pull-only
+	PushMinFollowers        int
+	HybridFollowerThreshold int
+
+	// Post creation rate limit per user; 0 disables limiting
+	RateLimitBackend   string
+	RateLimitPerMinute int
+	RateLimitTableName string
+
+	// Publish a PostCreated event to the SNS topic for every post, with the strategy
+	// chosen for it, for the Analytics Service
+	AnalyticsEvents bool
+
+	// Mentions: at most MaxMentionsPerPost distinct @usernames per post; 0 disables
+	MentionsTableName  string
+	MaxMentionsPerPost int
+
+	// External Services
+	SocialGraphServiceEndpoint string
+	UserServiceEndpoint        string
+
+	// gRPC client keepalive: ping after KeepaliveTime idle, drop after KeepaliveTimeout without ack
+	KeepaliveTime     time.Duration
+	KeepaliveTimeout  time.Duration
+	KeepaliveWhenIdle bool
+
+	// Experimental behavior toggled by FEATURE_<NAME> variables
+	Features featureflags.Flags
+}
+
+func Load() *Config {
+	tablePrefix := getEnv("TABLE_PREFIX", "")
+	return &Config{
+		TablePrefix:                tablePrefix,
+		PostsTableName:             tablePrefix + getEnv("DYNAMO_TABLE", "posts-table"),
+		UserIndexSortKey:           getEnv("USER_INDEX_SORT_KEY", "timestamp"),
+		ValidateSchema:             getEnvBool("VALIDATE_TABLE_SCHEMA", true),
+		PostTTLDays:                getEnvInt("POST_TTL_DAYS", 365),
+		DynamoDBOperationTimeout:   getEnvDuration("DYNAMODB_OPERATION_TIMEOUT", 5*time.Second),
+		PushWriteThrough:           getEnvBool("PUSH_WRITE_THROUGH", true),
+		DynamoDBCapacityMode:       strings.ToLower(getEnv("DYNAMODB_CAPACITY_MODE", "ondemand")),
+		SNSTopicARN:                getEnv("SNS_TOPIC_ARN", ""),
+		MaxContentLength:           getEnvInt("MAX_CONTENT_LENGTH", 280),
+		FollowerCacheMaxIDs:        getEnvInt("FOLLOWER_CACHE_MAX_IDS", 1000000),
+		FollowerCacheTTL:           getEnvDuration("FOLLOWER_CACHE_TTL", 30*time.Second),
+		FanoutPublishAttempts:      getEnvInt("FANOUT_PUBLISH_ATTEMPTS", 3),
+		FanoutRetryBaseDelay:       getEnvDuration("FANOUT_RETRY_BASE_DELAY", 200*time.Millisecond),
+		FanoutDLQURL:               getEnv("FANOUT_DLQ_URL", ""),
+		BatchGetPostsDefaultLimit:  getEnvInt("BATCH_GET_POSTS_DEFAULT_LIMIT", 50),
+		BatchGetPostsMaxLimit:      getEnvInt("BATCH_GET_POSTS_MAX_LIMIT", 100),
+		PostIDInstance:             getEnvInt("POST_ID_INSTANCE", -1),
+		PostIDLeaseTable:           getEnv("POST_ID_LEASE_TABLE", ""),
+		PostIDLeaseTTL:             getEnvDuration("POST_ID_LEASE_TTL", time.Minute),
+		Env:                        strings.ToLower(getEnv("ENVIRONMENT", "dev")),
+		PostStrategy:               strings.ToLower(getEnv("POST_STRATEGY", "hybrid")),
+		PushMinFollowers:           getEnvInt("PUSH_MIN_FOLLOWERS", 0),
+		HybridFollowerThreshold:    getEnvInt("HYBRID_FOLLOWER_THRESHOLD", getEnvInt("HYBRID_THRESHOLD", 10000)), // HYBRID_THRESHOLD is the old name
+		RateLimitBackend:           getEnv("RATE_LIMIT_BACKEND", "memory"),
+		RateLimitPerMinute:         getEnvInt("RATE_LIMIT_PER_MINUTE", 0),
+		RateLimitTableName:         tablePrefix + getEnv("RATE_LIMIT_TABLE", "post-rate-limits"),
+		MentionsTableName:          tablePrefix + getEnv("MENTIONS_TABLE", "post-mentions"),
+		MaxMentionsPerPost:         getEnvInt("MAX_MENTIONS_PER_POST", 10),
+		AnalyticsEvents:            getEnvBool("ANALYTICS_EVENTS", false),
+		SocialGraphServiceEndpoint: getEnv("SOCIAL_GRAPH_URL", "localhost:50052"),
+		UserServiceEndpoint:        getEnv("USER_SERVICE_URL", "localhost:50051"),
+		KeepaliveTime:              getEnvDuration("GRPC_KEEPALIVE_TIME", 30*time.Second),
+		KeepaliveTimeout:           getEnvDuration("GRPC_KEEPALIVE_TIMEOUT", 10*time.Second),
+		KeepaliveWhenIdle:          getEnvBool("GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM", true),
+		Features:                   featureflags.Load(),
+	}
+}
+
+// Validate checks that the loaded configuration is usable
+func (c *Config) Validate() error {
+	if !tableNamePattern.MatchString(c.PostsTableName) {
+		return fmt.Errorf("invalid posts table name %q: must be 3-255 characters of letters, digits, '_', '.' or '-'", c.PostsTableName)
+	}
+	// Both are set from the creation time (post_id starts with it), so either orders by time
+	switch c.UserIndexSortKey {
+	case "timestamp", "post_id":
+	default:
+		return fmt.Errorf("invalid USER_INDEX_SORT_KEY %q: must be 'timestamp' or 'post_id'", c.UserIndexSortKey)
+	}
+	switch c.PostStrategy {
+	case "push", "pull", "hybrid":
+	default:
+		return fmt.Errorf("invalid POST_STRATEGY %q: must be 'push', 'pull', or 'hybrid'", c.PostStrategy)
+	}
+	if _, ok := capacityModePresets[c.DynamoDBCapacityMode]; !ok {
+		return fmt.Errorf("invalid DYNAMODB_CAPACITY_MODE %q: must be 'ondemand' or 'provisioned'", c.DynamoDBCapacityMode)
+	}
+	// Fan-out messages carry the content and SNS rejects messages over 256KB
+	if c.MaxContentLength <= 0 || c.MaxContentLength > maxSNSContentLength {
+		return fmt.Errorf("invalid MAX_CONTENT_LENGTH %d: must be between 1 and %d", c.MaxContentLength, maxSNSContentLength)
+	}
+	if c.PostIDInstance < -1 || c.PostIDInstance > idgen.MaxInstanceID {
+		return fmt.Errorf("invalid POST_ID_INSTANCE %d: must be -1 or between 0 and %d", c.PostIDInstance, idgen.MaxInstanceID)
+	}
+	if c.PostIDInstance == -1 && c.PostIDLeaseTable == "" && c.Env != "dev" {
+		return fmt.Errorf("POST_ID_INSTANCE or POST_ID_LEASE_TABLE must be set in environment %q: a random instance ID may collide with another instance's", c.Env)
+	}
+	if c.PostIDLeaseTTL < 3*time.Second {
+		return fmt.Errorf("invalid POST_ID_LEASE_TTL %s: must be >= 3s", c.PostIDLeaseTTL)
+	}
+	if c.FollowerCacheMaxIDs < 0 {
+		return fmt.Errorf("invalid FOLLOWER_CACHE_MAX_IDS %d: must be >= 0", c.FollowerCacheMaxIDs)
+	}
+	if c.FollowerCacheTTL < 0 {
+		return fmt.Errorf("invalid FOLLOWER_CACHE_TTL %s: must be >= 0", c.FollowerCacheTTL)
+	}
+	if c.FanoutPublishAttempts < 1 {
+		return fmt.Errorf("invalid FANOUT_PUBLISH_ATTEMPTS %d: must be >= 1", c.FanoutPublishAttempts)
+	}
+	if c.FanoutRetryBaseDelay < 0 {
+		return fmt.Errorf("invalid FANOUT_RETRY_BASE_DELAY %s: must be >= 0", c.FanoutRetryBaseDelay)
+	}
+	if c.BatchGetPostsMaxLimit <= 0 {
+		return fmt.Errorf("invalid BATCH_GET_POSTS_MAX_LIMIT %d: must be > 0", c.BatchGetPostsMaxLimit)
+	}
+	if c.BatchGetPostsDefaultLimit <= 0 || c.BatchGetPostsDefaultLimit > c.BatchGetPostsMaxLimit {
+		return fmt.Errorf("invalid BATCH_GET_POSTS_DEFAULT_LIMIT %d: must be between 1 and BATCH_GET_POSTS_MAX_LIMIT (%d)", c.BatchGetPostsDefaultLimit, c.BatchGetPostsMaxLimit)
+	}
+	if err := grpcdial.ValidateKeepalive(c.KeepaliveParams()); err != nil {
+		return err
+	}
+	if c.PostTTLDays < 0 {
+		return fmt.Errorf("invalid POST_TTL_DAYS %d: must be >= 0", c.PostTTLDays)
+	}
+	if c.DynamoDBOperationTimeout < 0 {
+		return fmt.Errorf("invalid DYNAMODB_OPERATION_TIMEOUT %s: must be >= 0", c.DynamoDBOperationTimeout)
+	}
+	if c.PushMinFollowers < 0 {
+		return fmt.Errorf("invalid PUSH_MIN_FOLLOWERS %d: must be >= 0", c.PushMinFollowers)
+	}
+	if c.HybridFollowerThreshold <= 0 {
+		return fmt.Errorf("invalid HYBRID_FOLLOWER_THRESHOLD %d: must be > 0", c.HybridFollowerThreshold)
+	}
+	if c.RateLimitPerMinute < 0 {
+		return fmt.Errorf("invalid RATE_LIMIT_PER_MINUTE %d: must be >= 0", c.RateLimitPerMinute)
+	}
+	switch c.RateLimitBackend {
+	case "memory":
+	case "dynamodb":
+		if !tableNamePattern.MatchString(c.RateLimitTableName) {
+			return fmt.Errorf("invalid rate limit table name %q: must be 3-255 characters of letters, digits, '_', '.' or '-'", c.RateLimitTableName)
+		}
+	default:
+		return fmt.Errorf("invalid RATE_LIMIT_BACKEND %q: must be 'memory' or 'dynamodb'", c.RateLimitBackend)
+	}
+	if c.MaxMentionsPerPost < 0 {
+		return fmt.Errorf("invalid MAX_MENTIONS_PER_POST %d: must be >= 0", c.MaxMentionsPerPost)
+	}
+	if c.MaxMentionsPerPost > 0 && !tableNamePattern.MatchString(c.MentionsTableName) {
+		return fmt.Errorf("invalid mentions table name %q: must be 3-255 characters of letters, digits, '_', '.' or '-'", c.MentionsTableName)
+	}
+	return nil
+}
+
+// BatchWorkers is the worker pool size for the repository's batch reads
+func (c *Config) BatchWorkers() int {
+	return capacityModePresets[c.DynamoDBCapacityMode].batchWorkers
+}
+
+// FanoutConcurrency is how many push fan-out batches are published at once
+func (c *Config) FanoutConcurrency() int {
+	return capacityModePresets[c.DynamoDBCapacityMode].fanoutConcurrency
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if durationValue, err := time.ParseDuration(value); err == nil {
+			return durationValue
+		}
+	}
+	return defaultValue
+}
+
+// KeepaliveParams returns the keepalive settings for outgoing gRPC clients
+func (c *Config) KeepaliveParams() keepalive.ClientParameters {
+	return keepalive.ClientParameters{
+		Time:                c.KeepaliveTime,
+		Timeout:             c.KeepaliveTimeout,
+		PermitWithoutStream: c.KeepaliveWhenIdle,
+	}
+}
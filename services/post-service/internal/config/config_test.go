@@ -0,0 +1,64 @@
+package config
+
+import "testing"
+
+func TestHybridFollowerThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold string // HYBRID_FOLLOWER_THRESHOLD
+		legacy    string // HYBRID_THRESHOLD
+		want      int
+	}{
+		{name: "default", want: 10000},
+		{name: "set", threshold: "500", want: 500},
+		{name: "old name", legacy: "700", want: 700},
+		{name: "new name wins", threshold: "500", legacy: "700", want: 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HYBRID_FOLLOWER_THRESHOLD", tt.threshold)
+			t.Setenv("HYBRID_THRESHOLD", tt.legacy)
+			if got := Load().HybridFollowerThreshold; got != tt.want {
+				t.Errorf("HybridFollowerThreshold = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsNonPositiveHybridThreshold(t *testing.T) {
+	for _, threshold := range []int{0, -1} {
+		cfg := Load()
+		cfg.HybridFollowerThreshold = threshold
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate with HybridFollowerThreshold %d: want error", threshold)
+		}
+	}
+
+	if err := Load().Validate(); err != nil {
+		t.Errorf("Validate with defaults: %v", err)
+	}
+}
+
+func TestValidatePostIDInstanceSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		instance int
+		table    string
+		wantErr  bool
+	}{
+		{name: "dev picks at random", env: "dev", instance: -1},
+		{name: "prod without a source", env: "prod", instance: -1, wantErr: true},
+		{name: "prod with an instance", env: "prod", instance: 3},
+		{name: "prod with a lease table", env: "prod", instance: -1, table: "post-id-leases"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Load()
+			cfg.Env, cfg.PostIDInstance, cfg.PostIDLeaseTable = tt.env, tt.instance, tt.table
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"log"
+	"post-service/internal/repository"
 	"post-service/internal/service"
 
 	pb "github.com/cs6650/proto/post"
@@ -11,11 +13,13 @@ import (
 type GRPCHandler struct {
 	pb.UnimplementedPostServiceServer
 	postService *service.PostService
+	strategy    string
 }
 
-func NewGRPCHandler(postService *service.PostService) *GRPCHandler {
+func NewGRPCHandler(postService *service.PostService, strategy string) *GRPCHandler {
 	return &GRPCHandler{
 		postService: postService,
+		strategy:    strategy,
 	}
 }
 
@@ -32,3 +36,39 @@ func (h *GRPCHandler) BatchGetPosts(ctx context.Context, req *pb.BatchGetPostsRe
 		UserPosts: userPosts,
 	},nil
 }
+
+// GetPostCount endpoint
+func (h *GRPCHandler) GetPostCount(ctx context.Context, req *pb.GetPostCountRequest) (*pb.GetPostCountResponse, error) {
+	count, err := h.postService.GetPostCount(ctx, req.UserId)
+	if err != nil {
+		return &pb.GetPostCountResponse{
+			ErrorMessage: err.Error(),
+		}, nil
+	}
+	return &pb.GetPostCountResponse{
+		Count: count,
+	}, nil
+}
+
+// DeletePost endpoint
+func (h *GRPCHandler) DeletePost(ctx context.Context, req *pb.DeletePostRequest) (*pb.DeletePostResponse, error) {
+	err := h.postService.DeletePost(ctx, req.PostId)
+	if errors.Is(err, repository.ErrPostNotFound) {
+		return &pb.DeletePostResponse{Found: false}, nil
+	}
+	if err != nil {
+		return &pb.DeletePostResponse{
+			ErrorMessage: err.Error(),
+		}, nil
+	}
+	return &pb.DeletePostResponse{
+		Found: true,
+	}, nil
+}
+
+// GetWriteStrategy reports the active POST_STRATEGY so readers can detect a mismatch
+func (h *GRPCHandler) GetWriteStrategy(ctx context.Context, req *pb.GetWriteStrategyRequest) (*pb.GetWriteStrategyResponse, error) {
+	return &pb.GetWriteStrategyResponse{
+		Strategy: h.strategy,
+	}, nil
+}
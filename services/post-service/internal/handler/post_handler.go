@@ -1,13 +1,17 @@
 package handler
 
 import (
+	"errors"
+	"log"
 	"net/http"
-	"os"
 	"post-service/internal/model"
+	"post-service/internal/ratelimit"
+	"post-service/internal/repository"
 	"post-service/internal/service"
 	"strconv"
-	"strings"
+	"time"
 
+	"github.com/cs6650/proto/dynamotimeout"
 	pb "github.com/cs6650/proto/post"
 
 	"github.com/gin-gonic/gin"
@@ -15,11 +19,19 @@ import (
 
 type PostHandler struct {
 	postService *service.PostService
+	limiter     ratelimit.Limiter // nil disables rate limiting
+	strategy    string
+	version     string
+	startTime   time.Time
 }
 
-func NewPostHandler(postService *service.PostService) *PostHandler {
+func NewPostHandler(postService *service.PostService, limiter ratelimit.Limiter, strategy, version string) *PostHandler {
 	return &PostHandler{
 		postService: postService,
+		limiter:     limiter,
+		strategy:    strategy,
+		version:     version,
+		startTime:   time.Now(),
 	}
 }
 
@@ -31,104 +43,184 @@ func (h *PostHandler) ExecuteStrategy(c *gin.Context) {
 		return
 	}
 
-	// Get strategy from environment variable, default to "hybrid"
-	strategy := strings.ToLower(os.Getenv("POST_STRATEGY"))
-	if strategy == "" {
-		strategy = "hybrid"
+	if h.limiter != nil {
+		allowed, err := h.limiter.Allow(c.Request.Context(), strconv.FormatInt(req.UserID, 10))
+		if err != nil {
+			// Fail open so a limiter outage doesn't block posting
+			log.Printf("Rate limiter error for user %d: %v", req.UserID, err)
+		} else if !allowed {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded", "error_code": "RATE_LIMITED"})
+			return
+		}
 	}
 
+	// Strategy comes from POST_STRATEGY, validated at startup
+	strategy := h.strategy
+
 	var post *pb.Post
+	var decision *model.StrategyDecision
 	var err error
 	var message string
-	hybridThreshold, err := strconv.Atoi(os.Getenv("HYBRID_THRESHOLD"))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
 
 	switch strategy {
 	case "push":
 		post, err = h.postService.PushStrategy(c.Request.Context(), &req)
+		decision = &model.StrategyDecision{Path: "push"}
 		message = "Push to Followers' Feeds successfully"
 	case "pull":
 		post, err = h.postService.PullStrategy(c.Request.Context(), &req)
+		decision = &model.StrategyDecision{Path: "pull"}
 		message = "Save to Posts(Pull) successfully"
 	case "hybrid":
-		post, err = h.postService.HybridStrategy(c.Request.Context(), &req, hybridThreshold)
+		post, decision, err = h.postService.HybridStrategy(c.Request.Context(), &req)
 		message = "Run Hybrid Strategy successfully"
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid POST_STRATEGY. Must be 'push', 'pull', or 'hybrid'"})
 		return
 	}
 
+	if errors.Is(err, service.ErrContentTooLong) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "error_code": "CONTENT_TOO_LONG"})
+		return
+	}
+	if errors.Is(err, service.ErrEmptyContent) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "error_code": "EMPTY_CONTENT"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"post": post, "message": message, "strategy": strategy})
+	c.JSON(http.StatusOK, gin.H{"post": post, "message": message, "strategy": strategy, "decision": decision})
 }
 
-// PushStategy handler
-func (h *PostHandler)PushStrategy(c *gin.Context, req *model.CreatePostRequest){
+// BatchGetPosts handles POST /api/posts/batch, the HTTP form of the BatchGetPosts RPC
+// for debugging; the body is the request message as JSON
+func (h *PostHandler) BatchGetPosts(c *gin.Context) {
+	var req pb.BatchGetPostsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "error_code": "INVALID_REQUEST"})
+		return
+	}
 
-	post, err := h.postService.PushStrategy(c.Request.Context(), req)
+	result, err := h.postService.BatchGetPosts(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrInvalidBatchRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "error_code": "INVALID_REQUEST"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "error_code": "INTERNAL_ERROR"})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"post": post, "message": "Push to Followers' Feeds successfully"})
+	c.JSON(http.StatusOK, gin.H{"result": result, "message": "Batch get posts successfully"})
 }
 
-// PullStrategy Handler
-func (h *PostHandler)PullStrategy(c *gin.Context, req *model.CreatePostRequest){
-	post, err := h.postService.PullStrategy(c.Request.Context(), req)
+// GetPost handles GET /api/posts/:post_id
+// With ?include_author=true the author's user info is returned alongside the post; if the
+// User Service is unavailable the post is still returned, with a warning instead
+func (h *PostHandler) GetPost(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("post_id"), 10, 64)
+	if err != nil || postID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID", "error_code": "INVALID_REQUEST"})
+		return
+	}
+
+	post, err := h.postService.GetPost(c.Request.Context(), postID)
+	if errors.Is(err, repository.ErrPostNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found", "error_code": "POST_NOT_FOUND"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "error_code": "INTERNAL_ERROR"})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"post": post, "message": "Save to Posts(Pull) successfully"})
+	response := gin.H{"post": post}
+	if c.Query("include_author") == "true" {
+		author, err := h.postService.GetPostAuthor(c.Request.Context(), post)
+		if err != nil {
+			log.Printf("Failed to get author of post %d: %v", postID, err)
+			response["warning"] = "Author information unavailable"
+		} else {
+			response["author"] = author
+		}
+	}
+	c.JSON(http.StatusOK, response)
 }
 
-// HybridStrategy Handler
-func (h *PostHandler)HybridStrategy(c *gin.Context, req *model.CreatePostRequest, hybridThreshold int){
-	post, err := h.postService.HybridStrategy(c.Request.Context(), req, hybridThreshold)
+// DeletePost handles DELETE /api/posts/:post_id
+// Followers' timeline copies are removed asynchronously after the post itself
+func (h *PostHandler) DeletePost(c *gin.Context) {
+	postID, err := strconv.ParseInt(c.Param("post_id"), 10, 64)
+	if err != nil || postID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID", "error_code": "INVALID_REQUEST"})
+		return
+	}
+
+	err = h.postService.DeletePost(c.Request.Context(), postID)
+	if errors.Is(err, repository.ErrPostNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found", "error_code": "POST_NOT_FOUND"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "error_code": "INTERNAL_ERROR"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"post": post, "message": "Run Hybrid Strategy successfully"})
+
+	c.Status(http.StatusNoContent)
 }
-// BatchGetPosts handler
-func (h *PostHandler) BatchGetPosts(c *gin.Context) {
-	var req pb.BatchGetPostsRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+// GetUserPosts handles GET /api/users/:user_id/posts
+func (h *PostHandler) GetUserPosts(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID", "error_code": "INVALID_REQUEST"})
 		return
 	}
 
-	result, err := h.postService.BatchGetPosts(c.Request.Context(), &req) 
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > service.PostsLimit {
+		limit = 20
+	}
+
+	posts, err := h.postService.GetPostsByUser(c.Request.Context(), userID, int32(limit))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "error_code": "INTERNAL_ERROR"})
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"result": result, "message": "Run Hybrid Strategy successfully"})
+	c.JSON(http.StatusOK, gin.H{
+		"user_id": userID,
+		"posts":   posts,
+		"count":   len(posts),
+	})
 }
 
 // Health check endpoint
 func (h *PostHandler) Health(c *gin.Context) {
-	strategy := strings.ToLower(os.Getenv("POST_STRATEGY"))
-	if strategy == "" {
-		strategy = "hybrid"
-	}
+	strategy := h.strategy
+	deadLettered, dropped := service.FanoutFailures()
 	c.JSON(http.StatusOK, gin.H{
 		"status":               "healthy",
 		"service":              "post-service",
+		"version":              h.version,
+		"uptime":               time.Since(h.startTime).Round(time.Second).String(),
+		"timestamp":            time.Now().UTC().Format(time.RFC3339),
 		"current_strategy":     strategy,
 		"available_strategies": []string{"push", "pull", "hybrid"},
+		"decode_failures":      repository.DecodeFailures(),
+		"dynamodb_timeouts":    dynamotimeout.OperationTimeouts(),
+		"fanout_dead_lettered": deadLettered,
+		"fanout_dropped":       dropped,
 		"endpoints": gin.H{
 			"posts": "GET /api/posts",
+			"get_post": "GET /api/posts/:post_id?include_author=",
+			"batch_get_posts": "POST /api/posts/batch",
+			"delete_post": "DELETE /api/posts/:post_id",
 			"health":   "GET /api/health",
+			"user_posts": "GET /api/users/:user_id/posts",
 		},
 	})
 }
\ No newline at end of file
@@ -0,0 +1,127 @@
+package idgen
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	mathrand "math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Lease is an instance ID claimed in a DynamoDB table keyed by instance_id, so tasks of
+// one ECS service, which share a task definition and so cannot each be given their own
+// POST_ID_INSTANCE, still get distinct IDs. A lease expires ttl after it was last renewed;
+// only then may another instance claim the ID.
+type Lease struct {
+	InstanceID int
+
+	client *dynamodb.Client
+	table  string
+	owner  string // Random per claim, so a restarted task never mistakes an old lease for its own
+	ttl    time.Duration
+	now    func() time.Time // Replaced in tests
+
+	mu          sync.Mutex
+	lastRenewed time.Time
+}
+
+// ClaimInstance claims a free or expired instance ID, trying every ID once from a random
+// starting point so concurrently starting instances rarely contend for the same one
+func ClaimInstance(ctx context.Context, client *dynamodb.Client, table string, ttl time.Duration) (*Lease, error) {
+	owner := make([]byte, 16)
+	rand.Read(owner)
+	l := &Lease{client: client, table: table, owner: hex.EncodeToString(owner), ttl: ttl, now: time.Now}
+
+	start := mathrand.IntN(MaxInstanceID + 1)
+	for i := 0; i <= MaxInstanceID; i++ {
+		id := (start + i) % (MaxInstanceID + 1)
+		err := l.put(ctx, id, "attribute_not_exists(instance_id) OR expires_at < :now",
+			map[string]types.AttributeValue{":now": number(l.now().Unix())})
+		if err == nil {
+			l.InstanceID = id
+			return l, nil
+		}
+		if !isConditionFailed(err) {
+			return nil, fmt.Errorf("failed to claim instance ID %d: %w", id, err)
+		}
+	}
+	return nil, fmt.Errorf("no free instance ID in %s: all %d are leased", table, MaxInstanceID+1)
+}
+
+// Renew extends the lease by ttl. It fails if the lease expired and was claimed by another instance.
+func (l *Lease) Renew(ctx context.Context) error {
+	return l.put(ctx, l.InstanceID, "owner_id = :owner",
+		map[string]types.AttributeValue{":owner": &types.AttributeValueMemberS{Value: l.owner}})
+}
+
+// KeepAlive renews the lease every third of its TTL until ctx is done. A failed renewal
+// is retried on the next tick, unless the lease would lapse before the tick after that:
+// then lost is called, since another instance could soon claim the same instance ID.
+func (l *Lease) KeepAlive(ctx context.Context, lost func(error)) {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := l.Renew(ctx)
+		if err == nil {
+			continue
+		}
+		if isConditionFailed(err) || !l.heldFor(l.ttl/3) {
+			lost(fmt.Errorf("lease on instance ID %d lost: %w", l.InstanceID, err))
+			return
+		}
+		log.Printf("Failed to renew lease on instance ID %d, retrying: %v", l.InstanceID, err)
+	}
+}
+
+// heldFor reports whether the lease, as of its last successful renewal, is held for at
+// least d longer
+func (l *Lease) heldFor(d time.Duration) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.now().Add(d).Before(l.lastRenewed.Add(l.ttl))
+}
+
+// put writes the lease item for id, owned by l and expiring ttl from now, if condition holds
+func (l *Lease) put(ctx context.Context, id int, condition string, values map[string]types.AttributeValue) error {
+	now := l.now()
+	_, err := l.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(l.table),
+		Item: map[string]types.AttributeValue{
+			"instance_id": number(int64(id)),
+			"owner_id":    &types.AttributeValueMemberS{Value: l.owner},
+			// Whole seconds, as DynamoDB TTL expects; rounded up so the lease never ends early
+			"expires_at": number(now.Add(l.ttl + time.Second - 1).Unix()),
+		},
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	})
+	if err == nil {
+		l.mu.Lock()
+		l.lastRenewed = now
+		l.mu.Unlock()
+	}
+	return err
+}
+
+func number(n int64) *types.AttributeValueMemberN {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
+
+func isConditionFailed(err error) bool {
+	var conditionFailed *types.ConditionalCheckFailedException
+	return errors.As(err, &conditionFailed)
+}
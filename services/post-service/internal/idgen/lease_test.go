@@ -0,0 +1,246 @@
+package idgen
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+type leaseItem struct {
+	owner     string
+	expiresAt int64
+}
+
+// fakeLeaseTable is a DynamoDB endpoint serving PutItem on a lease table, evaluating the
+// conditions Lease uses
+type fakeLeaseTable struct {
+	mu     sync.Mutex
+	items  map[int]leaseItem
+	fail   bool // Answer every request with a server error
+	renews int  // Renewal attempts, failed or not
+}
+
+type attr struct{ N, S string }
+
+func (f *fakeLeaseTable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Item                      map[string]attr
+		ConditionExpression       string
+		ExpressionAttributeValues map[string]attr
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if req.ConditionExpression == "owner_id = :owner" {
+		f.renews++
+	}
+	if f.fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazonaws.dynamodb.v20120810#InternalServerError", "message": "unavailable"})
+		return
+	}
+
+	id, _ := strconv.Atoi(req.Item["instance_id"].N)
+	current, exists := f.items[id]
+
+	var ok bool
+	switch req.ConditionExpression {
+	case "attribute_not_exists(instance_id) OR expires_at < :now":
+		now, _ := strconv.ParseInt(req.ExpressionAttributeValues[":now"].N, 10, 64)
+		ok = !exists || current.expiresAt < now
+	case "owner_id = :owner":
+		ok = exists && current.owner == req.ExpressionAttributeValues[":owner"].S
+	}
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException", "message": "The conditional request failed"})
+		return
+	}
+
+	expiresAt, _ := strconv.ParseInt(req.Item["expires_at"].N, 10, 64)
+	f.items[id] = leaseItem{owner: req.Item["owner_id"].S, expiresAt: expiresAt}
+	w.Write([]byte(`{}`))
+}
+
+func (f *fakeLeaseTable) item(id int) (leaseItem, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	item, ok := f.items[id]
+	return item, ok
+}
+
+func newLeaseTable(t *testing.T) (*fakeLeaseTable, *dynamodb.Client) {
+	t.Helper()
+	fake := &fakeLeaseTable{items: make(map[int]leaseItem)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, dynamodb.New(dynamodb.Options{
+		Region:           "us-west-2",
+		BaseEndpoint:     aws.String(server.URL),
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+	})
+}
+
+func TestClaimInstanceGivesConcurrentInstancesDistinctIDs(t *testing.T) {
+	_, client := newLeaseTable(t)
+
+	const instances = 50
+	ids := make(chan int, instances)
+	var wg sync.WaitGroup
+	for i := 0; i < instances; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lease, err := ClaimInstance(context.Background(), client, "leases", time.Minute)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			ids <- lease.InstanceID
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Errorf("instance ID %d claimed twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestClaimInstanceTakesOnlyExpiredLeases(t *testing.T) {
+	fake, client := newLeaseTable(t)
+	future := time.Now().Add(time.Hour).Unix()
+	for id := 0; id <= MaxInstanceID; id++ {
+		fake.items[id] = leaseItem{owner: "other", expiresAt: future}
+	}
+	fake.items[7] = leaseItem{owner: "crashed", expiresAt: time.Now().Add(-time.Second).Unix()}
+
+	lease, err := ClaimInstance(context.Background(), client, "leases", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease.InstanceID != 7 {
+		t.Errorf("claimed instance ID %d, want the expired 7", lease.InstanceID)
+	}
+	item, _ := fake.item(7)
+	if item.owner != lease.owner || item.expiresAt < time.Now().Add(time.Minute).Unix() {
+		t.Errorf("lease item = %+v, want owned by the new instance for a minute", item)
+	}
+
+	if _, err := ClaimInstance(context.Background(), client, "leases", time.Minute); err == nil {
+		t.Error("claimed an instance ID with every lease held")
+	}
+}
+
+func TestRenewFailsOnceTaken(t *testing.T) {
+	fake, client := newLeaseTable(t)
+	lease, err := ClaimInstance(context.Background(), client, "leases", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lease.Renew(context.Background()); err != nil {
+		t.Fatalf("Renew of a held lease: %v", err)
+	}
+
+	// The lease lapsed and another instance claimed the ID
+	fake.items[lease.InstanceID] = leaseItem{owner: "other", expiresAt: time.Now().Add(time.Minute).Unix()}
+	if err := lease.Renew(context.Background()); !isConditionFailed(err) {
+		t.Errorf("Renew of a taken lease = %v, want a condition failure", err)
+	}
+	if item, _ := fake.item(lease.InstanceID); item.owner != "other" {
+		t.Errorf("Renew overwrote another instance's lease: %+v", item)
+	}
+}
+
+func TestKeepAliveReportsLostLease(t *testing.T) {
+	tests := []struct {
+		name       string
+		lose       func(f *fakeLeaseTable, l *Lease)
+		wantRenews int // Renewal attempts before the loss is reported
+	}{
+		{
+			name:       "taken by another instance",
+			lose:       func(f *fakeLeaseTable, l *Lease) { f.items[l.InstanceID] = leaseItem{owner: "other"} },
+			wantRenews: 1,
+		},
+		{
+			// The first failure leaves two thirds of the TTL, enough to retry; the second does not
+			name:       "renewals keep failing",
+			lose:       func(f *fakeLeaseTable, l *Lease) { f.fail = true },
+			wantRenews: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, client := newLeaseTable(t)
+			lease, err := ClaimInstance(context.Background(), client, "leases", 300*time.Millisecond)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fake.mu.Lock()
+			tt.lose(fake, lease)
+			fake.mu.Unlock()
+
+			lost := make(chan error, 1)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go lease.KeepAlive(ctx, func(err error) { lost <- err })
+
+			select {
+			case err := <-lost:
+				if err == nil {
+					t.Error("lost called with a nil error")
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("lease loss never reported")
+			}
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if fake.renews != tt.wantRenews {
+				t.Errorf("%d renewal attempts before the loss was reported, want %d", fake.renews, tt.wantRenews)
+			}
+		})
+	}
+}
+
+func TestKeepAliveRenewsUntilCancelled(t *testing.T) {
+	fake, client := newLeaseTable(t)
+	lease, err := ClaimInstance(context.Background(), client, "leases", 60*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		lease.KeepAlive(ctx, func(err error) { t.Errorf("lease lost: %v", err) })
+		close(done)
+	}()
+	time.Sleep(200 * time.Millisecond) // Over three TTLs
+	cancel()
+	<-done
+
+	if item, ok := fake.item(lease.InstanceID); !ok || item.owner != lease.owner {
+		t.Errorf("lease item = %+v, want still owned after renewals", item)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.renews < 3 {
+		t.Errorf("%d renewals in three TTLs, want at least 3", fake.renews)
+	}
+}
@@ -0,0 +1,68 @@
+// Package idgen generates post IDs that are unique across instances without coordination.
+package idgen
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Snowflake-style layout: Unix milliseconds, then instance ID, then a per-millisecond sequence.
+//
+//	bits 63     62..20          19..10      9..0
+//	     0      unix millis     instance    sequence
+//
+// Millis << 20 is slightly larger than the same instant's UnixNano, which post IDs used
+// before, so new IDs still sort after every older one and post_id keeps ordering by time.
+const (
+	instanceBits = 10
+	sequenceBits = 10
+
+	MaxInstanceID = 1<<instanceBits - 1
+	maxSequence   = 1<<sequenceBits - 1
+)
+
+// Generator hands out IDs for one instance; it is safe for concurrent use
+type Generator struct {
+	instanceID int64
+
+	mu       sync.Mutex
+	lastMs   int64
+	sequence int64
+}
+
+// NewGenerator returns a generator for instanceID, which must be unique among the
+// instances writing posts at the same time
+func NewGenerator(instanceID int) (*Generator, error) {
+	if instanceID < 0 || instanceID > MaxInstanceID {
+		return nil, fmt.Errorf("instance ID %d out of range: must be between 0 and %d", instanceID, MaxInstanceID)
+	}
+	return &Generator{instanceID: int64(instanceID)}, nil
+}
+
+// FirstIDAt returns the smallest ID any generator can return at t, so IDs below it were
+// all created before t
+func FirstIDAt(t time.Time) int64 {
+	return t.UnixMilli() << (instanceBits + sequenceBits)
+}
+
+// Next returns a new ID. IDs from one generator always increase: when the sequence of a
+// millisecond runs out, or the clock steps back, the next millisecond is borrowed rather
+// than waiting, and the clock catches up again within a few milliseconds.
+func (g *Generator) Next() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := max(time.Now().UnixMilli(), g.lastMs)
+	if ms == g.lastMs {
+		g.sequence = (g.sequence + 1) & maxSequence
+		if g.sequence == 0 {
+			ms++
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastMs = ms
+
+	return ms<<(instanceBits+sequenceBits) | g.instanceID<<sequenceBits | g.sequence
+}
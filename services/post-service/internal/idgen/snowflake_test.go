@@ -0,0 +1,110 @@
+package idgen
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewGeneratorRejectsOutOfRangeInstance(t *testing.T) {
+	tests := []struct {
+		instanceID int
+		wantErr    bool
+	}{
+		{instanceID: -1, wantErr: true},
+		{instanceID: 0},
+		{instanceID: MaxInstanceID},
+		{instanceID: MaxInstanceID + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := NewGenerator(tt.instanceID)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewGenerator(%d) error = %v, want error %t", tt.instanceID, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNextEmbedsInstanceID(t *testing.T) {
+	g, err := NewGenerator(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.Next() >> sequenceBits & MaxInstanceID; got != 42 {
+		t.Errorf("instance bits = %d, want 42", got)
+	}
+}
+
+func TestNextIncreases(t *testing.T) {
+	g, err := NewGenerator(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Enough IDs to run through several milliseconds' sequences
+	last := g.Next()
+	for i := 0; i < 10*(maxSequence+1); i++ {
+		id := g.Next()
+		if id <= last {
+			t.Fatalf("ID %d after %d, want increasing", id, last)
+		}
+		last = id
+	}
+}
+
+// Parallel callers on several instances must never mint the same ID
+func TestNextUniqueAcrossInstancesInParallel(t *testing.T) {
+	const (
+		instances    = 4
+		workers      = 8
+		idsPerWorker = 5000
+	)
+
+	ids := make(chan int64, instances*workers*idsPerWorker)
+	var wg sync.WaitGroup
+	for instance := 0; instance < instances; instance++ {
+		g, err := NewGenerator(instance)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < idsPerWorker; i++ {
+					ids <- g.Next()
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int64]bool, cap(ids))
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("duplicate ID %d", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestFirstIDAtBoundsIDsByTime(t *testing.T) {
+	at := time.UnixMilli(1_700_000_000_123)
+	first := FirstIDAt(at)
+
+	// The largest ID of the previous millisecond and the smallest of this one
+	lastBefore := (at.UnixMilli()-1)<<(instanceBits+sequenceBits) | MaxInstanceID<<sequenceBits | maxSequence
+	if lastBefore >= first {
+		t.Errorf("last ID of previous ms %d >= FirstIDAt %d", lastBefore, first)
+	}
+	if got := first >> (instanceBits + sequenceBits); got != at.UnixMilli() {
+		t.Errorf("FirstIDAt millis = %d, want %d", got, at.UnixMilli())
+	}
+
+	g, err := NewGenerator(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := g.Next(); id < FirstIDAt(time.Now().Add(-time.Second)) || id >= FirstIDAt(time.Now().Add(time.Second)) {
+		t.Errorf("Next() = %d, want within a second of now", id)
+	}
+}
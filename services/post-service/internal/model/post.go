@@ -26,13 +26,46 @@ type BatchGetPostsResponse struct{
     ErrorMessage string           `json:"error_message,omitempty"`
 }
 
+// Author is a post's author as returned with ?include_author=true
+type Author struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Found    bool   `json:"found"` // false when the user no longer exists; Username is then a placeholder
+}
+
+// StrategyDecision is how a post was actually written, returned as "decision" on creation
+type StrategyDecision struct {
+	Path          string `json:"path"`                     // push or pull, prefixed "hybrid->" when hybrid chose it
+	FollowerCount *int32 `json:"follower_count,omitempty"` // Follower count a hybrid decision was based on
+}
+
 // SNS message payload for fan-out
 type FanoutMessage struct {
-	EventType     string    `json:"event_type"`
+	EventType     string    `json:"event_type"` // FeedWrite, or FeedDelete (no content) once the post is deleted
+	PostID        int64     `json:"post_id"`
 	AuthorID      int64     `json:"author_id"`
 	TargetUserIDs []int64   `json:"target_user_ids"`
 	Content       string    `json:"content"`
 	CreatedTime   time.Time `json:"created_time"`
 }
 
+// SNS message payload recording how a new post was written, for the Analytics Service.
+// It is published for every post, pulled ones included, when ANALYTICS_EVENTS is set.
+type PostCreatedMessage struct {
+	EventType     string    `json:"event_type"` // PostCreated
+	PostID        int64     `json:"post_id"`
+	AuthorID      int64     `json:"author_id"`
+	CreatedTime   time.Time `json:"created_time"`
+	Strategy      string    `json:"strategy"`                 // Path taken for this post, as in StrategyDecision
+	FollowerCount *int32    `json:"follower_count,omitempty"` // Set when a hybrid decision looked it up
+}
 
+
+// SNS message payload for a user mentioned in a post
+type MentionMessage struct {
+	EventType       string    `json:"event_type"`
+	AuthorID        int64     `json:"author_id"`
+	MentionedUserID int64     `json:"mentioned_user_id"`
+	PostID          int64     `json:"post_id"`
+	CreatedTime     time.Time `json:"created_time"`
+}
@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// maxBucketUpdateAttempts bounds how often Allow re-reads a bucket another instance
+// updated between its read and its write
+const maxBucketUpdateAttempts = 5
+
+// DynamoDBLimiter is a token bucket limiter shared by all replicas, refilled like
+// MemoryLimiter. Each key is one item holding its tokens and the time they were last
+// refilled; Allow reads it, refills and takes a token, and writes it back only if no
+// other instance changed it in between, retrying otherwise. A bucket idle for a full
+// window has refilled completely, the same as a missing one, so items carry an
+// expires_at attribute for DynamoDB TTL to remove them then.
+//
+// The table needs a string partition key "limit_key" and TTL enabled on "expires_at".
+type DynamoDBLimiter struct {
+	client    *dynamodb.Client
+	tableName string
+	rate      float64 // tokens added per second
+	burst     float64
+	window    time.Duration    // time for an empty bucket to refill completely
+	now       func() time.Time // Replaced in tests
+}
+
+// NewDynamoDBLimiter allows limit requests per window for each key across all
+// instances, in bursts of up to limit
+func NewDynamoDBLimiter(client *dynamodb.Client, tableName string, limit int, window time.Duration) (*DynamoDBLimiter, error) {
+	if limit <= 0 || window <= 0 {
+		return nil, fmt.Errorf("invalid rate limit %d per %s: both must be positive", limit, window)
+	}
+	return &DynamoDBLimiter{
+		client:    client,
+		tableName: tableName,
+		rate:      float64(limit) / window.Seconds(),
+		burst:     float64(limit),
+		window:    window,
+		now:       time.Now,
+	}, nil
+}
+
+func (l *DynamoDBLimiter) Allow(ctx context.Context, key string) (bool, error) {
+	for attempt := 0; attempt < maxBucketUpdateAttempts; attempt++ {
+		allowed, err := l.take(ctx, key)
+		if err == nil {
+			return allowed, nil
+		}
+		var conditionErr *types.ConditionalCheckFailedException
+		if !errors.As(err, &conditionErr) {
+			return false, err
+		}
+	}
+	// Every attempt lost to other instances taking tokens from the same bucket
+	return false, nil
+}
+
+// take refills key's bucket and takes one token from it, if it has one. It fails with
+// ConditionalCheckFailedException when the bucket changed since it was read.
+func (l *DynamoDBLimiter) take(ctx context.Context, key string) (bool, error) {
+	limitKey := map[string]types.AttributeValue{
+		"limit_key": &types.AttributeValueMemberS{Value: key},
+	}
+	result, err := l.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(l.tableName),
+		Key:            limitKey,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to read rate limit bucket: %w", err)
+	}
+
+	now := l.now().UnixMilli()
+	tokens, refilledAt := l.burst, now // A missing bucket is full
+	condition := "attribute_not_exists(limit_key)"
+	values := map[string]types.AttributeValue{}
+	if result.Item != nil {
+		stored, errTokens := decodeFloat(result.Item, "tokens")
+		storedAt, errAt := decodeFloat(result.Item, "refilled_at")
+		if errTokens != nil || errAt != nil {
+			return false, fmt.Errorf("invalid rate limit bucket %q: %v", key, errors.Join(errTokens, errAt))
+		}
+		// Instances' clocks differ slightly; time never runs backwards for a bucket
+		refilledAt = max(now, int64(storedAt))
+		elapsed := float64(refilledAt-int64(storedAt)) / 1000
+		tokens = min(l.burst, stored+elapsed*l.rate)
+		condition = "refilled_at = :read_at AND tokens = :read_tokens"
+		values[":read_at"] = result.Item["refilled_at"]
+		values[":read_tokens"] = result.Item["tokens"]
+	}
+	if tokens < 1 {
+		return false, nil
+	}
+
+	// Gone once it would have refilled completely, rounded up to whole seconds for TTL
+	expiresAt := time.UnixMilli(refilledAt).Add(l.window + time.Second - 1).Unix()
+	_, err = l.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(l.tableName),
+		Item: map[string]types.AttributeValue{
+			"limit_key":   limitKey["limit_key"],
+			"tokens":      &types.AttributeValueMemberN{Value: strconv.FormatFloat(tokens-1, 'f', -1, 64)},
+			"refilled_at": &types.AttributeValueMemberN{Value: strconv.FormatInt(refilledAt, 10)},
+			"expires_at":  &types.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt, 10)},
+		},
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: nilIfEmpty(values),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update rate limit bucket: %w", err)
+	}
+	return true, nil
+}
+
+// decodeFloat reads a required Number attribute
+func decodeFloat(item map[string]types.AttributeValue, name string) (float64, error) {
+	attr, ok := item[name].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, fmt.Errorf("%s missing or not a number", name)
+	}
+	value, err := strconv.ParseFloat(attr.Value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return value, nil
+}
+
+// nilIfEmpty returns values, or nil when it is empty, as DynamoDB rejects an empty map
+func nilIfEmpty(values map[string]types.AttributeValue) map[string]types.AttributeValue {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
@@ -0,0 +1,224 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+type attr struct {
+	S string `json:",omitempty"`
+	N string `json:",omitempty"`
+}
+
+// fakeBucketTable is a DynamoDB endpoint serving GetItem and conditional PutItem on a
+// rate limit table, evaluating the conditions DynamoDBLimiter uses
+type fakeBucketTable struct {
+	mu    sync.Mutex
+	items map[string]map[string]attr // By limit_key
+	puts  int
+	fail  bool // Answer every request with a server error
+}
+
+func (f *fakeBucketTable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Key                       map[string]attr
+		Item                      map[string]attr
+		ConditionExpression       string
+		ExpressionAttributeValues map[string]attr
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazonaws.dynamodb.v20120810#InternalServerError", "message": "unavailable"})
+		return
+	}
+
+	switch target := r.Header.Get("X-Amz-Target"); {
+	case strings.HasSuffix(target, ".GetItem"):
+		item, ok := f.items[req.Key["limit_key"].S]
+		if !ok {
+			w.Write([]byte(`{}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"Item": item})
+	case strings.HasSuffix(target, ".PutItem"):
+		f.puts++
+		key := req.Item["limit_key"].S
+		current, exists := f.items[key]
+		var ok bool
+		switch req.ConditionExpression {
+		case "attribute_not_exists(limit_key)":
+			ok = !exists
+		case "refilled_at = :read_at AND tokens = :read_tokens":
+			ok = exists && current["refilled_at"] == req.ExpressionAttributeValues[":read_at"] &&
+				current["tokens"] == req.ExpressionAttributeValues[":read_tokens"]
+		}
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException", "message": "The conditional request failed"})
+			return
+		}
+		f.items[key] = req.Item
+		w.Write([]byte(`{}`))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazon.coral.validate#ValidationException", "message": "unsupported: " + target})
+	}
+}
+
+func (f *fakeBucketTable) item(key string) map[string]attr {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.items[key]
+}
+
+// newLimiters returns n limiters over one fake table, as on n replicas, all on clock
+func newLimiters(t *testing.T, n, limit int, window time.Duration, clock *time.Time) (*fakeBucketTable, []*DynamoDBLimiter) {
+	t.Helper()
+	fake := &fakeBucketTable{items: make(map[string]map[string]attr)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	client := dynamodb.New(dynamodb.Options{
+		Region:           "us-west-2",
+		BaseEndpoint:     aws.String(server.URL),
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+	})
+
+	limiters := make([]*DynamoDBLimiter, n)
+	for i := range limiters {
+		l, err := NewDynamoDBLimiter(client, "post-rate-limits", limit, window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.now = func() time.Time { return *clock }
+		limiters[i] = l
+	}
+	return fake, limiters
+}
+
+func allowed(t *testing.T, l *DynamoDBLimiter, key string, n int) int {
+	t.Helper()
+	count := 0
+	for i := 0; i < n; i++ {
+		ok, err := l.Allow(context.Background(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			count++
+		}
+	}
+	return count
+}
+
+func TestDynamoDBLimiterRefillsAtTheRate(t *testing.T) {
+	clock := time.Unix(1_700_000_000, 0)
+	fake, limiters := newLimiters(t, 1, 6, time.Minute, &clock) // One token every 10s
+	l := limiters[0]
+
+	if got := allowed(t, l, "user-1", 10); got != 6 {
+		t.Errorf("allowed %d of a burst of 10, want the bucket's 6", got)
+	}
+	clock = clock.Add(25 * time.Second)
+	if got := allowed(t, l, "user-1", 10); got != 2 {
+		t.Errorf("allowed %d after 25s, want the 2 tokens refilled", got)
+	}
+	// The half token left over carries on: 5s more complete a third
+	clock = clock.Add(5 * time.Second)
+	if got := allowed(t, l, "user-1", 10); got != 1 {
+		t.Errorf("allowed %d after 5s more, want 1", got)
+	}
+	// A bucket never holds more than the limit, however long it sat idle
+	clock = clock.Add(time.Hour)
+	if got := allowed(t, l, "user-1", 10); got != 6 {
+		t.Errorf("allowed %d after an hour idle, want 6", got)
+	}
+	if got := allowed(t, l, "user-2", 10); got != 6 {
+		t.Errorf("allowed %d for another key, want its own 6", got)
+	}
+
+	item := fake.item("user-1")
+	wantExpires := strconv.FormatInt(clock.Add(time.Minute).Unix(), 10)
+	if item["expires_at"].N != wantExpires || item["refilled_at"].N != strconv.FormatInt(clock.UnixMilli(), 10) {
+		t.Errorf("bucket = %+v, want refilled now and expiring a window later at %s", item, wantExpires)
+	}
+}
+
+func TestDynamoDBLimiterSharedAcrossReplicas(t *testing.T) {
+	clock := time.Unix(1_700_000_000, 0)
+	_, limiters := newLimiters(t, 3, 10, time.Minute, &clock)
+
+	var mu sync.Mutex
+	total := 0
+	var wg sync.WaitGroup
+	for _, l := range limiters {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				n := allowed(t, l, "user-1", 5)
+				mu.Lock()
+				total += n
+				mu.Unlock()
+			}()
+		}
+	}
+	wg.Wait()
+
+	// Contention may turn a request away after retries, but never admit one over the limit
+	if total == 0 || total > 10 {
+		t.Errorf("replicas allowed %d requests together, want between 1 and the limit of 10", total)
+	}
+	// Whatever contention turned away is still in the bucket
+	if rest := allowed(t, limiters[0], "user-1", 20); total+rest != 10 {
+		t.Errorf("%d requests allowed concurrently and %d after, want 10 in all", total, rest)
+	}
+}
+
+func TestDynamoDBLimiterDeniesWithoutWriting(t *testing.T) {
+	clock := time.Unix(1_700_000_000, 0)
+	fake, limiters := newLimiters(t, 1, 2, time.Minute, &clock)
+	allowed(t, limiters[0], "user-1", 2)
+	puts := fake.puts
+	if got := allowed(t, limiters[0], "user-1", 3); got != 0 {
+		t.Errorf("allowed %d from an empty bucket", got)
+	}
+	if fake.puts != puts {
+		t.Errorf("%d writes for denied requests, want none", fake.puts-puts)
+	}
+}
+
+func TestDynamoDBLimiterReportsTableErrors(t *testing.T) {
+	clock := time.Unix(1_700_000_000, 0)
+	fake, limiters := newLimiters(t, 1, 2, time.Minute, &clock)
+	fake.fail = true
+	if ok, err := limiters[0].Allow(context.Background(), "user-1"); err == nil || ok {
+		t.Errorf("Allow = %t, %v; want an error", ok, err)
+	}
+}
+
+func TestNewDynamoDBLimiterRejectsNonPositiveRates(t *testing.T) {
+	for _, tt := range []struct {
+		limit  int
+		window time.Duration
+	}{{0, time.Minute}, {10, 0}, {-1, time.Minute}} {
+		if _, err := NewDynamoDBLimiter(nil, "post-rate-limits", tt.limit, tt.window); err == nil {
+			t.Errorf("NewDynamoDBLimiter(%d, %s): want error", tt.limit, tt.window)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter decides whether a request identified by key may proceed
+type Limiter interface {
+	Allow(ctx context.Context, key string) (bool, error)
+}
+
+// MemoryLimiter is a per-instance token bucket limiter.
+// Each replica enforces the limit independently, so the effective global
+// limit scales with the number of instances.
+// Buckets idle for a full window have refilled completely, so they are swept away
+// once per window; a new bucket starts full, which is the same state.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	rate      float64 // tokens added per second
+	burst     float64
+	window    time.Duration // time for an empty bucket to refill completely
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// NewMemoryLimiter allows limit requests per window for each key
+func NewMemoryLimiter(limit int, window time.Duration) *MemoryLimiter {
+	return &MemoryLimiter{
+		rate:      float64(limit) / window.Seconds(),
+		burst:     float64(limit),
+		window:    window,
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (l *MemoryLimiter) Allow(ctx context.Context, key string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) >= l.window {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, lastSeen: now}
+		l.buckets[key] = b
+	}
+
+	// Refill based on elapsed time, capped at burst
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.lastSeen).Seconds()*l.rate)
+	b.lastSeen = now
+
+	if b.tokens < 1 {
+		return false, nil
+	}
+	b.tokens--
+	return true, nil
+}
+
+// sweep drops buckets idle long enough to have refilled completely, so the map holds
+// only keys seen within the last window rather than every key ever seen
+func (l *MemoryLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= l.window {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
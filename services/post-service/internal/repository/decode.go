@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	pb "github.com/cs6650/proto/post"
+)
+
+// decodeFailures counts DynamoDB items that could not be decoded since startup
+var decodeFailures atomic.Int64
+
+// DecodeFailures returns the number of items skipped due to decode errors
+func DecodeFailures() int64 {
+	return decodeFailures.Load()
+}
+
+// DecodeError reports which attribute of a DynamoDB item failed to decode
+// It indicates bad or drifted data, so retrying the read will not help
+type DecodeError struct {
+	Attribute string
+	Err       error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode attribute %q: %v", e.Attribute, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeNumber parses a required Number attribute as int64
+func decodeNumber(item map[string]types.AttributeValue, name string) (int64, error) {
+	attr, ok := item[name].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, &DecodeError{Attribute: name, Err: fmt.Errorf("missing or not a number")}
+	}
+	value, err := strconv.ParseInt(attr.Value, 10, 64)
+	if err != nil {
+		return 0, &DecodeError{Attribute: name, Err: err}
+	}
+	return value, nil
+}
+
+// decodeString reads a required String attribute
+func decodeString(item map[string]types.AttributeValue, name string) (string, error) {
+	attr, ok := item[name].(*types.AttributeValueMemberS)
+	if !ok {
+		return "", &DecodeError{Attribute: name, Err: fmt.Errorf("missing or not a string")}
+	}
+	return attr.Value, nil
+}
+
+// decodePost converts a posts-table item into a protobuf Post
+func decodePost(item map[string]types.AttributeValue) (*pb.Post, error) {
+	var post pb.Post
+	var err error
+
+	if post.PostId, err = decodeNumber(item, "post_id"); err != nil {
+		return nil, err
+	}
+	if post.UserId, err = decodeNumber(item, "user_id"); err != nil {
+		return nil, err
+	}
+	if post.Content, err = decodeString(item, "content"); err != nil {
+		return nil, err
+	}
+	if post.Timestamp, err = decodeNumber(item, "timestamp"); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// decodePosts decodes all items, skipping and reporting the ones that fail
+func decodePosts(items []map[string]types.AttributeValue, context string) []*pb.Post {
+	posts := make([]*pb.Post, 0, len(items))
+	skipped := 0
+	for i, item := range items {
+		post, err := decodePost(item)
+		if err != nil {
+			skipped++
+			log.Printf("[Decode] %s: skipping item %d: %v", context, i, err)
+			continue
+		}
+		posts = append(posts, post)
+	}
+
+	if skipped > 0 {
+		decodeFailures.Add(int64(skipped))
+		log.Printf("[Decode] %s: skipped %d of %d items", context, skipped, len(items))
+	}
+	return posts
+}
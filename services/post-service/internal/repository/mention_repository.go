@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// MentionRepository stores mention edges keyed by the mentioned user,
+// so a user's mentions can later be queried newest-first by post_id
+type MentionRepository struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+func NewMentionRepository(client *dynamodb.Client, tableName string) *MentionRepository {
+	return &MentionRepository{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+// CreateMention records that authorID mentioned mentionedUserID in postID
+func (r *MentionRepository) CreateMention(ctx context.Context, mentionedUserID, postID, authorID, createdAt int64) error {
+	item := map[string]types.AttributeValue{
+		"mentioned_user_id": &types.AttributeValueMemberN{
+			Value: fmt.Sprintf("%d", mentionedUserID),
+		},
+		"post_id": &types.AttributeValueMemberN{
+			Value: fmt.Sprintf("%d", postID),
+		},
+		"author_id": &types.AttributeValueMemberN{
+			Value: fmt.Sprintf("%d", authorID),
+		},
+		"timestamp": &types.AttributeValueMemberN{
+			Value: fmt.Sprintf("%d", createdAt),
+		},
+	}
+
+	_, err := r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create mention: %w", err)
+	}
+	return nil
+}
@@ -1,37 +1,121 @@
 package repository
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
-	"strconv"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 
 	pb "github.com/cs6650/proto/post"
+	"post-service/internal/idgen"
 )
 
+// ErrPostNotFound is returned when no post has the requested ID
+var ErrPostNotFound = errors.New("post not found")
+
+// ErrPostIDTaken is returned when creating a post whose ID another post already has
+var ErrPostIDTaken = errors.New("post ID already taken")
+
 type PostRepository struct {
-	client    *dynamodb.Client
-	tableName string
+	client     *dynamodb.Client
+	tableName  string
+	postTTL    time.Duration // 0 keeps posts forever
+	maxWorkers int           // Worker pool size for batch reads
+	sortKey    string        // Sort key of user_id-index, "timestamp" or "post_id"
 }
 
 // Create a new repository
-func NewPostRepository(client *dynamodb.Client, tableName string) *PostRepository {
+func NewPostRepository(client *dynamodb.Client, tableName string, postTTL time.Duration, maxWorkers int, userIndexSortKey string) *PostRepository {
 	return &PostRepository{
-		client:    client,
-		tableName: tableName,
+		client:     client,
+		tableName:  tableName,
+		postTTL:    postTTL,
+		maxWorkers: maxWorkers,
+		sortKey:    userIndexSortKey,
 	}
 }
 
+// userIndexName is the GSI used to read a user's posts newest-first
+const userIndexName = "user_id-index"
+
+// ValidateUserIndex checks that the table's user_id-index is keyed by user_id and sorted
+// by sortKey, a numeric time-ordered attribute. GetPostByUserID relies on this for
+// newest-first results; with any other schema the query would still succeed but return
+// posts in the wrong order, so callers should refuse to start instead.
+func (r *PostRepository) ValidateUserIndex(ctx context.Context, sortKey string) error {
+	result, err := r.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(r.tableName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe table %s: %w", r.tableName, err)
+	}
+
+	var index *types.GlobalSecondaryIndexDescription
+	for i := range result.Table.GlobalSecondaryIndexes {
+		if aws.ToString(result.Table.GlobalSecondaryIndexes[i].IndexName) == userIndexName {
+			index = &result.Table.GlobalSecondaryIndexes[i]
+			break
+		}
+	}
+	if index == nil {
+		return fmt.Errorf("table %s has no %s global secondary index", r.tableName, userIndexName)
+	}
+
+	var hashKey, rangeKey string
+	for _, k := range index.KeySchema {
+		switch k.KeyType {
+		case types.KeyTypeHash:
+			hashKey = aws.ToString(k.AttributeName)
+		case types.KeyTypeRange:
+			rangeKey = aws.ToString(k.AttributeName)
+		}
+	}
+	if hashKey != "user_id" || rangeKey != sortKey {
+		return fmt.Errorf("%s on table %s is keyed (%q, %q), want (\"user_id\", %q) so posts read newest-first",
+			userIndexName, r.tableName, hashKey, rangeKey, sortKey)
+	}
+
+	// A string sort key would order "9" after "10"
+	for _, def := range result.Table.AttributeDefinitions {
+		if aws.ToString(def.AttributeName) == sortKey && def.AttributeType != types.ScalarAttributeTypeN {
+			return fmt.Errorf("%s sort key %q on table %s has type %s, want N so posts order by time",
+				userIndexName, sortKey, r.tableName, def.AttributeType)
+		}
+	}
+	return nil
+}
+
 // Create a new post and save to dynamodb
 func (r *PostRepository) CreatePost(ctx context.Context, post *pb.Post) error {
+	// Never overwrite: a taken ID means two instances minted the same one
+	_, err := r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                r.postItem(post),
+		ConditionExpression: aws.String("attribute_not_exists(post_id)"),
+	})
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return fmt.Errorf("post %d: %w", post.PostId, ErrPostIDTaken)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create post: %w", err)
+	}
+
+	return nil
+}
+
+// postItem builds the posts table item for post, expiring postTTL after it was created
+func (r *PostRepository) postItem(post *pb.Post) map[string]types.AttributeValue {
 	// Manually create DynamoDB item with correct field names (post_id, user_id, etc.)
 	item := map[string]types.AttributeValue{
 		"post_id": &types.AttributeValueMemberN{
@@ -47,17 +131,39 @@ func (r *PostRepository) CreatePost(ctx context.Context, post *pb.Post) error {
 			Value: fmt.Sprintf("%d", post.Timestamp),
 		},
 	}
+	if r.postTTL > 0 {
+		item["expires_at"] = &types.AttributeValueMemberN{
+			Value: fmt.Sprintf("%d", time.Unix(post.Timestamp, 0).Add(r.postTTL).Unix()),
+		}
+	}
+	return item
+}
 
-	_, err := r.client.PutItem(ctx, &dynamodb.PutItemInput{
+// DeletePost removes a post by PostID and returns it, or ErrPostNotFound
+func (r *PostRepository) DeletePost(ctx context.Context, postID int64) (*pb.Post, error) {
+	result, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
 		TableName: aws.String(r.tableName),
-		Item:      item,
+		Key: map[string]types.AttributeValue{
+			"post_id": &types.AttributeValueMemberN{
+				Value: fmt.Sprintf("%d", postID),
+			},
+		},
+		ReturnValues: types.ReturnValueAllOld,
 	})
-
 	if err != nil {
-		return fmt.Errorf("failed to create post: %w", err)
+		return nil, fmt.Errorf("failed to delete post: %w", err)
 	}
 
-	return nil
+	if len(result.Attributes) == 0 {
+		return nil, ErrPostNotFound
+	}
+
+	post, err := decodePost(result.Attributes)
+	if err != nil {
+		decodeFailures.Add(1)
+		return nil, fmt.Errorf("deleted post %d: %w", postID, err)
+	}
+	return post, nil
 }
 
 // Retrieves a single post by PostID
@@ -76,12 +182,15 @@ func (r *PostRepository) GetPost(ctx context.Context, postID int64) (*pb.Post, e
 	}
 
 	if result.Item == nil {
-		return nil, fmt.Errorf("post not found")
+		return nil, ErrPostNotFound
 	}
 
-	var post pb.Post
-	err = attributevalue.UnmarshalMap(result.Item, &post)
-	return &post, err
+	post, err := decodePost(result.Item)
+	if err != nil {
+		decodeFailures.Add(1)
+		return nil, fmt.Errorf("post %d: %w", postID, err)
+	}
+	return post, nil
 }
 
 // batchCheckUsersHasPosts performs parallel COUNT queries to check which users have posts
@@ -92,7 +201,7 @@ func (r *PostRepository) batchCheckUsersHasPosts(ctx context.Context, userIDs []
 
 	hasPostsMap := make(map[int64]bool, len(userIDs))
 	hasPostsMutex := &sync.Mutex{}
-	maxWorkers := min(50, len(userIDs))
+	maxWorkers := min(r.maxWorkers, len(userIDs))
 
 	// Create worker pool for COUNT queries
 	userIDChan := make(chan int64, len(userIDs))
@@ -138,7 +247,8 @@ func (r *PostRepository) batchCheckUsersHasPosts(ctx context.Context, userIDs []
 }
 
 // Retrieve recent posts for multiple users (parallel execution with worker pool for better performance)
-func (r *PostRepository) GetPostByUserIDs(ctx context.Context, userIDs []int64, limit int32) (map[int64][]*pb.Post, error) {
+// A positive before limits each user to posts created before that Unix time
+func (r *PostRepository) GetPostByUserIDs(ctx context.Context, userIDs []int64, limit int32, before int64) (map[int64][]*pb.Post, error) {
 	// Check if we're in hybrid mode (read from environment variable)
 	postStrategy := os.Getenv("POST_STRATEGY")
 	checkCountFirst := postStrategy == "hybrid"
@@ -184,7 +294,7 @@ func (r *PostRepository) GetPostByUserIDs(ctx context.Context, userIDs []int64,
 	}
 
 	// Limit concurrent goroutines to avoid resource exhaustion
-	maxWorkers := min(50, len(usersToQuery))
+	maxWorkers := min(r.maxWorkers, len(usersToQuery))
 
 	// Create worker pool using buffered channel
 	userIDChan := make(chan int64, len(usersToQuery))
@@ -206,7 +316,7 @@ func (r *PostRepository) GetPostByUserIDs(ctx context.Context, userIDs []int64,
 			for userID := range userIDChan {
 				queryStart := time.Now()
 				// Skip COUNT check since we already verified these users have posts
-				posts, err := r.GetPostByUserID(ctx, userID, limit, false)
+				posts, err := r.GetPostByUserID(ctx, userID, limit, before, false)
 				queryDuration := time.Since(queryStart)
 
 				if err != nil {
@@ -251,7 +361,7 @@ func (r *PostRepository) GetPostByUserIDs(ctx context.Context, userIDs []int64,
 func (r *PostRepository) checkUserHasPosts(ctx context.Context, userID int64) (bool, error) {
 	result, err := r.client.Query(ctx, &dynamodb.QueryInput{
 		TableName:              aws.String(r.tableName),
-		IndexName:              aws.String("user_id-index"),
+		IndexName:              aws.String(userIndexName),
 		KeyConditionExpression: aws.String("user_id = :uid"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":uid": &types.AttributeValueMemberN{
@@ -269,8 +379,37 @@ func (r *PostRepository) checkUserHasPosts(ctx context.Context, userID int64) (b
 	return result.Count > 0, nil
 }
 
-// Retrieve recent posts for single user
-func (r *PostRepository) GetPostByUserID(ctx context.Context, userID int64, limit int32, checkCountFirst bool) ([]*pb.Post, error) {
+// CountPostsByUser counts a user's posts, paging through the index without reading item data
+func (r *PostRepository) CountPostsByUser(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			IndexName:              aws.String(userIndexName),
+			KeyConditionExpression: aws.String("user_id = :uid"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":uid": &types.AttributeValueMemberN{
+					Value: fmt.Sprintf("%d", userID),
+				},
+			},
+			Select:            types.SelectCount,
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to count posts: %w", err)
+		}
+
+		count += int64(result.Count)
+		if len(result.LastEvaluatedKey) == 0 {
+			return count, nil
+		}
+		startKey = result.LastEvaluatedKey
+	}
+}
+
+// Retrieve recent posts for single user, only those created before the Unix time before when it is positive
+func (r *PostRepository) GetPostByUserID(ctx context.Context, userID int64, limit int32, before int64, checkCountFirst bool) ([]*pb.Post, error) {
 	// Optimization for hybrid mode: First check if user has posts using COUNT query
 	// This avoids fetching data for users with no posts
 	if checkCountFirst {
@@ -285,10 +424,14 @@ func (r *PostRepository) GetPostByUserID(ctx context.Context, userID int64, limi
 		}
 	}
 
+	if before > 0 {
+		return r.getPostsBefore(ctx, userID, limit, before)
+	}
+
 	// User has posts (or checkCountFirst is false), fetch the actual data
 	result, err := r.client.Query(ctx, &dynamodb.QueryInput{
 		TableName:              aws.String(r.tableName),
-		IndexName:              aws.String("user_id-index"), // Use GSI for querying by user_id
+		IndexName:              aws.String(userIndexName), // Use GSI for querying by user_id
 		KeyConditionExpression: aws.String("user_id = :uid"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":uid": &types.AttributeValueMemberN{
@@ -303,38 +446,53 @@ func (r *PostRepository) GetPostByUserID(ctx context.Context, userID int64, limi
 		return nil, err
 	}
 
-	var posts []*pb.Post
-	for _, item := range result.Items {
-		post := &pb.Post{}
-
-		// Manually extract and convert fields due to DynamoDB type vs protobuf type mismatch
-		// post_id is stored as Number in DynamoDB
-		if postIDAttr, ok := item["post_id"].(*types.AttributeValueMemberN); ok {
-			if parsed, err := strconv.ParseInt(postIDAttr.Value, 10, 64); err == nil {
-				post.PostId = parsed
-			}
-		}
-
-		// user_id is stored as Number in DynamoDB
-		if userIDAttr, ok := item["user_id"].(*types.AttributeValueMemberN); ok {
-			if parsed, err := strconv.ParseInt(userIDAttr.Value, 10, 64); err == nil {
-				post.UserId = parsed
-			}
-		}
+	posts := decodePosts(result.Items, fmt.Sprintf("GetPostByUserID user_id=%d", userID))
+	sortNewestFirst(posts)
+	return posts, nil
+}
 
-		// content is stored as String
-		if contentAttr, ok := item["content"].(*types.AttributeValueMemberS); ok {
-			post.Content = contentAttr.Value
+// sortNewestFirst orders posts by timestamp, newest first, and by post ID within the
+// same second. The index already returns posts about in this order; sorting makes the
+// order exact when its sort key is not the timestamp, or timestamps tie. Which posts make
+// the page is still decided by the index, which ValidateUserIndex checks at startup.
+func sortNewestFirst(posts []*pb.Post) {
+	slices.SortFunc(posts, func(a, b *pb.Post) int {
+		if a.Timestamp != b.Timestamp {
+			return cmp.Compare(b.Timestamp, a.Timestamp)
 		}
+		return cmp.Compare(b.PostId, a.PostId)
+	})
+}
 
-		// timestamp is stored as Number
-		if timestampAttr, ok := item["timestamp"].(*types.AttributeValueMemberN); ok {
-			if parsed, err := strconv.ParseInt(timestampAttr.Value, 10, 64); err == nil {
-				post.Timestamp = parsed
-			}
-		}
+// getPostsBefore returns a user's newest posts created before the Unix time before.
+// The bound is a key condition on the index's sort key, so DynamoDB reads only matching
+// posts and one query fills the page. With a post_id sort key the bound is the first ID
+// of that second, which the time-prefixed IDs order the same way.
+func (r *PostRepository) getPostsBefore(ctx context.Context, userID int64, limit int32, before int64) ([]*pb.Post, error) {
+	bound := before
+	if r.sortKey == "post_id" {
+		bound = idgen.FirstIDAt(time.Unix(before, 0))
+	}
 
-		posts = append(posts, post)
+	result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String(userIndexName),
+		KeyConditionExpression: aws.String("user_id = :uid AND #sk < :before"),
+		ExpressionAttributeNames: map[string]string{
+			"#sk": r.sortKey, // timestamp is a reserved word
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":uid":    &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", userID)},
+			":before": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", bound)},
+		},
+		ScanIndexForward: aws.Bool(false), // Descending order
+		Limit:            aws.Int32(limit),
+	})
+	if err != nil {
+		return nil, err
 	}
+
+	posts := decodePosts(result.Items, fmt.Sprintf("GetPostByUserID user_id=%d before=%d", userID, before))
+	sortNewestFirst(posts)
 	return posts, nil
 }
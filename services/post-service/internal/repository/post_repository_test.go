@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"post-service/internal/idgen"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	pb "github.com/cs6650/proto/post"
+)
+
+func TestSortNewestFirst(t *testing.T) {
+	// Inserted out of order, with two posts in the same second
+	posts := []*pb.Post{
+		{PostId: 1, Timestamp: 100},
+		{PostId: 4, Timestamp: 300},
+		{PostId: 2, Timestamp: 200},
+		{PostId: 5, Timestamp: 200},
+		{PostId: 3, Timestamp: 50},
+	}
+	sortNewestFirst(posts)
+
+	want := []int64{4, 5, 2, 1, 3}
+	for i, post := range posts {
+		if post.PostId != want[i] {
+			t.Fatalf("order = %v, want post IDs %v", postIDs(posts), want)
+		}
+	}
+}
+
+func postIDs(posts []*pb.Post) []int64 {
+	ids := make([]int64, len(posts))
+	for i, post := range posts {
+		ids[i] = post.PostId
+	}
+	return ids
+}
+
+func TestPostItemExpiry(t *testing.T) {
+	post := &pb.Post{PostId: 7, UserId: 3, Content: "hello", Timestamp: 1_700_000_000}
+	tests := []struct {
+		name        string
+		postTTL     time.Duration
+		wantExpires string // empty means no expires_at attribute
+	}{
+		{name: "expiry disabled", postTTL: 0},
+		{name: "one day", postTTL: 24 * time.Hour, wantExpires: "1700086400"},
+		{name: "one year", postTTL: 365 * 24 * time.Hour, wantExpires: "1731536000"},
+	}
+	for _, tt := range tests {
+		r := &PostRepository{postTTL: tt.postTTL}
+		item := r.postItem(post)
+
+		expires, ok := item["expires_at"].(*types.AttributeValueMemberN)
+		switch {
+		case tt.wantExpires == "" && ok:
+			t.Errorf("%s: expires_at = %s, want none", tt.name, expires.Value)
+		case tt.wantExpires != "" && (!ok || expires.Value != tt.wantExpires):
+			t.Errorf("%s: expires_at = %v, want %s", tt.name, item["expires_at"], tt.wantExpires)
+		}
+		if id, ok := item["post_id"].(*types.AttributeValueMemberN); !ok || id.Value != "7" {
+			t.Errorf("%s: post_id = %v, want 7", tt.name, item["post_id"])
+		}
+	}
+}
+
+// fakeUserIndex is a DynamoDB endpoint serving user_id-index Queries over posts, bounded
+// on the index's sort key as getPostsBefore asks. Posts sharing a sort key value come
+// newest ID first.
+type fakeUserIndex struct {
+	posts  []*pb.Post
+	bounds []string // :before of each bounded query
+}
+
+func (f *fakeUserIndex) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ExpressionAttributeNames  map[string]string
+		ExpressionAttributeValues map[string]struct{ N string }
+		Limit                     int
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+
+	userID, _ := strconv.ParseInt(req.ExpressionAttributeValues[":uid"].N, 10, 64)
+	sortKey := func(p *pb.Post) int64 { return p.Timestamp }
+	if req.ExpressionAttributeNames["#sk"] == "post_id" {
+		sortKey = func(p *pb.Post) int64 { return p.PostId }
+	}
+	bound := req.ExpressionAttributeValues[":before"].N
+	f.bounds = append(f.bounds, bound)
+	before, _ := strconv.ParseInt(bound, 10, 64)
+
+	var matched []*pb.Post
+	for _, p := range f.posts {
+		if p.UserId == userID && (bound == "" || sortKey(p) < before) {
+			matched = append(matched, p)
+		}
+	}
+	slices.SortFunc(matched, func(a, b *pb.Post) int {
+		return cmp.Or(cmp.Compare(sortKey(b), sortKey(a)), cmp.Compare(b.PostId, a.PostId))
+	})
+	if req.Limit > 0 && len(matched) > req.Limit {
+		matched = matched[:req.Limit]
+	}
+
+	items := []map[string]any{}
+	for _, p := range matched {
+		items = append(items, map[string]any{
+			"post_id":   map[string]string{"N": fmt.Sprint(p.PostId)},
+			"user_id":   map[string]string{"N": fmt.Sprint(p.UserId)},
+			"content":   map[string]string{"S": p.Content},
+			"timestamp": map[string]string{"N": fmt.Sprint(p.Timestamp)},
+		})
+	}
+	json.NewEncoder(w).Encode(map[string]any{"Items": items, "Count": len(items)})
+}
+
+func TestGetPostsBefore(t *testing.T) {
+	// User 1's posts, two per second from 100 to 102 and one at 103; user 2's post at 101
+	at := func(second, n int64) *pb.Post {
+		return &pb.Post{PostId: idgen.FirstIDAt(time.Unix(second, 0)) + n, UserId: 1, Content: "post", Timestamp: second}
+	}
+	posts := []*pb.Post{at(100, 0), at(100, 1), at(101, 0), at(101, 1), at(102, 0), at(102, 1), at(103, 0)}
+	other := at(101, 2)
+	other.UserId = 2
+
+	tests := []struct {
+		name   string
+		before int64
+		limit  int32
+		want   []*pb.Post
+	}{
+		{name: "excludes the bound's second", before: 102, limit: 10, want: []*pb.Post{posts[3], posts[2], posts[1], posts[0]}},
+		{name: "newest first up to the limit", before: 103, limit: 3, want: []*pb.Post{posts[5], posts[4], posts[3]}},
+		{name: "page boundary inside a second", before: 101, limit: 1, want: []*pb.Post{posts[1]}},
+		{name: "nothing older", before: 100, limit: 10, want: nil},
+	}
+	for _, sortKey := range []string{"timestamp", "post_id"} {
+		for _, tt := range tests {
+			t.Run(sortKey+"/"+tt.name, func(t *testing.T) {
+				fake := &fakeUserIndex{posts: append(slices.Clone(posts), other)}
+				server := httptest.NewServer(fake)
+				defer server.Close()
+				r := NewPostRepository(dynamodb.New(dynamodb.Options{
+					Region:           "us-west-2",
+					BaseEndpoint:     aws.String(server.URL),
+					Credentials:      aws.AnonymousCredentials{},
+					RetryMaxAttempts: 1,
+				}), "posts", 0, 1, sortKey)
+
+				got, err := r.GetPostByUserID(context.Background(), 1, tt.limit, tt.before, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if fmt.Sprint(postIDs(got)) != fmt.Sprint(postIDs(tt.want)) {
+					t.Errorf("posts = %v, want %v", postIDs(got), postIDs(tt.want))
+				}
+
+				wantBound := fmt.Sprint(tt.before)
+				if sortKey == "post_id" {
+					wantBound = fmt.Sprint(idgen.FirstIDAt(time.Unix(tt.before, 0)))
+				}
+				if len(fake.bounds) != 1 || fake.bounds[0] != wantBound {
+					t.Errorf("queried with bounds %v, want one query bounded at %s", fake.bounds, wantBound)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"post-service/internal/client"
+	"post-service/internal/model"
+	"post-service/internal/repository"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/cs6650/proto/social_graph"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// fakeSocialGraph serves follower pages, clamped to maxPageSize as the real service does
+type fakeSocialGraph struct {
+	pb.UnimplementedSocialGraphServiceServer
+	followers   map[int64][]int64
+	counts      map[int64]int32 // GetFollowersCount answers; users without one are not found
+	maxPageSize int32
+
+	mu        sync.Mutex
+	pageCalls int
+}
+
+func (f *fakeSocialGraph) GetFollowers(ctx context.Context, req *pb.GetFollowersRequest) (*pb.GetFollowersResponse, error) {
+	f.mu.Lock()
+	f.pageCalls++
+	f.mu.Unlock()
+
+	all := f.followers[req.UserId]
+	limit := min(req.Limit, f.maxPageSize)
+	start := min(int(req.Offset), len(all))
+	end := min(start+int(limit), len(all))
+	return &pb.GetFollowersResponse{
+		UserIds:    all[start:end],
+		TotalCount: int32(len(all)),
+		HasMore:    end < len(all),
+		PageSize:   int32(end - start),
+	}, nil
+}
+
+func (f *fakeSocialGraph) GetFollowersCount(ctx context.Context, req *pb.GetFollowersCountRequest) (*pb.GetFollowersCountResponse, error) {
+	count, ok := f.counts[req.UserId]
+	if !ok {
+		return &pb.GetFollowersCountResponse{UserId: req.UserId, ErrorMessage: "user not found"}, nil
+	}
+	return &pb.GetFollowersCountResponse{UserId: req.UserId, FollowersCount: count}, nil
+}
+
+func (f *fakeSocialGraph) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pageCalls
+}
+
+// newSocialGraphClient serves fake over gRPC on a local port and returns a client for it
+func newSocialGraphClient(t *testing.T, fake *fakeSocialGraph) *client.SocialGraphClient {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterSocialGraphServiceServer(server, fake)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	c, err := client.NewSocialGraphClient(lis.Addr().String(), keepalive.ClientParameters{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+// followerIDs returns the IDs from first to first+n-1
+func followerIDs(first int64, n int) []int64 {
+	ids := make([]int64, n)
+	for i := range ids {
+		ids[i] = first + int64(i)
+	}
+	return ids
+}
+
+// fakeSNS is an SNS endpoint recording published fan-out messages, and every message's
+// raw body and event_type attribute. fail, when set, is asked about every Publish call,
+// numbered from 1, and fails it with a throttling error.
+type fakeSNS struct {
+	fail func(call int) bool
+
+	mu         sync.Mutex
+	calls      int
+	messages   []model.FanoutMessage
+	bodies     []string
+	eventTypes []string
+}
+
+func (f *fakeSNS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	f.mu.Lock()
+	f.calls++
+	call := f.calls
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/xml")
+	if f.fail != nil && f.fail(call) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `<ErrorResponse><Error><Type>Sender</Type><Code>Throttling</Code><Message>Rate exceeded</Message></Error><RequestId>r</RequestId></ErrorResponse>`)
+		return
+	}
+
+	var message model.FanoutMessage
+	json.Unmarshal([]byte(r.Form.Get("Message")), &message)
+	f.mu.Lock()
+	f.messages = append(f.messages, message)
+	f.bodies = append(f.bodies, r.Form.Get("Message"))
+	f.eventTypes = append(f.eventTypes, r.Form.Get("MessageAttributes.entry.1.Value.StringValue"))
+	f.mu.Unlock()
+	fmt.Fprintf(w, `<PublishResponse xmlns="http://sns.amazonaws.com/doc/2010-03-31/"><PublishResult><MessageId>m-%d</MessageId></PublishResult><ResponseMetadata><RequestId>r</RequestId></ResponseMetadata></PublishResponse>`, call)
+}
+
+func (f *fakeSNS) published() (int, []model.FanoutMessage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls, append([]model.FanoutMessage(nil), f.messages...)
+}
+
+// events returns the raw bodies of published messages whose event_type attribute is eventType
+func (f *fakeSNS) events(eventType string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var bodies []string
+	for i, attribute := range f.eventTypes {
+		if attribute == eventType {
+			bodies = append(bodies, f.bodies[i])
+		}
+	}
+	return bodies
+}
+
+// fakeSQS is an SQS endpoint recording SendMessage bodies, failing them all when fail is set
+type fakeSQS struct {
+	fail bool
+
+	mu     sync.Mutex
+	bodies []model.FanoutMessage
+}
+
+func (f *fakeSQS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct{ MessageBody string }
+	json.NewDecoder(r.Body).Decode(&req)
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	if f.fail || !strings.HasSuffix(r.Header.Get("X-Amz-Target"), ".SendMessage") {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazonaws.sqs#InternalError", "message": "unavailable"})
+		return
+	}
+
+	var message model.FanoutMessage
+	json.Unmarshal([]byte(req.MessageBody), &message)
+	f.mu.Lock()
+	f.bodies = append(f.bodies, message)
+	f.mu.Unlock()
+	json.NewEncoder(w).Encode(map[string]string{"MessageId": "dlq-1"})
+}
+
+func (f *fakeSQS) sent() []model.FanoutMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]model.FanoutMessage(nil), f.bodies...)
+}
+
+// newSNSClient returns an SNS client for a fake endpoint. SDK retries are off so only
+// the fan-out service's own retries are exercised.
+func newSNSClient(t *testing.T, handler http.Handler) *sns.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return sns.New(sns.Options{
+		Region:           "us-west-2",
+		BaseEndpoint:     aws.String(server.URL),
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+	})
+}
+
+func newSQSClient(t *testing.T, handler http.Handler) *sqs.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return sqs.New(sqs.Options{
+		Region:                           "us-west-2",
+		BaseEndpoint:                     aws.String(server.URL),
+		Credentials:                      aws.AnonymousCredentials{},
+		RetryMaxAttempts:                 1,
+		DisableMessageChecksumValidation: true,
+	})
+}
+
+// fakePostsTable is a DynamoDB endpoint holding the posts table, serving the PutItem and
+// GetItem calls PostRepository makes for single posts. Items are kept in wire form.
+type fakePostsTable struct {
+	mu    sync.Mutex
+	items map[string]map[string]json.RawMessage // By post_id
+}
+
+func (f *fakePostsTable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Item map[string]json.RawMessage
+		Key  map[string]struct{ N string }
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch target := r.Header.Get("X-Amz-Target"); {
+	case strings.HasSuffix(target, ".PutItem"):
+		var id struct{ N string }
+		json.Unmarshal(req.Item["post_id"], &id)
+		if _, taken := f.items[id.N]; taken {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException", "message": "The conditional request failed"})
+			return
+		}
+		f.items[id.N] = req.Item
+		w.Write([]byte(`{}`))
+	case strings.HasSuffix(target, ".GetItem"):
+		json.NewEncoder(w).Encode(map[string]any{"Item": f.items[req.Key["post_id"].N]})
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazon.coral.validate#ValidationException", "message": "unsupported: " + target})
+	}
+}
+
+func (f *fakePostsTable) has(postID int64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.items[fmt.Sprint(postID)]
+	return ok
+}
+
+// expiresAt returns a stored post's expires_at, or 0 when it has none
+func (f *fakePostsTable) expiresAt(postID int64) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var expires struct{ N string }
+	json.Unmarshal(f.items[fmt.Sprint(postID)]["expires_at"], &expires)
+	n, _ := strconv.ParseInt(expires.N, 10, 64)
+	return n
+}
+
+// newPostsRepository returns a PostRepository backed by a fresh fakePostsTable
+func newPostsRepository(t *testing.T, postTTL time.Duration) (*repository.PostRepository, *fakePostsTable) {
+	t.Helper()
+	fake := &fakePostsTable{items: make(map[string]map[string]json.RawMessage)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	client := dynamodb.New(dynamodb.Options{
+		Region:           "us-west-2",
+		BaseEndpoint:     aws.String(server.URL),
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+	})
+	return repository.NewPostRepository(client, "posts", postTTL, 1, "timestamp"), fake
+}
@@ -7,98 +7,309 @@ import (
 	"log"
 	"post-service/internal/client"
 	"post-service/internal/model"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	pb "github.com/cs6650/proto/post"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
 )
 
 const (
+	// BatchSize is the number of followers targeted by each fan-out message batch
 	BatchSize = 1000
+
+	// MaxSNSMessageBytes is SNS's message size limit
+	MaxSNSMessageBytes = 256 * 1024
 )
 
+// fanoutDeadLettered counts fan-out messages sent to the dead-letter queue after SNS
+// publishing failed, and fanoutDropped counts posts whose fan-out did not reach every
+// follower page, either published or dead-lettered, since startup
+var fanoutDeadLettered, fanoutDropped atomic.Int64
+
+// FanoutFailures reports fan-out messages dead-lettered and fan-outs dropped since startup
+func FanoutFailures() (deadLettered, dropped int64) {
+	return fanoutDeadLettered.Load(), fanoutDropped.Load()
+}
+
 type FanoutService struct {
 	socialGraphClient *client.SocialGraphClient
 	snsClient *sns.Client
 	snsTopicARN string
+	concurrency int // Batches published at once
+	followerCache *followerCache // Recent posters' followers; nil when disabled
+	strategy string // POST_STRATEGY; under pull nothing is fanned out
+
+	publishAttempts int           // SNS publish attempts per message, including the first
+	retryBaseDelay  time.Duration // Wait before the first retry, doubled for each one after
+	sqsClient       *sqs.Client
+	dlqURL          string // Messages that still fail to publish go here; empty drops them
 }
 
-func NewFanoutService(socialGraphClient *client.SocialGraphClient, snsClient * sns.Client, snsTopicARN string) *FanoutService {
+// A followerCacheMaxIDs or followerCacheTTL of 0 disables follower caching.
+// Messages that fail publishAttempts times are sent to the SQS queue at dlqURL, in the
+// same form the Timeline Service reads from its queue, so they can be redriven there.
+func NewFanoutService(socialGraphClient *client.SocialGraphClient, snsClient * sns.Client, snsTopicARN string, strategy string, concurrency int, followerCacheMaxIDs int, followerCacheTTL time.Duration, publishAttempts int, retryBaseDelay time.Duration, sqsClient *sqs.Client, dlqURL string) *FanoutService {
 	return &FanoutService{
 		socialGraphClient: socialGraphClient,
 		snsClient: snsClient,
 		snsTopicARN: snsTopicARN,
+		concurrency: concurrency,
+		followerCache: newFollowerCache(followerCacheMaxIDs, followerCacheTTL),
+		strategy: strategy,
+		publishAttempts: publishAttempts,
+		retryBaseDelay:  retryBaseDelay,
+		sqsClient:       sqsClient,
+		dlqURL:          dlqURL,
 	}
 }
 
+// ExecutePushFanout publishes a FeedWrite event for the post to each page of the author's followers
 func (s *FanoutService)ExecutePushFanout(ctx context.Context, post *pb.Post) error {
-	offset := int32(0)
-	for {
-		batch, err := s.socialGraphClient.GetFollowers(ctx, post.UserId, BatchSize, offset)
-		if err != nil {
-			return fmt.Errorf("failed to fetch followers batch through rpc: %w", err)
-		}
+	return s.countDropped(s.publishToFollowers(ctx, post, "FeedWrite"))
+}
 
-		// Publish post to SNS for this batch
-		message := model.FanoutMessage{
-			EventType:     "FeedWrite",
-			AuthorID:      post.UserId,
-			TargetUserIDs: batch.UserIds,
-			Content:       post.Content,
-			CreatedTime:   time.Unix(post.Timestamp, 0).UTC(),
-		}
+// countDropped counts a failed fan-out as dropped: some followers never get the event
+func (s *FanoutService) countDropped(err error) error {
+	if err != nil {
+		fanoutDropped.Add(1)
+	}
+	return err
+}
 
-		messageJSON, err := json.Marshal(message)
-		if err != nil {
-			return fmt.Errorf("failed to marshal fanout message: %w", err)
-		}
+// ExecuteDeleteFanout publishes a FeedDelete event for a deleted post to each page of the
+// author's followers, so the Timeline Service removes their copies. Followers gained or
+// lost since the post was fanned out are not tracked: a lost follower keeps their copy
+// until it expires. Under the pull strategy nothing was fanned out, so nothing is published.
+func (s *FanoutService) ExecuteDeleteFanout(ctx context.Context, post *pb.Post) error {
+	if s.strategy == "pull" {
+		return nil
+	}
+	// Timelines find their copies by post ID and author; the content is not needed
+	deleted := &pb.Post{PostId: post.PostId, UserId: post.UserId, Timestamp: post.Timestamp}
+	return s.countDropped(s.publishToFollowers(ctx, deleted, "FeedDelete"))
+}
 
-		_, err = s.snsClient.Publish(ctx, &sns.PublishInput{
-			TopicArn: aws.String(s.snsTopicARN),
-			Message: aws.String(string(messageJSON)),
-		})
+// publishToFollowers pages through the author's followers and publishes an eventType
+// event to each batch of up to BatchSize of them, with up to s.concurrency publishes in
+// flight. It stops at the first failure. An author's batches are cached after a complete
+// walk, so their next events within the cache TTL reuse them without calling Social Graph.
+func (s *FanoutService) publishToFollowers(ctx context.Context, post *pb.Post, eventType string) error {
+	sem := make(chan struct{}, s.concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var publishErr error
+	failed := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return publishErr
+	}
+
+	// Publish post to SNS for one batch
+	publish := func(followers []int64, batchNum int) {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := s.publishBatch(ctx, post, eventType, followers, batchNum); err != nil {
+				mu.Lock()
+				if publishErr == nil {
+					publishErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	if pages, ok := s.followerCache.Get(post.UserId); ok {
+		for i, followers := range pages {
+			if failed() != nil {
+				break
+			}
+			publish(followers, i+1)
+		}
+		wg.Wait()
+		if publishErr != nil {
+			return publishErr
+		}
+		log.Printf("Successfully published %s messages to SNS for post %d (cached followers)", eventType, post.PostId)
+		return nil
+	}
 
+	// Social Graph clamps pages to its MAX_PAGE_SIZE, usually well below BatchSize, so
+	// pages are gathered until a batch is full rather than published one by one
+	var pages [][]int64
+	var batch []int64
+	offset := int32(0)
+	for failed() == nil {
+		page, err := s.socialGraphClient.GetFollowers(ctx, post.UserId, BatchSize-int32(len(batch)), offset)
 		if err != nil {
-			return fmt.Errorf("failed to publish batch %d to SNS: %w", offset + 1, err)
+			wg.Wait()
+			return fmt.Errorf("failed to fetch followers batch through rpc: %w", err)
 		}
+		batch = append(batch, page.UserIds...)
+		last := !page.HasMore || len(page.UserIds) == 0
 
-		// Check if this was the last batch after processing it
-		if !batch.HasMore {
+		if len(batch) >= BatchSize || (last && len(batch) > 0) {
+			publish(batch, len(pages)+1)
+			pages = append(pages, batch)
+			batch = nil
+		}
+		if last {
+			s.followerCache.Set(post.UserId, pages)
 			break
 		}
-
-		offset += BatchSize
+		offset += int32(len(page.UserIds))
+	}
+	wg.Wait()
+	if publishErr != nil {
+		return publishErr
 	}
-	log.Printf("Successfully published fan-out message to SNS for post %d", post.PostId)
+	log.Printf("Successfully published %s messages to SNS for post %d", eventType, post.PostId)
 	return nil
 }
 
 // publishBatch publishes a single batch of followers to SNS
-func (s *FanoutService) publishBatch(ctx context.Context, post *pb.Post, followers []int64, batchNum int) error {
+// The batch is split across several messages when one would exceed the SNS size limit
+func (s *FanoutService) publishBatch(ctx context.Context, post *pb.Post, eventType string, followers []int64, batchNum int) error {
 	message := model.FanoutMessage{
-		EventType: "FeedWrite",
+		EventType: eventType,
+		PostID: post.PostId,
 		AuthorID: post.UserId,
 		TargetUserIDs: followers,
 		Content: post.Content,
 		CreatedTime: time.Unix(post.Timestamp, 0).UTC(),
 	}
 
-	messageJSON, err := json.Marshal(message)
+	messages, err := splitFanoutMessage(message, MaxSNSMessageBytes)
 	if err != nil {
-		return fmt.Errorf("failed to marshal fanout message for batch %d: %w", batchNum, err)
+		return fmt.Errorf("failed to build fanout messages for batch %d: %w", batchNum, err)
+	}
+
+	deadLettered := 0
+	for _, messageJSON := range messages {
+		err := s.publish(ctx, eventType, messageJSON)
+		if err == nil {
+			continue
+		}
+		if dlqErr := s.deadLetter(ctx, messageJSON); dlqErr != nil {
+			return fmt.Errorf("failed to publish batch %d to SNS: %w (dead-letter: %v)", batchNum, err, dlqErr)
+		}
+		deadLettered++
+		log.Printf("Sent %s batch %d for post %d to the dead-letter queue after SNS publishing failed: %v", eventType, batchNum, post.PostId, err)
+	}
+
+	if deadLettered > 0 {
+		log.Printf("Published %d of %d messages of %s batch %d to SNS for post %d; the rest were dead-lettered", len(messages)-deadLettered, len(messages), eventType, batchNum, post.PostId)
+		return nil
 	}
+	log.Printf("Published %s batch %d to SNS for post %d (%d followers, %d messages)", eventType, batchNum, post.PostId, len(followers), len(messages))
+	return nil
+}
 
+// PublishPostCreated publishes a PostCreated event recording the path decision took for
+// post. It is for analytics only, so it is not retried or dead-lettered.
+func (s *FanoutService) PublishPostCreated(ctx context.Context, post *pb.Post, decision *model.StrategyDecision) error {
+	messageJSON, err := json.Marshal(model.PostCreatedMessage{
+		EventType: "PostCreated",
+		PostID: post.PostId,
+		AuthorID: post.UserId,
+		CreatedTime: time.Unix(post.Timestamp, 0).UTC(),
+		Strategy: decision.Path,
+		FollowerCount: decision.FollowerCount,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal PostCreated event: %w", err)
+	}
 	_, err = s.snsClient.Publish(ctx, &sns.PublishInput{
 		TopicArn: aws.String(s.snsTopicARN),
 		Message: aws.String(string(messageJSON)),
+		MessageAttributes: eventTypeAttribute("PostCreated"),
 	})
+	return err
+}
 
-	if err != nil {
-		return fmt.Errorf("failed to publish batch %d to SNS: %w", batchNum, err)
+// eventTypeAttribute is the event_type message attribute SNS subscriptions filter on
+func eventTypeAttribute(eventType string) map[string]snstypes.MessageAttributeValue {
+	return map[string]snstypes.MessageAttributeValue{
+		"event_type": {DataType: aws.String("String"), StringValue: aws.String(eventType)},
+	}
+}
+
+// publish publishes one message to SNS, retrying failures with exponential backoff
+func (s *FanoutService) publish(ctx context.Context, eventType string, messageJSON []byte) error {
+	var err error
+	for attempt := 1; attempt <= s.publishAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(s.retryBaseDelay << (attempt - 2)):
+			case <-ctx.Done():
+				return fmt.Errorf("%w (after %d attempts, last error: %v)", ctx.Err(), attempt-1, err)
+			}
+		}
+		_, err = s.snsClient.Publish(ctx, &sns.PublishInput{
+			TopicArn: aws.String(s.snsTopicARN),
+			Message: aws.String(string(messageJSON)),
+			MessageAttributes: eventTypeAttribute(eventType),
+		})
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// deadLetter sends a message that could not be published to the dead-letter queue
+func (s *FanoutService) deadLetter(ctx context.Context, messageJSON []byte) error {
+	if s.dlqURL == "" {
+		return fmt.Errorf("no dead-letter queue configured")
 	}
-	
-	log.Printf("Published batch %d to SNS for post %d (%d followers)", batchNum, post.PostId, len(followers))
+	// The publish may have failed on the request's deadline; the copy is still worth keeping
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if _, err := s.sqsClient.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(s.dlqURL),
+		MessageBody: aws.String(string(messageJSON)),
+	}); err != nil {
+		return err
+	}
+	fanoutDeadLettered.Add(1)
 	return nil
 }
+
+// splitFanoutMessage serializes the message, halving its target IDs until every
+// part fits in maxBytes. Together the parts cover every target ID exactly once.
+func splitFanoutMessage(message model.FanoutMessage, maxBytes int) ([][]byte, error) {
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fanout message: %w", err)
+	}
+	if len(messageJSON) <= maxBytes {
+		return [][]byte{messageJSON}, nil
+	}
+	if len(message.TargetUserIDs) <= 1 {
+		return nil, fmt.Errorf("fanout message is %d bytes with %d target IDs, exceeds the %d byte limit",
+			len(messageJSON), len(message.TargetUserIDs), maxBytes)
+	}
+
+	mid := len(message.TargetUserIDs) / 2
+	first, second := message, message
+	first.TargetUserIDs = message.TargetUserIDs[:mid]
+	second.TargetUserIDs = message.TargetUserIDs[mid:]
+
+	firstParts, err := splitFanoutMessage(first, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	secondParts, err := splitFanoutMessage(second, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	return append(firstParts, secondParts...), nil
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"post-service/internal/model"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/cs6650/proto/post"
+)
+
+func fanoutMessage(targets int, contentBytes int) model.FanoutMessage {
+	ids := make([]int64, targets)
+	for i := range ids {
+		ids[i] = 1_700_000_000_000_000_000 + int64(i)
+	}
+	return model.FanoutMessage{
+		EventType:     "FeedWrite",
+		PostID:        1,
+		AuthorID:      2,
+		TargetUserIDs: ids,
+		Content:       strings.Repeat("x", contentBytes),
+		CreatedTime:   time.Unix(1_700_000_000, 0).UTC(),
+	}
+}
+
+func TestSplitFanoutMessage(t *testing.T) {
+	tests := []struct {
+		name         string
+		message      model.FanoutMessage
+		maxBytes     int
+		wantMessages int // 0 means more than one
+	}{
+		{name: "fits", message: fanoutMessage(BatchSize, 280), maxBytes: MaxSNSMessageBytes, wantMessages: 1},
+		{name: "long content over SNS limit", message: fanoutMessage(BatchSize, 240*1024), maxBytes: MaxSNSMessageBytes},
+		{name: "small limit", message: fanoutMessage(BatchSize, 280), maxBytes: 4096},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := splitFanoutMessage(tt.message, tt.maxBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantMessages == 1 && len(messages) != 1 {
+				t.Fatalf("got %d messages, want 1", len(messages))
+			}
+			if tt.wantMessages == 0 && len(messages) < 2 {
+				t.Fatalf("got %d messages, want the batch split", len(messages))
+			}
+
+			// Every part is under the limit and every ID is published once, in order
+			var published []int64
+			for i, messageJSON := range messages {
+				if len(messageJSON) > tt.maxBytes {
+					t.Errorf("message %d is %d bytes, over the %d byte limit", i, len(messageJSON), tt.maxBytes)
+				}
+				var part model.FanoutMessage
+				if err := json.Unmarshal(messageJSON, &part); err != nil {
+					t.Fatal(err)
+				}
+				if part.PostID != tt.message.PostID || part.Content != tt.message.Content {
+					t.Errorf("message %d lost the post fields", i)
+				}
+				published = append(published, part.TargetUserIDs...)
+			}
+			if !slices.Equal(published, tt.message.TargetUserIDs) {
+				t.Errorf("published %d target IDs, want all %d in order", len(published), len(tt.message.TargetUserIDs))
+			}
+		})
+	}
+}
+
+// A message too large even for one target cannot be split and is an error
+func TestSplitFanoutMessageTooLargeForOneTarget(t *testing.T) {
+	if _, err := splitFanoutMessage(fanoutMessage(4, 8192), 4096); err == nil {
+		t.Error("want error for content over the limit")
+	}
+}
+
+// Social Graph clamps follower pages to 100, but each published batch still targets
+// BatchSize followers, so fan-out makes one publish per BatchSize followers
+func TestPushFanoutFillsBatches(t *testing.T) {
+	tests := []struct {
+		followers   int
+		wantBatches []int
+	}{
+		{followers: 0},
+		{followers: 250, wantBatches: []int{250}},
+		{followers: BatchSize, wantBatches: []int{BatchSize}},
+		{followers: 2*BatchSize + 500, wantBatches: []int{BatchSize, BatchSize, 500}},
+	}
+	for _, tt := range tests {
+		graph := &fakeSocialGraph{followers: map[int64][]int64{7: followerIDs(100, tt.followers)}, maxPageSize: 100}
+		snsFake := &fakeSNS{}
+		s := NewFanoutService(newSocialGraphClient(t, graph), newSNSClient(t, snsFake), "arn:topic", "push", 4, 0, 0, 1, 0, nil, "")
+
+		if err := s.ExecutePushFanout(context.Background(), &pb.Post{PostId: 1, UserId: 7, Content: "hi"}); err != nil {
+			t.Fatalf("%d followers: %v", tt.followers, err)
+		}
+
+		calls, messages := snsFake.published()
+		if calls != len(tt.wantBatches) {
+			t.Errorf("%d followers: %d publishes, want %d", tt.followers, calls, len(tt.wantBatches))
+		}
+		slices.SortFunc(messages, func(a, b model.FanoutMessage) int { return int(a.TargetUserIDs[0] - b.TargetUserIDs[0]) })
+		var sizes []int
+		var targets []int64
+		for _, m := range messages {
+			sizes = append(sizes, len(m.TargetUserIDs))
+			targets = append(targets, m.TargetUserIDs...)
+		}
+		if !slices.Equal(sizes, tt.wantBatches) {
+			t.Errorf("%d followers: batch sizes %v, want %v", tt.followers, sizes, tt.wantBatches)
+		}
+		if !slices.Equal(targets, followerIDs(100, tt.followers)) {
+			t.Errorf("%d followers: batches do not cover every follower once, in order", tt.followers)
+		}
+		if want := max(1, (tt.followers+99)/100); graph.calls() != want {
+			t.Errorf("%d followers: %d page fetches, want %d", tt.followers, graph.calls(), want)
+		}
+	}
+}
+
+func TestPublishRetriesAndDeadLetters(t *testing.T) {
+	tests := []struct {
+		name            string
+		snsFails        func(call int) bool
+		dlqFails        bool
+		noDLQ           bool
+		wantErr         bool
+		wantSNSCalls    int
+		wantPublished   int
+		wantDLQ         int
+		wantDeadLetters int64 // Change in the dead-lettered counter
+		wantDropped     int64 // Change in the dropped counter
+		wantPublishLog  bool  // Whether the batch is logged as fully published
+	}{
+		{name: "first attempt", wantSNSCalls: 1, wantPublished: 1, wantPublishLog: true},
+		{name: "retried until published", snsFails: func(call int) bool { return call < 3 }, wantSNSCalls: 3, wantPublished: 1, wantPublishLog: true},
+		{name: "dead-lettered", snsFails: func(int) bool { return true }, wantSNSCalls: 3, wantDLQ: 1, wantDeadLetters: 1},
+		{name: "dead-letter fails", snsFails: func(int) bool { return true }, dlqFails: true, wantErr: true, wantSNSCalls: 3, wantDropped: 1},
+		{name: "no dead-letter queue", snsFails: func(int) bool { return true }, noDLQ: true, wantErr: true, wantSNSCalls: 3, wantDropped: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := &fakeSocialGraph{followers: map[int64][]int64{7: followerIDs(100, 30)}, maxPageSize: 100}
+			snsFake := &fakeSNS{fail: tt.snsFails}
+			sqsFake := &fakeSQS{fail: tt.dlqFails}
+			dlqURL := "https://sqs.us-west-2.amazonaws.com/123/post-service-fanout-dlq"
+			if tt.noDLQ {
+				dlqURL = ""
+			}
+			s := NewFanoutService(newSocialGraphClient(t, graph), newSNSClient(t, snsFake), "arn:topic", "push", 1, 0, 0,
+				3, time.Millisecond, newSQSClient(t, sqsFake), dlqURL)
+
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+			deadLetteredBefore, droppedBefore := FanoutFailures()
+
+			err := s.ExecutePushFanout(context.Background(), &pb.Post{PostId: 1, UserId: 7, Content: "hi"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %t", err, tt.wantErr)
+			}
+
+			calls, published := snsFake.published()
+			if calls != tt.wantSNSCalls || len(published) != tt.wantPublished {
+				t.Errorf("SNS: %d calls, %d published, want %d, %d", calls, len(published), tt.wantSNSCalls, tt.wantPublished)
+			}
+			dlq := sqsFake.sent()
+			if len(dlq) != tt.wantDLQ {
+				t.Errorf("%d messages dead-lettered, want %d", len(dlq), tt.wantDLQ)
+			}
+			for _, m := range dlq {
+				if m.PostID != 1 || !slices.Equal(m.TargetUserIDs, followerIDs(100, 30)) {
+					t.Errorf("dead-lettered message = post %d with %d targets, want the published form", m.PostID, len(m.TargetUserIDs))
+				}
+			}
+
+			deadLettered, dropped := FanoutFailures()
+			if deadLettered-deadLetteredBefore != tt.wantDeadLetters || dropped-droppedBefore != tt.wantDropped {
+				t.Errorf("counters moved by %d dead-lettered, %d dropped, want %d, %d",
+					deadLettered-deadLetteredBefore, dropped-droppedBefore, tt.wantDeadLetters, tt.wantDropped)
+			}
+			if got := strings.Contains(logs.String(), "Published FeedWrite batch"); got != tt.wantPublishLog {
+				t.Errorf("batch logged as published: %t, want %t\n%s", got, tt.wantPublishLog, logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"time"
+
+	"github.com/cs6650/proto/lru"
+)
+
+// followerCache keeps recent posters' follower pages for a short TTL, so a burst of posts
+// by one author pages through Social Graph once instead of once per post. Follow and
+// unfollow events do not reach this service, so entries are only dropped on expiry or
+// eviction: a post may miss a follower gained, or reach one lost, within the TTL.
+// The cache is bounded by the follower IDs it holds, not by authors, so one celebrity's
+// pages take the room of many small accounts'. A nil cache is valid and caches nothing.
+type followerCache = lru.Cache[int64, [][]int64]
+
+// newFollowerCache returns a cache holding up to maxIDs follower IDs across all authors
+// for ttl each, or nil (caching disabled) when maxIDs or ttl is not positive
+func newFollowerCache(maxIDs int, ttl time.Duration) *followerCache {
+	return lru.NewWeighted[int64](maxIDs, ttl, followerCount)
+}
+
+// followerCount is the number of follower IDs across an author's pages
+func followerCount(pages [][]int64) int {
+	n := 0
+	for _, page := range pages {
+		n += len(page)
+	}
+	return n
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// pagesOf builds follower pages of the given sizes
+func pagesOf(sizes ...int) [][]int64 {
+	pages := make([][]int64, len(sizes))
+	for i, size := range sizes {
+		pages[i] = make([]int64, size)
+	}
+	return pages
+}
+
+func TestFollowerCacheDisabled(t *testing.T) {
+	for _, c := range []*followerCache{newFollowerCache(0, time.Minute), newFollowerCache(100, 0)} {
+		c.Set(1, pagesOf(3))
+		if _, ok := c.Get(1); ok {
+			t.Error("disabled cache returned pages")
+		}
+	}
+}
+
+func TestFollowerCacheExpiry(t *testing.T) {
+	c := newFollowerCache(100, 30*time.Millisecond)
+	c.Set(1, pagesOf(3, 2))
+	if pages, ok := c.Get(1); !ok || followerCount(pages) != 5 {
+		t.Fatalf("Get = %d followers, %t, want 5, true", followerCount(pages), ok)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, ok := c.Get(1); ok {
+		t.Error("pages served after the TTL")
+	}
+}
+
+// The bound is follower IDs, so one large author evicts several small ones
+func TestFollowerCacheEvictsByFollowerCount(t *testing.T) {
+	tests := []struct {
+		name      string
+		authors   map[int64][][]int64 // Set in author ID order
+		wantKept  []int64
+		wantEvict []int64
+	}{
+		{
+			name:     "all fit",
+			authors:  map[int64][][]int64{1: pagesOf(4), 2: pagesOf(3, 3)},
+			wantKept: []int64{1, 2},
+		},
+		{
+			name:      "least recent evicted first",
+			authors:   map[int64][][]int64{1: pagesOf(4), 2: pagesOf(3), 3: pagesOf(5)},
+			wantKept:  []int64{2, 3},
+			wantEvict: []int64{1},
+		},
+		{
+			name:      "large author evicts several",
+			authors:   map[int64][][]int64{1: pagesOf(3), 2: pagesOf(3), 3: pagesOf(6, 3)},
+			wantKept:  []int64{3},
+			wantEvict: []int64{1, 2},
+		},
+		{
+			name:      "larger than the cache is not cached",
+			authors:   map[int64][][]int64{1: pagesOf(2), 2: pagesOf(6, 6)},
+			wantKept:  []int64{1},
+			wantEvict: []int64{2},
+		},
+	}
+	for _, tt := range tests {
+		c := newFollowerCache(10, time.Minute)
+		for id := int64(1); id <= int64(len(tt.authors)); id++ {
+			c.Set(id, tt.authors[id])
+		}
+		for _, id := range tt.wantKept {
+			if _, ok := c.Get(id); !ok {
+				t.Errorf("%s: author %d evicted", tt.name, id)
+			}
+		}
+		for _, id := range tt.wantEvict {
+			if _, ok := c.Get(id); ok {
+				t.Errorf("%s: author %d still cached", tt.name, id)
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"post-service/internal/client"
+	"post-service/internal/model"
+	"post-service/internal/repository"
+	"regexp"
+	"time"
+
+	pb "github.com/cs6650/proto/post"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+)
+
+// mentionPattern matches @username, where usernames are 3-30 word characters
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@(\w{3,30})\b`)
+
+// MentionService resolves @username mentions in posts, stores the mention edges
+// and publishes a UserMentioned event per mentioned user for downstream consumers
+type MentionService struct {
+	userClient  *client.UserClient
+	repo        *repository.MentionRepository
+	snsClient   *sns.Client
+	snsTopicARN string
+	maxMentions int
+}
+
+func NewMentionService(userClient *client.UserClient, repo *repository.MentionRepository, snsClient *sns.Client, snsTopicARN string, maxMentions int) *MentionService {
+	return &MentionService{
+		userClient:  userClient,
+		repo:        repo,
+		snsClient:   snsClient,
+		snsTopicARN: snsTopicARN,
+		maxMentions: maxMentions,
+	}
+}
+
+// parseMentions returns the distinct usernames mentioned in content, in order of
+// first appearance, capped at limit
+func parseMentions(content string, limit int) []string {
+	seen := make(map[string]bool)
+	usernames := make([]string, 0)
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		if len(usernames) >= limit {
+			break
+		}
+		username := match[1]
+		if !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
+
+// ProcessMentions handles every mention in the post. A mention that fails is
+// logged and skipped so one bad username does not block the others
+func (s *MentionService) ProcessMentions(ctx context.Context, post *pb.Post) {
+	usernames := parseMentions(post.Content, s.maxMentions)
+	if len(usernames) == 0 {
+		return
+	}
+
+	processed := 0
+	for _, username := range usernames {
+		userID, found, err := s.userClient.GetUserIDByUsername(ctx, username)
+		if err != nil {
+			log.Printf("Failed to resolve mention @%s in post %d: %v", username, post.PostId, err)
+			continue
+		}
+		// Unknown usernames and self-mentions produce no edge
+		if !found || userID == post.UserId {
+			continue
+		}
+
+		if err := s.repo.CreateMention(ctx, userID, post.PostId, post.UserId, post.Timestamp); err != nil {
+			log.Printf("Failed to store mention of user %d in post %d: %v", userID, post.PostId, err)
+			continue
+		}
+		if err := s.publishMention(ctx, post, userID); err != nil {
+			log.Printf("Failed to publish mention of user %d in post %d: %v", userID, post.PostId, err)
+			continue
+		}
+		processed++
+	}
+
+	log.Printf("Processed %d/%d mentions for post %d", processed, len(usernames), post.PostId)
+}
+
+// publishMention publishes a single UserMentioned event to SNS
+func (s *MentionService) publishMention(ctx context.Context, post *pb.Post, mentionedUserID int64) error {
+	message := model.MentionMessage{
+		EventType:       "UserMentioned",
+		AuthorID:        post.UserId,
+		MentionedUserID: mentionedUserID,
+		PostID:          post.PostId,
+		CreatedTime:     time.Unix(post.Timestamp, 0).UTC(),
+	}
+
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mention message: %w", err)
+	}
+
+	_, err = s.snsClient.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(s.snsTopicARN),
+		Message:  aws.String(string(messageJSON)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish mention to SNS: %w", err)
+	}
+	return nil
+}
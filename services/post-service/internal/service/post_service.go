@@ -2,11 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"post-service/internal/client"
+	"post-service/internal/idgen"
 	"post-service/internal/model"
 	"post-service/internal/repository"
+	"strings"
 	"time"
+	"unicode"
 
 	pb "github.com/cs6650/proto/post"
 )
@@ -15,76 +20,243 @@ const (
 	PostsLimit = 50
 )
 
+// ErrContentTooLong is returned when post content exceeds the configured maximum
+var ErrContentTooLong = errors.New("content too long")
+
+// ErrEmptyContent is returned when post content is empty or only whitespace
+var ErrEmptyContent = errors.New("content is empty")
+
+// ErrInvalidBatchRequest is returned for BatchGetPosts requests that cannot be served
+var ErrInvalidBatchRequest = errors.New("invalid BatchGetPosts request")
+
 type PostService struct {
-	repo          *repository.PostRepository
-	fanoutService *FanoutService
+	repo             *repository.PostRepository
+	ids              *idgen.Generator
+	fanoutService    *FanoutService
+	mentionService   *MentionService
+	userClient       *client.UserClient
+	pushMinFollowers int
+	hybridThreshold  int
+	pushWriteThrough bool
+	maxContentLength int
+	inlineFanout     bool
+	postEvents       bool // Publish a PostCreated event for every post, for analytics
+
+	batchDefaultLimit int32 // BatchGetPosts limit when the request gives none
+	batchMaxLimit     int32 // Larger BatchGetPosts limits are clamped to this
 }
 
-func NewPostService(repo *repository.PostRepository, fanoutService *FanoutService) *PostService {
+// mentionService may be nil, in which case mentions are not processed
+func NewPostService(repo *repository.PostRepository, ids *idgen.Generator, fanoutService *FanoutService, mentionService *MentionService, userClient *client.UserClient, pushMinFollowers, hybridThreshold int, pushWriteThrough bool, maxContentLength int, inlineFanout, postEvents bool, batchDefaultLimit, batchMaxLimit int32) *PostService {
 	return &PostService{
-		repo:          repo,
-		fanoutService: fanoutService,
+		repo:              repo,
+		ids:               ids,
+		fanoutService:     fanoutService,
+		mentionService:    mentionService,
+		userClient:        userClient,
+		pushMinFollowers:  pushMinFollowers,
+		hybridThreshold:   hybridThreshold,
+		pushWriteThrough:  pushWriteThrough,
+		maxContentLength:  maxContentLength,
+		inlineFanout:      inlineFanout,
+		postEvents:        postEvents,
+		batchDefaultLimit: batchDefaultLimit,
+		batchMaxLimit:     batchMaxLimit,
 	}
 }
 
 // createPost creates a new post object from the request
-func (s *PostService) createPost(req *model.CreatePostRequest) *pb.Post {
+// It is the shared creation point, so content limits are enforced here for every transport.
+// Trailing whitespace is trimmed before the length check and is not stored.
+func (s *PostService) createPost(req *model.CreatePostRequest) (*pb.Post, error) {
+	content := strings.TrimRightFunc(req.Content, unicode.IsSpace)
+	if content == "" {
+		return nil, ErrEmptyContent
+	}
+	if len(content) > s.maxContentLength {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the %d byte limit", ErrContentTooLong, len(content), s.maxContentLength)
+	}
 	return &pb.Post{
-		PostId:    time.Now().UnixNano(),
+		PostId:    s.ids.Next(),
 		UserId:    req.UserID,
-		Content:   req.Content,
+		Content:   content,
 		Timestamp: time.Now().Unix(),
+	}, nil
+}
+
+// maxPostIDAttempts bounds how many fresh IDs savePost tries for one post
+const maxPostIDAttempts = 3
+
+// savePost writes a new post to the posts table. A taken ID means another instance
+// shares this one's instance ID; the post is retried under fresh IDs instead of
+// overwriting the other post.
+func (s *PostService) savePost(ctx context.Context, post *pb.Post) error {
+	for attempt := 1; ; attempt++ {
+		err := s.repo.CreatePost(ctx, post)
+		if !errors.Is(err, repository.ErrPostIDTaken) || attempt == maxPostIDAttempts {
+			return err
+		}
+		log.Printf("Post ID %d already taken, retrying with a new ID; check POST_ID_INSTANCE is unique per instance", post.PostId)
+		post.PostId = s.ids.Next()
 	}
 }
 
+// processMentions handles the post's mentions off the request path
+func (s *PostService) processMentions(post *pb.Post) {
+	if s.mentionService == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		s.mentionService.ProcessMentions(ctx, post)
+	}()
+}
+
+// publishCreated publishes the post's PostCreated event, recording the path decision
+// took, when post events are enabled. Like fan-out it runs off the request path unless
+// fan-out runs inline; a failure only loses the event.
+func (s *PostService) publishCreated(ctx context.Context, post *pb.Post, decision *model.StrategyDecision) {
+	if !s.postEvents {
+		return
+	}
+	publish := func(ctx context.Context) {
+		if err := s.fanoutService.PublishPostCreated(ctx, post, decision); err != nil {
+			log.Printf("Failed to publish PostCreated event for post %d: %v", post.PostId, err)
+		}
+	}
+	if s.inlineFanout {
+		publish(ctx)
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		publish(ctx)
+	}()
+}
+
 func (s *PostService) PushStrategy(ctx context.Context, req *model.CreatePostRequest) (*pb.Post, error) {
-	post := s.createPost(req)
+	return s.push(ctx, req, &model.StrategyDecision{Path: "push"})
+}
+
+// push writes a post by fanning it out to followers' timelines; decision records why
+func (s *PostService) push(ctx context.Context, req *model.CreatePostRequest, decision *model.StrategyDecision) (*pb.Post, error) {
+	post, err := s.createPost(req)
+	if err != nil {
+		return nil, err
+	}
 
-	// Fanout
+	// Write-through keeps the canonical post in the posts table so deletes and
+	// edits can find it; the fanned-out timeline copies expire on their own
+	if s.pushWriteThrough {
+		if err := s.savePost(ctx, post); err != nil {
+			return nil, fmt.Errorf("failed to create post: %w", err)
+		}
+	}
+	s.processMentions(post)
+	s.publishCreated(ctx, post, decision)
+
+	// Fanout; a failed inline fan-out still returns the created post, as the background one does
+	if s.inlineFanout {
+		if err := s.fanoutService.ExecutePushFanout(ctx, post); err != nil {
+			log.Printf("Fan-out error for post %d: %v", post.PostId, err)
+		}
+		return post, nil
+	}
 	go func() {
 		if err := s.fanoutService.ExecutePushFanout(context.Background(), post); err != nil {
-			fmt.Printf("Fan-out error for post %d: %v\n", post.PostId, err)
+			log.Printf("Fan-out error for post %d: %v", post.PostId, err)
 		}
 	}()
 	return post, nil
 }
 
 func (s *PostService) PullStrategy(ctx context.Context, req *model.CreatePostRequest) (*pb.Post, error) {
-	post := s.createPost(req)
+	return s.pull(ctx, req, &model.StrategyDecision{Path: "pull"})
+}
+
+// pull writes a post only to the posts table, for readers to fetch; decision records why
+func (s *PostService) pull(ctx context.Context, req *model.CreatePostRequest, decision *model.StrategyDecision) (*pb.Post, error) {
+	post, err := s.createPost(req)
+	if err != nil {
+		return nil, err
+	}
 
 	// Save to DynamoDB
-	if err := s.repo.CreatePost(ctx, post); err != nil {
+	if err := s.savePost(ctx, post); err != nil {
 		return nil, fmt.Errorf("failed to create post: %w", err)
 	}
+	s.processMentions(post)
+	s.publishCreated(ctx, post, decision)
 	return post, nil
 }
 
-func (s *PostService) HybridStrategy(ctx context.Context, req *model.CreatePostRequest, hybridThreshold int) (*pb.Post, error) {
-	post := s.createPost(req)
+// HybridStrategy pushes only for authors whose follower count falls in the band
+// [pushMinFollowers, hybridThreshold). Authors below the band post rarely to few
+// readers, so fetching their posts live is cheap; authors at or above it would
+// cause write storms on fan-out. Both ends of the band fall back to pull.
+// The returned decision records which way it went and the follower count behind it.
+func (s *PostService) HybridStrategy(ctx context.Context, req *model.CreatePostRequest) (*pb.Post, *model.StrategyDecision, error) {
+	post, err := s.createPost(req)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// Get follower count
-	followers, err := s.fanoutService.socialGraphClient.GetFollowers(ctx, post.UserId, 1, 0)
+	followerCount, err := s.fanoutService.socialGraphClient.GetFollowersCount(ctx, post.UserId)
 	if err != nil {
-		return post, fmt.Errorf("failed to get followers: %w", err)
+		return nil, nil, fmt.Errorf("failed to get followers count: %w", err)
 	}
 
-	log.Printf("User %d has %d followers", post.UserId, followers.TotalCount)
+	log.Printf("User %d has %d followers", post.UserId, followerCount)
 
-	// Check threshold
-	if followers.TotalCount >= int32(hybridThreshold) {
-		log.Printf("User %d has >= %d followers, skipping push fan-out", post.UserId, hybridThreshold)
-		post, err = s.PullStrategy(ctx, req)
+	if !s.inPushBand(followerCount) {
+		log.Printf("User %d follower count %d is outside push band [%d, %d), skipping push fan-out",
+			post.UserId, followerCount, s.pushMinFollowers, s.hybridThreshold)
+		decision := &model.StrategyDecision{Path: "hybrid->pull", FollowerCount: &followerCount}
+		post, err = s.pull(ctx, req, decision)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create post: %w", err)
+			return nil, nil, fmt.Errorf("failed to create post: %w", err)
 		}
-		return post, nil
+		return post, decision, nil
 	}
 
-	post, err = s.PushStrategy(ctx, req)
+	decision := &model.StrategyDecision{Path: "hybrid->push", FollowerCount: &followerCount}
+	post, err = s.push(ctx, req, decision)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create post: %w", err)
+		return nil, nil, fmt.Errorf("failed to create post: %w", err)
 	}
-	return post, nil
+	return post, decision, nil
+}
+
+// inPushBand reports whether an author with followerCount followers is pushed to,
+// i.e. the count falls in [pushMinFollowers, hybridThreshold)
+func (s *PostService) inPushBand(followerCount int32) bool {
+	return followerCount >= int32(s.pushMinFollowers) && followerCount < int32(s.hybridThreshold)
+}
+
+// DeletePost removes a post and then its fanned-out timeline copies, the latter in the
+// background unless fan-out runs inline. It returns repository.ErrPostNotFound for an
+// unknown post; pushed posts are only found when written through (PUSH_WRITE_THROUGH).
+func (s *PostService) DeletePost(ctx context.Context, postID int64) error {
+	post, err := s.repo.DeletePost(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	if s.inlineFanout {
+		if err := s.fanoutService.ExecuteDeleteFanout(ctx, post); err != nil {
+			log.Printf("Delete fan-out error for post %d: %v", post.PostId, err)
+		}
+		return nil
+	}
+	go func() {
+		if err := s.fanoutService.ExecuteDeleteFanout(context.Background(), post); err != nil {
+			log.Printf("Delete fan-out error for post %d: %v", post.PostId, err)
+		}
+	}()
+	return nil
 }
 
 // Get single post
@@ -92,13 +264,48 @@ func (s *PostService) GetPost(ctx context.Context, postID int64) (*pb.Post, erro
 	return s.repo.GetPost(ctx, postID)
 }
 
+// unknownAuthorName stands in for the username of an author who no longer exists
+const unknownAuthorName = "[deleted]"
+
+// GetPostAuthor looks up a post's author for enriched reads. An author the User Service
+// does not know gets a placeholder rather than an error, so the post still renders.
+func (s *PostService) GetPostAuthor(ctx context.Context, post *pb.Post) (*model.Author, error) {
+	usernames, err := s.userClient.GetUsernames(ctx, []int64{post.UserId})
+	if err != nil {
+		return nil, err
+	}
+
+	username, found := usernames[post.UserId]
+	if !found {
+		username = unknownAuthorName
+	}
+	return &model.Author{ID: post.UserId, Username: username, Found: found}, nil
+}
+
+// GetPostsByUser returns a single user's most recent posts
+func (s *PostService) GetPostsByUser(ctx context.Context, userID int64, limit int32) ([]*pb.Post, error) {
+	posts, err := s.repo.GetPostByUserID(ctx, userID, limit, 0, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get posts: %w", err)
+	}
+	return posts, nil
+}
+
+// GetPostCount returns how many posts a user has
+func (s *PostService) GetPostCount(ctx context.Context, userID int64) (int64, error) {
+	return s.repo.CountPostsByUser(ctx, userID)
+}
+
 // BatchGetPosts for Timeline Service
+// A zero limit uses the configured default and larger limits are clamped to the maximum;
+// requests without users or with negative values fail with ErrInvalidBatchRequest
 func (s *PostService) BatchGetPosts(ctx context.Context, req *pb.BatchGetPostsRequest) (map[int64]*pb.PostList, error) {
-	if req.Limit == 0 {
-		req.Limit = PostsLimit
+	limit, err := s.batchLimit(req)
+	if err != nil {
+		return nil, err
 	}
 
-	posts, err := s.repo.GetPostByUserIDs(ctx, req.UserIds, req.Limit)
+	posts, err := s.repo.GetPostByUserIDs(ctx, req.UserIds, limit, req.BeforeTimestamp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get posts: %w", err)
 	}
@@ -109,3 +316,22 @@ func (s *PostService) BatchGetPosts(ctx context.Context, req *pb.BatchGetPostsRe
 	}
 	return result, nil
 }
+
+// batchLimit validates a BatchGetPosts request and returns the per-user limit to read
+func (s *PostService) batchLimit(req *pb.BatchGetPostsRequest) (int32, error) {
+	if len(req.UserIds) == 0 {
+		return 0, fmt.Errorf("%w: user_ids must not be empty", ErrInvalidBatchRequest)
+	}
+	if req.Limit < 0 {
+		return 0, fmt.Errorf("%w: limit %d must be >= 0", ErrInvalidBatchRequest, req.Limit)
+	}
+	if req.BeforeTimestamp < 0 {
+		return 0, fmt.Errorf("%w: before_timestamp %d must be >= 0", ErrInvalidBatchRequest, req.BeforeTimestamp)
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = s.batchDefaultLimit
+	}
+	return min(limit, s.batchMaxLimit), nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"post-service/internal/idgen"
+	"post-service/internal/model"
+	"slices"
+	"testing"
+	"time"
+
+	pb "github.com/cs6650/proto/post"
+)
+
+func TestInPushBand(t *testing.T) {
+	s := &PostService{pushMinFollowers: 10, hybridThreshold: 1000}
+	tests := []struct {
+		name          string
+		followerCount int32
+		want          bool
+	}{
+		{name: "no followers", followerCount: 0, want: false},
+		{name: "below band", followerCount: 9, want: false},
+		{name: "band start", followerCount: 10, want: true},
+		{name: "inside band", followerCount: 500, want: true},
+		{name: "band end", followerCount: 999, want: true},
+		{name: "at threshold", followerCount: 1000, want: false},
+		{name: "above band", followerCount: 50000, want: false},
+	}
+	for _, tt := range tests {
+		if got := s.inPushBand(tt.followerCount); got != tt.want {
+			t.Errorf("%s: inPushBand(%d) = %t, want %t", tt.name, tt.followerCount, got, tt.want)
+		}
+	}
+}
+
+// A minimum of 0 pushes for every author under the threshold, as before the band existed
+func TestInPushBandWithoutMinimum(t *testing.T) {
+	s := &PostService{pushMinFollowers: 0, hybridThreshold: 1000}
+	for _, count := range []int32{0, 1, 999} {
+		if !s.inPushBand(count) {
+			t.Errorf("inPushBand(%d) = false, want true", count)
+		}
+	}
+	if s.inPushBand(1000) {
+		t.Error("inPushBand(1000) = true, want false")
+	}
+}
+
+// HybridStrategy pushes just under the configured threshold and pulls from it on
+func TestHybridPathPerPost(t *testing.T) {
+	// Push band [10, 100): below it and at the threshold, posts are pulled
+	graph := &fakeSocialGraph{
+		followers:   map[int64][]int64{1: followerIDs(1000, 5), 2: followerIDs(2000, 10), 3: followerIDs(3000, 99), 4: followerIDs(4000, 100)},
+		counts:      map[int64]int32{1: 5, 2: 10, 3: 99, 4: 100},
+		maxPageSize: 100,
+	}
+	tests := []struct {
+		author   int64
+		wantPath string
+	}{
+		{author: 1, wantPath: "hybrid->pull"},
+		{author: 2, wantPath: "hybrid->push"},
+		{author: 3, wantPath: "hybrid->push"},
+		{author: 4, wantPath: "hybrid->pull"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantPath, func(t *testing.T) {
+			snsFake := &fakeSNS{}
+			repo, table := newPostsRepository(t, 0)
+			fanout := NewFanoutService(newSocialGraphClient(t, graph), newSNSClient(t, snsFake), "arn:topic", "hybrid", 1, 0, 0, 1, 0, nil, "")
+			ids, _ := idgen.NewGenerator(1)
+			// Inline fan-out and no write-through, so a push publishes before returning and never writes the table
+			s := NewPostService(repo, ids, fanout, nil, nil, 10, 100, false, 1000, true, false, 20, 100)
+
+			post, decision, err := s.HybridStrategy(context.Background(), &model.CreatePostRequest{UserID: tt.author, Content: "hi"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decision.Path != tt.wantPath || *decision.FollowerCount != graph.counts[tt.author] {
+				t.Errorf("decision = %s with %d followers, want %s with %d", decision.Path, *decision.FollowerCount, tt.wantPath, graph.counts[tt.author])
+			}
+
+			_, published := snsFake.published()
+			pushed := len(published) > 0
+			pulled := table.has(post.PostId)
+			if wantPush := tt.wantPath == "hybrid->push"; pushed != wantPush || pulled == wantPush {
+				t.Errorf("author %d: published %t, stored %t; want the post %s", tt.author, pushed, pulled, tt.wantPath)
+			}
+			if pushed && (published[0].PostID != post.PostId || !slices.Equal(published[0].TargetUserIDs, graph.followers[tt.author])) {
+				t.Errorf("pushed post %d to %v, want post %d to every follower", published[0].PostID, published[0].TargetUserIDs, post.PostId)
+			}
+		})
+	}
+}
+
+// With post events on, every post publishes one PostCreated event recording the path
+// actually taken for it, pulled posts included
+func TestPostCreatedEventRecordsChosenPath(t *testing.T) {
+	// Push band [10, 100): author 1 is pulled and author 2 pushed under hybrid
+	graph := &fakeSocialGraph{
+		followers:   map[int64][]int64{1: followerIDs(1000, 5), 2: followerIDs(2000, 10)},
+		counts:      map[int64]int32{1: 5, 2: 10},
+		maxPageSize: 100,
+	}
+	tests := []struct {
+		name      string
+		author    int64
+		create    func(s *PostService, ctx context.Context, req *model.CreatePostRequest) (*pb.Post, error)
+		wantPath  string
+		wantCount *int32
+		wantFeed  bool // a FeedWrite is published too
+	}{
+		{name: "push", author: 1, create: (*PostService).PushStrategy, wantPath: "push", wantFeed: true},
+		{name: "pull", author: 2, create: (*PostService).PullStrategy, wantPath: "pull"},
+		{name: "hybrid pull", author: 1, create: hybrid, wantPath: "hybrid->pull", wantCount: ptr(int32(5))},
+		{name: "hybrid push", author: 2, create: hybrid, wantPath: "hybrid->push", wantCount: ptr(int32(10)), wantFeed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snsFake := &fakeSNS{}
+			repo, _ := newPostsRepository(t, 0)
+			fanout := NewFanoutService(newSocialGraphClient(t, graph), newSNSClient(t, snsFake), "arn:topic", "hybrid", 1, 0, 0, 1, 0, nil, "")
+			ids, _ := idgen.NewGenerator(1)
+			s := NewPostService(repo, ids, fanout, nil, nil, 10, 100, false, 1000, true, true, 20, 100)
+
+			post, err := tt.create(s, context.Background(), &model.CreatePostRequest{UserID: tt.author, Content: "hi"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			created := snsFake.events("PostCreated")
+			if len(created) != 1 {
+				t.Fatalf("published %d PostCreated events, want 1", len(created))
+			}
+			var event model.PostCreatedMessage
+			if err := json.Unmarshal([]byte(created[0]), &event); err != nil {
+				t.Fatal(err)
+			}
+			if event.EventType != "PostCreated" || event.PostID != post.PostId || event.AuthorID != tt.author ||
+				event.CreatedTime.Unix() != post.Timestamp || event.Strategy != tt.wantPath {
+				t.Errorf("published %+v, want post %d by %d taking %s", event, post.PostId, tt.author, tt.wantPath)
+			}
+			if (event.FollowerCount == nil) != (tt.wantCount == nil) || (tt.wantCount != nil && *event.FollowerCount != *tt.wantCount) {
+				t.Errorf("follower_count = %v, want %v", event.FollowerCount, tt.wantCount)
+			}
+			if got := len(snsFake.events("FeedWrite")); (got > 0) != tt.wantFeed {
+				t.Errorf("published %d FeedWrite messages, want some: %t", got, tt.wantFeed)
+			}
+		})
+	}
+}
+
+func hybrid(s *PostService, ctx context.Context, req *model.CreatePostRequest) (*pb.Post, error) {
+	post, _, err := s.HybridStrategy(ctx, req)
+	return post, err
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestBatchLimit(t *testing.T) {
+	s := &PostService{batchDefaultLimit: 20, batchMaxLimit: 100}
+	tests := []struct {
+		name    string
+		req     *pb.BatchGetPostsRequest
+		want    int32
+		wantErr bool
+	}{
+		{name: "no users", req: &pb.BatchGetPostsRequest{Limit: 10}, wantErr: true},
+		{name: "negative limit", req: &pb.BatchGetPostsRequest{UserIds: []int64{1}, Limit: -1}, wantErr: true},
+		{name: "negative before", req: &pb.BatchGetPostsRequest{UserIds: []int64{1}, BeforeTimestamp: -1}, wantErr: true},
+		{name: "zero limit uses default", req: &pb.BatchGetPostsRequest{UserIds: []int64{1}}, want: 20},
+		{name: "limit kept", req: &pb.BatchGetPostsRequest{UserIds: []int64{1, 2}, Limit: 50}, want: 50},
+		{name: "limit at max", req: &pb.BatchGetPostsRequest{UserIds: []int64{1}, Limit: 100}, want: 100},
+		{name: "limit clamped", req: &pb.BatchGetPostsRequest{UserIds: []int64{1}, Limit: 100000}, want: 100},
+	}
+	for _, tt := range tests {
+		got, err := s.batchLimit(tt.req)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidBatchRequest) {
+				t.Errorf("%s: error = %v, want ErrInvalidBatchRequest", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: batchLimit = %d, %v, want %d", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+// A push create keeps the canonical post readable and publishes the copy followers' timelines get
+func TestPushCreateIsReadableAndFannedOut(t *testing.T) {
+	graph := &fakeSocialGraph{followers: map[int64][]int64{3: {10, 11}}, maxPageSize: 100}
+	snsFake := &fakeSNS{}
+	repo, table := newPostsRepository(t, 365*24*time.Hour)
+	fanout := NewFanoutService(newSocialGraphClient(t, graph), newSNSClient(t, snsFake), "arn:topic", "push", 1, 0, 0, 1, 0, nil, "")
+	ids, _ := idgen.NewGenerator(1)
+	s := NewPostService(repo, ids, fanout, nil, nil, 0, 100, true, 1000, true, false, 20, 100)
+
+	post, err := s.PushStrategy(context.Background(), &model.CreatePostRequest{UserID: 3, Content: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.GetPost(context.Background(), post.PostId)
+	if err != nil {
+		t.Fatalf("GetPost after a push create: %v", err)
+	}
+	if got.PostId != post.PostId || got.UserId != 3 || got.Content != "hello" || got.Timestamp != post.Timestamp {
+		t.Errorf("GetPost = %+v, want the created post %+v", got, post)
+	}
+	if expires := table.expiresAt(post.PostId); expires != post.Timestamp+365*24*60*60 {
+		t.Errorf("expires_at = %d, want a year after creation", expires)
+	}
+
+	_, published := snsFake.published()
+	if len(published) != 1 {
+		t.Fatalf("published %d messages, want 1", len(published))
+	}
+	m := published[0]
+	if m.EventType != "FeedWrite" || m.PostID != post.PostId || m.AuthorID != 3 || m.Content != "hello" ||
+		!slices.Equal(m.TargetUserIDs, []int64{10, 11}) || m.CreatedTime.Unix() != post.Timestamp {
+		t.Errorf("published %+v, want the post as a FeedWrite to both followers", m)
+	}
+}
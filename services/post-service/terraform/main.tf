@@ -26,12 +26,45 @@ locals {
   sns_topic_arn = var.sns_topic_arn != "" ? var.sns_topic_arn : aws_sns_topic.post_service[0].arn
 }
 
+# Dead-letter queue for fan-out messages that still fail to publish to SNS after retries.
+# Bodies are the fan-out messages as published, so they can be replayed into the topic.
+resource "aws_sqs_queue" "fanout_dlq" {
+  name                      = "${var.service_name}-fanout-dlq"
+  message_retention_seconds = var.fanout_dlq_retention_seconds
+
+  tags = {
+    Name    = "${var.service_name} Fan-out DLQ"
+    Service = var.service_name
+  }
+}
+
+# Let the service's tasks send to the fan-out DLQ
+resource "aws_iam_role_policy" "fanout_dlq_send" {
+  count = var.task_role_arn == "" ? 0 : 1
+  name  = "${var.service_name}-fanout-dlq-send"
+  role  = regex("[^/]+$", var.task_role_arn)
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Effect   = "Allow"
+        Action   = "sqs:SendMessage"
+        Resource = aws_sqs_queue.fanout_dlq.arn
+      }
+    ]
+  })
+}
+
 # Configure DynamoDB tables
 module "dynamodb" {
   source = "./modules/dynamodb"
   
-  table_name  = var.dynamo_table
-  environment = var.environment
+  table_name          = var.dynamo_table
+  mentions_table_name = var.mentions_table
+  post_id_lease_table_name = var.post_id_lease_table
+  rate_limit_table_name    = var.rate_limit_table
+  environment         = var.environment
 }
 
 # Service-specific security group for ECS tasks
@@ -154,6 +187,10 @@ module "ecs" {
       name  = "AWS_REGION"
       value = var.aws_region
     },
+    {
+      name  = "GRPC_REFLECTION"
+      value = "false"
+    },
     {
       name  = "DYNAMO_TABLE"
       value = module.dynamodb.table_name
@@ -162,14 +199,70 @@ module "ecs" {
       name  = "POST_STRATEGY"
       value = var.post_strategy
     },
+    {
+      # PostCreated events for the analytics service; the timeline subscription filters them out
+      name  = "ANALYTICS_EVENTS"
+      value = "true"
+    },
     {
       name  = "SOCIAL_GRAPH_URL"
       value = var.social_graph_url
     },
     {
-      name  = "HYBRID_THRESHOLD"
+      name  = "HYBRID_FOLLOWER_THRESHOLD"
       value = tostring(var.hybrid_threshold)
     },
+    {
+      name  = "PUSH_MIN_FOLLOWERS"
+      value = tostring(var.push_min_followers)
+    },
+    {
+      name  = "POST_TTL_DAYS"
+      value = tostring(var.post_ttl_days)
+    },
+    {
+      name  = "PUSH_WRITE_THROUGH"
+      value = tostring(var.push_write_through)
+    },
+    {
+      name  = "USER_SERVICE_URL"
+      value = var.user_service_url
+    },
+    {
+      name  = "ENVIRONMENT"
+      value = var.environment
+    },
+    {
+      # Each task claims its own instance ID; tasks share one definition, so a fixed
+      # POST_ID_INSTANCE would be the same on all of them
+      name  = "POST_ID_LEASE_TABLE"
+      value = module.dynamodb.post_id_lease_table_name
+    },
+    {
+      name  = "MENTIONS_TABLE"
+      value = module.dynamodb.mentions_table_name
+    },
+    {
+      name  = "MAX_MENTIONS_PER_POST"
+      value = tostring(var.max_mentions_per_post)
+    },
+    {
+      name  = "FANOUT_DLQ_URL"
+      value = aws_sqs_queue.fanout_dlq.url
+    },
+    {
+      # Buckets in DynamoDB, so the limit holds across tasks rather than per task
+      name  = "RATE_LIMIT_BACKEND"
+      value = "dynamodb"
+    },
+    {
+      name  = "RATE_LIMIT_TABLE"
+      value = module.dynamodb.rate_limit_table_name
+    },
+    {
+      name  = "RATE_LIMIT_PER_MINUTE"
+      value = tostring(var.rate_limit_per_minute)
+    },
   ]
 
   # Auto-scaling configuration
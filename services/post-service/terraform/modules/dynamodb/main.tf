@@ -27,9 +27,83 @@ resource "aws_dynamodb_table" "posts" {
     write_capacity  = 0
   }
 
+  # Canonical posts expire after POST_TTL_DAYS when it is set
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
 
   tags = {
     Name        = var.table_name
     Environment = var.environment
   }
 }
+
+# Mentions table - one edge per (mentioned user, post)
+resource "aws_dynamodb_table" "mentions" {
+  name         = var.mentions_table_name
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "mentioned_user_id"
+  range_key    = "post_id"
+
+  attribute {
+    name = "mentioned_user_id"
+    type = "N"
+  }
+  attribute {
+    name = "post_id"
+    type = "N"
+  }
+
+  tags = {
+    Name        = var.mentions_table_name
+    Environment = var.environment
+  }
+}
+
+# Post ID instance leases - one item per claimed instance ID, so each task embeds a
+# distinct instance ID in the post IDs it mints
+resource "aws_dynamodb_table" "post_id_leases" {
+  name         = var.post_id_lease_table_name
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "instance_id"
+
+  attribute {
+    name = "instance_id"
+    type = "N"
+  }
+
+  # Only clears out leases of stopped tasks; claims check expires_at themselves
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
+
+  tags = {
+    Name        = var.post_id_lease_table_name
+    Environment = var.environment
+  }
+}
+
+# Post rate limit buckets - one token bucket per user, shared by every task
+resource "aws_dynamodb_table" "rate_limits" {
+  name         = var.rate_limit_table_name
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "limit_key"
+
+  attribute {
+    name = "limit_key"
+    type = "S"
+  }
+
+  # A bucket idle long enough to refill completely is the same as none
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
+
+  tags = {
+    Name        = var.rate_limit_table_name
+    Environment = var.environment
+  }
+}
@@ -7,3 +7,18 @@ output "table_arn" {
   description = "ARN of the DynamoDB posts table"
   value       = aws_dynamodb_table.posts.arn
 }
+
+output "mentions_table_name" {
+  description = "Name of the DynamoDB mentions table"
+  value       = aws_dynamodb_table.mentions.name
+}
+
+output "post_id_lease_table_name" {
+  description = "Name of the DynamoDB post ID instance lease table"
+  value       = aws_dynamodb_table.post_id_leases.name
+}
+
+output "rate_limit_table_name" {
+  description = "Name of the DynamoDB post rate limit table"
+  value       = aws_dynamodb_table.rate_limits.name
+}
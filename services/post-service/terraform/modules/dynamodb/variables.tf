@@ -4,6 +4,24 @@ variable "table_name" {
   default     = "posts"
 }
 
+variable "mentions_table_name" {
+  description = "Name of the DynamoDB table for mention edges"
+  type        = string
+  default     = "post-mentions"
+}
+
+variable "post_id_lease_table_name" {
+  description = "Name of the DynamoDB table post ID instance IDs are leased from"
+  type        = string
+  default     = "post-id-leases"
+}
+
+variable "rate_limit_table_name" {
+  description = "Name of the DynamoDB table holding post rate limit buckets"
+  type        = string
+  default     = "post-rate-limits"
+}
+
 variable "environment" {
   description = "Environment name for tagging"
   type        = string
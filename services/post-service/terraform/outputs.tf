@@ -3,6 +3,11 @@ output "sns_topic_arn" {
   value       = local.sns_topic_arn
 }
 
+output "fanout_dlq_url" {
+  description = "URL of the SQS queue holding fan-out messages that failed to publish"
+  value       = aws_sqs_queue.fanout_dlq.url
+}
+
 output "security_group_id" {
   description = "Security group ID for the post service"
   value       = aws_security_group.app.id
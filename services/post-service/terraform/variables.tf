@@ -117,6 +117,66 @@ variable "hybrid_threshold" {
   default     = 50000
 }
 
+variable "post_ttl_days" {
+  description = "Days before a post expires from the posts table (0 disables expiry); keep above the timeline service's timeline_ttl_days"
+  type        = number
+  default     = 365
+}
+
+variable "fanout_dlq_retention_seconds" {
+  description = "How long fan-out messages that failed to publish are kept for replay"
+  type        = number
+  default     = 1209600 # 14 days, the SQS maximum
+}
+
+variable "push_write_through" {
+  description = "Also write the canonical post to the posts table in push mode"
+  type        = bool
+  default     = true
+}
+
+variable "user_service_url" {
+  description = "User service URL (gRPC endpoint) used to resolve @mentions"
+  type        = string
+  default     = "user-service-grpc:50051"
+}
+
+variable "post_id_lease_table" {
+  description = "DynamoDB table each task leases its post ID instance ID from"
+  type        = string
+  default     = "post-id-leases"
+}
+
+variable "rate_limit_table" {
+  description = "DynamoDB table holding each user's post rate limit bucket"
+  type        = string
+  default     = "post-rate-limits"
+}
+
+variable "rate_limit_per_minute" {
+  description = "Posts each user may create per minute across all tasks (0 disables the limit)"
+  type        = number
+  default     = 0
+}
+
+variable "mentions_table" {
+  description = "DynamoDB table name for mention edges"
+  type        = string
+  default     = "post-mentions"
+}
+
+variable "max_mentions_per_post" {
+  description = "Maximum distinct @mentions processed per post (0 disables mentions)"
+  type        = number
+  default     = 10
+}
+
+variable "push_min_followers" {
+  description = "Minimum follower count for push in hybrid strategy (0 disables the lower bound)"
+  type        = number
+  default     = 0
+}
+
 # SNS Topic ARN (optional, can be created by this module or passed in)
 variable "sns_topic_arn" {
   description = "SNS topic ARN (optional, will create if not provided)"
@@ -1,25 +1,68 @@
 package config
 
 import (
+	"fmt"
+	"net"
 	"os"
+	"regexp"
 	"strconv"
+	"time"
+
+	"github.com/cs6650/proto/featureflags"
+	"github.com/cs6650/proto/grpcdial"
+	"google.golang.org/grpc/keepalive"
 )
 
+// tableNamePattern matches DynamoDB's table naming rules
+var tableNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,255}$`)
+
+// FeatureSelfFollow (FEATURE_SELF_FOLLOW) lets a user follow themselves
+const FeatureSelfFollow = "SELF_FOLLOW"
+
 type Config struct {
 	// Server
 	HTTPPort int
 	GRPCPort int
 	Env      string
 
+	// The consistency check can rewrite both tables, so it is served only on AdminAddr, a
+	// listener apart from HTTPPort that defaults to loopback: reachable from inside the
+	// task (ECS Exec) but never through the load balancer
+	AdminAddr string
+
 	// AWS
 	AWSRegion string
 
 	// DynamoDB
+	TablePrefix        string
 	FollowersTableName string
 	FollowingTableName string
 
 	// External Services
 	UserServiceEndpoint string
+	PostServiceEndpoint string
+
+	// gRPC client keepalive: ping after KeepaliveTime idle, drop after KeepaliveTimeout without ack
+	KeepaliveTime     time.Duration
+	KeepaliveTimeout  time.Duration
+	KeepaliveWhenIdle bool
+
+	// Largest follower/following page a caller may request, over HTTP or gRPC
+	MaxPageSize int
+
+	// Username enrichment: max IDs per BatchGetUserInfo call and calls in flight
+	UserInfoBatchSize   int
+	UserInfoConcurrency int
+
+	// Username cache in front of BatchGetUserInfo; a size or TTL of 0 disables it
+	UserCacheSize int
+	UserCacheTTL  time.Duration
+
+	// Follower/following consistency checker: run every ConsistencyInterval (0 = only on
+	// demand), checking ConsistencyMaxUsers users per table each pass; repair fixes what it finds
+	ConsistencyInterval time.Duration
+	ConsistencyMaxUsers int
+	ConsistencyRepair   bool
 
 	// Data Generation (for testing)
 	DefaultNumUsers      int
@@ -29,23 +72,80 @@ type Config struct {
 
 	// Logging
 	LogLevel string
+
+	// Experimental behavior toggled by FEATURE_<NAME> variables
+	Features featureflags.Flags
 }
 
 func Load() *Config {
+	tablePrefix := getEnv("TABLE_PREFIX", "")
 	return &Config{
 		HTTPPort:            getEnvInt("HTTP_PORT", 8085),
 		GRPCPort:            getEnvInt("GRPC_PORT", 50052),
+		AdminAddr:           getEnv("ADMIN_ADDR", "127.0.0.1:9085"),
 		Env:                 getEnv("ENVIRONMENT", "dev"),
 		AWSRegion:           getEnv("AWS_REGION", "us-west-2"),
-		FollowersTableName:  getEnv("FOLLOWERS_TABLE", "social-graph-followers"),
-		FollowingTableName:  getEnv("FOLLOWING_TABLE", "social-graph-following"),
+		TablePrefix:         tablePrefix,
+		FollowersTableName:  tablePrefix + getEnv("FOLLOWERS_TABLE", "social-graph-followers"),
+		FollowingTableName:  tablePrefix + getEnv("FOLLOWING_TABLE", "social-graph-following"),
 		UserServiceEndpoint: getEnv("USER_SERVICE_URL", "user-service-grpc:50051"),
+		PostServiceEndpoint: getEnv("POST_SERVICE_URL", "post-service-grpc:50053"),
+		KeepaliveTime:       getEnvDuration("GRPC_KEEPALIVE_TIME", 30*time.Second),
+		KeepaliveTimeout:    getEnvDuration("GRPC_KEEPALIVE_TIMEOUT", 10*time.Second),
+		KeepaliveWhenIdle:   getEnvBool("GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM", true),
+		MaxPageSize:         getEnvInt("MAX_PAGE_SIZE", 100),
+		UserInfoBatchSize:   getEnvInt("USER_INFO_BATCH_SIZE", 100),
+		UserInfoConcurrency: getEnvInt("USER_INFO_MAX_CONCURRENCY", 4),
+		UserCacheSize:       getEnvInt("USER_INFO_CACHE_SIZE", 10000),
+		UserCacheTTL:        getEnvDuration("USER_INFO_CACHE_TTL", 5*time.Minute),
+		ConsistencyInterval: getEnvDuration("CONSISTENCY_CHECK_INTERVAL", 0),
+		ConsistencyMaxUsers: getEnvInt("CONSISTENCY_CHECK_MAX_USERS", 1000),
+		ConsistencyRepair:   getEnvBool("CONSISTENCY_CHECK_REPAIR", false),
 		DefaultNumUsers:     getEnvInt("DEFAULT_NUM_USERS", 10000),
 		DefaultNumFollowers: getEnvInt("DEFAULT_NUM_FOLLOWERS", 100),
 		PowerLawExponent:    getEnvFloat("POWER_LAW_EXPONENT", 2.0),
 		CelebrityThreshold:  getEnvInt("CELEBRITY_THRESHOLD", 50000),
 		LogLevel:            getEnv("LOG_LEVEL", "info"),
+		Features:            featureflags.Load(),
+	}
+}
+
+// Validate checks that the loaded configuration is usable
+func (c *Config) Validate() error {
+	for _, name := range []string{c.FollowersTableName, c.FollowingTableName} {
+		if !tableNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid table name %q: must be 3-255 characters of letters, digits, '_', '.' or '-'", name)
+		}
+	}
+	if _, _, err := net.SplitHostPort(c.AdminAddr); err != nil {
+		return fmt.Errorf("invalid ADMIN_ADDR %q: %w", c.AdminAddr, err)
+	}
+	if err := grpcdial.ValidateKeepalive(c.KeepaliveParams()); err != nil {
+		return err
+	}
+	// HTTP falls back to a page of 50 for out-of-range limits, so that must stay valid
+	if c.MaxPageSize < 50 {
+		return fmt.Errorf("invalid MAX_PAGE_SIZE %d: must be >= 50", c.MaxPageSize)
+	}
+	if c.UserInfoBatchSize <= 0 {
+		return fmt.Errorf("invalid USER_INFO_BATCH_SIZE %d: must be > 0", c.UserInfoBatchSize)
+	}
+	if c.UserInfoConcurrency <= 0 {
+		return fmt.Errorf("invalid USER_INFO_MAX_CONCURRENCY %d: must be > 0", c.UserInfoConcurrency)
 	}
+	if c.UserCacheSize < 0 {
+		return fmt.Errorf("invalid USER_INFO_CACHE_SIZE %d: must be >= 0", c.UserCacheSize)
+	}
+	if c.UserCacheTTL < 0 {
+		return fmt.Errorf("invalid USER_INFO_CACHE_TTL %s: must be >= 0", c.UserCacheTTL)
+	}
+	if c.ConsistencyInterval < 0 {
+		return fmt.Errorf("invalid CONSISTENCY_CHECK_INTERVAL %s: must be >= 0", c.ConsistencyInterval)
+	}
+	if c.ConsistencyMaxUsers <= 0 {
+		return fmt.Errorf("invalid CONSISTENCY_CHECK_MAX_USERS %d: must be > 0", c.ConsistencyMaxUsers)
+	}
+	return nil
 }
 
 func getEnv(key, defaultValue string) string {
@@ -72,3 +172,30 @@ func getEnvFloat(key string, defaultValue float64) float64 {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if durationVal, err := time.ParseDuration(value); err == nil {
+			return durationVal
+		}
+	}
+	return defaultValue
+}
+
+// KeepaliveParams returns the keepalive settings for outgoing gRPC clients
+func (c *Config) KeepaliveParams() keepalive.ClientParameters {
+	return keepalive.ClientParameters{
+		Time:                c.KeepaliveTime,
+		Timeout:             c.KeepaliveTimeout,
+		PermitWithoutStream: c.KeepaliveWhenIdle,
+	}
+}
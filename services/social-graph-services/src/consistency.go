@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Every "A follows B" edge is stored twice: B in A's following_ids and A in B's
+// follower_ids. Follows write both in one transaction, but unfollows remove them one at
+// a time and bulk loads write each table separately, so an edge can still end up
+// recorded on one side only.
+// The checker scans both tables a slice at a time and reports such asymmetric edges.
+//
+// When repairing, FollowingTable is treated as authoritative, since it is what
+// CheckFollowRelationship and GetFollowRelationship read: an edge missing from the
+// followers side is added there, and one missing from the following side is removed
+// from the followers side. Repairs therefore only ever write FollowersTable. Before
+// repairing, the following side is read again so that a follow or unfollow that was
+// between its two writes while the pass ran is left alone.
+
+// AsymmetricEdge is a follow edge recorded in only one of the two tables
+type AsymmetricEdge struct {
+	FollowerID  int64  `json:"follower_id"`
+	FolloweeID  int64  `json:"followee_id"`
+	MissingFrom string `json:"missing_from"` // "followers" or "following"
+	Repaired    bool   `json:"repaired"`
+}
+
+// ConsistencyReport summarizes one checker pass
+type ConsistencyReport struct {
+	UsersChecked int              `json:"users_checked"`
+	EdgesChecked int              `json:"edges_checked"`
+	Asymmetric   []AsymmetricEdge `json:"asymmetric"`
+	RepairErrors int              `json:"repair_errors"`
+	Duration     string           `json:"duration"`
+}
+
+// ConsistencyChecker scans the follower and following tables for asymmetric edges.
+// Each pass checks up to maxUsers items of each table, resuming where the previous
+// pass stopped and wrapping around, so repeated passes cover the whole graph.
+type ConsistencyChecker struct {
+	db       *DynamoDBClient
+	maxUsers int
+	repair   bool
+
+	mu             sync.Mutex // Serializes passes and guards the scan positions
+	followingStart map[string]types.AttributeValue
+	followersStart map[string]types.AttributeValue
+}
+
+// NewConsistencyChecker creates a checker that examines up to maxUsers users per table
+// each pass, repairing what it finds when repair is true
+func NewConsistencyChecker(db *DynamoDBClient, maxUsers int, repair bool) *ConsistencyChecker {
+	return &ConsistencyChecker{
+		db:       db,
+		maxUsers: maxUsers,
+		repair:   repair,
+	}
+}
+
+// Run performs one pass over the next slice of both tables
+func (c *ConsistencyChecker) Run(ctx context.Context) (*ConsistencyReport, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	start := time.Now()
+	report := &ConsistencyReport{Asymmetric: []AsymmetricEdge{}}
+
+	next, err := c.checkFollowing(ctx, report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check FollowingTable: %w", err)
+	}
+	c.followingStart = next
+
+	next, err = c.checkFollowers(ctx, report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check FollowersTable: %w", err)
+	}
+	c.followersStart = next
+
+	report.Duration = time.Since(start).Round(time.Millisecond).String()
+	return report, nil
+}
+
+// RunEvery runs a pass every interval until ctx is cancelled, logging what it finds
+func (c *ConsistencyChecker) RunEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		report, err := c.Run(ctx)
+		if err != nil {
+			log.Printf("[Consistency] pass failed: %v", err)
+			continue
+		}
+		log.Printf("[Consistency] checked %d users, %d edges: %d asymmetric, %d repair errors (%s)",
+			report.UsersChecked, report.EdgesChecked, len(report.Asymmetric), report.RepairErrors, report.Duration)
+		for _, edge := range report.Asymmetric {
+			log.Printf("[Consistency] %d -> %d missing from %s (repaired: %t)", edge.FollowerID, edge.FolloweeID, edge.MissingFrom, edge.Repaired)
+		}
+	}
+}
+
+// checkFollowing verifies that every followee in the scanned following lists has the
+// follower in its followers. It returns the key to resume from, nil after the last page.
+func (c *ConsistencyChecker) checkFollowing(ctx context.Context, report *ConsistencyReport) (map[string]types.AttributeValue, error) {
+	items, next, err := c.scan(ctx, c.db.followingTableName, "user_id, following_ids, followed_at", c.followingStart)
+	if err != nil {
+		return nil, err
+	}
+
+	followersOf := make(map[int64]map[int64]bool)
+	for _, item := range items {
+		var record FollowingRecord
+		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+			log.Printf("[Consistency] skipping unreadable following record: %v", err)
+			continue
+		}
+		followerID, err := strconv.ParseInt(record.UserID, 10, 64)
+		if err != nil {
+			continue
+		}
+		report.UsersChecked++
+
+		for _, followeeID := range parseIDList(c.db.followingTableName, followerID, "following_ids", record.FollowingIDs) {
+			report.EdgesChecked++
+			followers, ok := followersOf[followeeID]
+			if !ok {
+				ids, err := c.db.allFollowerIDs(ctx, followeeID)
+				if err != nil {
+					return nil, err
+				}
+				followers = toSet(ids)
+				followersOf[followeeID] = followers
+			}
+			if followers[followerID] {
+				continue
+			}
+
+			edge := AsymmetricEdge{FollowerID: followerID, FolloweeID: followeeID, MissingFrom: "followers"}
+			if c.repair && c.stillFollowing(ctx, followerID, followeeID, true) {
+				followedAt := time.Now()
+				if ts, ok := record.FollowedAt[strconv.FormatInt(followeeID, 10)]; ok {
+					followedAt = time.Unix(ts, 0)
+				}
+				if _, err := c.db.appendFollower(ctx, strconv.FormatInt(followeeID, 10), record.UserID, followedAt); err != nil {
+					log.Printf("[Consistency] failed to add follower %d to %d: %v", followerID, followeeID, err)
+					report.RepairErrors++
+				} else {
+					edge.Repaired = true
+					followers[followerID] = true
+				}
+			}
+			report.Asymmetric = append(report.Asymmetric, edge)
+		}
+	}
+	return next, nil
+}
+
+// checkFollowers verifies that every follower in the scanned followers lists has the
+// followee in its following list. It returns the key to resume from, nil after the last page.
+func (c *ConsistencyChecker) checkFollowers(ctx context.Context, report *ConsistencyReport) (map[string]types.AttributeValue, error) {
+	items, next, err := c.scan(ctx, c.db.followersTableName, "user_id", c.followersStart)
+	if err != nil {
+		return nil, err
+	}
+
+	followingOf := make(map[int64]map[int64]bool)
+	for _, item := range items {
+		key, _ := item["user_id"].(*types.AttributeValueMemberS)
+		// Overflow shards are read through their base item
+		if key == nil || strings.Contains(key.Value, "#") {
+			continue
+		}
+		followeeID, err := strconv.ParseInt(key.Value, 10, 64)
+		if err != nil {
+			continue
+		}
+		report.UsersChecked++
+
+		followerIDs, err := c.db.allFollowerIDs(ctx, followeeID)
+		if err != nil {
+			return nil, err
+		}
+		for _, followerID := range followerIDs {
+			report.EdgesChecked++
+			following, ok := followingOf[followerID]
+			if !ok {
+				ids, err := c.db.GetFollowingIDs(ctx, followerID)
+				if err != nil {
+					return nil, err
+				}
+				following = toSet(ids)
+				followingOf[followerID] = following
+			}
+			if following[followeeID] {
+				continue
+			}
+
+			edge := AsymmetricEdge{FollowerID: followerID, FolloweeID: followeeID, MissingFrom: "following"}
+			if c.repair && c.stillFollowing(ctx, followerID, followeeID, false) {
+				if _, err := c.db.removeFollower(ctx, key.Value, strconv.FormatInt(followerID, 10)); err != nil {
+					log.Printf("[Consistency] failed to remove follower %d from %d: %v", followerID, followeeID, err)
+					report.RepairErrors++
+				} else {
+					edge.Repaired = true
+				}
+			}
+			report.Asymmetric = append(report.Asymmetric, edge)
+		}
+	}
+	return next, nil
+}
+
+// stillFollowing re-reads the following side of an edge and reports whether it still
+// says want, i.e. whether the repair the pass decided on still applies
+func (c *ConsistencyChecker) stillFollowing(ctx context.Context, followerID, followeeID int64, want bool) bool {
+	following, err := c.db.CheckFollowRelationship(ctx, followerID, followeeID)
+	if err != nil {
+		log.Printf("[Consistency] failed to re-check %d -> %d, not repairing: %v", followerID, followeeID, err)
+		return false
+	}
+	return following == want
+}
+
+// scan reads up to maxUsers items of table from start, following pages as needed
+func (c *ConsistencyChecker) scan(ctx context.Context, table, projection string, start map[string]types.AttributeValue) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+	var items []map[string]types.AttributeValue
+	for len(items) < c.maxUsers {
+		result, err := c.db.client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:            aws.String(table),
+			ProjectionExpression: aws.String(projection),
+			ExclusiveStartKey:    start,
+			Limit:                aws.Int32(int32(c.maxUsers - len(items))),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		items = append(items, result.Items...)
+		start = result.LastEvaluatedKey
+		if len(start) == 0 {
+			return items, nil, nil
+		}
+	}
+	return items, start, nil
+}
+
+// allFollowerIDs returns every follower of a user across all shards
+func (db *DynamoDBClient) allFollowerIDs(ctx context.Context, userID int64) ([]int64, error) {
+	followers, _, err := db.GetFollowers(ctx, userID, math.MaxInt32, nil)
+	if err != nil {
+		return nil, err
+	}
+	return followers, nil
+}
+
+// toSet returns ids as a set
+func toSet(ids []int64) map[int64]bool {
+	set := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// decodeFailures counts DynamoDB records and list entries that could not be decoded
+var decodeFailures atomic.Int64
+
+// DecodeError reports which table record and attribute failed to decode
+type DecodeError struct {
+	Table     string
+	UserID    int64
+	Attribute string
+	Err       error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode %s record for user %d (attribute %q): %v", e.Table, e.UserID, e.Attribute, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// newDecodeError records a decode failure and returns a DecodeError describing it
+func newDecodeError(table string, userID int64, attribute string, err error) error {
+	decodeFailures.Add(1)
+	return &DecodeError{Table: table, UserID: userID, Attribute: attribute, Err: err}
+}
+
+// parseIDList converts a list attribute of string IDs to int64, skipping and
+// reporting any entries that fail to parse
+func parseIDList(table string, userID int64, attribute string, ids []string) []int64 {
+	parsed := make([]int64, 0, len(ids))
+	skipped := 0
+	for i, idStr := range ids {
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			skipped++
+			log.Printf("[Decode] %s user=%d: skipping %s[%d]=%q: %v", table, userID, attribute, i, idStr, err)
+			continue
+		}
+		parsed = append(parsed, id)
+	}
+
+	if skipped > 0 {
+		decodeFailures.Add(int64(skipped))
+		log.Printf("[Decode] %s user=%d: skipped %d of %d entries in %s", table, userID, skipped, len(ids), attribute)
+	}
+	return parsed
+}
+
+// listLength returns the number of elements in a list attribute without decoding them
+// A missing attribute counts as an empty list
+func listLength(item map[string]types.AttributeValue, attribute string) (int32, error) {
+	attr, ok := item[attribute]
+	if !ok {
+		return 0, nil
+	}
+	list, ok := attr.(*types.AttributeValueMemberL)
+	if !ok {
+		return 0, fmt.Errorf("expected list, got %T", attr)
+	}
+	return int32(len(list.Value)), nil
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -14,16 +18,40 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
-// FollowerRecord represents a user's follower list in DynamoDB
+// errInvalidUserID is returned when a relationship involves a non-positive user ID
+var errInvalidUserID = errors.New("user IDs must be positive")
+
+// errInvalidCursor is returned when a pagination cursor is malformed or was not issued by this service
+var errInvalidCursor = errors.New("invalid cursor")
+
+// FollowerRecord represents one shard of a user's follower list in DynamoDB (see shards.go)
+// FollowedAt maps follower ID to the Unix time the follow was created, mirroring the
+// following side; followers in the shard without an entry predate tracking
 type FollowerRecord struct {
-	UserID      string   `dynamodbav:"user_id"`
-	FollowerIDs []string `dynamodbav:"follower_ids"`
+	UserID      string           `dynamodbav:"user_id"`
+	FollowerIDs []string         `dynamodbav:"follower_ids"`
+	FollowedAt  map[string]int64 `dynamodbav:"followed_at,omitempty"`
 }
 
 // FollowingRecord represents a user's following list in DynamoDB
+// FollowedAt maps followee ID to the Unix time the follow was created; records
+// loaded in bulk, or created before it was tracked, have no entry
 type FollowingRecord struct {
-	UserID       string   `dynamodbav:"user_id"`
-	FollowingIDs []string `dynamodbav:"following_ids"`
+	UserID       string           `dynamodbav:"user_id"`
+	FollowingIDs []string         `dynamodbav:"following_ids"`
+	FollowedAt   map[string]int64 `dynamodbav:"followed_at,omitempty"`
+}
+
+// FollowRelationship describes the relationship between a follower and a target in both directions
+type FollowRelationship struct {
+	IsFollowing  bool
+	FollowedAt   time.Time // Zero when IsFollowing is false or the follow predates tracking
+	IsFollowedBy bool      // Target follows the follower back
+}
+
+// IsMutual reports whether both users follow each other
+func (r FollowRelationship) IsMutual() bool {
+	return r.IsFollowing && r.IsFollowedBy
 }
 
 // DynamoDBClient wraps the AWS DynamoDB client
@@ -43,200 +71,429 @@ func NewDynamoDBClient(client *dynamodb.Client, followersTable, followingTable s
 }
 
 // InsertFollowRelationship inserts a follow relationship into both tables using list format
-// Uses DynamoDB's list append operation (if not exists, creates new list)
-func (db *DynamoDBClient) InsertFollowRelationship(ctx context.Context, followerID, followeeID int64) error {
+// Uses DynamoDB's list append operation (if not exists, creates new list). Both appends are
+// written in one transaction, so a failure leaves neither side rather than an asymmetric
+// edge. Each is conditional on the ID not already being in the list, so a repeated or
+// racing follow is a no-op rather than a duplicate entry that would inflate the counts.
+// followedAt is recorded on both sides of the edge, by the same appends, so the time a
+// caller reports can be read back later. An edge with only one side, left by writes from
+// before follows were transactional, gets its missing side.
+func (db *DynamoDBClient) InsertFollowRelationship(ctx context.Context, followerID, followeeID int64, followedAt time.Time) error {
+	if followerID <= 0 || followeeID <= 0 {
+		return errInvalidUserID
+	}
 	followerIDStr := fmt.Sprintf("%d", followerID)
 	followeeIDStr := fmt.Sprintf("%d", followeeID)
 
-	// Add to FollowersTable (user_id = followee, add follower to follower_ids list)
-	_, err := db.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
-		TableName: aws.String(db.followersTableName),
-		Key: map[string]types.AttributeValue{
-			"user_id": &types.AttributeValueMemberS{Value: followeeIDStr},
-		},
-		UpdateExpression: aws.String("SET follower_ids = list_append(if_not_exists(follower_ids, :empty_list), :new_follower)"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":new_follower": &types.AttributeValueMemberL{
-				Value: []types.AttributeValue{
-					&types.AttributeValueMemberS{Value: followerIDStr},
-				},
-			},
-			":empty_list": &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
-		},
-	})
+	// The transaction's condition only sees the last followers shard, so a follower
+	// already in an earlier one is looked for first
+	present, err := db.hasFollower(ctx, followeeIDStr, followerIDStr)
 	if err != nil {
 		return fmt.Errorf("failed to update FollowersTable: %w", err)
 	}
+	if present {
+		return db.completeFollowing(ctx, followerIDStr, followeeIDStr, followedAt)
+	}
 
-	// Add to FollowingTable (user_id = follower, add followee to following_ids list)
-	_, err = db.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+	for attempt := 1; attempt <= maxShardAttempts; attempt++ {
+		// The follower goes to the last follower_ids shard of the followee
+		shards, err := db.followerShardCount(ctx, followeeIDStr)
+		if err != nil {
+			return fmt.Errorf("failed to update FollowersTable: %w", err)
+		}
+		shardKey := followerShardKey(followeeIDStr, shards)
+
+		_, err = db.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{
+				{Update: db.followerAppend(shardKey, followerIDStr, followedAt)},
+				{Update: db.followingAppend(followerIDStr, followeeIDStr, followedAt)},
+			},
+		})
+		if err == nil {
+			return nil
+		}
+
+		followersFailed, followingFailed := transactionConditionsFailed(err)
+		if !followersFailed && !followingFailed {
+			return fmt.Errorf("failed to write follow relationship: %w", err)
+		}
+
+		if followingFailed {
+			// The followee is already followed, or the following item has no followed_at yet
+			following, hasTimes, err := db.followingState(ctx, followerIDStr, followeeIDStr)
+			if err != nil {
+				return err
+			}
+			if following != nil {
+				// Keep the original follow time, and give the edge its missing followers side
+				at := *following
+				if at.IsZero() {
+					at = followedAt
+				}
+				if _, err := db.appendFollower(ctx, followeeIDStr, followerIDStr, at); err != nil {
+					return fmt.Errorf("failed to update FollowersTable: %w", err)
+				}
+				return nil
+			}
+			if !hasTimes {
+				if err := db.ensureFollowedAtMap(ctx, db.followingTableName, followerIDStr); err != nil {
+					return err
+				}
+			}
+		}
+
+		if followersFailed {
+			// The follower is already in this shard, or it is full or has no followed_at yet
+			present, err := db.prepareFollowerShard(ctx, followeeIDStr, followerIDStr, shards)
+			if err != nil {
+				return fmt.Errorf("failed to update FollowersTable: %w", err)
+			}
+			if present {
+				return db.completeFollowing(ctx, followerIDStr, followeeIDStr, followedAt)
+			}
+		}
+	}
+
+	return fmt.Errorf("followers of %s kept changing; gave up after %d attempts", followeeIDStr, maxShardAttempts)
+}
+
+// completeFollowing adds the following side of an edge whose followers side is already recorded
+func (db *DynamoDBClient) completeFollowing(ctx context.Context, followerIDStr, followeeIDStr string, followedAt time.Time) error {
+	err := db.updateItem(ctx, db.followingAppend(followerIDStr, followeeIDStr, followedAt))
+	if err == nil {
+		return nil
+	}
+	if !isConditionalCheckFailed(err) {
+		return fmt.Errorf("failed to update FollowingTable: %w", err)
+	}
+
+	following, hasTimes, err := db.followingState(ctx, followerIDStr, followeeIDStr)
+	if err != nil || following != nil {
+		// Already following; keep the original follow time
+		return err
+	}
+	if !hasTimes {
+		if err := db.ensureFollowedAtMap(ctx, db.followingTableName, followerIDStr); err != nil {
+			return err
+		}
+	}
+	if err := db.updateItem(ctx, db.followingAppend(followerIDStr, followeeIDStr, followedAt)); err != nil && !isConditionalCheckFailed(err) {
+		return fmt.Errorf("failed to update FollowingTable: %w", err)
+	}
+	return nil
+}
+
+// followingAppend is the update adding followeeIDStr and its follow time to
+// followerIDStr's following, conditional on it not being there already and the item's
+// followed_at map existing, for the same reason as followerAppend
+func (db *DynamoDBClient) followingAppend(followerIDStr, followeeIDStr string, followedAt time.Time) *types.Update {
+	return &types.Update{
 		TableName: aws.String(db.followingTableName),
 		Key: map[string]types.AttributeValue{
 			"user_id": &types.AttributeValueMemberS{Value: followerIDStr},
 		},
-		UpdateExpression: aws.String("SET following_ids = list_append(if_not_exists(following_ids, :empty_list), :new_following)"),
+		UpdateExpression:         aws.String("SET following_ids = list_append(if_not_exists(following_ids, :empty_list), :new_following), followed_at.#followee = :followed_at"),
+		ConditionExpression:      aws.String("attribute_exists(followed_at) AND NOT contains(following_ids, :followee_id)"),
+		ExpressionAttributeNames: map[string]string{"#followee": followeeIDStr},
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":new_following": &types.AttributeValueMemberL{
 				Value: []types.AttributeValue{
 					&types.AttributeValueMemberS{Value: followeeIDStr},
 				},
 			},
-			":empty_list": &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
+			":followee_id": &types.AttributeValueMemberS{Value: followeeIDStr},
+			":followed_at": &types.AttributeValueMemberN{Value: strconv.FormatInt(followedAt.Unix(), 10)},
+			":empty_list":  &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
 		},
+	}
+}
+
+// followingState reads whether followerIDStr follows followeeIDStr, returning the follow
+// time if so (the zero time when none was recorded), and whether the following item has
+// a followed_at map
+func (db *DynamoDBClient) followingState(ctx context.Context, followerIDStr, followeeIDStr string) (*time.Time, bool, error) {
+	result, err := db.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(db.followingTableName),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: followerIDStr},
+		},
+		ProjectionExpression: aws.String("following_ids, followed_at"),
+		ConsistentRead:       aws.Bool(true),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to update FollowingTable: %w", err)
+		return nil, false, fmt.Errorf("failed to read following of %s: %w", followerIDStr, err)
 	}
 
-	return nil
+	var record FollowingRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &record); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal following record: %w", err)
+	}
+	_, hasTimes := result.Item["followed_at"].(*types.AttributeValueMemberM)
+	if !slices.Contains(record.FollowingIDs, followeeIDStr) {
+		return nil, hasTimes, nil
+	}
+	var followedAt time.Time
+	if at, ok := record.FollowedAt[followeeIDStr]; ok {
+		followedAt = time.Unix(at, 0)
+	}
+	return &followedAt, hasTimes, nil
+}
+
+// updateItem applies a single update outside a transaction
+func (db *DynamoDBClient) updateItem(ctx context.Context, update *types.Update) error {
+	_, err := db.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 update.TableName,
+		Key:                       update.Key,
+		UpdateExpression:          update.UpdateExpression,
+		ConditionExpression:       update.ConditionExpression,
+		ExpressionAttributeNames:  update.ExpressionAttributeNames,
+		ExpressionAttributeValues: update.ExpressionAttributeValues,
+	})
+	return err
+}
+
+// transactionConditionsFailed reports which of InsertFollowRelationship's two updates,
+// followers then following, failed its condition when err cancelled the transaction
+func transactionConditionsFailed(err error) (bool, bool) {
+	var cancelled *types.TransactionCanceledException
+	if !errors.As(err, &cancelled) || len(cancelled.CancellationReasons) != 2 {
+		return false, false
+	}
+	failed := func(reason types.CancellationReason) bool {
+		return aws.ToString(reason.Code) == "ConditionalCheckFailed"
+	}
+	return failed(cancelled.CancellationReasons[0]), failed(cancelled.CancellationReasons[1])
 }
 
+// isConditionalCheckFailed reports whether err is a failed ConditionExpression
+func isConditionalCheckFailed(err error) bool {
+	var conditionFailed *types.ConditionalCheckFailedException
+	return errors.As(err, &conditionFailed)
+}
+
+// maxRemoveAttempts bounds how often a list removal is retried when a concurrent
+// write shifts the element it was about to remove
+const maxRemoveAttempts = 5
+
 // DeleteFollowRelationship removes a follow relationship from both tables using list format
 // Note: This is O(n) operation - finds and removes the ID from the list
 func (db *DynamoDBClient) DeleteFollowRelationship(ctx context.Context, followerID, followeeID int64) error {
+	if followerID <= 0 || followeeID <= 0 {
+		return errInvalidUserID
+	}
 	followerIDStr := fmt.Sprintf("%d", followerID)
 	followeeIDStr := fmt.Sprintf("%d", followeeID)
 
-	// First, get the current follower list to find the index
-	getFollowersResult, err := db.client.GetItem(ctx, &dynamodb.GetItemInput{
-		TableName: aws.String(db.followersTableName),
-		Key: map[string]types.AttributeValue{
-			"user_id": &types.AttributeValueMemberS{Value: followeeIDStr},
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to get followers list: %w", err)
+	// Remove from FollowersTable (user_id = followee, remove follower from whichever shard holds it)
+	if _, err := db.removeFollower(ctx, followeeIDStr, followerIDStr); err != nil {
+		return fmt.Errorf("failed to remove from FollowersTable: %w", err)
 	}
 
-	// Find index of follower to remove
-	if getFollowersResult.Item != nil {
-		var record FollowerRecord
-		if err := attributevalue.UnmarshalMap(getFollowersResult.Item, &record); err == nil {
-			for idx, fid := range record.FollowerIDs {
-				if fid == followerIDStr {
-					// Remove from FollowersTable using index
-					_, err = db.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
-						TableName: aws.String(db.followersTableName),
-						Key: map[string]types.AttributeValue{
-							"user_id": &types.AttributeValueMemberS{Value: followeeIDStr},
-						},
-						UpdateExpression: aws.String(fmt.Sprintf("REMOVE follower_ids[%d]", idx)),
-					})
-					if err != nil {
-						return fmt.Errorf("failed to remove from FollowersTable: %w", err)
-					}
-					break
-				}
-			}
-		}
+	// Remove from FollowingTable (user_id = follower, remove followee from following_ids list)
+	if _, err := db.removeListElement(ctx, db.followingTableName, followerIDStr, "following_ids", followeeIDStr); err != nil {
+		return fmt.Errorf("failed to remove from FollowingTable: %w", err)
 	}
 
-	// Get the current following list to find the index
-	getFollowingResult, err := db.client.GetItem(ctx, &dynamodb.GetItemInput{
-		TableName: aws.String(db.followingTableName),
-		Key: map[string]types.AttributeValue{
-			"user_id": &types.AttributeValueMemberS{Value: followerIDStr},
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to get following list: %w", err)
-	}
-
-	// Find index of followee to remove
-	if getFollowingResult.Item != nil {
-		var record FollowingRecord
-		if err := attributevalue.UnmarshalMap(getFollowingResult.Item, &record); err == nil {
-			for idx, fid := range record.FollowingIDs {
-				if fid == followeeIDStr {
-					// Remove from FollowingTable using index
-					_, err = db.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
-						TableName: aws.String(db.followingTableName),
-						Key: map[string]types.AttributeValue{
-							"user_id": &types.AttributeValueMemberS{Value: followerIDStr},
-						},
-						UpdateExpression: aws.String(fmt.Sprintf("REMOVE following_ids[%d]", idx)),
-					})
-					if err != nil {
-						return fmt.Errorf("failed to remove from FollowingTable: %w", err)
-					}
-					break
-				}
+	return nil
+}
+
+// removeListElement removes value from the list attribute listAttr of the item keyed by userIDStr.
+// DynamoDB can only remove list elements by index, so the index is read first and the
+// removal is conditional on that index still holding value; if a concurrent update has
+// shifted the list, the read is repeated. The element's followed_at entry, if the
+// item has that map, is removed with it. A missing item or value is not an error; the
+// result reports whether value was found and removed.
+func (db *DynamoDBClient) removeListElement(ctx context.Context, tableName, userIDStr, listAttr, value string) (bool, error) {
+	key := map[string]types.AttributeValue{
+		"user_id": &types.AttributeValueMemberS{Value: userIDStr},
+	}
+
+	for attempt := 1; attempt <= maxRemoveAttempts; attempt++ {
+		// Strongly consistent so the index reflects any removal that just won a race
+		result, err := db.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:      aws.String(tableName),
+			Key:            key,
+			ConsistentRead: aws.Bool(true),
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to get %s: %w", listAttr, err)
+		}
+		if result.Item == nil {
+			return false, nil
+		}
+
+		var ids []string
+		if av, ok := result.Item[listAttr]; ok {
+			if err := attributevalue.Unmarshal(av, &ids); err != nil {
+				return false, fmt.Errorf("failed to unmarshal %s: %w", listAttr, err)
 			}
 		}
+		idx := -1
+		for i, id := range ids {
+			if id == value {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return false, nil
+		}
+
+		path := fmt.Sprintf("#list[%d]", idx)
+		input := &dynamodb.UpdateItemInput{
+			TableName:                aws.String(tableName),
+			Key:                      key,
+			UpdateExpression:         aws.String("REMOVE " + path),
+			ConditionExpression:      aws.String(path + " = :expected"),
+			ExpressionAttributeNames: map[string]string{"#list": listAttr},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":expected": &types.AttributeValueMemberS{Value: value},
+			},
+		}
+		// Items written before follow times were tracked have no timestamp map; a nested
+		// REMOVE fails if the map itself is missing, so only include it when present
+		if _, ok := result.Item["followed_at"]; ok {
+			input.UpdateExpression = aws.String("REMOVE " + path + ", followed_at.#id")
+			input.ExpressionAttributeNames["#id"] = value
+		}
+
+		_, err = db.client.UpdateItem(ctx, input)
+		if err == nil {
+			return true, nil
+		}
+		if !isConditionalCheckFailed(err) {
+			return false, err
+		}
+		log.Printf("%s of %s in %s changed during removal (attempt %d/%d), re-reading", listAttr, userIDStr, tableName, attempt, maxRemoveAttempts)
 	}
 
-	return nil
+	return false, fmt.Errorf("%s of %s kept changing; gave up after %d attempts", listAttr, userIDStr, maxRemoveAttempts)
 }
 
 // GetFollowers retrieves all followers of a user (from list format)
-// Note: With list format, this is now O(1) instead of O(n) query
+// Note: With list format, this is one read per follower shard instead of an O(n) query
 func (db *DynamoDBClient) GetFollowers(ctx context.Context, userID int64, limit int32, lastEvaluatedKey map[string]types.AttributeValue) ([]int64, map[string]types.AttributeValue, error) {
 	userIDStr := fmt.Sprintf("%d", userID)
 
-	result, err := db.client.GetItem(ctx, &dynamodb.GetItemInput{
-		TableName: aws.String(db.followersTableName),
-		Key: map[string]types.AttributeValue{
-			"user_id": &types.AttributeValueMemberS{Value: userIDStr},
-		},
-	})
+	shards, err := db.getFollowerShards(ctx, userIDStr, "follower_ids", false)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get followers: %w", err)
 	}
 
-	if result.Item == nil {
+	if len(shards) == 0 {
 		return []int64{}, nil, nil
 	}
 
-	var record FollowerRecord
-	err = attributevalue.UnmarshalMap(result.Item, &record)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to unmarshal follower record: %w", err)
+	var followerIDs []string
+	for _, item := range shards {
+		var record FollowerRecord
+		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+			return nil, nil, newDecodeError(db.followersTableName, userID, "follower_ids", err)
+		}
+		followerIDs = append(followerIDs, record.FollowerIDs...)
 	}
 
 	// Convert string IDs to int64
-	followers := make([]int64, 0, len(record.FollowerIDs))
-	for _, fidStr := range record.FollowerIDs {
-		fid, err := strconv.ParseInt(fidStr, 10, 64)
-		if err != nil {
-			log.Printf("failed to parse follower ID %s: %v", fidStr, err)
-			continue
-		}
-		followers = append(followers, fid)
-	}
+	followers := parseIDList(db.followersTableName, userID, "follower_ids", followerIDs)
 
 	// Simple pagination: slice the result
 	// Note: This is in-memory pagination. For better efficiency, consider storing offset in cursor
-	startIdx := 0
-	if lastEvaluatedKey != nil {
-		if offsetVal, ok := lastEvaluatedKey["offset"]; ok {
-			if offsetN, ok := offsetVal.(*types.AttributeValueMemberN); ok {
-				offset, _ := strconv.Atoi(offsetN.Value)
-				startIdx = offset
-			}
+	startIdx, endIdx, nextKey := offsetPage(len(followers), limit, lastEvaluatedKey)
+	return followers[startIdx:endIdx], nextKey, nil
+}
+
+// getFollowersByRecency returns all followers of a user with their follow times, newest first
+// Follows that predate tracking have no time and sort after the rest; within them, and
+// among equal times, later list positions (later follows) come first
+func (db *DynamoDBClient) getFollowersByRecency(ctx context.Context, userID int64) ([]FollowerInfo, error) {
+	userIDStr := fmt.Sprintf("%d", userID)
+
+	shards, err := db.getFollowerShards(ctx, userIDStr, "follower_ids, followed_at", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get followers: %w", err)
+	}
+
+	var followerIDs []string
+	followedAt := make(map[string]int64)
+	for _, item := range shards {
+		var record FollowerRecord
+		if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+			return nil, newDecodeError(db.followersTableName, userID, "follower_ids", err)
+		}
+		followerIDs = append(followerIDs, record.FollowerIDs...)
+		for id, ts := range record.FollowedAt {
+			followedAt[id] = ts
 		}
 	}
 
-	endIdx := startIdx + int(limit)
-	if endIdx > len(followers) {
-		endIdx = len(followers)
+	// Lists are in append order, so reversing them puts untracked follows newest first
+	slices.Reverse(followerIDs)
+	ids := parseIDList(db.followersTableName, userID, "follower_ids", followerIDs)
+
+	followers := make([]FollowerInfo, len(ids))
+	times := make([]int64, len(ids))
+	for i, id := range ids {
+		followers[i] = FollowerInfo{UserID: id}
+		if ts, ok := followedAt[strconv.FormatInt(id, 10)]; ok {
+			followers[i].FollowedAt = time.Unix(ts, 0).UTC().Format(time.RFC3339)
+			times[i] = ts
+		}
 	}
 
-	paginatedFollowers := followers[startIdx:endIdx]
+	sort.Stable(byFollowedAt{followers, times})
+	return followers, nil
+}
+
+// byFollowedAt sorts followers by descending follow time, keeping the two slices aligned
+type byFollowedAt struct {
+	followers []FollowerInfo
+	times     []int64
+}
+
+func (b byFollowedAt) Len() int           { return len(b.followers) }
+func (b byFollowedAt) Less(i, j int) bool { return b.times[i] > b.times[j] }
+func (b byFollowedAt) Swap(i, j int) {
+	b.followers[i], b.followers[j] = b.followers[j], b.followers[i]
+	b.times[i], b.times[j] = b.times[j], b.times[i]
+}
+
+// offsetPage returns the [start, end) window of a list of n entries that a page of limit
+// entries starting at lastEvaluatedKey's offset covers, and the key of the next page (nil on the last)
+func offsetPage(n int, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (int, int, map[string]types.AttributeValue) {
+	startIdx := 0
+	if offsetN, ok := lastEvaluatedKey["offset"].(*types.AttributeValueMemberN); ok {
+		startIdx, _ = strconv.Atoi(offsetN.Value)
+	}
+	// A cursor from before the list shrank may point past its end
+	startIdx = min(startIdx, n)
+	endIdx := min(startIdx+int(limit), n)
 
 	// Create next cursor if there are more results
 	var nextKey map[string]types.AttributeValue
-	if endIdx < len(followers) {
+	if endIdx < n {
 		nextKey = map[string]types.AttributeValue{
 			"offset": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", endIdx)},
 		}
 	}
-
-	return paginatedFollowers, nextKey, nil
+	return startIdx, endIdx, nextKey
 }
 
 // GetFollowing retrieves all users that a user follows (from list format)
 // Note: With list format, this is now O(1) instead of O(n) query
 func (db *DynamoDBClient) GetFollowing(ctx context.Context, userID int64, limit int32, lastEvaluatedKey map[string]types.AttributeValue) ([]int64, map[string]types.AttributeValue, error) {
+	following, err := db.GetFollowingIDs(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Simple pagination: slice the result
+	startIdx, endIdx, nextKey := offsetPage(len(following), limit, lastEvaluatedKey)
+	return following[startIdx:endIdx], nextKey, nil
+}
+
+// GetFollowingIDs retrieves the complete list of users that a user follows
+// Only the following_ids attribute is read; no pagination or counts are computed
+func (db *DynamoDBClient) GetFollowingIDs(ctx context.Context, userID int64) ([]int64, error) {
 	userIDStr := fmt.Sprintf("%d", userID)
 
 	result, err := db.client.GetItem(ctx, &dynamodb.GetItemInput{
@@ -244,94 +501,44 @@ func (db *DynamoDBClient) GetFollowing(ctx context.Context, userID int64, limit
 		Key: map[string]types.AttributeValue{
 			"user_id": &types.AttributeValueMemberS{Value: userIDStr},
 		},
+		ProjectionExpression: aws.String("following_ids"),
 	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get following: %w", err)
+		return nil, fmt.Errorf("failed to get following IDs: %w", err)
 	}
 
 	if result.Item == nil {
-		return []int64{}, nil, nil
+		return []int64{}, nil
 	}
 
 	var record FollowingRecord
 	err = attributevalue.UnmarshalMap(result.Item, &record)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to unmarshal following record: %w", err)
+		return nil, newDecodeError(db.followingTableName, userID, "following_ids", err)
 	}
 
-	// Convert string IDs to int64
-	following := make([]int64, 0, len(record.FollowingIDs))
-	for _, fidStr := range record.FollowingIDs {
-		fid, err := strconv.ParseInt(fidStr, 10, 64)
-		if err != nil {
-			log.Printf("failed to parse following ID %s: %v", fidStr, err)
-			continue
-		}
-		following = append(following, fid)
-	}
-
-	// Simple pagination: slice the result
-	startIdx := 0
-	if lastEvaluatedKey != nil {
-		if offsetVal, ok := lastEvaluatedKey["offset"]; ok {
-			if offsetN, ok := offsetVal.(*types.AttributeValueMemberN); ok {
-				offset, _ := strconv.Atoi(offsetN.Value)
-				startIdx = offset
-			}
-		}
-	}
-
-	endIdx := startIdx + int(limit)
-	if endIdx > len(following) {
-		endIdx = len(following)
-	}
-
-	paginatedFollowing := following[startIdx:endIdx]
-
-	// Create next cursor if there are more results
-	var nextKey map[string]types.AttributeValue
-	if endIdx < len(following) {
-		nextKey = map[string]types.AttributeValue{
-			"offset": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", endIdx)},
-		}
-	}
-
-	return paginatedFollowing, nextKey, nil
+	return parseIDList(db.followingTableName, userID, "following_ids", record.FollowingIDs), nil
 }
 
 // GetFollowersCount returns the count of followers for a user (from list format)
 func (db *DynamoDBClient) GetFollowersCount(ctx context.Context, userID int64) (int32, error) {
 	userIDStr := fmt.Sprintf("%d", userID)
 
-	result, err := db.client.GetItem(ctx, &dynamodb.GetItemInput{
-		TableName: aws.String(db.followersTableName),
-		Key: map[string]types.AttributeValue{
-			"user_id": &types.AttributeValueMemberS{Value: userIDStr},
-		},
-		ProjectionExpression: aws.String("follower_ids"),
-	})
+	shards, err := db.getFollowerShards(ctx, userIDStr, "follower_ids", false)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get followers count: %w", err)
 	}
 
-	if result.Item == nil {
-		return 0, nil
-	}
-
-	var record FollowerRecord
-	err = attributevalue.UnmarshalMap(result.Item, &record)
-	if err != nil {
-		return 0, fmt.Errorf("failed to unmarshal follower record: %w", err)
+	var count int32
+	for _, item := range shards {
+		n, err := listLength(item, "follower_ids")
+		if err != nil {
+			return 0, newDecodeError(db.followersTableName, userID, "follower_ids", err)
+		}
+		count += n
 	}
 
-	count := int32(len(record.FollowerIDs))
-	// Debug logging for verification
-	sampleSize := 5
-	if len(record.FollowerIDs) < sampleSize {
-		sampleSize = len(record.FollowerIDs)
-	}
-	log.Printf("GetFollowersCount: user=%d, count=%d, sample_ids=%v", userID, count, record.FollowerIDs[:sampleSize])
-	
+	debugf("GetFollowersCount: user=%d, count=%d", userID, count)
 	return count, nil
 }
 
@@ -354,17 +561,18 @@ func (db *DynamoDBClient) GetFollowingCount(ctx context.Context, userID int64) (
 		return 0, nil
 	}
 
-	var record FollowingRecord
-	err = attributevalue.UnmarshalMap(result.Item, &record)
+	count, err := listLength(result.Item, "following_ids")
 	if err != nil {
-		return 0, fmt.Errorf("failed to unmarshal following record: %w", err)
+		return 0, newDecodeError(db.followingTableName, userID, "following_ids", err)
 	}
-
-	return int32(len(record.FollowingIDs)), nil
+	return count, nil
 }
 
 // CheckFollowRelationship checks if follower follows followee (from list format)
 func (db *DynamoDBClient) CheckFollowRelationship(ctx context.Context, followerID, followeeID int64) (bool, error) {
+	if followerID <= 0 || followeeID <= 0 {
+		return false, errInvalidUserID
+	}
 	followerIDStr := fmt.Sprintf("%d", followerID)
 	followeeIDStr := fmt.Sprintf("%d", followeeID)
 
@@ -399,6 +607,95 @@ func (db *DynamoDBClient) CheckFollowRelationship(ctx context.Context, followerI
 	return false, nil
 }
 
+// BatchCheckFollowRelationship reports, for each followee, whether follower follows them
+// The follower's following list is read once, so the whole batch costs one GetItem
+func (db *DynamoDBClient) BatchCheckFollowRelationship(ctx context.Context, followerID int64, followeeIDs []int64) (map[int64]bool, error) {
+	if followerID <= 0 {
+		return nil, errInvalidUserID
+	}
+
+	record, err := db.getFollowingRecord(ctx, fmt.Sprintf("%d", followerID), "following_ids")
+	if err != nil {
+		return nil, err
+	}
+
+	following := make(map[string]struct{}, len(record.FollowingIDs))
+	for _, fid := range record.FollowingIDs {
+		following[fid] = struct{}{}
+	}
+
+	result := make(map[int64]bool, len(followeeIDs))
+	for _, id := range followeeIDs {
+		_, ok := following[fmt.Sprintf("%d", id)]
+		result[id] = ok
+	}
+	return result, nil
+}
+
+// GetFollowRelationship checks both directions between follower and followee and,
+// when follower follows followee, when that follow was created
+func (db *DynamoDBClient) GetFollowRelationship(ctx context.Context, followerID, followeeID int64) (*FollowRelationship, error) {
+	if followerID <= 0 || followeeID <= 0 {
+		return nil, errInvalidUserID
+	}
+	followerIDStr := fmt.Sprintf("%d", followerID)
+	followeeIDStr := fmt.Sprintf("%d", followeeID)
+
+	forward, err := db.getFollowingRecord(ctx, followerIDStr, "following_ids, followed_at")
+	if err != nil {
+		return nil, err
+	}
+	reverse, err := db.getFollowingRecord(ctx, followeeIDStr, "following_ids")
+	if err != nil {
+		return nil, err
+	}
+
+	rel := &FollowRelationship{}
+	for _, fid := range forward.FollowingIDs {
+		if fid == followeeIDStr {
+			rel.IsFollowing = true
+			break
+		}
+	}
+	if rel.IsFollowing {
+		if ts, ok := forward.FollowedAt[followeeIDStr]; ok {
+			rel.FollowedAt = time.Unix(ts, 0).UTC()
+		}
+	}
+	for _, fid := range reverse.FollowingIDs {
+		if fid == followerIDStr {
+			rel.IsFollowedBy = true
+			break
+		}
+	}
+
+	return rel, nil
+}
+
+// getFollowingRecord reads the projected attributes of a user's following record
+// A missing record is returned as an empty one
+func (db *DynamoDBClient) getFollowingRecord(ctx context.Context, userIDStr, projection string) (*FollowingRecord, error) {
+	result, err := db.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(db.followingTableName),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: userIDStr},
+		},
+		ProjectionExpression: aws.String(projection),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check follow relationship: %w", err)
+	}
+
+	var record FollowingRecord
+	if result.Item == nil {
+		return &record, nil
+	}
+	if err := attributevalue.UnmarshalMap(result.Item, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal following record: %w", err)
+	}
+	return &record, nil
+}
+
 // BatchInsertFollowRelationships inserts multiple follow relationships
 // Note: For list format, this uses individual UpdateItem calls (not optimal for bulk loading)
 // For initial data loading, use the Python script which writes directly in list format
@@ -406,7 +703,7 @@ func (db *DynamoDBClient) BatchInsertFollowRelationships(ctx context.Context, re
 	// Process each relationship individually
 	for _, rel := range relationships {
 		followerID, followeeID := rel[0], rel[1]
-		if err := db.InsertFollowRelationship(ctx, followerID, followeeID); err != nil {
+		if err := db.InsertFollowRelationship(ctx, followerID, followeeID, time.Now()); err != nil {
 			log.Printf("Failed to insert relationship %d -> %d: %v", followerID, followeeID, err)
 			// Continue with other relationships instead of failing completely
 		}
@@ -416,9 +713,11 @@ func (db *DynamoDBClient) BatchInsertFollowRelationships(ctx context.Context, re
 }
 
 // FollowerInfo represents a follower with user information
+// FollowedAt is RFC3339, like FollowUser's created_at, and empty for follows that predate tracking
 type FollowerInfo struct {
-	UserID   int64  `json:"user_id"`
-	Username string `json:"username,omitempty"`
+	UserID     int64  `json:"user_id"`
+	Username   string `json:"username,omitempty"`
+	FollowedAt string `json:"followed_at,omitempty"`
 }
 
 // FollowingInfo represents a following user with user information
@@ -427,7 +726,45 @@ type FollowingInfo struct {
 	Username string `json:"username,omitempty"`
 }
 
-// GetFollowersList retrieves followers with cursor-based pagination
+// listCursor is the JSON inside the base64 cursors of GetFollowersList and GetFollowingList
+type listCursor struct {
+	Offset int `json:"offset"`
+}
+
+// decodeListCursor turns a list cursor into the key GetFollowers and GetFollowing resume from
+// An empty cursor means the first page; anything that is not a cursor this service issued
+// is rejected with errInvalidCursor rather than silently restarting from the first page
+func decodeListCursor(cursor string) (map[string]types.AttributeValue, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	cursorBytes, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidCursor, err)
+	}
+	var c listCursor
+	if err := json.Unmarshal(cursorBytes, &c); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidCursor, err)
+	}
+	if c.Offset <= 0 {
+		return nil, fmt.Errorf("%w: offset must be positive", errInvalidCursor)
+	}
+	return map[string]types.AttributeValue{
+		"offset": &types.AttributeValueMemberN{Value: strconv.Itoa(c.Offset)},
+	}, nil
+}
+
+// encodeListCursor is the inverse of decodeListCursor
+func encodeListCursor(key map[string]types.AttributeValue) string {
+	var c listCursor
+	if offsetN, ok := key["offset"].(*types.AttributeValueMemberN); ok {
+		c.Offset, _ = strconv.Atoi(offsetN.Value)
+	}
+	cursorBytes, _ := json.Marshal(c) // Marshalling a struct of one int cannot fail
+	return base64.StdEncoding.EncodeToString(cursorBytes)
+}
+
+// GetFollowersList retrieves followers, newest follow first, with cursor-based pagination
 // Returns list of followers, next cursor (base64 encoded), and hasMore flag
 func (db *DynamoDBClient) GetFollowersList(ctx context.Context, userID string, limit int32, cursor string) ([]FollowerInfo, string, bool, error) {
 	// Convert string userID to int64
@@ -437,72 +774,52 @@ func (db *DynamoDBClient) GetFollowersList(ctx context.Context, userID string, l
 	}
 
 	// Decode cursor if provided
-	var lastEvaluatedKey map[string]types.AttributeValue
-	if cursor != "" {
-		cursorBytes, err := base64.StdEncoding.DecodeString(cursor)
-		if err != nil {
-			return nil, "", false, fmt.Errorf("invalid cursor: %w", err)
-		}
-		if err := json.Unmarshal(cursorBytes, &lastEvaluatedKey); err != nil {
-			return nil, "", false, fmt.Errorf("invalid cursor format: %w", err)
-		}
-	}
-
-	// Get followers from DynamoDB
-	followerIDs, newLastEvaluatedKey, err := db.GetFollowers(ctx, uid, limit, lastEvaluatedKey)
+	lastEvaluatedKey, err := decodeListCursor(cursor)
 	if err != nil {
 		return nil, "", false, err
 	}
 
-	// Convert to FollowerInfo list
-	followers := make([]FollowerInfo, len(followerIDs))
-	for i, fid := range followerIDs {
-		followers[i] = FollowerInfo{
-			UserID: fid,
-			// Username can be populated later if needed (requires user service call)
-		}
+	// Get followers from DynamoDB; usernames are populated later by the caller
+	allFollowers, err := db.getFollowersByRecency(ctx, uid)
+	if err != nil {
+		return nil, "", false, err
 	}
+	startIdx, endIdx, newLastEvaluatedKey := offsetPage(len(allFollowers), limit, lastEvaluatedKey)
+	followers := allFollowers[startIdx:endIdx]
 
 	// Encode next cursor
 	var nextCursor string
 	hasMore := newLastEvaluatedKey != nil
 	if hasMore {
-		cursorBytes, err := json.Marshal(newLastEvaluatedKey)
-		if err != nil {
-			return nil, "", false, fmt.Errorf("failed to encode cursor: %w", err)
-		}
-		nextCursor = base64.StdEncoding.EncodeToString(cursorBytes)
+		nextCursor = encodeListCursor(newLastEvaluatedKey)
 	}
 
 	return followers, nextCursor, hasMore, nil
 }
 
 // GetFollowingList retrieves following users with cursor-based pagination
-// Returns list of following users, next cursor (base64 encoded), and hasMore flag
-func (db *DynamoDBClient) GetFollowingList(ctx context.Context, userID string, limit int32, cursor string) ([]FollowingInfo, string, bool, error) {
+// Returns list of following users, next cursor (base64 encoded), hasMore flag, and the
+// total number followed. The page and the total come from the same read, so they always agree
+func (db *DynamoDBClient) GetFollowingList(ctx context.Context, userID string, limit int32, cursor string) ([]FollowingInfo, string, bool, int32, error) {
 	// Convert string userID to int64
 	uid, err := strconv.ParseInt(userID, 10, 64)
 	if err != nil {
-		return nil, "", false, fmt.Errorf("invalid user ID: %w", err)
+		return nil, "", false, 0, fmt.Errorf("invalid user ID: %w", err)
 	}
 
 	// Decode cursor if provided
-	var lastEvaluatedKey map[string]types.AttributeValue
-	if cursor != "" {
-		cursorBytes, err := base64.StdEncoding.DecodeString(cursor)
-		if err != nil {
-			return nil, "", false, fmt.Errorf("invalid cursor: %w", err)
-		}
-		if err := json.Unmarshal(cursorBytes, &lastEvaluatedKey); err != nil {
-			return nil, "", false, fmt.Errorf("invalid cursor format: %w", err)
-		}
+	lastEvaluatedKey, err := decodeListCursor(cursor)
+	if err != nil {
+		return nil, "", false, 0, err
 	}
 
 	// Get following from DynamoDB
-	followingIDs, newLastEvaluatedKey, err := db.GetFollowing(ctx, uid, limit, lastEvaluatedKey)
+	allFollowing, err := db.GetFollowingIDs(ctx, uid)
 	if err != nil {
-		return nil, "", false, err
+		return nil, "", false, 0, err
 	}
+	startIdx, endIdx, newLastEvaluatedKey := offsetPage(len(allFollowing), limit, lastEvaluatedKey)
+	followingIDs := allFollowing[startIdx:endIdx]
 
 	// Convert to FollowingInfo list
 	following := make([]FollowingInfo, len(followingIDs))
@@ -517,14 +834,10 @@ func (db *DynamoDBClient) GetFollowingList(ctx context.Context, userID string, l
 	var nextCursor string
 	hasMore := newLastEvaluatedKey != nil
 	if hasMore {
-		cursorBytes, err := json.Marshal(newLastEvaluatedKey)
-		if err != nil {
-			return nil, "", false, fmt.Errorf("failed to encode cursor: %w", err)
-		}
-		nextCursor = base64.StdEncoding.EncodeToString(cursorBytes)
+		nextCursor = encodeListCursor(newLastEvaluatedKey)
 	}
 
-	return following, nextCursor, hasMore, nil
+	return following, nextCursor, hasMore, int32(len(allFollowing)), nil
 }
 
 // GetFollowerCount is an alias for GetFollowersCount for HTTP API consistency
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// captureLogs redirects the standard logger for the rest of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func TestGetFollowersCountLogsOnlyAtDebug(t *testing.T) {
+	fake, db := newFakeDynamoDB(t)
+	fake.put("followers", "1", map[string]any{"follower_ids": idList("2", "3", "4")})
+
+	for _, tt := range []struct {
+		level   string
+		debug   bool
+		wantLog bool
+	}{
+		{level: "info", debug: false, wantLog: false},
+		{level: "debug", debug: true, wantLog: true},
+	} {
+		debugLogging = tt.debug
+		logs := captureLogs(t)
+
+		count, err := db.GetFollowersCount(context.Background(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 3 {
+			t.Errorf("%s: count = %d, want 3", tt.level, count)
+		}
+		if logged := strings.Contains(logs.String(), "GetFollowersCount"); logged != tt.wantLog {
+			t.Errorf("%s: logged %t, want %t (logs %q)", tt.level, logged, tt.wantLog, logs.String())
+		}
+		if strings.Contains(logs.String(), "sample_ids") {
+			t.Errorf("%s: logged sample_ids: %q", tt.level, logs.String())
+		}
+	}
+	debugLogging = false
+}
+
+// Removers that all read the list before any of them writes must still each remove
+// exactly their own element: stale indexes fail the condition and are re-read
+func TestRemoveListElementConcurrent(t *testing.T) {
+	tests := []struct {
+		name   string
+		remove []string
+		want   []string
+	}{
+		{name: "adjacent", remove: []string{"2", "3", "4"}, want: []string{"1", "5", "6"}},
+		{name: "ends", remove: []string{"1", "6"}, want: []string{"2", "3", "4", "5"}},
+		// One remover per retry attempt, so the last to win may have re-read every time
+		{name: "as many as attempts", remove: []string{"1", "2", "3", "4", "5"}, want: []string{"6"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDynamoDB(t)
+			followedAt := map[string]any{}
+			for _, id := range []string{"1", "2", "3", "4", "5", "6"} {
+				followedAt[id] = map[string]any{"N": "1700000000"}
+			}
+			fake.put("following", "9", map[string]any{
+				"following_ids": idList("1", "2", "3", "4", "5", "6"),
+				"followed_at":   map[string]any{"M": followedAt},
+			})
+
+			// The first read of every remover is served from the same snapshot, and none
+			// returns until all have read
+			var mu sync.Mutex
+			reads := 0
+			allRead := make(chan struct{})
+			fake.intercept = func(operation string, body map[string]any) (bool, int, any) {
+				if operation != "GetItem" {
+					return false, 0, nil
+				}
+				mu.Lock()
+				reads++
+				first := reads <= len(tt.remove)
+				mu.Unlock()
+				if !first {
+					return false, 0, nil
+				}
+				status, resp := fake.handle(operation, body)
+				encoded, _ := json.Marshal(resp)
+				mu.Lock()
+				if reads == len(tt.remove) {
+					close(allRead)
+				}
+				mu.Unlock()
+				<-allRead
+				return true, status, json.RawMessage(encoded)
+			}
+
+			var wg sync.WaitGroup
+			errs := make([]error, len(tt.remove))
+			for i, id := range tt.remove {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					removed, err := db.removeListElement(context.Background(), "following", "9", "following_ids", id)
+					if err == nil && !removed {
+						err = fmt.Errorf("%s not removed", id)
+					}
+					errs[i] = err
+				}()
+			}
+			wg.Wait()
+
+			for _, err := range errs {
+				if err != nil {
+					t.Error(err)
+				}
+			}
+			if got := fake.list("following", "9", "following_ids"); !slices.Equal(got, tt.want) {
+				t.Errorf("following_ids = %v, want %v", got, tt.want)
+			}
+			fake.mu.Lock()
+			remaining := fake.items["following"]["9"]["followed_at"].(map[string]any)["M"].(map[string]any)
+			if len(remaining) != len(tt.want) {
+				t.Errorf("followed_at has %d entries, want %d", len(remaining), len(tt.want))
+			}
+			for _, id := range tt.want {
+				if _, ok := remaining[id]; !ok {
+					t.Errorf("followed_at lost %s", id)
+				}
+			}
+			fake.mu.Unlock()
+		})
+	}
+}
+
+func TestDecodeListCursor(t *testing.T) {
+	valid := encodeListCursor(map[string]types.AttributeValue{"offset": &types.AttributeValueMemberN{Value: "40"}})
+	tests := []struct {
+		name       string
+		cursor     string
+		wantOffset string
+		wantErr    bool
+	}{
+		{name: "empty starts at the first page"},
+		{name: "issued cursor", cursor: valid, wantOffset: "40"},
+		{name: "not base64", cursor: "!!not-a-cursor!!", wantErr: true},
+		{name: "truncated", cursor: valid[:len(valid)-4], wantErr: true},
+		{name: "not JSON", cursor: base64.StdEncoding.EncodeToString([]byte("offset=40")), wantErr: true},
+		{name: "zero offset", cursor: base64.StdEncoding.EncodeToString([]byte(`{"offset":0}`)), wantErr: true},
+		{name: "negative offset", cursor: base64.StdEncoding.EncodeToString([]byte(`{"offset":-10}`)), wantErr: true},
+	}
+	for _, tt := range tests {
+		key, err := decodeListCursor(tt.cursor)
+		if tt.wantErr {
+			if !errors.Is(err, errInvalidCursor) {
+				t.Errorf("%s: error = %v, want errInvalidCursor", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		offset, _ := key["offset"].(*types.AttributeValueMemberN)
+		if (offset == nil && tt.wantOffset != "") || (offset != nil && offset.Value != tt.wantOffset) {
+			t.Errorf("%s: key = %v, want offset %q", tt.name, key, tt.wantOffset)
+		}
+	}
+}
+
+// transactionCanceled is a TransactWriteItems failure with one reason code per item
+func transactionCanceled(codes ...string) map[string]any {
+	reasons := make([]any, len(codes))
+	for i, code := range codes {
+		reasons[i] = map[string]any{"Code": code}
+	}
+	resp := dynamoError("TransactionCanceledException", "Transaction cancelled")
+	resp["CancellationReasons"] = reasons
+	return resp
+}
+
+// Both sides of a follow are written by one transaction along with their follow times;
+// a failed transaction writes nothing, and an edge with one side gets the other
+func TestInsertFollowRelationshipIsTransactional(t *testing.T) {
+	const now = "1700000000"
+	tests := []struct {
+		name          string
+		followers     map[string]any // item of user 2 in followers, if any
+		following     map[string]any // item of user 1 in following, if any
+		failStatus    int            // when set, TransactWriteItems fails with this response
+		failResp      any
+		wantErr       bool
+		wantFollowers []string
+		wantFollowing []string
+		wantTime      string // followed_at on both sides
+	}{
+		{
+			name:          "new follow",
+			wantFollowers: []string{"1"},
+			wantFollowing: []string{"2"},
+			wantTime:      now,
+		},
+		{
+			name:          "followers with other follows",
+			followers:     map[string]any{"follower_ids": idList("3"), "followed_at": times("100", "3")},
+			following:     map[string]any{"following_ids": idList("4"), "followed_at": times("100", "4")},
+			wantFollowers: []string{"3", "1"},
+			wantFollowing: []string{"4", "2"},
+			wantTime:      now,
+		},
+		{
+			name:          "already following",
+			followers:     map[string]any{"follower_ids": idList("1"), "followed_at": times("100", "1")},
+			following:     map[string]any{"following_ids": idList("2"), "followed_at": times("100", "2")},
+			wantFollowers: []string{"1"},
+			wantFollowing: []string{"2"},
+			wantTime:      "100",
+		},
+		{
+			name:          "only the following side",
+			followers:     map[string]any{"follower_ids": idList("3")},
+			following:     map[string]any{"following_ids": idList("2"), "followed_at": times("100", "2")},
+			wantFollowers: []string{"3", "1"},
+			wantFollowing: []string{"2"},
+			wantTime:      "100",
+		},
+		{
+			name:          "only the followers side",
+			followers:     map[string]any{"follower_ids": idList("1"), "followed_at": times(now, "1")},
+			following:     map[string]any{"following_ids": idList("4")},
+			wantFollowers: []string{"1"},
+			wantFollowing: []string{"4", "2"},
+			wantTime:      now,
+		},
+		{
+			name:       "throttled",
+			failStatus: http.StatusBadRequest,
+			failResp:   transactionCanceled("None", "ThrottlingError"),
+			wantErr:    true,
+		},
+		{
+			name:       "validation error",
+			failStatus: http.StatusBadRequest,
+			failResp:   dynamoError("ValidationException", "bad request"),
+			wantErr:    true,
+		},
+		{
+			name:       "server error",
+			failStatus: http.StatusInternalServerError,
+			failResp:   dynamoError("InternalServerError", "boom"),
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDynamoDB(t)
+			if tt.followers != nil {
+				fake.put("followers", "2", tt.followers)
+			}
+			if tt.following != nil {
+				fake.put("following", "1", tt.following)
+			}
+			if tt.failStatus != 0 {
+				fake.put("followers", "2", map[string]any{"follower_ids": idList("3"), "followed_at": times("100", "3")})
+				fake.put("following", "1", map[string]any{"following_ids": idList("4"), "followed_at": times("100", "4")})
+				tt.wantFollowers, tt.wantFollowing = []string{"3"}, []string{"4"}
+				fake.intercept = func(operation string, body map[string]any) (bool, int, any) {
+					return operation == "TransactWriteItems", tt.failStatus, tt.failResp
+				}
+			}
+
+			err := db.InsertFollowRelationship(context.Background(), 1, 2, time.Unix(1_700_000_000, 0))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %t", err, tt.wantErr)
+			}
+
+			if got := fake.list("followers", "2", "follower_ids"); !slices.Equal(got, tt.wantFollowers) {
+				t.Errorf("follower_ids = %v, want %v", got, tt.wantFollowers)
+			}
+			if got := fake.list("following", "1", "following_ids"); !slices.Equal(got, tt.wantFollowing) {
+				t.Errorf("following_ids = %v, want %v", got, tt.wantFollowing)
+			}
+			if got := fake.followedAt("followers", "2", "1"); got != tt.wantTime {
+				t.Errorf("followers followed_at = %q, want %q", got, tt.wantTime)
+			}
+			if got := fake.followedAt("following", "1", "2"); got != tt.wantTime {
+				t.Errorf("following followed_at = %q, want %q", got, tt.wantTime)
+			}
+			if got := fake.calls("UpdateItem"); tt.wantErr && got != 0 {
+				t.Errorf("%d UpdateItem calls after a failed transaction, want 0", got)
+			}
+		})
+	}
+}
+
+// times is a wire-format followed_at map of the given IDs, all followed at unix second at
+func times(at string, ids ...string) map[string]any {
+	entries := map[string]any{}
+	for _, id := range ids {
+		entries[id] = map[string]any{"N": at}
+	}
+	return map[string]any{"M": entries}
+}
+
+// A follower is added once across all shards, with its follow time written by the same update
+func TestAppendFollower(t *testing.T) {
+	full := make([]string, maxFollowersPerShard)
+	for i := range full {
+		full[i] = fmt.Sprintf("%d", 1000+i)
+	}
+	followedAt := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name      string
+		followers map[string]map[string]any // followers items by key
+		follower  string
+		wantAdded bool
+		wantShard string // where the follower ends up
+		wantLists map[string][]string
+		wantTime  string
+	}{
+		{
+			name:      "no followers yet",
+			follower:  "7",
+			wantAdded: true,
+			wantShard: "2",
+			wantLists: map[string][]string{"2": {"7"}},
+			wantTime:  "1700000000",
+		},
+		{
+			name: "in an earlier shard",
+			followers: map[string]map[string]any{
+				"2":   {"follower_ids": idList("3"), "followed_at": times("100", "3"), "shard_count": map[string]any{"N": "1"}},
+				"2#1": {"follower_ids": idList("5"), "followed_at": times("200", "5")},
+			},
+			follower:  "3",
+			wantShard: "2",
+			wantLists: map[string][]string{"2": {"3"}, "2#1": {"5"}},
+			wantTime:  "100",
+		},
+		{
+			name: "appended to the last shard",
+			followers: map[string]map[string]any{
+				"2":   {"follower_ids": idList("3"), "followed_at": times("100", "3"), "shard_count": map[string]any{"N": "1"}},
+				"2#1": {"follower_ids": idList("5"), "followed_at": times("200", "5")},
+			},
+			follower:  "7",
+			wantAdded: true,
+			wantShard: "2#1",
+			wantLists: map[string][]string{"2": {"3"}, "2#1": {"5", "7"}},
+			wantTime:  "1700000000",
+		},
+		{
+			name: "shard without follow times",
+			followers: map[string]map[string]any{
+				"2": {"follower_ids": idList("3")},
+			},
+			follower:  "7",
+			wantAdded: true,
+			wantShard: "2",
+			wantLists: map[string][]string{"2": {"3", "7"}},
+			wantTime:  "1700000000",
+		},
+		{
+			name: "full shard",
+			followers: map[string]map[string]any{
+				"2": {"follower_ids": idList(full...), "followed_at": times("100")},
+			},
+			follower:  "7",
+			wantAdded: true,
+			wantShard: "2#1",
+			wantLists: map[string][]string{"2": full, "2#1": {"7"}},
+			wantTime:  "1700000000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, db := newFakeDynamoDB(t)
+			for key, item := range tt.followers {
+				fake.put("followers", key, item)
+			}
+
+			added, err := db.appendFollower(context.Background(), "2", tt.follower, followedAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if added != tt.wantAdded {
+				t.Errorf("added = %t, want %t", added, tt.wantAdded)
+			}
+			for key, want := range tt.wantLists {
+				if got := fake.list("followers", key, "follower_ids"); !slices.Equal(got, want) {
+					t.Errorf("follower_ids of %s = %v, want %v", key, got, want)
+				}
+			}
+			if got := fake.followedAt("followers", tt.wantShard, tt.follower); got != tt.wantTime {
+				t.Errorf("followed_at of %s in %s = %q, want %q", tt.follower, tt.wantShard, got, tt.wantTime)
+			}
+		})
+	}
+}
+
+// A follower already in an earlier followers shard is not added again to the last one
+// when a follow completes an edge missing its following side
+func TestInsertFollowRelationshipChecksEveryShard(t *testing.T) {
+	fake, db := newFakeDynamoDB(t)
+	fake.put("followers", "2", map[string]any{"follower_ids": idList("1"), "followed_at": times("100", "1"), "shard_count": map[string]any{"N": "1"}})
+	fake.put("followers", "2#1", map[string]any{"follower_ids": idList("5"), "followed_at": times("200", "5")})
+
+	if err := db.InsertFollowRelationship(context.Background(), 1, 2, time.Unix(1_700_000_000, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fake.list("followers", "2", "follower_ids"); !slices.Equal(got, []string{"1"}) {
+		t.Errorf("follower_ids of 2 = %v, want [1]", got)
+	}
+	if got := fake.list("followers", "2#1", "follower_ids"); !slices.Equal(got, []string{"5"}) {
+		t.Errorf("follower_ids of 2#1 = %v, want [5]", got)
+	}
+	if got := fake.list("following", "1", "following_ids"); !slices.Equal(got, []string{"2"}) {
+		t.Errorf("following_ids of 1 = %v, want [2]", got)
+	}
+}
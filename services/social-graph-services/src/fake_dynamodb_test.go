@@ -0,0 +1,445 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// fakeDynamoDB is an in-memory DynamoDB endpoint covering the few operations the tests
+// drive: GetItem, BatchGetItem, the conditional list-element REMOVE of
+// removeListElement, and the SET updates of follows, alone or in TransactWriteItems.
+// Items are kept in wire format, keyed by table and user_id. Anything else fails unless
+// intercept handles it.
+type fakeDynamoDB struct {
+	mu         sync.Mutex
+	items      map[string]map[string]map[string]any
+	operations []string
+
+	// intercept, when set, sees every request first; returning handled skips the built-in behaviour
+	intercept func(operation string, body map[string]any) (handled bool, status int, resp any)
+}
+
+// newFakeDynamoDB starts a fake and returns it with a client reading its followers and following tables
+func newFakeDynamoDB(t *testing.T) (*fakeDynamoDB, *DynamoDBClient) {
+	t.Helper()
+	fake := &fakeDynamoDB{items: map[string]map[string]map[string]any{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client := dynamodb.New(dynamodb.Options{
+		Region:           "us-west-2",
+		BaseEndpoint:     aws.String(server.URL),
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+	})
+	return fake, NewDynamoDBClient(client, "followers", "following")
+}
+
+// idList is a wire-format list of string IDs
+func idList(ids ...string) map[string]any {
+	list := make([]any, len(ids))
+	for i, id := range ids {
+		list[i] = map[string]any{"S": id}
+	}
+	return map[string]any{"L": list}
+}
+
+// put stores an item under user_id in table
+func (f *fakeDynamoDB) put(table, userID string, attributes map[string]any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	item := map[string]any{"user_id": map[string]any{"S": userID}}
+	for name, value := range attributes {
+		item[name] = value
+	}
+	if f.items[table] == nil {
+		f.items[table] = map[string]map[string]any{}
+	}
+	f.items[table][userID] = item
+}
+
+// list returns the string IDs in an item's list attribute
+func (f *fakeDynamoDB) list(table, userID, attribute string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []string
+	list, _ := f.items[table][userID][attribute].(map[string]any)
+	values, _ := list["L"].([]any)
+	for _, value := range values {
+		ids = append(ids, value.(map[string]any)["S"].(string))
+	}
+	return ids
+}
+
+// followedAt returns the follow time stored for id in an item's followed_at map, or "" if none
+func (f *fakeDynamoDB) followedAt(table, userID, id string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	times, _ := f.items[table][userID]["followed_at"].(map[string]any)
+	entries, _ := times["M"].(map[string]any)
+	entry, _ := entries[id].(map[string]any)
+	n, _ := entry["N"].(string)
+	return n
+}
+
+// calls returns how many requests were made for operation
+func (f *fakeDynamoDB) calls(operation string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, op := range f.operations {
+		if op == operation {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeDynamoDB) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.")
+	var body map[string]any
+	json.NewDecoder(r.Body).Decode(&body)
+
+	f.mu.Lock()
+	f.operations = append(f.operations, operation)
+	f.mu.Unlock()
+
+	status, resp := http.StatusOK, any(nil)
+	handled := false
+	if f.intercept != nil {
+		handled, status, resp = f.intercept(operation, body)
+	}
+	if !handled {
+		status, resp = f.handle(operation, body)
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// dynamoError is a DynamoDB error response body of the given exception type
+func dynamoError(exception, message string) map[string]any {
+	return map[string]any{"__type": "com.amazonaws.dynamodb.v20120810#" + exception, "message": message}
+}
+
+var removeElement = regexp.MustCompile(`^REMOVE #list\[(\d+)\](, followed_at\.#id)?$`)
+
+func (f *fakeDynamoDB) handle(operation string, body map[string]any) (int, any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch operation {
+	case "GetItem":
+		item := f.items[body["TableName"].(string)][keyOf(body["Key"])]
+		if item == nil {
+			return http.StatusOK, map[string]any{}
+		}
+		return http.StatusOK, map[string]any{"Item": item}
+
+	case "BatchGetItem":
+		responses := map[string]any{}
+		for table, request := range body["RequestItems"].(map[string]any) {
+			var found []any
+			for _, key := range request.(map[string]any)["Keys"].([]any) {
+				if item := f.items[table][keyOf(key)]; item != nil {
+					found = append(found, item)
+				}
+			}
+			responses[table] = found
+		}
+		return http.StatusOK, map[string]any{"Responses": responses}
+
+	case "TransactWriteItems":
+		// All conditions are checked before anything is written, as in one transaction
+		var updates []map[string]any
+		reasons := []any{}
+		failed := false
+		for _, item := range body["TransactItems"].([]any) {
+			update, ok := item.(map[string]any)["Update"].(map[string]any)
+			if !ok {
+				return http.StatusBadRequest, dynamoError("ValidationException", "unsupported transaction item")
+			}
+			updates = append(updates, update)
+			code := "None"
+			if ok, err := f.condition(update); err != nil {
+				return http.StatusBadRequest, dynamoError("ValidationException", err.Error())
+			} else if !ok {
+				code, failed = "ConditionalCheckFailed", true
+			}
+			reasons = append(reasons, map[string]any{"Code": code})
+		}
+		if failed {
+			resp := dynamoError("TransactionCanceledException", "Transaction cancelled")
+			resp["CancellationReasons"] = reasons
+			return http.StatusBadRequest, resp
+		}
+		for _, update := range updates {
+			if err := f.set(update); err != nil {
+				return http.StatusBadRequest, dynamoError("ValidationException", err.Error())
+			}
+		}
+		return http.StatusOK, map[string]any{}
+
+	case "UpdateItem":
+		if strings.HasPrefix(body["UpdateExpression"].(string), "SET ") {
+			ok, err := f.condition(body)
+			if err != nil {
+				return http.StatusBadRequest, dynamoError("ValidationException", err.Error())
+			}
+			if !ok {
+				return http.StatusBadRequest, dynamoError("ConditionalCheckFailedException", "The conditional request failed")
+			}
+			if err := f.set(body); err != nil {
+				return http.StatusBadRequest, dynamoError("ValidationException", err.Error())
+			}
+			return http.StatusOK, map[string]any{}
+		}
+		match := removeElement.FindStringSubmatch(body["UpdateExpression"].(string))
+		if match == nil {
+			return http.StatusBadRequest, dynamoError("ValidationException", "unsupported update "+body["UpdateExpression"].(string))
+		}
+		index, _ := strconv.Atoi(match[1])
+		attribute := body["ExpressionAttributeNames"].(map[string]any)["#list"].(string)
+		expected := body["ExpressionAttributeValues"].(map[string]any)[":expected"].(map[string]any)["S"]
+
+		item := f.items[body["TableName"].(string)][keyOf(body["Key"])]
+		list, _ := item[attribute].(map[string]any)
+		values, _ := list["L"].([]any)
+		if index >= len(values) || values[index].(map[string]any)["S"] != expected {
+			return http.StatusBadRequest, dynamoError("ConditionalCheckFailedException", "The conditional request failed")
+		}
+		list["L"] = append(values[:index:index], values[index+1:]...)
+		if match[2] != "" {
+			id := body["ExpressionAttributeNames"].(map[string]any)["#id"].(string)
+			if times, ok := item["followed_at"].(map[string]any)["M"].(map[string]any); ok {
+				delete(times, id)
+			}
+		}
+		return http.StatusOK, map[string]any{}
+	}
+	return http.StatusBadRequest, dynamoError("ValidationException", fmt.Sprintf("unsupported operation %s", operation))
+}
+
+// keyOf returns the user_id of a wire-format key
+func keyOf(key any) string {
+	return key.(map[string]any)["user_id"].(map[string]any)["S"].(string)
+}
+
+// expression resolves the names and values an update's expressions refer to
+type expression struct {
+	names  map[string]any
+	values map[string]any
+	item   map[string]any
+}
+
+func (f *fakeDynamoDB) expressionOf(update map[string]any) expression {
+	names, _ := update["ExpressionAttributeNames"].(map[string]any)
+	values, _ := update["ExpressionAttributeValues"].(map[string]any)
+	item := f.items[update["TableName"].(string)][keyOf(update["Key"])]
+	return expression{names: names, values: values, item: item}
+}
+
+// name resolves a top-level attribute name, which may be a #placeholder
+func (e expression) name(name string) string {
+	if strings.HasPrefix(name, "#") {
+		return e.names[name].(string)
+	}
+	return name
+}
+
+// operand resolves a :value or an attribute of the item; a missing attribute is nil
+func (e expression) operand(operand string) map[string]any {
+	if strings.HasPrefix(operand, ":") {
+		value, _ := e.values[operand].(map[string]any)
+		return value
+	}
+	value, _ := e.item[e.name(operand)].(map[string]any)
+	return value
+}
+
+// condition evaluates an update's ConditionExpression against the stored item. It
+// understands AND, OR, NOT, parentheses, attribute_exists, attribute_not_exists,
+// contains on lists, size(...) < and =.
+func (f *fakeDynamoDB) condition(update map[string]any) (bool, error) {
+	condition, _ := update["ConditionExpression"].(string)
+	if condition == "" {
+		return true, nil
+	}
+	return f.expressionOf(update).evaluate(condition)
+}
+
+func (e expression) evaluate(condition string) (bool, error) {
+	condition = strings.TrimSpace(condition)
+	for _, operator := range []string{" OR ", " AND "} {
+		if parts := splitTopLevel(condition, operator); len(parts) > 1 {
+			for _, part := range parts {
+				ok, err := e.evaluate(part)
+				if err != nil {
+					return false, err
+				}
+				if ok == (operator == " OR ") {
+					return ok, nil
+				}
+			}
+			return operator == " AND ", nil
+		}
+	}
+	if rest, ok := strings.CutPrefix(condition, "NOT "); ok {
+		result, err := e.evaluate(rest)
+		return !result, err
+	}
+	if strings.HasPrefix(condition, "(") && strings.HasSuffix(condition, ")") {
+		return e.evaluate(condition[1 : len(condition)-1])
+	}
+
+	if name, ok := call(condition, "attribute_exists"); ok {
+		return e.operand(name) != nil, nil
+	}
+	if name, ok := call(condition, "attribute_not_exists"); ok {
+		return e.operand(name) == nil, nil
+	}
+	if args, ok := call(condition, "contains"); ok {
+		list, value, _ := strings.Cut(args, ", ")
+		elements, _ := e.operand(list)["L"].([]any)
+		want := e.operand(value)
+		for _, element := range elements {
+			if element.(map[string]any)["S"] == want["S"] {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	if left, right, ok := strings.Cut(condition, " < "); ok {
+		name, isSize := call(left, "size")
+		if !isSize {
+			return false, fmt.Errorf("unsupported condition %q", condition)
+		}
+		elements, _ := e.operand(name)["L"].([]any)
+		limit, err := strconv.Atoi(e.operand(right)["N"].(string))
+		return len(elements) < limit, err
+	}
+	if left, right, ok := strings.Cut(condition, " = "); ok {
+		stored, want := e.operand(left), e.operand(right)
+		return stored != nil && fmt.Sprint(stored) == fmt.Sprint(want), nil
+	}
+	return false, fmt.Errorf("unsupported condition %q", condition)
+}
+
+// set applies an update's SET actions, creating the item if it is missing. Each action
+// sets a top-level attribute or a key of a map attribute to a :value, if_not_exists or
+// list_append(if_not_exists(...), :value). As in DynamoDB, a key cannot be set in a map
+// that does not exist, nor in one the same update sets.
+func (f *fakeDynamoDB) set(update map[string]any) error {
+	table := update["TableName"].(string)
+	key := keyOf(update["Key"])
+	if f.items[table] == nil {
+		f.items[table] = map[string]map[string]any{}
+	}
+	if f.items[table][key] == nil {
+		f.items[table][key] = map[string]any{"user_id": map[string]any{"S": key}}
+	}
+	e := f.expressionOf(update)
+
+	assignments := map[string]map[string]any{}
+	for _, action := range splitTopLevel(strings.TrimPrefix(update["UpdateExpression"].(string), "SET "), ", ") {
+		path, operand, ok := strings.Cut(action, " = ")
+		if !ok {
+			return fmt.Errorf("unsupported action %q", action)
+		}
+		value, err := e.value(operand)
+		if err != nil {
+			return err
+		}
+		assignments[path] = value
+	}
+	for path, value := range assignments {
+		attribute, mapKey, nested := strings.Cut(path, ".")
+		attribute = e.name(attribute)
+		if !nested {
+			e.item[attribute] = value
+			continue
+		}
+		if _, overlaps := assignments[strings.Split(path, ".")[0]]; overlaps {
+			return fmt.Errorf("two document paths overlap with each other: %s", path)
+		}
+		stored, _ := e.item[attribute].(map[string]any)
+		entries, ok := stored["M"].(map[string]any)
+		if !ok {
+			return fmt.Errorf("the document path provided in the update expression is invalid for update: %s", path)
+		}
+		entries[e.name(mapKey)] = value
+	}
+	return nil
+}
+
+// value evaluates the right-hand side of a SET action
+func (e expression) value(operand string) (map[string]any, error) {
+	if args, ok := call(operand, "list_append"); ok {
+		parts := splitTopLevel(args, ", ")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("unsupported list_append %q", operand)
+		}
+		first, err := e.value(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		second, err := e.value(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		a, _ := first["L"].([]any)
+		b, _ := second["L"].([]any)
+		return map[string]any{"L": append(append([]any{}, a...), b...)}, nil
+	}
+	if args, ok := call(operand, "if_not_exists"); ok {
+		name, fallback, _ := strings.Cut(args, ", ")
+		if stored := e.operand(name); stored != nil {
+			return stored, nil
+		}
+		return e.operand(fallback), nil
+	}
+	if strings.HasPrefix(operand, ":") {
+		return e.operand(operand), nil
+	}
+	return nil, fmt.Errorf("unsupported operand %q", operand)
+}
+
+// call returns the arguments of expression if it is a call of function
+func call(expression, function string) (string, bool) {
+	args, ok := strings.CutPrefix(expression, function+"(")
+	if !ok || !strings.HasSuffix(args, ")") {
+		return "", false
+	}
+	return args[:len(args)-1], true
+}
+
+// splitTopLevel splits s at separator wherever it is outside parentheses
+func splitTopLevel(s, separator string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		if depth == 0 && strings.HasPrefix(s[i:], separator) {
+			parts = append(parts, s[start:i])
+			start = i + len(separator)
+			i += len(separator) - 1
+		}
+	}
+	return append(parts, s[start:])
+}
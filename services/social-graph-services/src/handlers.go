@@ -4,19 +4,27 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/cs6650/proto/logthrottle"
 	pb "github.com/cs6650/proto/social_graph"
 )
 
+// countErrorLogs throttles follower count failures, requested for every post while
+// DynamoDB is throttling or down
+var countErrorLogs = logthrottle.New(5, time.Minute)
+
 // SocialGraphServer implements the gRPC service
 type SocialGraphServer struct {
 	pb.UnimplementedSocialGraphServiceServer
-	db *DynamoDBClient
+	db              *DynamoDBClient
+	maxPageSize     int32 // Largest follower page a caller may request, shared with HTTP
+	allowSelfFollow bool  // FEATURE_SELF_FOLLOW
 }
 
 // NewSocialGraphServer creates a new gRPC server
-func NewSocialGraphServer(db *DynamoDBClient) *SocialGraphServer {
-	return &SocialGraphServer{db: db}
+func NewSocialGraphServer(db *DynamoDBClient, maxPageSize int, allowSelfFollow bool) *SocialGraphServer {
+	return &SocialGraphServer{db: db, maxPageSize: int32(maxPageSize), allowSelfFollow: allowSelfFollow}
 }
 
 // FollowUser creates a follow relationship
@@ -25,11 +33,11 @@ func (s *SocialGraphServer) FollowUser(ctx context.Context, req *pb.FollowUserRe
 	targetID := req.TargetUserId
 
 	// Validation
-	if followerID == targetID {
+	if code, msg := validateFollow(followerID, targetID, s.allowSelfFollow); code != "" {
 		return &pb.FollowUserResponse{
 			Success:      false,
-			ErrorMessage: "Cannot follow yourself",
-			ErrorCode:    "SELF_FOLLOW_NOT_ALLOWED",
+			ErrorMessage: msg,
+			ErrorCode:    code,
 		}, nil
 	}
 
@@ -53,7 +61,7 @@ func (s *SocialGraphServer) FollowUser(ctx context.Context, req *pb.FollowUserRe
 	}
 
 	// Insert relationship
-	err = s.db.InsertFollowRelationship(ctx, followerID, targetID)
+	err = s.db.InsertFollowRelationship(ctx, followerID, targetID, time.Now())
 	if err != nil {
 		log.Printf("Error inserting follow relationship: %v", err)
 		return &pb.FollowUserResponse{
@@ -68,11 +76,42 @@ func (s *SocialGraphServer) FollowUser(ctx context.Context, req *pb.FollowUserRe
 	}, nil
 }
 
+// validateFollow checks the numeric IDs of a follow request, shared by gRPC and HTTP
+// so both reject the same self-follows. It returns an error code and message, or
+// empty strings when the request is valid.
+func validateFollow(followerID, targetID int64, allowSelfFollow bool) (string, string) {
+	if code, msg := validateUserIDs(followerID, targetID); code != "" {
+		return code, msg
+	}
+	if followerID == targetID && !allowSelfFollow {
+		return "SELF_FOLLOW_NOT_ALLOWED", "Cannot follow yourself"
+	}
+	return "", ""
+}
+
+// validateUserIDs checks only that both IDs are positive. Unfollows use it on both
+// transports: a self-follow made while FEATURE_SELF_FOLLOW was on must stay removable.
+func validateUserIDs(followerID, targetID int64) (string, string) {
+	if followerID <= 0 || targetID <= 0 {
+		return "INVALID_REQUEST", "User IDs must be positive"
+	}
+	return "", ""
+}
+
 // UnfollowUser removes a follow relationship
 func (s *SocialGraphServer) UnfollowUser(ctx context.Context, req *pb.UnfollowUserRequest) (*pb.UnfollowUserResponse, error) {
 	followerID := req.FollowerUserId
 	targetID := req.TargetUserId
 
+	// Validation
+	if code, msg := validateUserIDs(followerID, targetID); code != "" {
+		return &pb.UnfollowUserResponse{
+			Success:      false,
+			ErrorMessage: msg,
+			ErrorCode:    code,
+		}, nil
+	}
+
 	// Check if relationship exists
 	exists, err := s.db.CheckFollowRelationship(ctx, followerID, targetID)
 	if err != nil {
@@ -111,16 +150,17 @@ func (s *SocialGraphServer) UnfollowUser(ctx context.Context, req *pb.UnfollowUs
 // GetFollowers retrieves followers of a user (used for fan-out operations)
 func (s *SocialGraphServer) GetFollowers(ctx context.Context, req *pb.GetFollowersRequest) (*pb.GetFollowersResponse, error) {
 	userID := req.UserId
+	// Unset limits get a full page; larger ones are clamped like the HTTP API
 	limit := req.Limit
-	if limit == 0 {
-		limit = 1000 // Default limit as per spec
+	if limit <= 0 || limit > s.maxPageSize {
+		limit = s.maxPageSize
 	}
-	offset := req.Offset
+	offset := max(req.Offset, 0)
 
 	// Get total count first
 	totalCount, err := s.db.GetFollowersCount(ctx, userID)
 	if err != nil {
-		log.Printf("Error getting followers count: %v", err)
+		countErrorLogs.Printf("Error getting followers count: %v", err)
 		return &pb.GetFollowersResponse{
 			ErrorMessage: "Failed to get followers count",
 		}, nil
@@ -153,6 +193,7 @@ func (s *SocialGraphServer) GetFollowers(ctx context.Context, req *pb.GetFollowe
 	return &pb.GetFollowersResponse{
 		UserIds:     paginatedFollowers,
 		TotalCount:  totalCount,
+		PageSize:    int32(len(paginatedFollowers)),
 		HasMore:     hasMore,
 	}, nil
 }
@@ -160,9 +201,15 @@ func (s *SocialGraphServer) GetFollowers(ctx context.Context, req *pb.GetFollowe
 // GetFollowingList retrieves all users that a user follows (for Timeline Service)
 func (s *SocialGraphServer) GetFollowingList(ctx context.Context, req *pb.GetFollowingListRequest) (*pb.GetFollowingListResponse, error) {
 	userID := req.UserId
+	if userID <= 0 {
+		return &pb.GetFollowingListResponse{
+			ErrorCode:    "INVALID_REQUEST",
+			ErrorMessage: "user_id must be positive",
+		}, nil
+	}
 
-	// Get all following users (use large limit to get all)
-	following, _, err := s.db.GetFollowing(ctx, userID, 10000, nil)
+	// The complete list, not a page: pull reads need every followed user
+	following, err := s.db.GetFollowingIDs(ctx, userID)
 	if err != nil {
 		log.Printf("Error getting following list: %v", err)
 		return &pb.GetFollowingListResponse{
@@ -176,13 +223,42 @@ func (s *SocialGraphServer) GetFollowingList(ctx context.Context, req *pb.GetFol
 	}, nil
 }
 
+// GetFollowingIDs returns only the IDs a user follows, without counts (for Timeline Service)
+// With a limit it returns that many IDs from offset on, so callers can stop paging early;
+// limits are not clamped to the page size since omitting one returns the whole list anyway
+func (s *SocialGraphServer) GetFollowingIDs(ctx context.Context, req *pb.GetFollowingIDsRequest) (*pb.GetFollowingIDsResponse, error) {
+	userID := req.UserId
+
+	following, err := s.db.GetFollowingIDs(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting following IDs: %v", err)
+		return &pb.GetFollowingIDsResponse{
+			ErrorCode:    "INTERNAL_ERROR",
+			ErrorMessage: "Failed to get following IDs",
+		}, nil
+	}
+
+	hasMore := false
+	if req.Limit > 0 {
+		start := min(int(max(req.Offset, 0)), len(following))
+		end := min(start+int(req.Limit), len(following))
+		hasMore = end < len(following)
+		following = following[start:end]
+	}
+
+	return &pb.GetFollowingIDsResponse{
+		UserIds: following,
+		HasMore: hasMore,
+	}, nil
+}
+
 // GetFollowersCount returns follower count
 func (s *SocialGraphServer) GetFollowersCount(ctx context.Context, req *pb.GetFollowersCountRequest) (*pb.GetFollowersCountResponse, error) {
 	userID := req.UserId
 
 	count, err := s.db.GetFollowersCount(ctx, userID)
 	if err != nil {
-		log.Printf("Error getting followers count: %v", err)
+		countErrorLogs.Printf("Error getting followers count: %v", err)
 		return &pb.GetFollowersCountResponse{
 			UserId:       userID,
 			ErrorMessage: "Failed to get followers count",
@@ -214,21 +290,76 @@ func (s *SocialGraphServer) GetFollowingCount(ctx context.Context, req *pb.GetFo
 	}, nil
 }
 
-// CheckFollowRelationship checks if a follow relationship exists
+// CheckFollowRelationship checks a follow relationship in both directions
 func (s *SocialGraphServer) CheckFollowRelationship(ctx context.Context, req *pb.CheckFollowRelationshipRequest) (*pb.CheckFollowRelationshipResponse, error) {
 	followerID := req.FollowerUserId
 	targetID := req.TargetUserId
 
-	exists, err := s.db.CheckFollowRelationship(ctx, followerID, targetID)
+	if followerID <= 0 || targetID <= 0 {
+		return &pb.CheckFollowRelationshipResponse{
+			ErrorMessage: "User IDs must be positive",
+			ErrorCode:    "INVALID_REQUEST",
+		}, nil
+	}
+
+	rel, err := s.db.GetFollowRelationship(ctx, followerID, targetID)
 	if err != nil {
 		log.Printf("Error checking follow relationship: %v", err)
 		return &pb.CheckFollowRelationshipResponse{
 			ErrorMessage: "Failed to check follow relationship",
+			ErrorCode:    "INTERNAL_ERROR",
 		}, nil
 	}
 
-	return &pb.CheckFollowRelationshipResponse{
-		IsFollowing: exists,
+	resp := &pb.CheckFollowRelationshipResponse{
+		IsFollowing:  rel.IsFollowing,
+		IsFollowedBy: rel.IsFollowedBy,
+		IsMutual:     rel.IsMutual(),
+	}
+	if !rel.FollowedAt.IsZero() {
+		resp.FollowedAt = rel.FollowedAt.Unix()
+	}
+	return resp, nil
+}
+
+// maxBatchCheckTargets bounds how many users one batch relationship check may ask about
+const maxBatchCheckTargets = 500
+
+// validateBatchCheck returns an error code and message when a batch relationship check
+// is not allowed, or empty strings when it is; shared by the gRPC and HTTP handlers
+func validateBatchCheck(followerID int64, targetIDs []int64) (code, msg string) {
+	if followerID <= 0 {
+		return "INVALID_REQUEST", "follower_user_id must be positive"
+	}
+	if len(targetIDs) == 0 {
+		return "INVALID_REQUEST", "target_user_ids is required"
+	}
+	if len(targetIDs) > maxBatchCheckTargets {
+		return "INVALID_REQUEST", fmt.Sprintf("target_user_ids may contain at most %d IDs", maxBatchCheckTargets)
+	}
+	return "", ""
+}
+
+// BatchCheckFollowRelationship checks which of the target users the follower follows
+func (s *SocialGraphServer) BatchCheckFollowRelationship(ctx context.Context, req *pb.BatchCheckFollowRelationshipRequest) (*pb.BatchCheckFollowRelationshipResponse, error) {
+	if code, msg := validateBatchCheck(req.FollowerUserId, req.TargetUserIds); code != "" {
+		return &pb.BatchCheckFollowRelationshipResponse{
+			ErrorCode:    code,
+			ErrorMessage: msg,
+		}, nil
+	}
+
+	following, err := s.db.BatchCheckFollowRelationship(ctx, req.FollowerUserId, req.TargetUserIds)
+	if err != nil {
+		log.Printf("Error batch checking follow relationships: %v", err)
+		return &pb.BatchCheckFollowRelationshipResponse{
+			ErrorCode:    "INTERNAL_ERROR",
+			ErrorMessage: "Failed to check follow relationships",
+		}, nil
+	}
+
+	return &pb.BatchCheckFollowRelationshipResponse{
+		IsFollowing: following,
 	}, nil
 }
 
@@ -247,6 +378,9 @@ func (s *SocialGraphServer) BatchCreateFollowRelationships(ctx context.Context,
 	dbRelationships := make([][2]int64, 0, len(relationships))
 	for _, rel := range relationships {
 		// Validate
+		if rel.FollowerUserId <= 0 || rel.TargetUserId <= 0 {
+			continue // Skip non-positive IDs
+		}
 		if rel.FollowerUserId == rel.TargetUserId {
 			continue // Skip self-follows
 		}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	pb "github.com/cs6650/proto/social_graph"
+)
+
+func TestValidateFollow(t *testing.T) {
+	tests := []struct {
+		name            string
+		followerID      int64
+		targetID        int64
+		allowSelfFollow bool
+		wantCode        string
+	}{
+		{name: "valid", followerID: 1, targetID: 2},
+		{name: "zero target", followerID: 1, targetID: 0, wantCode: "INVALID_REQUEST"},
+		{name: "zero follower", followerID: 0, targetID: 2, wantCode: "INVALID_REQUEST"},
+		{name: "negative target", followerID: 1, targetID: -2, wantCode: "INVALID_REQUEST"},
+		{name: "self follow", followerID: 3, targetID: 3, wantCode: "SELF_FOLLOW_NOT_ALLOWED"},
+		{name: "self follow allowed", followerID: 3, targetID: 3, allowSelfFollow: true},
+	}
+	for _, tt := range tests {
+		if code, _ := validateFollow(tt.followerID, tt.targetID, tt.allowSelfFollow); code != tt.wantCode {
+			t.Errorf("%s: code = %q, want %q", tt.name, code, tt.wantCode)
+		}
+	}
+}
+
+// Invalid IDs are rejected before DynamoDB is touched, so no server or table is needed
+func TestFollowUserRejectsZeroTarget(t *testing.T) {
+	s := NewSocialGraphServer(NewDynamoDBClient(nil, "followers", "following"), 100, false)
+	resp, err := s.FollowUser(context.Background(), &pb.FollowUserRequest{FollowerUserId: 1, TargetUserId: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Success || resp.ErrorCode != "INVALID_REQUEST" {
+		t.Errorf("FollowUser(1 -> 0) = success %t, code %q, want INVALID_REQUEST", resp.Success, resp.ErrorCode)
+	}
+}
+
+func TestDynamoDBClientRejectsNonPositiveIDs(t *testing.T) {
+	db := NewDynamoDBClient(nil, "followers", "following")
+	ctx := context.Background()
+	for _, ids := range [][2]int64{{1, 0}, {0, 1}, {-1, 2}} {
+		if err := db.InsertFollowRelationship(ctx, ids[0], ids[1], time.Now()); !errors.Is(err, errInvalidUserID) {
+			t.Errorf("InsertFollowRelationship%v error = %v, want errInvalidUserID", ids, err)
+		}
+		if _, err := db.CheckFollowRelationship(ctx, ids[0], ids[1]); !errors.Is(err, errInvalidUserID) {
+			t.Errorf("CheckFollowRelationship%v error = %v, want errInvalidUserID", ids, err)
+		}
+		if err := db.DeleteFollowRelationship(ctx, ids[0], ids[1]); !errors.Is(err, errInvalidUserID) {
+			t.Errorf("DeleteFollowRelationship%v error = %v, want errInvalidUserID", ids, err)
+		}
+	}
+}
+
+func TestGetFollowersClampsLimit(t *testing.T) {
+	fake, db := newFakeDynamoDB(t)
+	followers := make([]string, 250)
+	for i := range followers {
+		followers[i] = strconv.Itoa(i + 2)
+	}
+	fake.put("followers", "1", map[string]any{"follower_ids": idList(followers...)})
+	s := NewSocialGraphServer(db, 100, false)
+
+	tests := []struct {
+		name         string
+		limit        int32
+		wantPageSize int32
+		wantHasMore  bool
+	}{
+		{name: "unset gets a full page", limit: 0, wantPageSize: 100, wantHasMore: true},
+		{name: "in range", limit: 30, wantPageSize: 30, wantHasMore: true},
+		{name: "huge is clamped", limit: 100000, wantPageSize: 100, wantHasMore: true},
+	}
+	for _, tt := range tests {
+		resp, err := s.GetFollowers(context.Background(), &pb.GetFollowersRequest{UserId: 1, Limit: tt.limit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.ErrorMessage != "" {
+			t.Fatalf("%s: %s", tt.name, resp.ErrorMessage)
+		}
+		if resp.PageSize != tt.wantPageSize || int32(len(resp.UserIds)) != tt.wantPageSize {
+			t.Errorf("%s: page of %d (%d IDs), want %d", tt.name, resp.PageSize, len(resp.UserIds), tt.wantPageSize)
+		}
+		if resp.HasMore != tt.wantHasMore || resp.TotalCount != 250 {
+			t.Errorf("%s: has_more %t, total %d, want %t, 250", tt.name, resp.HasMore, resp.TotalCount, tt.wantHasMore)
+		}
+	}
+}
+
+func TestCheckFollowRelationshipRejectsNonPositiveIDs(t *testing.T) {
+	s := NewSocialGraphServer(NewDynamoDBClient(nil, "followers", "following"), 100, false)
+	for _, ids := range [][2]int64{{1, 0}, {0, 1}, {-1, 2}} {
+		resp, err := s.CheckFollowRelationship(context.Background(), &pb.CheckFollowRelationshipRequest{FollowerUserId: ids[0], TargetUserId: ids[1]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.ErrorCode != "INVALID_REQUEST" || resp.IsFollowing {
+			t.Errorf("CheckFollowRelationship%v = code %q, following %t, want INVALID_REQUEST", ids, resp.ErrorCode, resp.IsFollowing)
+		}
+	}
+}
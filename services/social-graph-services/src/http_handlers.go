@@ -2,10 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
+	"log"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/cs6650/proto/usercache"
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,13 +19,23 @@ import (
 type HTTPHandler struct {
 	db                *DynamoDBClient
 	userServiceClient UserServiceClient
+	postServiceClient PostServiceClient
+	maxPageSize       int              // Largest follower/following page a caller may request
+	allowSelfFollow   bool             // FEATURE_SELF_FOLLOW
+	userCache         *usercache.Cache // Shared with userServiceClient; reported by Health
+	checker           *ConsistencyChecker
 }
 
 // NewHTTPHandler creates a new HTTP handler
-func NewHTTPHandler(db *DynamoDBClient, userServiceClient UserServiceClient) *HTTPHandler {
+func NewHTTPHandler(db *DynamoDBClient, userServiceClient UserServiceClient, postServiceClient PostServiceClient, maxPageSize int, allowSelfFollow bool, userCache *usercache.Cache, checker *ConsistencyChecker) *HTTPHandler {
 	return &HTTPHandler{
 		db:                db,
 		userServiceClient: userServiceClient,
+		postServiceClient: postServiceClient,
+		maxPageSize:       maxPageSize,
+		allowSelfFollow:   allowSelfFollow,
+		userCache:         userCache,
+		checker:           checker,
 	}
 }
 
@@ -30,12 +46,34 @@ type FollowRequest struct {
 	Action         string `json:"action" binding:"required,oneof=follow unfollow"`
 }
 
+// BatchCheckRequest represents the request body for batch relationship checks
+type BatchCheckRequest struct {
+	FollowerUserID int64   `json:"follower_user_id" binding:"required"`
+	TargetUserIDs  []int64 `json:"target_user_ids" binding:"required"`
+}
+
+// UnfollowRequest represents the request body for the dedicated unfollow endpoint
+type UnfollowRequest struct {
+	FollowerUserID string `json:"follower_user_id" binding:"required"`
+	TargetUserID   string `json:"target_user_id" binding:"required"`
+}
+
+// parseUserID parses a user ID from a request string, ignoring surrounding whitespace
+// An optional leading '+' is accepted by strconv.ParseInt itself
+func parseUserID(s string) (int64, error) {
+	return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+}
+
 // Health returns service health status
 func (h *HTTPHandler) Health(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "healthy",
-		"service": "social-graph-service",
-		"version": "1.0.0",
+		"status":          "healthy",
+		"service":         "social-graph-service",
+		"version":         version,
+		"uptime":          time.Since(startTime).Round(time.Second).String(),
+		"timestamp":       time.Now().UTC().Format(time.RFC3339),
+		"decode_failures": decodeFailures.Load(),
+		"user_cache":      h.userCache.Stats(),
 	})
 }
 
@@ -96,7 +134,78 @@ func (h *HTTPHandler) GetFollowingCount(c *gin.Context) {
 	})
 }
 
-// CheckFollowRelationship checks if a follow relationship exists
+// UserStatsResponse holds a user's profile counts
+// A count that could not be fetched is 0 with its Available flag set to false
+type UserStatsResponse struct {
+	UserID         int64           `json:"user_id"`
+	FollowerCount  int64           `json:"follower_count"`
+	FollowingCount int64           `json:"following_count"`
+	PostCount      int64           `json:"post_count"`
+	Available      map[string]bool `json:"available"`
+	Warning        string          `json:"warning,omitempty"`
+}
+
+// GetUserStats returns follower, following and post counts in one call
+// The three counts are fetched concurrently; a failed count does not fail the request
+func (h *HTTPHandler) GetUserStats(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Invalid user_id format",
+			"error_code": "INVALID_REQUEST",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var followerCount, followingCount int32
+	var postCount int64
+	var followerErr, followingErr, postErr error
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		followerCount, followerErr = h.db.GetFollowerCount(ctx, strconv.FormatInt(userID, 10))
+	}()
+	go func() {
+		defer wg.Done()
+		followingCount, followingErr = h.db.GetFollowingCount(ctx, userID)
+	}()
+	go func() {
+		defer wg.Done()
+		postCount, postErr = h.postServiceClient.GetPostCount(ctx, userID)
+	}()
+	wg.Wait()
+
+	resp := UserStatsResponse{
+		UserID:         userID,
+		FollowerCount:  int64(followerCount),
+		FollowingCount: int64(followingCount),
+		PostCount:      postCount,
+		Available: map[string]bool{
+			"follower_count":  followerErr == nil,
+			"following_count": followingErr == nil,
+			"post_count":      postErr == nil,
+		},
+	}
+
+	var unavailable []string
+	for field, err := range map[string]error{"follower_count": followerErr, "following_count": followingErr, "post_count": postErr} {
+		if err != nil {
+			log.Printf("Failed to get %s for user %d: %v", field, userID, err)
+			unavailable = append(unavailable, field)
+		}
+	}
+	if len(unavailable) > 0 {
+		sort.Strings(unavailable)
+		resp.Warning = "Some counts are unavailable: " + strings.Join(unavailable, ", ")
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CheckFollowRelationship checks a follow relationship in both directions
 func (h *HTTPHandler) CheckFollowRelationship(c *gin.Context) {
 	followerID := c.Query("followerId")
 	targetID := c.Query("targetId")
@@ -125,7 +234,15 @@ func (h *HTTPHandler) CheckFollowRelationship(c *gin.Context) {
 		return
 	}
 
-	exists, err := h.db.CheckFollowRelationship(c.Request.Context(), fid, tid)
+	if fid <= 0 || tid <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "followerId and targetId must be positive",
+			"error_code": "INVALID_REQUEST",
+		})
+		return
+	}
+
+	rel, err := h.db.GetFollowRelationship(c.Request.Context(), fid, tid)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to check follow relationship",
@@ -133,16 +250,24 @@ func (h *HTTPHandler) CheckFollowRelationship(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"followerId":  followerID,
-		"targetId":    targetID,
-		"isFollowing": exists,
-	})
+	resp := gin.H{
+		"followerId":   followerID,
+		"targetId":     targetID,
+		"isFollowing":  rel.IsFollowing,
+		"isFollowedBy": rel.IsFollowedBy,
+		"isMutual":     rel.IsMutual(),
+	}
+	// Omitted when not following, or for follows created before timestamps were tracked
+	if !rel.FollowedAt.IsZero() {
+		resp["followedAt"] = rel.FollowedAt.Format(time.RFC3339)
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
-// FollowUser handles follow/unfollow actions
-func (h *HTTPHandler) FollowUser(c *gin.Context) {
-	var req FollowRequest
+// BatchCheckFollowRelationship reports which of target_user_ids the follower follows
+// in a single lookup, for rendering follow buttons on a list of users
+func (h *HTTPHandler) BatchCheckFollowRelationship(c *gin.Context) {
+	var req BatchCheckRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":      "Invalid request body",
@@ -151,17 +276,42 @@ func (h *HTTPHandler) FollowUser(c *gin.Context) {
 		return
 	}
 
-	// Validate: cannot follow yourself
-	if req.FollowerUserID == req.TargetUserID {
+	if code, msg := validateBatchCheck(req.FollowerUserID, req.TargetUserIDs); code != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      msg,
+			"error_code": code,
+		})
+		return
+	}
+
+	following, err := h.db.BatchCheckFollowRelationship(c.Request.Context(), req.FollowerUserID, req.TargetUserIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "Failed to check follow relationships",
+			"error_code": "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"follower_user_id": req.FollowerUserID,
+		"is_following":     following,
+	})
+}
+
+// FollowUser handles follow/unfollow actions
+func (h *HTTPHandler) FollowUser(c *gin.Context) {
+	var req FollowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "Cannot follow yourself",
-			"error_code": "SELF_FOLLOW_NOT_ALLOWED",
+			"error":      "Invalid request body",
+			"error_code": "INVALID_REQUEST",
 		})
 		return
 	}
 
 	// Convert string IDs to int64
-	followerID, err := strconv.ParseInt(req.FollowerUserID, 10, 64)
+	followerID, err := parseUserID(req.FollowerUserID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":      "Invalid follower_user_id",
@@ -170,7 +320,7 @@ func (h *HTTPHandler) FollowUser(c *gin.Context) {
 		return
 	}
 
-	targetID, err := strconv.ParseInt(req.TargetUserID, 10, 64)
+	targetID, err := parseUserID(req.TargetUserID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":      "Invalid target_user_id",
@@ -179,6 +329,20 @@ func (h *HTTPHandler) FollowUser(c *gin.Context) {
 		return
 	}
 
+	// Validate on the parsed values, as gRPC does, so " 5", "+5" and "005" all count as user 5.
+	// Unfollows only need valid IDs, as on POST /unfollow.
+	code, msg := validateFollow(followerID, targetID, h.allowSelfFollow)
+	if req.Action == "unfollow" {
+		code, msg = validateUserIDs(followerID, targetID)
+	}
+	if code != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      msg,
+			"error_code": code,
+		})
+		return
+	}
+
 	if req.Action == "follow" {
 		// Check if already following
 		exists, err := h.db.CheckFollowRelationship(c.Request.Context(), followerID, targetID)
@@ -198,8 +362,9 @@ func (h *HTTPHandler) FollowUser(c *gin.Context) {
 			return
 		}
 
-		// Add follow relationship
-		if err := h.db.InsertFollowRelationship(c.Request.Context(), followerID, targetID); err != nil {
+		// Add follow relationship; the reported created_at is the time stored with it
+		createdAt := time.Now().UTC()
+		if err := h.db.InsertFollowRelationship(c.Request.Context(), followerID, targetID, createdAt); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error":      "Failed to create follow relationship",
 				"error_code": "INTERNAL_ERROR",
@@ -211,40 +376,86 @@ func (h *HTTPHandler) FollowUser(c *gin.Context) {
 		c.JSON(http.StatusCreated, gin.H{
 			"follower_id":  followerID,
 			"following_id": targetID,
-			"created_at":   time.Now().UTC().Format(time.RFC3339),
+			"created_at":   createdAt.Format(time.RFC3339),
 		})
 	} else if req.Action == "unfollow" {
-		// Check if following exists
-		exists, err := h.db.CheckFollowRelationship(c.Request.Context(), followerID, targetID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":      "Failed to check follow relationship",
-				"error_code": "INTERNAL_ERROR",
-			})
-			return
-		}
+		h.unfollow(c, followerID, targetID)
+	}
+}
 
-		if !exists {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":      "Not following this user",
-				"error_code": "NOT_FOLLOWING",
-			})
-			return
-		}
+// UnfollowUser handles unfollow requests on their own route; the combined
+// /follow endpoint with "action":"unfollow" behaves the same
+func (h *HTTPHandler) UnfollowUser(c *gin.Context) {
+	var req UnfollowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Invalid request body",
+			"error_code": "INVALID_REQUEST",
+		})
+		return
+	}
 
-		// Remove follow relationship
-		if err := h.db.DeleteFollowRelationship(c.Request.Context(), followerID, targetID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":      "Failed to remove follow relationship",
-				"error_code": "INTERNAL_ERROR",
-			})
-			return
-		}
+	followerID, err := parseUserID(req.FollowerUserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Invalid follower_user_id",
+			"error_code": "INVALID_REQUEST",
+		})
+		return
+	}
 
-		c.JSON(http.StatusOK, gin.H{
-			"message": "Successfully unfollowed user",
+	targetID, err := parseUserID(req.TargetUserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Invalid target_user_id",
+			"error_code": "INVALID_REQUEST",
+		})
+		return
+	}
+
+	if code, msg := validateUserIDs(followerID, targetID); code != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      msg,
+			"error_code": code,
 		})
+		return
 	}
+
+	h.unfollow(c, followerID, targetID)
+}
+
+// unfollow removes an existing follow relationship, responding 404 NOT_FOLLOWING if there is none
+func (h *HTTPHandler) unfollow(c *gin.Context, followerID, targetID int64) {
+	// Check if following exists
+	exists, err := h.db.CheckFollowRelationship(c.Request.Context(), followerID, targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "Failed to check follow relationship",
+			"error_code": "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":      "Not following this user",
+			"error_code": "NOT_FOLLOWING",
+		})
+		return
+	}
+
+	// Remove follow relationship
+	if err := h.db.DeleteFollowRelationship(c.Request.Context(), followerID, targetID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "Failed to remove follow relationship",
+			"error_code": "INTERNAL_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Successfully unfollowed user",
+	})
 }
 
 // GetFollowers returns the list of followers for a user
@@ -261,7 +472,7 @@ func (h *HTTPHandler) GetFollowers(c *gin.Context) {
 	// Get query parameters
 	limitStr := c.DefaultQuery("limit", "50")
 	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 || limit > 100 {
+	if err != nil || limit <= 0 || limit > h.maxPageSize {
 		limit = 50
 	}
 
@@ -269,6 +480,13 @@ func (h *HTTPHandler) GetFollowers(c *gin.Context) {
 
 	// Get followers list with pagination
 	followers, nextCursor, hasMore, err := h.db.GetFollowersList(c.Request.Context(), userID, int32(limit), cursor)
+	if errors.Is(err, errInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Invalid cursor",
+			"error_code": "INVALID_CURSOR",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":      "Failed to get followers",
@@ -295,6 +513,7 @@ func (h *HTTPHandler) GetFollowers(c *gin.Context) {
 	response := gin.H{
 		"user_id":     userID,
 		"followers":   followers,
+		"page_size":   len(followers),
 		"total_count": totalCount,
 		"next_cursor": nextCursor,
 		"has_more":    hasMore,
@@ -322,38 +541,29 @@ func (h *HTTPHandler) GetFollowing(c *gin.Context) {
 	// Get query parameters
 	limitStr := c.DefaultQuery("limit", "50")
 	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 || limit > 100 {
+	if err != nil || limit <= 0 || limit > h.maxPageSize {
 		limit = 50
 	}
 
 	cursor := c.Query("cursor")
 
 	// Get following list with pagination
-	following, nextCursor, hasMore, err := h.db.GetFollowingList(c.Request.Context(), userID, int32(limit), cursor)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "Failed to get following",
-			"error_code": "INTERNAL_ERROR",
+	following, nextCursor, hasMore, totalCount, err := h.db.GetFollowingList(c.Request.Context(), userID, int32(limit), cursor)
+	if errors.Is(err, errInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Invalid cursor",
+			"error_code": "INVALID_CURSOR",
 		})
 		return
 	}
-
-	// Convert string userID to int64 for count query
-	uid, err := strconv.ParseInt(userID, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "Invalid userId format",
-			"error_code": "INVALID_REQUEST",
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":      "Failed to get following",
+			"error_code": "INTERNAL_ERROR",
 		})
 		return
 	}
 
-	// Get total count
-	totalCount, err := h.db.GetFollowingCount(c.Request.Context(), uid)
-	if err != nil {
-		totalCount = 0 // Fallback to 0 if count fails
-	}
-
 	// Populate usernames from User Service
 	userServiceAvailable := true
 	if err := h.populateFollowingUsernames(c.Request.Context(), following); err != nil {
@@ -365,6 +575,7 @@ func (h *HTTPHandler) GetFollowing(c *gin.Context) {
 	response := gin.H{
 		"user_id":     userID,
 		"following":   following,
+		"page_size":   len(following),
 		"total_count": totalCount,
 		"next_cursor": nextCursor,
 		"has_more":    hasMore,
@@ -378,6 +589,28 @@ func (h *HTTPHandler) GetFollowing(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// deletedUsername is shown for users the User Service reports as not found,
+// so clients can tell a deleted account from a lookup that failed
+const deletedUsername = "[deleted]"
+
+// lookupUsernames fetches usernames from User Service, mapping users it reports as
+// not found to deletedUsername. IDs it neither returns nor reports are left out.
+func (h *HTTPHandler) lookupUsernames(ctx context.Context, userIDs []int64) (map[int64]string, error) {
+	users, notFound, err := h.userServiceClient.BatchGetUserInfo(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := make(map[int64]string, len(users)+len(notFound))
+	for userID, userInfo := range users {
+		usernames[userID] = userInfo.Username
+	}
+	for _, userID := range notFound {
+		usernames[userID] = deletedUsername
+	}
+	return usernames, nil
+}
+
 // populateFollowerUsernames fetches usernames from User Service and populates the FollowerInfo slice
 func (h *HTTPHandler) populateFollowerUsernames(ctx context.Context, followers []FollowerInfo) error {
 	if len(followers) == 0 {
@@ -390,17 +623,14 @@ func (h *HTTPHandler) populateFollowerUsernames(ctx context.Context, followers [
 		userIDs[i] = follower.UserID
 	}
 
-	// Batch get user info from User Service
-	users, _, err := h.userServiceClient.BatchGetUserInfo(ctx, userIDs)
+	usernames, err := h.lookupUsernames(ctx, userIDs)
 	if err != nil {
 		return err
 	}
 
 	// Populate usernames
 	for i := range followers {
-		if userInfo, ok := users[followers[i].UserID]; ok {
-			followers[i].Username = userInfo.Username
-		}
+		followers[i].Username = usernames[followers[i].UserID]
 	}
 
 	return nil
@@ -418,17 +648,14 @@ func (h *HTTPHandler) populateFollowingUsernames(ctx context.Context, following
 		userIDs[i] = f.UserID
 	}
 
-	// Batch get user info from User Service
-	users, _, err := h.userServiceClient.BatchGetUserInfo(ctx, userIDs)
+	usernames, err := h.lookupUsernames(ctx, userIDs)
 	if err != nil {
 		return err
 	}
 
 	// Populate usernames
 	for i := range following {
-		if userInfo, ok := users[following[i].UserID]; ok {
-			following[i].Username = userInfo.Username
-		}
+		following[i].Username = usernames[following[i].UserID]
 	}
 
 	return nil
@@ -461,6 +688,20 @@ func (h *HTTPHandler) LoadTestData(c *gin.Context) {
 	})
 }
 
+// CheckConsistency runs one consistency checker pass over the follower and following tables
+// and returns what it found. Like the background checker it only repairs when
+// CONSISTENCY_CHECK_REPAIR is set. It is served only on the admin listener.
+func (h *HTTPHandler) CheckConsistency(c *gin.Context) {
+	report, err := h.checker.Run(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Consistency check failed: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
 // TestUserServiceConnection tests the connection to user-service gRPC
 // This is a diagnostic endpoint for testing Service Connect connectivity
 func (h *HTTPHandler) TestUserServiceConnection(c *gin.Context) {
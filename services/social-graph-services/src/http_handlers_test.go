@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	pb "github.com/cs6650/proto/social_graph"
+	"github.com/gin-gonic/gin"
+)
+
+// serveFollow posts body to HTTPHandler.FollowUser and returns the status and error_code
+func serveFollow(t *testing.T, h *HTTPHandler, body string) (int, string) {
+	t.Helper()
+	return servePost(t, h.FollowUser, body)
+}
+
+// servePost posts a JSON body to handler, returning the status and error_code
+func servePost(t *testing.T, handler gin.HandlerFunc, body string) (int, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/", handler)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	var resp struct {
+		ErrorCode string `json:"error_code"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp.ErrorCode
+}
+
+func newTestHTTPHandler() *HTTPHandler {
+	return NewHTTPHandler(NewDynamoDBClient(nil, "followers", "following"), nil, nil, 100, false, nil, nil)
+}
+
+func TestHTTPFollowRejectsZeroTarget(t *testing.T) {
+	status, code := serveFollow(t, newTestHTTPHandler(), `{"follower_user_id":"1","target_user_id":"0","action":"follow"}`)
+	if status != http.StatusBadRequest || code != "INVALID_REQUEST" {
+		t.Errorf("follow 1 -> 0 = %d %q, want 400 INVALID_REQUEST", status, code)
+	}
+}
+
+func TestParseUserID(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "5", want: 5},
+		{in: " 5", want: 5},
+		{in: "5\n", want: 5},
+		{in: "+5", want: 5},
+		{in: "005", want: 5},
+		{in: "", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "5 5", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseUserID(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseUserID(%q) = %d, %v, want %d, error %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// Differently written IDs for the same user are still a self-follow
+func TestHTTPFollowRejectsPaddedSelfFollow(t *testing.T) {
+	for _, ids := range [][2]string{{" 5", "5"}, {"5", "+5"}, {`5\t`, " 5 "}} {
+		body := `{"follower_user_id":"` + ids[0] + `","target_user_id":"` + ids[1] + `","action":"follow"}`
+		status, code := serveFollow(t, newTestHTTPHandler(), body)
+		if status != http.StatusBadRequest || code != "SELF_FOLLOW_NOT_ALLOWED" {
+			t.Errorf("follow %q -> %q = %d %q, want 400 SELF_FOLLOW_NOT_ALLOWED", ids[0], ids[1], status, code)
+		}
+	}
+}
+
+// HTTP and gRPC share validateFollow, so both reject the same follows with the same code
+func TestFollowValidationMatchesAcrossTransports(t *testing.T) {
+	tests := []struct {
+		follower, target string
+		wantCode         string
+	}{
+		{follower: "007", target: "7", wantCode: "SELF_FOLLOW_NOT_ALLOWED"},
+		{follower: "7", target: "0", wantCode: "INVALID_REQUEST"},
+		{follower: "-3", target: "7", wantCode: "INVALID_REQUEST"},
+	}
+	s := NewSocialGraphServer(NewDynamoDBClient(nil, "followers", "following"), 100, false)
+	for _, tt := range tests {
+		body := `{"follower_user_id":"` + tt.follower + `","target_user_id":"` + tt.target + `","action":"follow"}`
+		if status, code := serveFollow(t, newTestHTTPHandler(), body); status != http.StatusBadRequest || code != tt.wantCode {
+			t.Errorf("HTTP follow %s -> %s = %d %q, want 400 %s", tt.follower, tt.target, status, code, tt.wantCode)
+		}
+
+		followerID, _ := parseUserID(tt.follower)
+		targetID, _ := parseUserID(tt.target)
+		resp, err := s.FollowUser(context.Background(), &pb.FollowUserRequest{FollowerUserId: followerID, TargetUserId: targetID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.ErrorCode != tt.wantCode {
+			t.Errorf("gRPC follow %d -> %d code = %q, want %s", followerID, targetID, resp.ErrorCode, tt.wantCode)
+		}
+	}
+}
+
+func TestHTTPListRejectsMalformedCursor(t *testing.T) {
+	_, db := newFakeDynamoDB(t)
+	h := NewHTTPHandler(db, nil, nil, 100, false, nil, nil)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/:user_id/followers", h.GetFollowers)
+	router.GET("/:user_id/following", h.GetFollowing)
+
+	valid := encodeListCursor(map[string]types.AttributeValue{"offset": &types.AttributeValueMemberN{Value: "50"}})
+	tests := []struct {
+		name       string
+		cursor     string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "no cursor", wantStatus: http.StatusOK},
+		{name: "issued cursor", cursor: valid, wantStatus: http.StatusOK},
+		{name: "garbage", cursor: "garbage!", wantStatus: http.StatusBadRequest, wantCode: "INVALID_CURSOR"},
+		{name: "truncated", cursor: valid[:len(valid)-4], wantStatus: http.StatusBadRequest, wantCode: "INVALID_CURSOR"},
+	}
+	for _, list := range []string{"followers", "following"} {
+		for _, tt := range tests {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/1/"+list+"?cursor="+url.QueryEscape(tt.cursor), nil))
+
+			var resp struct {
+				ErrorCode string `json:"error_code"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if w.Code != tt.wantStatus || resp.ErrorCode != tt.wantCode {
+				t.Errorf("%s %s: %d %q, want %d %q", list, tt.name, w.Code, resp.ErrorCode, tt.wantStatus, tt.wantCode)
+			}
+		}
+	}
+}
+
+// A self-follow made while FEATURE_SELF_FOLLOW was on stays removable once it is off,
+// over both transports, while invalid IDs are still rejected
+func TestUnfollowAllowsSelfFollowRemoval(t *testing.T) {
+	tests := []struct {
+		name       string
+		follower   string
+		target     string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "self unfollow", follower: "5", target: "5", wantStatus: http.StatusOK},
+		{name: "zero target", follower: "5", target: "0", wantStatus: http.StatusBadRequest, wantCode: "INVALID_REQUEST"},
+		{name: "negative follower", follower: "-5", target: "5", wantStatus: http.StatusBadRequest, wantCode: "INVALID_REQUEST"},
+	}
+	selfFollow := func(fake *fakeDynamoDB) {
+		fake.put("followers", "5", map[string]any{"follower_ids": idList("5")})
+		fake.put("following", "5", map[string]any{"following_ids": idList("5")})
+	}
+
+	for _, tt := range tests {
+		body := `{"follower_user_id":"` + tt.follower + `","target_user_id":"` + tt.target + `"}`
+		actionBody := `{"follower_user_id":"` + tt.follower + `","target_user_id":"` + tt.target + `","action":"unfollow"}`
+		for route, serve := range map[string]func(h *HTTPHandler) (int, string){
+			"POST /unfollow":          func(h *HTTPHandler) (int, string) { return servePost(t, h.UnfollowUser, body) },
+			"POST /follow (unfollow)": func(h *HTTPHandler) (int, string) { return servePost(t, h.FollowUser, actionBody) },
+		} {
+			fake, db := newFakeDynamoDB(t)
+			selfFollow(fake)
+			status, code := serve(NewHTTPHandler(db, nil, nil, 100, false, nil, nil))
+			if status != tt.wantStatus || code != tt.wantCode {
+				t.Errorf("%s %s: %d %q, want %d %q", route, tt.name, status, code, tt.wantStatus, tt.wantCode)
+			}
+			if removed := len(fake.list("following", "5", "following_ids")) == 0; removed != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("%s %s: self-follow removed %t", route, tt.name, removed)
+			}
+		}
+
+		fake, db := newFakeDynamoDB(t)
+		selfFollow(fake)
+		followerID, _ := parseUserID(tt.follower)
+		targetID, _ := parseUserID(tt.target)
+		resp, err := NewSocialGraphServer(db, 100, false).UnfollowUser(context.Background(), &pb.UnfollowUserRequest{FollowerUserId: followerID, TargetUserId: targetID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Success != (tt.wantStatus == http.StatusOK) || resp.ErrorCode != tt.wantCode {
+			t.Errorf("gRPC %s: success %t, code %q, want %q", tt.name, resp.Success, resp.ErrorCode, tt.wantCode)
+		}
+	}
+}
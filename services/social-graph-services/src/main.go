@@ -6,17 +6,38 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	appConfig "github.com/PCBZ/CS6650-Project/services/social-graph-services/src/config"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/cs6650/proto/grpcdial"
+	"github.com/cs6650/proto/grpcreflection"
 	pb "github.com/cs6650/proto/social_graph"
+	"github.com/cs6650/proto/usercache"
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/keepalive"
 )
 
+// version is injected at build time via -ldflags "-X main.version=<version>"
+var version = "dev"
+
+// startTime is used to report process uptime in health checks
+var startTime = time.Now()
+
+// debugLogging enables per-request diagnostic logs when LOG_LEVEL=debug
+var debugLogging bool
+
+// debugf logs only when debug logging is enabled
+func debugf(format string, args ...interface{}) {
+	if debugLogging {
+		log.Printf(format, args...)
+	}
+}
+
 // corsMiddleware handles CORS for requests from API Gateway
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -38,6 +59,10 @@ func main() {
 	// Load configuration
 	cfg := appConfig.Load()
 	log.Printf("Loaded config: %+v", cfg)
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	debugLogging = strings.EqualFold(cfg.LogLevel, "debug")
 	log.Printf("Social Graph Service starting - Environment: %s, HTTP Port: %d, gRPC Port: %d",
 		cfg.Env, cfg.HTTPPort, cfg.GRPCPort)
 
@@ -57,7 +82,9 @@ func main() {
 	log.Printf("DynamoDB Tables: %s, %s", cfg.FollowersTableName, cfg.FollowingTableName)
 
 	// Initialize User Service client
-	userServiceClient, err := NewUserServiceClient(cfg.UserServiceEndpoint)
+	keepaliveParams := cfg.KeepaliveParams()
+	userCache := usercache.New(cfg.UserCacheSize, cfg.UserCacheTTL)
+	userServiceClient, err := NewUserServiceClient(cfg.UserServiceEndpoint, cfg.UserInfoBatchSize, cfg.UserInfoConcurrency, keepaliveParams, userCache)
 	if err != nil {
 		log.Printf("WARNING: Failed to create User Service client: %v", err)
 		log.Printf("Using mock User Service client for development")
@@ -65,9 +92,23 @@ func main() {
 	}
 	defer userServiceClient.Close()
 
+	// Initialize Post Service client; post counts are reported unavailable if it fails
+	postServiceClient, err := NewPostServiceClient(cfg.PostServiceEndpoint, keepaliveParams)
+	if err != nil {
+		log.Fatalf("Failed to create Post Service client: %v", err)
+	}
+	defer postServiceClient.Close()
+
 	// Initialize handlers
-	grpcHandler := NewSocialGraphServer(dbClient)
-	httpHandler := NewHTTPHandler(dbClient, userServiceClient)
+	allowSelfFollow := cfg.Features.Enabled(appConfig.FeatureSelfFollow)
+	grpcHandler := NewSocialGraphServer(dbClient, cfg.MaxPageSize, allowSelfFollow)
+	consistencyChecker := NewConsistencyChecker(dbClient, cfg.ConsistencyMaxUsers, cfg.ConsistencyRepair)
+	httpHandler := NewHTTPHandler(dbClient, userServiceClient, postServiceClient, cfg.MaxPageSize, allowSelfFollow, userCache, consistencyChecker)
+
+	if cfg.ConsistencyInterval > 0 {
+		log.Printf("Consistency checker: every %s, %d users per pass, repair=%t", cfg.ConsistencyInterval, cfg.ConsistencyMaxUsers, cfg.ConsistencyRepair)
+		go consistencyChecker.RunEvery(context.Background(), cfg.ConsistencyInterval)
+	}
 
 	// Setup HTTP router
 	router := gin.Default()
@@ -78,6 +119,7 @@ func main() {
 	{
 		// Follow/unfollow operations
 		apiSocialGraph.POST("/follow", httpHandler.FollowUser)
+		apiSocialGraph.POST("/unfollow", httpHandler.UnfollowUser)
 		
 		// User followers and following lists
 		apiSocialGraph.GET("/:user_id/followers", httpHandler.GetFollowers)
@@ -87,7 +129,9 @@ func main() {
 		apiSocialGraph.GET("/health", httpHandler.Health)
 		apiSocialGraph.GET("/followers/:userId/count", httpHandler.GetFollowerCount)
 		apiSocialGraph.GET("/following/:userId/count", httpHandler.GetFollowingCount)
+		apiSocialGraph.GET("/:user_id/stats", httpHandler.GetUserStats)
 		apiSocialGraph.GET("/relationship/check", httpHandler.CheckFollowRelationship)
+		apiSocialGraph.POST("/relationship/batch-check", httpHandler.BatchCheckFollowRelationship)
 		
 		// Test/diagnostic endpoints
 		apiSocialGraph.GET("/test/user-service", httpHandler.TestUserServiceConnection)
@@ -101,6 +145,7 @@ func main() {
 	{
 		// Follow/unfollow operations
 		api.POST("/follow", httpHandler.FollowUser)
+		api.POST("/unfollow", httpHandler.UnfollowUser)
 		
 		// User followers and following lists
 		api.GET("/:user_id/followers", httpHandler.GetFollowers)
@@ -110,7 +155,9 @@ func main() {
 		api.GET("/health", httpHandler.Health)
 		api.GET("/followers/:userId/count", httpHandler.GetFollowerCount)
 		api.GET("/following/:userId/count", httpHandler.GetFollowingCount)
+		api.GET("/:user_id/stats", httpHandler.GetUserStats)
 		api.GET("/relationship/check", httpHandler.CheckFollowRelationship)
+		api.POST("/relationship/batch-check", httpHandler.BatchCheckFollowRelationship)
 		
 		// Admin endpoints
 		api.POST("/admin/load-test-data", httpHandler.LoadTestData)
@@ -118,16 +165,23 @@ func main() {
 
 	// Direct routes (without /api prefix)
 	router.POST("/follow", httpHandler.FollowUser)
+	router.POST("/unfollow", httpHandler.UnfollowUser)
 	router.GET("/:user_id/followers", httpHandler.GetFollowers)
 	router.GET("/:user_id/following", httpHandler.GetFollowing)
 	router.GET("/health", httpHandler.Health)
 	router.GET("/followers/:userId/count", httpHandler.GetFollowerCount)
 	router.GET("/following/:userId/count", httpHandler.GetFollowingCount)
+	router.GET("/:user_id/stats", httpHandler.GetUserStats)
 	router.GET("/relationship/check", httpHandler.CheckFollowRelationship)
+	router.POST("/relationship/batch-check", httpHandler.BatchCheckFollowRelationship)
 	router.POST("/admin/load-test-data", httpHandler.LoadTestData)
 
+	// The consistency check gets its own router, served only on the internal admin listener
+	adminRouter := gin.Default()
+	adminRouter.POST("/api/admin/consistency-check", httpHandler.CheckConsistency)
+
 	var wg sync.WaitGroup
-	wg.Add(2)
+	wg.Add(3)
 
 	// Start gRPC server in goroutine
 	go func() {
@@ -137,11 +191,19 @@ func main() {
 			log.Fatalf("Failed to listen on gRPC port %d: %v", cfg.GRPCPort, err)
 		}
 
-		grpcServer := grpc.NewServer()
+		grpcServer := grpc.NewServer(
+			// Accept keepalive pings from clients, including on idle connections
+			grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+				MinTime:             grpcdial.MinKeepaliveTime,
+				PermitWithoutStream: true,
+			}),
+		)
 		pb.RegisterSocialGraphServiceServer(grpcServer, grpcHandler)
 		
-		// Enable reflection for debugging with grpcurl
-		reflection.Register(grpcServer)
+		// Reflection for grpcurl, on in dev; GRPC_REFLECTION overrides
+		if grpcreflection.Register(grpcServer) {
+			log.Println("gRPC reflection enabled")
+		}
 
 		log.Printf("Social Graph Service gRPC server listening on port %d", cfg.GRPCPort)
 		if err := grpcServer.Serve(lis); err != nil {
@@ -158,6 +220,15 @@ func main() {
 		}
 	}()
 
-	// Wait for both servers
+	// Start admin HTTP server in goroutine
+	go func() {
+		defer wg.Done()
+		log.Printf("Social Graph Service admin server listening on %s", cfg.AdminAddr)
+		if err := adminRouter.Run(cfg.AdminAddr); err != nil {
+			log.Fatalf("Failed to start admin server: %v", err)
+		}
+	}()
+
+	// Wait for all servers
 	wg.Wait()
 }
\ No newline at end of file
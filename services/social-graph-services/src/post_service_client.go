@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cs6650/proto/grpcdial"
+	pb "github.com/cs6650/proto/post"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// PostServiceClient interface for Post Service gRPC operations
+type PostServiceClient interface {
+	GetPostCount(ctx context.Context, userID int64) (int64, error)
+	Close() error
+}
+
+// postServiceClient implements PostServiceClient with actual gRPC calls
+type postServiceClient struct {
+	client pb.PostServiceClient
+	conn   *grpc.ClientConn
+}
+
+// GetPostCount calls the Post Service via gRPC to count a user's posts
+func (c *postServiceClient) GetPostCount(ctx context.Context, userID int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	resp, err := c.client.GetPostCount(ctx, &pb.GetPostCountRequest{UserId: userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to call GetPostCount: %w", err)
+	}
+	if resp.ErrorMessage != "" {
+		return 0, fmt.Errorf("post service error: %s", resp.ErrorMessage)
+	}
+	return resp.Count, nil
+}
+
+// Close closes the gRPC connection
+func (c *postServiceClient) Close() error {
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// NewPostServiceClient creates a new Post Service client with real gRPC connection
+func NewPostServiceClient(endpoint string, keepaliveParams keepalive.ClientParameters) (PostServiceClient, error) {
+	log.Printf("Connecting to Post Service at %s...", endpoint)
+
+	conn, err := grpc.NewClient(endpoint, grpcdial.Options(keepaliveParams)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Post Service client for %s: %w", endpoint, err)
+	}
+
+	log.Printf("Post Service client created for %s", endpoint)
+
+	return &postServiceClient{
+		client: pb.NewPostServiceClient(conn),
+		conn:   conn,
+	}, nil
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// A user's followers live in one FollowersTable item until its follower_ids list nears
+// DynamoDB's 400KB item limit. From then on new followers go to overflow items keyed
+// "<user_id>#1", "<user_id>#2", ...; the base item keeps the first shard and records
+// how many overflow shards exist in shard_count. Readers aggregate all shards in order.
+
+// maxFollowersPerShard caps each shard well below the item size limit. A follower
+// costs bytes twice: its ID in follower_ids, and a followed_at map entry holding the ID
+// again as key with a timestamp. At the worst case of a 19-digit ID that is about 55
+// bytes per follower, so 6k followers stay around 330KB.
+const maxFollowersPerShard = 6000
+
+// maxShardAttempts bounds retries when appends race with a shard filling up
+const maxShardAttempts = 5
+
+// maxBatchGetKeys is DynamoDB's BatchGetItem key limit
+const maxBatchGetKeys = 100
+
+// followerShardKey is the FollowersTable key of shard n of a user's followers; shard 0 is the base item
+func followerShardKey(userIDStr string, shard int) string {
+	if shard == 0 {
+		return userIDStr
+	}
+	return fmt.Sprintf("%s#%d", userIDStr, shard)
+}
+
+// followerShardCount returns how many overflow shards a user's followers have
+func (db *DynamoDBClient) followerShardCount(ctx context.Context, userIDStr string) (int, error) {
+	result, err := db.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(db.followersTableName),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: userIDStr},
+		},
+		ProjectionExpression: aws.String("shard_count"),
+		ConsistentRead:       aws.Bool(true),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get shard count: %w", err)
+	}
+	return shardCount(result.Item)
+}
+
+// shardCount reads shard_count from a base followers item; a missing attribute means no overflow shards
+func shardCount(item map[string]types.AttributeValue) (int, error) {
+	attr, ok := item["shard_count"]
+	if !ok {
+		return 0, nil
+	}
+	n, ok := attr.(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, fmt.Errorf("expected number for shard_count, got %T", attr)
+	}
+	return strconv.Atoi(n.Value)
+}
+
+// appendFollower adds followerIDStr, with its follow time, to the last shard of
+// followeeIDStr's followers, opening a new shard when that one is full. An ID already in
+// any shard is left alone, keeping its original follow time; the result reports whether
+// the ID was added.
+func (db *DynamoDBClient) appendFollower(ctx context.Context, followeeIDStr, followerIDStr string, followedAt time.Time) (bool, error) {
+	// The append's condition only sees the last shard, so all of them are checked first
+	present, err := db.hasFollower(ctx, followeeIDStr, followerIDStr)
+	if err != nil || present {
+		return false, err
+	}
+
+	for attempt := 1; attempt <= maxShardAttempts; attempt++ {
+		shards, err := db.followerShardCount(ctx, followeeIDStr)
+		if err != nil {
+			return false, err
+		}
+		err = db.updateItem(ctx, db.followerAppend(followerShardKey(followeeIDStr, shards), followerIDStr, followedAt))
+		if err == nil {
+			return true, nil
+		}
+		if !isConditionalCheckFailed(err) {
+			return false, err
+		}
+
+		present, err := db.prepareFollowerShard(ctx, followeeIDStr, followerIDStr, shards)
+		if err != nil || present {
+			return false, err
+		}
+	}
+
+	return false, fmt.Errorf("followers of %s kept changing; gave up after %d attempts", followeeIDStr, maxShardAttempts)
+}
+
+// followerAppend is the update adding followerIDStr and its follow time to a follower
+// shard, conditional on the ID not being there already, the shard having room, and its
+// followed_at map existing: a nested path can only be set in an existing map, and setting
+// the map in the same update would overlap the path (see prepareFollowerShard)
+func (db *DynamoDBClient) followerAppend(shardKey, followerIDStr string, followedAt time.Time) *types.Update {
+	return &types.Update{
+		TableName: aws.String(db.followersTableName),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: shardKey},
+		},
+		UpdateExpression:         aws.String("SET follower_ids = list_append(if_not_exists(follower_ids, :empty_list), :new_follower), followed_at.#follower = :followed_at"),
+		ConditionExpression:      aws.String("attribute_exists(followed_at) AND NOT contains(follower_ids, :follower_id) AND (attribute_not_exists(follower_ids) OR size(follower_ids) < :max)"),
+		ExpressionAttributeNames: map[string]string{"#follower": followerIDStr},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":new_follower": &types.AttributeValueMemberL{
+				Value: []types.AttributeValue{
+					&types.AttributeValueMemberS{Value: followerIDStr},
+				},
+			},
+			":follower_id": &types.AttributeValueMemberS{Value: followerIDStr},
+			":followed_at": &types.AttributeValueMemberN{Value: strconv.FormatInt(followedAt.Unix(), 10)},
+			":empty_list":  &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
+			":max":         &types.AttributeValueMemberN{Value: strconv.Itoa(maxFollowersPerShard)},
+		},
+	}
+}
+
+// prepareFollowerShard is called when an append of followerIDStr to shard current of a
+// user's followers failed its condition. It reports whether the follower is in that shard
+// after all; otherwise it opens the next shard if this one is full, or creates the shard's
+// followed_at map if it has none, so the append can be retried. If neither is needed, an
+// unfollow made room or another writer changed the shards in between; retrying is enough.
+func (db *DynamoDBClient) prepareFollowerShard(ctx context.Context, userIDStr, followerIDStr string, current int) (bool, error) {
+	shardKey := followerShardKey(userIDStr, current)
+	present, length, hasTimes, err := db.followerShardState(ctx, shardKey, followerIDStr)
+	if err != nil || present {
+		return present, err
+	}
+	switch {
+	case length >= maxFollowersPerShard:
+		if err := db.openFollowerShard(ctx, userIDStr, current); err != nil {
+			return false, err
+		}
+		return false, db.ensureFollowedAtMap(ctx, db.followersTableName, followerShardKey(userIDStr, current+1))
+	case !hasTimes:
+		return false, db.ensureFollowedAtMap(ctx, db.followersTableName, shardKey)
+	}
+	return false, nil
+}
+
+// ensureFollowedAtMap creates the followed_at map of an item, and the item itself, if
+// missing, so follow times can be set in it
+func (db *DynamoDBClient) ensureFollowedAtMap(ctx context.Context, tableName, key string) error {
+	_, err := db.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: key},
+		},
+		UpdateExpression: aws.String("SET followed_at = if_not_exists(followed_at, :empty_map)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":empty_map": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create followed_at of %s in %s: %w", key, tableName, err)
+	}
+	return nil
+}
+
+// hasFollower reports whether any shard of a user's followers holds followerIDStr
+func (db *DynamoDBClient) hasFollower(ctx context.Context, userIDStr, followerIDStr string) (bool, error) {
+	shards, err := db.getFollowerShards(ctx, userIDStr, "follower_ids", true)
+	if err != nil {
+		return false, fmt.Errorf("failed to read followers of %s: %w", userIDStr, err)
+	}
+	for _, shard := range shards {
+		var ids []string
+		if err := attributevalue.Unmarshal(shard["follower_ids"], &ids); err != nil {
+			return false, fmt.Errorf("failed to unmarshal follower_ids of %s: %w", userIDStr, err)
+		}
+		if slices.Contains(ids, followerIDStr) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// removeFollower removes followerIDStr from whichever shard of followeeIDStr's followers
+// holds it, reporting whether it was found
+func (db *DynamoDBClient) removeFollower(ctx context.Context, followeeIDStr, followerIDStr string) (bool, error) {
+	shards, err := db.followerShardCount(ctx, followeeIDStr)
+	if err != nil {
+		return false, err
+	}
+	for n := 0; n <= shards; n++ {
+		removed, err := db.removeListElement(ctx, db.followersTableName, followerShardKey(followeeIDStr, n), "follower_ids", followerIDStr)
+		if err != nil || removed {
+			return removed, err
+		}
+	}
+	return false, nil
+}
+
+// followerShardState reports whether a shard contains followerIDStr, how long its list
+// is, and whether it has a followed_at map
+func (db *DynamoDBClient) followerShardState(ctx context.Context, shardKey, followerIDStr string) (bool, int, bool, error) {
+	result, err := db.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(db.followersTableName),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: shardKey},
+		},
+		ProjectionExpression: aws.String("follower_ids, followed_at"),
+		ConsistentRead:       aws.Bool(true),
+	})
+	if err != nil {
+		return false, 0, false, fmt.Errorf("failed to get follower shard %s: %w", shardKey, err)
+	}
+
+	_, hasTimes := result.Item["followed_at"].(*types.AttributeValueMemberM)
+	list, _ := result.Item["follower_ids"].(*types.AttributeValueMemberL)
+	if list == nil {
+		return false, 0, hasTimes, nil
+	}
+	for _, av := range list.Value {
+		if s, ok := av.(*types.AttributeValueMemberS); ok && s.Value == followerIDStr {
+			return true, len(list.Value), hasTimes, nil
+		}
+	}
+	return false, len(list.Value), hasTimes, nil
+}
+
+// openFollowerShard moves a user's followers from shard current to current+1. If another
+// writer already did so the condition fails, which is fine: the caller re-reads the count.
+func (db *DynamoDBClient) openFollowerShard(ctx context.Context, userIDStr string, current int) error {
+	condition := "shard_count = :current"
+	values := map[string]types.AttributeValue{
+		":next":    &types.AttributeValueMemberN{Value: strconv.Itoa(current + 1)},
+		":current": &types.AttributeValueMemberN{Value: strconv.Itoa(current)},
+	}
+	if current == 0 {
+		condition = "attribute_not_exists(shard_count)"
+		delete(values, ":current")
+	}
+
+	_, err := db.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(db.followersTableName),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: userIDStr},
+		},
+		UpdateExpression:          aws.String("SET shard_count = :next"),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	})
+	if err != nil && !isConditionalCheckFailed(err) {
+		return fmt.Errorf("failed to open follower shard %d: %w", current+1, err)
+	}
+	return nil
+}
+
+// getFollowerShards returns every shard item of a user's followers, base item first,
+// with the given projection applied, read strongly consistent when consistent is set.
+// A user with no followers record returns nil.
+func (db *DynamoDBClient) getFollowerShards(ctx context.Context, userIDStr, projection string, consistent bool) ([]map[string]types.AttributeValue, error) {
+	result, err := db.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(db.followersTableName),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: userIDStr},
+		},
+		ProjectionExpression: aws.String(projection + ", shard_count"),
+		ConsistentRead:       aws.Bool(consistent),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	shards, err := shardCount(result.Item)
+	if err != nil {
+		return nil, err
+	}
+	items := []map[string]types.AttributeValue{result.Item}
+	if shards == 0 {
+		return items, nil
+	}
+
+	byKey := make(map[string]map[string]types.AttributeValue, shards)
+	for start := 1; start <= shards; start += maxBatchGetKeys {
+		keys := make([]map[string]types.AttributeValue, 0, maxBatchGetKeys)
+		for n := start; n <= shards && n < start+maxBatchGetKeys; n++ {
+			keys = append(keys, map[string]types.AttributeValue{
+				"user_id": &types.AttributeValueMemberS{Value: followerShardKey(userIDStr, n)},
+			})
+		}
+		if err := db.batchGetFollowerShards(ctx, keys, projection, consistent, byKey); err != nil {
+			return nil, err
+		}
+	}
+
+	// Shards that were opened but never written to have no item
+	for n := 1; n <= shards; n++ {
+		if item, ok := byKey[followerShardKey(userIDStr, n)]; ok {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// batchGetFollowerShards reads keys into byKey (indexed by user_id), resending unprocessed keys with backoff
+func (db *DynamoDBClient) batchGetFollowerShards(ctx context.Context, keys []map[string]types.AttributeValue, projection string, consistent bool, byKey map[string]map[string]types.AttributeValue) error {
+	requestItems := map[string]types.KeysAndAttributes{
+		db.followersTableName: {
+			Keys:                 keys,
+			ProjectionExpression: aws.String(projection + ", user_id"),
+			ConsistentRead:       aws.Bool(consistent),
+		},
+	}
+
+	for attempt := 0; ; attempt++ {
+		result, err := db.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: requestItems})
+		if err != nil {
+			return err
+		}
+		for _, item := range result.Responses[db.followersTableName] {
+			if key, ok := item["user_id"].(*types.AttributeValueMemberS); ok {
+				byKey[key.Value] = item
+			}
+		}
+
+		if len(result.UnprocessedKeys) == 0 {
+			return nil
+		}
+		if attempt >= maxShardAttempts {
+			return fmt.Errorf("%d follower shards still unprocessed after %d retries", len(result.UnprocessedKeys[db.followersTableName].Keys), maxShardAttempts)
+		}
+		requestItems = result.UnprocessedKeys
+		time.Sleep(time.Duration(50*(1<<attempt)) * time.Millisecond)
+	}
+}
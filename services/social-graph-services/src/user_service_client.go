@@ -4,14 +4,19 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	pb "github.com/cs6650/proto"
+	"github.com/cs6650/proto/grpcdial"
+	"github.com/cs6650/proto/usercache"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 )
 
 // UserServiceClient interface for User Service gRPC operations
+// BatchGetUserInfo returns the users found and, separately, the IDs the User Service
+// reported as not found, leaving callers to decide how to present missing users
 type UserServiceClient interface {
 	BatchGetUserInfo(ctx context.Context, userIDs []int64) (map[int64]*pb.UserInfo, []int64, error)
 	Close() error
@@ -19,16 +24,83 @@ type UserServiceClient interface {
 
 // userServiceClient implements UserServiceClient with actual gRPC calls
 type userServiceClient struct {
-	client pb.UserServiceClient
-	conn   *grpc.ClientConn
+	client      pb.UserServiceClient
+	conn        *grpc.ClientConn
+	batchSize   int              // Max user IDs per BatchGetUserInfo call
+	concurrency int              // Max BatchGetUserInfo calls in flight per request
+	cache       *usercache.Cache // Usernames served without a call; nil disables
 }
 
-// BatchGetUserInfo calls the User Service via gRPC to get user information
+// BatchGetUserInfo returns user information for userIDs, serving cached usernames
+// directly and calling the User Service only for the rest
 func (c *userServiceClient) BatchGetUserInfo(ctx context.Context, userIDs []int64) (map[int64]*pb.UserInfo, []int64, error) {
+	cached, misses := c.cache.Lookup(userIDs)
+
+	users, notFound, err := c.fetchUserInfo(ctx, misses)
+	if err != nil {
+		return nil, nil, err
+	}
+	for userID, userInfo := range users {
+		c.cache.Set(userID, userInfo.Username)
+	}
+	for userID, username := range cached {
+		users[userID] = &pb.UserInfo{UserId: userID, Username: username}
+	}
+	return users, notFound, nil
+}
+
+// fetchUserInfo calls the User Service via gRPC to get user information
+// Large ID lists are split into batches of batchSize, with at most concurrency calls in flight
+func (c *userServiceClient) fetchUserInfo(ctx context.Context, userIDs []int64) (map[int64]*pb.UserInfo, []int64, error) {
 	if len(userIDs) == 0 {
 		return make(map[int64]*pb.UserInfo), nil, nil
 	}
+	if len(userIDs) <= c.batchSize {
+		return c.batchGetUserInfo(ctx, userIDs)
+	}
+
+	users := make(map[int64]*pb.UserInfo, len(userIDs))
+	var notFound []int64
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.concurrency)
+
+	for start := 0; start < len(userIDs); start += c.batchSize {
+		end := min(start+c.batchSize, len(userIDs))
+
+		wg.Add(1)
+		go func(batch []int64) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			batchUsers, batchNotFound, err := c.batchGetUserInfo(ctx, batch)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			for userID, userInfo := range batchUsers {
+				users[userID] = userInfo
+			}
+			notFound = append(notFound, batchNotFound...)
+		}(userIDs[start:end])
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	return users, notFound, nil
+}
 
+// batchGetUserInfo makes a single BatchGetUserInfo call
+func (c *userServiceClient) batchGetUserInfo(ctx context.Context, userIDs []int64) (map[int64]*pb.UserInfo, []int64, error) {
 	// Create gRPC request
 	req := &pb.BatchGetUserInfoRequest{
 		UserIds: userIDs,
@@ -60,14 +132,11 @@ func (c *userServiceClient) Close() error {
 }
 
 // NewUserServiceClient creates a new User Service client with real gRPC connection
-func NewUserServiceClient(endpoint string) (UserServiceClient, error) {
+func NewUserServiceClient(endpoint string, batchSize, concurrency int, keepaliveParams keepalive.ClientParameters, cache *usercache.Cache) (UserServiceClient, error) {
 	log.Printf("Connecting to User Service at %s...", endpoint)
 
 	// Establish gRPC connection
-	conn, err := grpc.NewClient(
-		endpoint,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	conn, err := grpc.NewClient(endpoint, grpcdial.Options(keepaliveParams)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create User Service client for %s: %w", endpoint, err)
 	}
@@ -75,8 +144,11 @@ func NewUserServiceClient(endpoint string) (UserServiceClient, error) {
 	log.Printf("User Service client created for %s", endpoint)
 
 	return &userServiceClient{
-		client: pb.NewUserServiceClient(conn),
-		conn:   conn,
+		client:      pb.NewUserServiceClient(conn),
+		conn:        conn,
+		batchSize:   batchSize,
+		concurrency: concurrency,
+		cache:       cache,
 	}, nil
 }
 
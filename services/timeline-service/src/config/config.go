@@ -1,53 +1,236 @@
 package config
 
 import (
+	"fmt"
+	"net"
 	"os"
+	"regexp"
 	"strconv"
+	"time"
+
+	"github.com/cs6650/proto/grpcdial"
+	"google.golang.org/grpc/keepalive"
 )
 
+// tableNamePattern matches DynamoDB's table naming rules
+var tableNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,255}$`)
+
+// MaxSQSVisibilityTimeout is the longest visibility timeout SQS accepts
+const MaxSQSVisibilityTimeout = 12 * time.Hour
+
+// MaxTimelineCacheTTL caps TIMELINE_CACHE_TTL. Fan-outs only invalidate the cache of
+// the replica that ran them, so on the others a timeline is up to one TTL stale.
+const MaxTimelineCacheTTL = 10 * time.Second
+
 type Config struct {
 	// Server
-	Port int
-	Env  string
+	Port     int
+	GRPCPort int
+	Env      string
+
+	// Admin endpoints rewrite timelines, so they are served only on AdminAddr, a listener
+	// apart from Port that defaults to loopback: reachable from inside the task (ECS Exec)
+	// but never through the load balancer
+	AdminAddr string
 
 	// AWS
 	AWSRegion string
 
 	// DynamoDB
+	TablePrefix    string
 	PostsTableName string
 
+	// Days before a fanned-out timeline entry expires; 0 disables expiry
+	TimelineTTLDays int
+
+	// Ceiling on each DynamoDB operation, retries included, whatever the caller's
+	// deadline; 0 disables it
+	DynamoDBOperationTimeout time.Duration
+
 	// SQS
-	SQSQueueURL string
+	SQSQueueURL    string
+	SQSBatchDelete bool
+	SQSWorkers     int // Messages processed concurrently
+
+	// Extend a message's visibility timeout every SQSVisibilityHeartbeat (0 = never) while it is
+	// processed, for at most SQSMaxVisibilityExtension, so long fan-outs are not redelivered
+	SQSVisibilityHeartbeat    time.Duration
+	SQSMaxVisibilityExtension time.Duration
+
+	// On shutdown, SQS messages in flight get SQSDrainTimeout to finish before the
+	// service exits; keep it under the orchestrator's stop timeout (ECS: 30s)
+	SQSDrainTimeout time.Duration
+
+	// Messages still failing after SQSMaxReceives receives are moved to DLQURL; an empty URL retries forever
+	DLQURL         string
+	SQSMaxReceives int
 
 	// Service Endpoints
 	UserServiceEndpoint        string
 	PostServiceEndpoint        string
 	SocialGraphServiceEndpoint string
 
+	// Reconnect and retry a User Service call once when it fails with Unavailable
+	RetryOnUnavailable bool
+
+	// Username cache in front of the User Service; a size or TTL of 0 disables it
+	UserCacheSize int
+	UserCacheTTL  time.Duration
+
+	// Timeline page cache, one per replica; a size or TTL of 0 disables it. Fan-outs
+	// invalidate cached pages only on the replica that ran them, and pulled posts are
+	// never invalidated, so timelines can be up to one TTL (at most MaxTimelineCacheTTL) late
+	TimelineCacheSize int
+	TimelineCacheTTL  time.Duration
+
+	// gRPC client keepalive: ping after KeepaliveTime idle, drop after KeepaliveTimeout without ack
+	KeepaliveTime     time.Duration
+	KeepaliveTimeout  time.Duration
+	KeepaliveWhenIdle bool
+
 	// Fan-out Strategy
 	FanoutStrategy     string
 	CelebrityThreshold int
 
+	// Hybrid reads: serve one branch's posts with a warning when the other fails
+	HybridAllowPartial bool
+
+	// Hybrid reads hold at most this many posts while merging the branches; 0 = the timeline
+	// limit. Below the limit, timelines are cut to the newest HybridMaxMerged posts.
+	HybridMaxMerged int
+
+	// Pull reads: concurrent BatchGetPosts calls, and posts per followed user (0 = timeline limit, min 10)
+	PullFetchWorkers int
+	PullPostsPerUser int
+
+	// Pull reads only consider the first PullMaxFollowing followed users; 0 = all
+	PullMaxFollowing int
+
+	// Show users their own posts in their timeline under every strategy
+	IncludeOwnPosts bool
+
+	// Timeline pages are clamped to TimelineMaxLimit posts
+	TimelineMaxLimit int
+
+	// Timeline Enrichment
+	RelationshipMaxAuthors int
+
 	// Logging
 	LogLevel string
 }
 
 func Load() *Config {
+	tablePrefix := getEnv("TABLE_PREFIX", "")
 	return &Config{
 		Port:                       getEnvInt("PORT", 8084),
+		GRPCPort:                   getEnvInt("GRPC_PORT", 50054),
+		AdminAddr:                  getEnv("ADMIN_ADDR", "127.0.0.1:9084"),
 		Env:                        getEnv("ENVIRONMENT", "dev"),
 		AWSRegion:                  getEnv("AWS_REGION", "us-west-2"),
-		PostsTableName:             getEnv("DYNAMODB_TABLE_NAME", "posts-timeline_service"),
+		TablePrefix:                tablePrefix,
+		PostsTableName:             tablePrefix + getEnv("DYNAMODB_TABLE_NAME", "posts-timeline_service"),
+		TimelineTTLDays:            getEnvInt("TIMELINE_TTL_DAYS", 30),
+		DynamoDBOperationTimeout:   getEnvDuration("DYNAMODB_OPERATION_TIMEOUT", 5*time.Second),
 		SQSQueueURL:                getEnv("SQS_QUEUE_URL", ""),
+		SQSBatchDelete:             getEnvBool("SQS_BATCH_DELETE", true),
+		SQSWorkers:                 getEnvInt("SQS_WORKERS", 10),
+		SQSVisibilityHeartbeat:     getEnvDuration("SQS_VISIBILITY_HEARTBEAT", 20*time.Second),
+		SQSMaxVisibilityExtension:  getEnvDuration("SQS_MAX_VISIBILITY_EXTENSION", 15*time.Minute),
+		SQSDrainTimeout:            getEnvDuration("SQS_DRAIN_TIMEOUT", 20*time.Second),
+		DLQURL:                     getEnv("DLQ_URL", ""),
+		SQSMaxReceives:             getEnvInt("SQS_MAX_RECEIVES", 5),
 		UserServiceEndpoint:        getEnv("USER_SERVICE_URL", "user-service-grpc:50051"),
 		PostServiceEndpoint:        getEnv("POST_SERVICE_URL", "post-service-grpc:50051"),
 		SocialGraphServiceEndpoint: getEnv("SOCIAL_GRAPH_SERVICE_URL", "social-graph-service-grpc:50051"),
+		RetryOnUnavailable:         getEnvBool("USER_SERVICE_RETRY_UNAVAILABLE", true),
+		UserCacheSize:              getEnvInt("USER_INFO_CACHE_SIZE", 10000),
+		UserCacheTTL:               getEnvDuration("USER_INFO_CACHE_TTL", 5*time.Minute),
+		TimelineCacheSize:          getEnvInt("TIMELINE_CACHE_SIZE", 10000),
+		TimelineCacheTTL:           getEnvDuration("TIMELINE_CACHE_TTL", 0),
+		KeepaliveTime:              getEnvDuration("GRPC_KEEPALIVE_TIME", 30*time.Second),
+		KeepaliveTimeout:           getEnvDuration("GRPC_KEEPALIVE_TIMEOUT", 10*time.Second),
+		KeepaliveWhenIdle:          getEnvBool("GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM", true),
 		FanoutStrategy:             getEnv("FANOUT_STRATEGY", "push"),
 		CelebrityThreshold:         getEnvInt("CELEBRITY_THRESHOLD", 50000),
+		HybridAllowPartial:         getEnvBool("HYBRID_ALLOW_PARTIAL", true),
+		HybridMaxMerged:            getEnvInt("HYBRID_MAX_MERGED_POSTS", 0),
+		PullFetchWorkers:           getEnvInt("PULL_FETCH_WORKERS", 4),
+		PullPostsPerUser:           getEnvInt("PULL_POSTS_PER_USER", 0),
+		PullMaxFollowing:           getEnvInt("FANOUT_PULL_MAX_FOLLOWING", 1000),
+		IncludeOwnPosts:            getEnvBool("INCLUDE_OWN_POSTS", false),
+		TimelineMaxLimit:           getEnvInt("TIMELINE_MAX_LIMIT", 100),
+		RelationshipMaxAuthors:     getEnvInt("RELATIONSHIP_MAX_AUTHORS", 100),
 		LogLevel:                   getEnv("LOG_LEVEL", "info"),
 	}
 }
 
+// Validate checks that the loaded configuration is usable
+func (c *Config) Validate() error {
+	if !tableNamePattern.MatchString(c.PostsTableName) {
+		return fmt.Errorf("invalid posts table name %q: must be 3-255 characters of letters, digits, '_', '.' or '-'", c.PostsTableName)
+	}
+	switch c.FanoutStrategy {
+	case "push", "pull", "hybrid":
+	default:
+		return fmt.Errorf("invalid FANOUT_STRATEGY %q: must be 'push', 'pull', or 'hybrid'", c.FanoutStrategy)
+	}
+	if err := grpcdial.ValidateKeepalive(c.KeepaliveParams()); err != nil {
+		return err
+	}
+	if _, _, err := net.SplitHostPort(c.AdminAddr); err != nil {
+		return fmt.Errorf("invalid ADMIN_ADDR %q: %w", c.AdminAddr, err)
+	}
+	if c.TimelineTTLDays < 0 {
+		return fmt.Errorf("invalid TIMELINE_TTL_DAYS %d: must be >= 0", c.TimelineTTLDays)
+	}
+	if c.DynamoDBOperationTimeout < 0 {
+		return fmt.Errorf("invalid DYNAMODB_OPERATION_TIMEOUT %s: must be >= 0", c.DynamoDBOperationTimeout)
+	}
+	if c.SQSWorkers <= 0 {
+		return fmt.Errorf("invalid SQS_WORKERS %d: must be > 0", c.SQSWorkers)
+	}
+	if c.SQSVisibilityHeartbeat != 0 && (c.SQSVisibilityHeartbeat < time.Second || c.SQSVisibilityHeartbeat > MaxSQSVisibilityTimeout/2) {
+		return fmt.Errorf("invalid SQS_VISIBILITY_HEARTBEAT %s: must be 0 or between 1s and %s", c.SQSVisibilityHeartbeat, MaxSQSVisibilityTimeout/2)
+	}
+	if c.SQSMaxVisibilityExtension < 0 {
+		return fmt.Errorf("invalid SQS_MAX_VISIBILITY_EXTENSION %s: must be >= 0", c.SQSMaxVisibilityExtension)
+	}
+	if c.SQSDrainTimeout <= 0 {
+		return fmt.Errorf("invalid SQS_DRAIN_TIMEOUT %s: must be > 0", c.SQSDrainTimeout)
+	}
+	if c.SQSMaxReceives <= 0 {
+		return fmt.Errorf("invalid SQS_MAX_RECEIVES %d: must be > 0", c.SQSMaxReceives)
+	}
+	if c.UserCacheSize < 0 {
+		return fmt.Errorf("invalid USER_INFO_CACHE_SIZE %d: must be >= 0", c.UserCacheSize)
+	}
+	if c.UserCacheTTL < 0 {
+		return fmt.Errorf("invalid USER_INFO_CACHE_TTL %s: must be >= 0", c.UserCacheTTL)
+	}
+	if c.TimelineCacheSize < 0 {
+		return fmt.Errorf("invalid TIMELINE_CACHE_SIZE %d: must be >= 0", c.TimelineCacheSize)
+	}
+	if c.TimelineCacheTTL < 0 || c.TimelineCacheTTL > MaxTimelineCacheTTL {
+		return fmt.Errorf("invalid TIMELINE_CACHE_TTL %s: must be between 0 and %s", c.TimelineCacheTTL, MaxTimelineCacheTTL)
+	}
+	if c.HybridMaxMerged < 0 {
+		return fmt.Errorf("invalid HYBRID_MAX_MERGED_POSTS %d: must be >= 0", c.HybridMaxMerged)
+	}
+	if c.PullFetchWorkers <= 0 {
+		return fmt.Errorf("invalid PULL_FETCH_WORKERS %d: must be > 0", c.PullFetchWorkers)
+	}
+	if c.PullPostsPerUser < 0 {
+		return fmt.Errorf("invalid PULL_POSTS_PER_USER %d: must be >= 0", c.PullPostsPerUser)
+	}
+	if c.TimelineMaxLimit <= 0 {
+		return fmt.Errorf("invalid TIMELINE_MAX_LIMIT %d: must be > 0", c.TimelineMaxLimit)
+	}
+	if c.PullMaxFollowing < 0 {
+		return fmt.Errorf("invalid FANOUT_PULL_MAX_FOLLOWING %d: must be >= 0", c.PullMaxFollowing)
+	}
+	return nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -63,3 +246,30 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if durationVal, err := time.ParseDuration(value); err == nil {
+			return durationVal
+		}
+	}
+	return defaultValue
+}
+
+// KeepaliveParams returns the keepalive settings for outgoing gRPC clients
+func (c *Config) KeepaliveParams() keepalive.ClientParameters {
+	return keepalive.ClientParameters{
+		Time:                c.KeepaliveTime,
+		Timeout:             c.KeepaliveTimeout,
+		PermitWithoutStream: c.KeepaliveWhenIdle,
+	}
+}
@@ -3,16 +3,19 @@ package db
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/cs6650/proto/dynamotimeout"
 )
 
 type DynamoDBClient struct {
 	client *dynamodb.Client
 }
 
-func NewDynamoDBClient(ctx context.Context, region string) (*DynamoDBClient, error) {
+// Every operation of the client is bounded by operationTimeout; 0 leaves it to the caller's context
+func NewDynamoDBClient(ctx context.Context, region string, operationTimeout time.Duration) (*DynamoDBClient, error) {
 	// Load config with explicit credential providers to avoid IMDS issues
 	cfg, err := config.LoadDefaultConfig(ctx,
 		config.WithRegion(region),
@@ -22,7 +25,7 @@ func NewDynamoDBClient(ctx context.Context, region string) (*DynamoDBClient, err
 		return nil, fmt.Errorf("unable to load AWS config: %w", err)
 	}
 
-	client := dynamodb.NewFromConfig(cfg)
+	client := dynamodb.NewFromConfig(cfg, dynamotimeout.WithOperationTimeout(operationTimeout))
 	return &DynamoDBClient{client: client}, nil
 }
 
@@ -0,0 +1,232 @@
+package fanout
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
+)
+
+// TimelineCache holds timeline pages for a few seconds so clients polling the same
+// timeline are served from memory instead of re-running the strategy. Entries are
+// shared by all strategies and dropped for every follower a post is fanned out to,
+// so pushed posts appear at once; posts only reachable by pulling can be up to one
+// TTL late. A nil *TimelineCache is valid and caches nothing.
+//
+// Each replica has its own cache, and only the replica that handles a fan-out drops
+// the followers' pages. The others keep serving theirs until they expire, so on every
+// replica a timeline is at most one TTL stale; config caps the TTL to bound this.
+type TimelineCache struct {
+	capacity int // Users with cached pages
+	ttl      time.Duration
+	now      func() time.Time // Replaced in tests
+
+	mu          sync.Mutex
+	entries     map[int64]map[timelineCacheKey]timelineCacheEntry
+	invalidated map[int64]time.Time            // Last invalidation per user, so reads begun earlier are not cached
+	stats       map[string]*TimelineCacheStats // By strategy name
+}
+
+// maxTimelineRead bounds how long a timeline read can run; invalidations older than
+// this can no longer race with a read and are forgotten
+const maxTimelineRead = 30 * time.Second
+
+// timelineCacheKey identifies one page of one user's timeline
+type timelineCacheKey struct {
+	strategy string
+	limit    int
+	cursor   string
+}
+
+type timelineCacheEntry struct {
+	timeline  *models.TimelineResponse
+	expiresAt time.Time
+}
+
+// TimelineCacheStats counts one strategy's cache lookups, for health endpoints
+type TimelineCacheStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+// NewTimelineCache returns a cache holding up to capacity users' pages for ttl each,
+// or nil (caching disabled) when capacity or ttl is not positive
+func NewTimelineCache(capacity int, ttl time.Duration) *TimelineCache {
+	if capacity <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &TimelineCache{
+		capacity:    capacity,
+		ttl:         ttl,
+		now:         time.Now,
+		entries:     make(map[int64]map[timelineCacheKey]timelineCacheEntry),
+		invalidated: make(map[int64]time.Time),
+		stats:       make(map[string]*TimelineCacheStats),
+	}
+}
+
+// Invalidate drops every cached page of the given users' timelines on this replica
+func (c *TimelineCache) Invalidate(userIDs ...int64) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if len(c.invalidated) >= c.capacity {
+		for userID, at := range c.invalidated {
+			if now.Sub(at) > maxTimelineRead {
+				delete(c.invalidated, userID)
+			}
+		}
+	}
+	for _, userID := range userIDs {
+		delete(c.entries, userID)
+		c.invalidated[userID] = now
+	}
+}
+
+// Stats returns each strategy's hit counters
+func (c *TimelineCache) Stats() map[string]TimelineCacheStats {
+	stats := make(map[string]TimelineCacheStats)
+	if c == nil {
+		return stats
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for strategy, s := range c.stats {
+		snapshot := *s
+		if total := s.Hits + s.Misses; total > 0 {
+			snapshot.HitRate = float64(s.Hits) / float64(total)
+		}
+		stats[strategy] = snapshot
+	}
+	return stats
+}
+
+// get returns a copy of a live cached page and counts the lookup
+func (c *TimelineCache) get(userID int64, key timelineCacheKey) (*models.TimelineResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.stats[key.strategy]
+	if !ok {
+		s = &TimelineCacheStats{}
+		c.stats[key.strategy] = s
+	}
+
+	entry, ok := c.entries[userID][key]
+	if !ok || c.now().After(entry.expiresAt) {
+		s.Misses++
+		return nil, false
+	}
+	s.Hits++
+	return cloneTimeline(entry.timeline), true
+}
+
+// set caches a copy of a page read starting at readAt, unless the user's timeline was
+// invalidated since, as the page may predate the fan-out. When full, users whose pages
+// have all expired are dropped first and then an arbitrary user, since every entry is
+// gone within one TTL anyway.
+func (c *TimelineCache) set(userID int64, key timelineCacheKey, timeline *models.TimelineResponse, readAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if at, ok := c.invalidated[userID]; ok && !at.Before(readAt) {
+		return
+	}
+	now := c.now()
+	pages, ok := c.entries[userID]
+	if !ok {
+		if len(c.entries) >= c.capacity {
+			c.evict(now)
+		}
+		pages = make(map[timelineCacheKey]timelineCacheEntry)
+		c.entries[userID] = pages
+	}
+	pages[key] = timelineCacheEntry{timeline: cloneTimeline(timeline), expiresAt: now.Add(c.ttl)}
+}
+
+// evict makes room for one user. Callers must hold c.mu
+func (c *TimelineCache) evict(now time.Time) {
+	for userID, pages := range c.entries {
+		live := false
+		for _, entry := range pages {
+			if !now.After(entry.expiresAt) {
+				live = true
+				break
+			}
+		}
+		if !live {
+			delete(c.entries, userID)
+		}
+	}
+	for userID := range c.entries {
+		if len(c.entries) < c.capacity {
+			return
+		}
+		delete(c.entries, userID)
+	}
+}
+
+// cloneTimeline copies a page so the handler's enrichment never changes a cached one
+func cloneTimeline(timeline *models.TimelineResponse) *models.TimelineResponse {
+	clone := *timeline
+	clone.Timeline = append([]models.TimelinePost(nil), timeline.Timeline...)
+	return &clone
+}
+
+// cachedStrategy serves GetTimeline from a TimelineCache in front of a strategy and
+// invalidates the followers' cached timelines on each fan-out
+type cachedStrategy struct {
+	Strategy
+	cache *TimelineCache
+}
+
+// WithCache returns strategy with its timelines cached in cache, or strategy itself
+// when cache is nil
+func WithCache(strategy Strategy, cache *TimelineCache) Strategy {
+	if cache == nil {
+		return strategy
+	}
+	return &cachedStrategy{Strategy: strategy, cache: cache}
+}
+
+// FanoutPost writes the post and then invalidates the timelines it was written to,
+// including on failure since some batches may have been written
+func (s *cachedStrategy) FanoutPost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error {
+	err := s.Strategy.FanoutPost(ctx, req, followerIDs)
+	s.cache.Invalidate(followerIDs...)
+	s.cache.Invalidate(req.AuthorID) // Own posts may be included
+	return err
+}
+
+// RemovePost removes the post and then invalidates the timelines it was removed from
+func (s *cachedStrategy) RemovePost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error {
+	err := s.Strategy.RemovePost(ctx, req, followerIDs)
+	s.cache.Invalidate(followerIDs...)
+	s.cache.Invalidate(req.AuthorID)
+	return err
+}
+
+func (s *cachedStrategy) GetTimeline(ctx context.Context, userID int64, limit int, cursor string) (*models.TimelineResponse, error) {
+	key := timelineCacheKey{strategy: s.GetName(), limit: limit, cursor: cursor}
+	if timeline, ok := s.cache.get(userID, key); ok {
+		return timeline, nil
+	}
+
+	readAt := s.cache.now()
+	timeline, err := s.Strategy.GetTimeline(ctx, userID, limit, cursor)
+	if err != nil {
+		return nil, err
+	}
+	// Partial pages are not cached, so the next poll retries the failed branch
+	if timeline.Warning == "" {
+		s.cache.set(userID, key, timeline, readAt)
+	}
+	return timeline, nil
+}
@@ -0,0 +1,222 @@
+package fanout
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
+)
+
+// storedTimelines is a strategy over a timeline per user shared by every replica, as the
+// timeline table is, counting the reads that reach it
+type storedTimelines struct {
+	mu        sync.Mutex
+	timelines map[int64][]string
+	reads     int
+	warning   string // Set on every page read
+	fanout    func() // Runs during each read, to race a fan-out with it
+}
+
+func (s *storedTimelines) GetName() string { return "push" }
+
+func (s *storedTimelines) FanoutPost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range followerIDs {
+		s.timelines[id] = append([]string{req.PostID}, s.timelines[id]...)
+	}
+	return nil
+}
+
+func (s *storedTimelines) RemovePost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error {
+	return nil
+}
+
+func (s *storedTimelines) GetTimeline(ctx context.Context, userID int64, limit int, cursor string) (*models.TimelineResponse, error) {
+	s.mu.Lock()
+	s.reads++
+	page := &models.TimelineResponse{Timeline: []models.TimelinePost{}, Warning: s.warning}
+	for _, id := range s.timelines[userID] {
+		page.Timeline = append(page.Timeline, models.TimelinePost{PostID: id})
+	}
+	fanout := s.fanout
+	s.mu.Unlock()
+	if fanout != nil {
+		fanout()
+	}
+	page.TotalCount = len(page.Timeline)
+	return page, nil
+}
+
+func (s *storedTimelines) readCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reads
+}
+
+// fakeClock is a settable time for TimelineCache.now
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestCache(capacity int, ttl time.Duration, clock *fakeClock) *TimelineCache {
+	cache := NewTimelineCache(capacity, ttl)
+	cache.now = clock.now
+	return cache
+}
+
+func timelineIDs(t *testing.T, strategy Strategy, userID int64) string {
+	t.Helper()
+	page, err := strategy.GetTimeline(context.Background(), userID, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprint(postIDsOf(page))
+}
+
+func postIDsOf(page *models.TimelineResponse) []string {
+	ids := make([]string, len(page.Timeline))
+	for i, post := range page.Timeline {
+		ids[i] = post.PostID
+	}
+	return ids
+}
+
+func TestTimelineCacheServesPagesUntilExpiry(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	store := &storedTimelines{timelines: map[int64][]string{1: {"p1"}}}
+	cached := WithCache(store, newTestCache(10, 5*time.Second, clock))
+
+	timelineIDs(t, cached, 1)
+	timelineIDs(t, cached, 1)
+	if got := store.readCount(); got != 1 {
+		t.Errorf("%d reads for two requests within the TTL, want 1", got)
+	}
+	clock.advance(5*time.Second + time.Nanosecond)
+	timelineIDs(t, cached, 1)
+	if got := store.readCount(); got != 2 {
+		t.Errorf("%d reads after the TTL, want 2", got)
+	}
+
+	stats := cached.(*cachedStrategy).cache.Stats()["push"]
+	if stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("stats = %+v, want 1 hit and 2 misses", stats)
+	}
+}
+
+func TestTimelineCacheInvalidatesFannedOutTimelines(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	store := &storedTimelines{timelines: map[int64][]string{1: {"p1"}, 2: {"p1"}, 3: {"p1"}}}
+	cached := WithCache(store, newTestCache(10, 5*time.Second, clock))
+	for _, user := range []int64{1, 2, 3} {
+		timelineIDs(t, cached, user)
+	}
+
+	// User 2 posts to follower 1; both pages are dropped, user 3's is kept
+	if err := cached.FanoutPost(context.Background(), &models.FanoutRequest{PostID: "p2", AuthorID: 2}, []int64{1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := timelineIDs(t, cached, 1); got != "[p2 p1]" {
+		t.Errorf("follower's timeline after the fan-out = %s, want [p2 p1]", got)
+	}
+	timelineIDs(t, cached, 2)
+	timelineIDs(t, cached, 3)
+	if got := store.readCount(); got != 5 {
+		t.Errorf("%d reads, want 5: the follower and author re-read, the other user served from the cache", got)
+	}
+}
+
+func TestTimelineCacheStalenessAcrossReplicas(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	store := &storedTimelines{timelines: map[int64][]string{1: {"p1"}}}
+	const ttl = 3 * time.Second
+	replicaA := WithCache(store, newTestCache(10, ttl, clock))
+	replicaB := WithCache(store, newTestCache(10, ttl, clock))
+	timelineIDs(t, replicaA, 1)
+	timelineIDs(t, replicaB, 1)
+
+	// Replica A handles the fan-out; only its cache is invalidated
+	clock.advance(time.Second)
+	if err := replicaA.FanoutPost(context.Background(), &models.FanoutRequest{PostID: "p2", AuthorID: 9}, []int64{1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := timelineIDs(t, replicaA, 1); got != "[p2 p1]" {
+		t.Errorf("replica A = %s, want [p2 p1] at once", got)
+	}
+	if got := timelineIDs(t, replicaB, 1); got != "[p1]" {
+		t.Errorf("replica B within the TTL = %s, want its cached [p1]", got)
+	}
+	// Replica B's page was cached a second before the fan-out, so it is fresh within one TTL of it
+	clock.advance(ttl - time.Second + time.Nanosecond)
+	if got := timelineIDs(t, replicaB, 1); got != "[p2 p1]" {
+		t.Errorf("replica B one TTL after caching = %s, want [p2 p1]", got)
+	}
+}
+
+func TestTimelineCacheSkipsReadsRacingAFanout(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	store := &storedTimelines{timelines: map[int64][]string{1: {"p1"}}}
+	cached := WithCache(store, newTestCache(10, 5*time.Second, clock))
+
+	// The fan-out lands while the first read is running, after it read the timeline
+	store.fanout = func() {
+		store.fanout = nil
+		cached.FanoutPost(context.Background(), &models.FanoutRequest{PostID: "p2", AuthorID: 9}, []int64{1})
+	}
+	if got := timelineIDs(t, cached, 1); got != "[p1]" {
+		t.Fatalf("racing read = %s, want [p1]", got)
+	}
+	if got := timelineIDs(t, cached, 1); got != "[p2 p1]" {
+		t.Errorf("read after the fan-out = %s, want [p2 p1], not the racing read's page", got)
+	}
+}
+
+func TestTimelineCacheSkipsPartialPagesAndCopies(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	store := &storedTimelines{timelines: map[int64][]string{1: {"p1"}}, warning: "Live posts unavailable"}
+	cached := WithCache(store, newTestCache(10, 5*time.Second, clock))
+	timelineIDs(t, cached, 1)
+	timelineIDs(t, cached, 1)
+	if got := store.readCount(); got != 2 {
+		t.Errorf("%d reads of a partial page, want 2: partial pages are not cached", got)
+	}
+
+	store.warning = ""
+	page, _ := cached.GetTimeline(context.Background(), 1, 10, "")
+	page.Timeline[0].PostID = "changed"
+	if got := timelineIDs(t, cached, 1); got != "[p1]" {
+		t.Errorf("cached page = %s after the caller changed its copy, want [p1]", got)
+	}
+}
+
+func TestTimelineCacheEvictsAtCapacity(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	store := &storedTimelines{timelines: map[int64][]string{}}
+	cache := newTestCache(2, 5*time.Second, clock)
+	cached := WithCache(store, cache)
+	for _, user := range []int64{1, 2, 3} {
+		timelineIDs(t, cached, user)
+	}
+	if len(cache.entries) != 2 {
+		t.Errorf("%d users cached, want the capacity of 2", len(cache.entries))
+	}
+	if _, ok := cache.entries[3]; !ok {
+		t.Error("the newest user was evicted instead of making room")
+	}
+}
+
+func TestNilTimelineCache(t *testing.T) {
+	store := &storedTimelines{timelines: map[int64][]string{}}
+	if cached := WithCache(store, NewTimelineCache(0, time.Second)); cached != Strategy(store) {
+		t.Error("WithCache with a disabled cache wrapped the strategy")
+	}
+	var cache *TimelineCache
+	cache.Invalidate(1) // Must not panic
+	if stats := cache.Stats(); len(stats) != 0 {
+		t.Errorf("nil cache stats = %v, want none", stats)
+	}
+}
@@ -0,0 +1,116 @@
+package fanout
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrInvalidCursor means a cursor could not be decoded or was issued by another strategy
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursors are opaque to clients: base64url-encoded JSON naming the strategy that issued
+// them and the position after the last post served. Each strategy pages in its own
+// order, so a cursor is only accepted by the strategy that issued it.
+type cursorEnvelope struct {
+	Strategy string          `json:"s"`
+	Position json.RawMessage `json:"p"`
+}
+
+// encodeCursor returns the cursor for a strategy's position
+func encodeCursor(strategy string, position any) string {
+	raw, _ := json.Marshal(position) // Positions are plain structs
+	envelope, _ := json.Marshal(cursorEnvelope{Strategy: strategy, Position: raw})
+	return base64.RawURLEncoding.EncodeToString(envelope)
+}
+
+// decodeCursor reads a cursor issued by strategy into position
+func decodeCursor(cursor, strategy string, position any) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	var envelope cursorEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if envelope.Strategy != strategy {
+		return fmt.Errorf("%w: issued by %q strategy, current strategy is %q", ErrInvalidCursor, envelope.Strategy, strategy)
+	}
+	if err := json.Unmarshal(envelope.Position, position); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return nil
+}
+
+// pushCursor is the UserPostsIndex key of the last timeline entry read, and the post
+// last served, whose repeat entries the next page skips
+type pushCursor struct {
+	PostID    string `json:"id"` // The entry's post_id key, i.e. its EntryID
+	CreatedAt string `json:"t"`  // As stored: RFC 3339
+	Served    string `json:"served,omitempty"`
+}
+
+// pushCursorFromKey reads a position from a query's LastEvaluatedKey, or nil at the end
+func pushCursorFromKey(key map[string]types.AttributeValue) *pushCursor {
+	postID, _ := key["post_id"].(*types.AttributeValueMemberS)
+	createdAt, _ := key["created_at"].(*types.AttributeValueMemberS)
+	if postID == nil || createdAt == nil {
+		return nil
+	}
+	return &pushCursor{PostID: postID.Value, CreatedAt: createdAt.Value}
+}
+
+// pushCursorAfter returns the position just after a post served from the pushed timeline
+func pushCursorAfter(post models.TimelinePost) *pushCursor {
+	return &pushCursor{PostID: post.EntryID, CreatedAt: post.CreatedAt.Format(time.RFC3339), Served: post.PostID}
+}
+
+// startKey is the ExclusiveStartKey that resumes a user's timeline query after c
+func (c *pushCursor) startKey(userID int64) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"post_id":    &types.AttributeValueMemberS{Value: c.PostID},
+		"user_id":    &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", userID)},
+		"created_at": &types.AttributeValueMemberS{Value: c.CreatedAt},
+	}
+}
+
+// pullCursor is the creation time and ID of the last post served; the next page holds
+// posts that sort after it in newerThan order
+type pullCursor struct {
+	CreatedAt int64  `json:"t"` // Unix seconds, as the Post Service stores it
+	PostID    string `json:"id"`
+}
+
+// pullCursorAfter returns the position just after a post served by the pull strategy
+func pullCursorAfter(post models.TimelinePost) *pullCursor {
+	return &pullCursor{CreatedAt: post.CreatedAt.Unix(), PostID: post.PostID}
+}
+
+// post returns the cursor position as a post, for comparing with newerThan
+func (c *pullCursor) post() models.TimelinePost {
+	return models.TimelinePost{PostID: c.PostID, CreatedAt: time.Unix(c.CreatedAt, 0)}
+}
+
+// hybridCursor holds each branch's position. A branch is done once every post it
+// returned has been served and it reported no further page; done branches are not read again.
+type hybridCursor struct {
+	Push     *pushCursor `json:"push,omitempty"`
+	Pull     *pullCursor `json:"pull,omitempty"`
+	PushDone bool        `json:"push_done,omitempty"`
+	PullDone bool        `json:"pull_done,omitempty"`
+}
+
+// newerThan orders timelines: newest first, with ties broken by post ID so that
+// paging splits posts created in the same second consistently
+func newerThan(a, b models.TimelinePost) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.PostID > b.PostID
+}
@@ -0,0 +1,161 @@
+package fanout
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/fanout/fanouttest"
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	positions := []struct {
+		strategy string
+		position any
+		decoded  any // Zero value to decode into
+	}{
+		{"push", &pushCursor{PostID: "e7", CreatedAt: "2023-11-14T22:13:20Z", Served: "p7"}, &pushCursor{}},
+		{"pull", &pullCursor{CreatedAt: 1_700_000_000, PostID: "p7"}, &pullCursor{}},
+		{"hybrid", &hybridCursor{Push: &pushCursor{PostID: "e7", CreatedAt: "2023-11-14T22:13:20Z"}, PullDone: true}, &hybridCursor{}},
+	}
+	for _, p := range positions {
+		cursor := encodeCursor(p.strategy, p.position)
+		if err := decodeCursor(cursor, p.strategy, p.decoded); err != nil {
+			t.Errorf("%s: decode: %v", p.strategy, err)
+			continue
+		}
+		if !reflect.DeepEqual(p.decoded, p.position) {
+			t.Errorf("%s: decoded %+v, want %+v", p.strategy, p.decoded, p.position)
+		}
+	}
+}
+
+func TestMalformedCursorsAreRejected(t *testing.T) {
+	_, client := fanouttest.NewTimelineTable(t)
+	social := &fakeFollowing{following: []int64{2}}
+	posts := &pagedPosts{}
+	strategies := []Strategy{
+		NewPushStrategy(client, "timeline", 0, false),
+		NewPullStrategy(posts, social, 1, 0, 0, false),
+		NewHybridStrategy(client, "timeline", posts, social, 0, false, 0, 1, 0, 0, false),
+	}
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	for _, strategy := range strategies {
+		other := "pull"
+		if strategy.GetName() == "pull" {
+			other = "push"
+		}
+		cursors := map[string]string{
+			"not base64":             "not a cursor!",
+			"padded base64":          base64.URLEncoding.EncodeToString([]byte(`{"s":"push","p":{}}`)),
+			"not JSON":               encode("push"),
+			"another strategy's":     encodeCursor(other, &pullCursor{CreatedAt: 1, PostID: "p1"}),
+			"position of wrong type": encode(fmt.Sprintf(`{"s":%q,"p":"p1"}`, strategy.GetName())),
+		}
+		for name, cursor := range cursors {
+			_, err := strategy.GetTimeline(context.Background(), 1, 10, cursor)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("%s with %s cursor: err = %v, want ErrInvalidCursor", strategy.GetName(), name, err)
+			}
+		}
+	}
+}
+
+// pagedPosts serves a fixed set of posts per user, newest first, honouring the limit and
+// before bound of BatchGetPosts as the Post Service does
+type pagedPosts struct {
+	byUser map[int64][]models.TimelinePost
+}
+
+func (p *pagedPosts) BatchGetPosts(ctx context.Context, userIDs []int64, limit int32, before int64) (map[int64][]models.TimelinePost, error) {
+	result := make(map[int64][]models.TimelinePost, len(userIDs))
+	for _, userID := range userIDs {
+		posts := slices.Clone(p.byUser[userID])
+		slices.SortFunc(posts, func(a, b models.TimelinePost) int {
+			if newerThan(a, b) {
+				return -1
+			}
+			return 1
+		})
+		for _, post := range posts {
+			if before > 0 && post.CreatedAt.Unix() >= before {
+				continue
+			}
+			if len(result[userID]) == int(limit) {
+				break
+			}
+			result[userID] = append(result[userID], post)
+		}
+	}
+	return result, nil
+}
+
+func (p *pagedPosts) GetWriteStrategy(ctx context.Context) (string, error) {
+	return "hybrid", nil
+}
+
+// authoredPosts returns posts by author, named by ID, created at the given seconds after
+// the base time seedTimeline uses
+func authoredPosts(author int64, posts map[string]int) []models.TimelinePost {
+	base := time.Unix(1_700_000_000, 0).UTC()
+	var timeline []models.TimelinePost
+	for id, at := range posts {
+		timeline = append(timeline, models.TimelinePost{PostID: id, AuthorID: author, CreatedAt: base.Add(time.Duration(at) * time.Second)})
+	}
+	return timeline
+}
+
+func TestPullPagesAcrossBoundaries(t *testing.T) {
+	// Three followed users, with several posts sharing a second across and within users
+	posts := &pagedPosts{byUser: map[int64][]models.TimelinePost{
+		2: authoredPosts(2, map[string]int{"a9": 9, "a7": 7, "a5": 5, "a5b": 5, "a1": 1}),
+		3: authoredPosts(3, map[string]int{"b8": 8, "b7": 7, "b5": 5, "b2": 2}),
+		4: authoredPosts(4, map[string]int{"c7": 7, "c3": 3}),
+	}}
+	want := []string{"a9", "b8", "c7", "b7", "a7", "b5", "a5b", "a5", "c3", "b2", "a1"}
+	social := &fakeFollowing{following: []int64{2, 3, 4}}
+
+	for _, postsPerUser := range []int32{0, 2} { // 0 fetches a page's worth per user
+		for _, limit := range []int{1, 2, 3, 4, 11, 20} {
+			pull := NewPullStrategy(posts, social, 1, postsPerUser, 0, false)
+			pages := readAllPages(t, pull, 1, limit)
+			if got := slices.Concat(pages...); !slices.Equal(got, want) {
+				t.Errorf("postsPerUser %d, limit %d: pages %v, want the posts %v once each in order", postsPerUser, limit, pages, want)
+			}
+			for i, page := range pages {
+				if len(page) > limit {
+					t.Errorf("postsPerUser %d, limit %d: page %d holds %d posts", postsPerUser, limit, i+1, len(page))
+				}
+			}
+		}
+	}
+}
+
+func TestHybridMergesAcrossPages(t *testing.T) {
+	_, client := fanouttest.NewTimelineTable(t)
+	// Pushed posts from a celebrity-free follow list; h6 was also pushed and is pulled again
+	posts := &pagedPosts{byUser: map[int64][]models.TimelinePost{
+		2: authoredPosts(2, map[string]int{"h9": 9, "h6": 6, "h4": 4, "h4b": 4, "h1": 1}),
+	}}
+	social := &fakeFollowing{following: []int64{2}}
+	hybrid := NewHybridStrategy(client, "timeline", posts, social, 0, false, 0, 1, 2, 0, false)
+	seedTimeline(t, hybrid.pushStrategy, 1, []timelineEntry{
+		{"s10", "s10", 10}, {"s8", "s8", 8}, {"s7", "s7a", 7}, {"s7", "s7b", 7},
+		{"h6", "h6", 6}, {"s5", "s5", 5}, {"s4", "s4", 4}, {"s2", "s2", 2},
+	})
+	want := []string{"s10", "h9", "s8", "s7", "h6", "s5", "s4", "h4b", "h4", "s2", "h1"}
+
+	for _, limit := range []int{1, 2, 3, 5, 20} {
+		pages := readAllPages(t, hybrid, 1, limit)
+		if got := slices.Concat(pages...); !slices.Equal(got, want) {
+			t.Errorf("limit %d: pages %v, want the posts %v once each in order", limit, pages, want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package fanout
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// decodeFailures counts DynamoDB items that could not be decoded since startup
+var decodeFailures atomic.Int64
+
+// DecodeFailures returns the number of items skipped due to decode errors
+func DecodeFailures() int64 {
+	return decodeFailures.Load()
+}
+
+// decodeTimelinePosts unmarshals items one by one so a single bad item does not
+// fail the whole page. It returns the decoded posts and how many were skipped.
+func decodeTimelinePosts(items []map[string]types.AttributeValue, context string) ([]models.TimelinePost, int) {
+	posts := make([]models.TimelinePost, 0, len(items))
+	skipped := 0
+	for i, item := range items {
+		var post models.TimelinePost
+		if err := attributevalue.UnmarshalMap(item, &post); err != nil {
+			skipped++
+			log.Printf("[Decode] %s: skipping item %d (post_id=%s): %v", context, i, describeKey(item, "post_id"), err)
+			continue
+		}
+		if post.PostID == "" {
+			post.PostID = post.EntryID
+		}
+		posts = append(posts, post)
+	}
+
+	if skipped > 0 {
+		decodeFailures.Add(int64(skipped))
+		log.Printf("[Decode] %s: skipped %d of %d items", context, skipped, len(items))
+	}
+	return posts, skipped
+}
+
+// describeKey renders a key attribute for log messages
+func describeKey(item map[string]types.AttributeValue, name string) string {
+	switch v := item[name].(type) {
+	case *types.AttributeValueMemberN:
+		return v.Value
+	case *types.AttributeValueMemberS:
+		return v.Value
+	case nil:
+		return "<missing>"
+	default:
+		return fmt.Sprintf("<%T>", v)
+	}
+}
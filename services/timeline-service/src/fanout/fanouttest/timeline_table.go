@@ -0,0 +1,167 @@
+// Package fanouttest provides an in-memory DynamoDB timeline table for tests of the
+// fan-out strategies and the code that drives them.
+package fanouttest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// TimelineTable serves the BatchWriteItem and UserPostsIndex Query calls the push
+// strategy makes, over HTTP, as DynamoDB would. Items are kept in wire form, keyed by
+// post_id. Like DynamoDB, a Query that stops at its Limit returns a LastEvaluatedKey
+// even when no items follow.
+type TimelineTable struct {
+	mu      sync.Mutex
+	items   map[string]map[string]json.RawMessage
+	queries int
+}
+
+type attr struct {
+	S string `json:",omitempty"`
+	N string `json:",omitempty"`
+}
+
+// NewTimelineTable returns an empty table and a client for it
+func NewTimelineTable(t testing.TB) (*TimelineTable, *dynamodb.Client) {
+	t.Helper()
+	table := &TimelineTable{items: make(map[string]map[string]json.RawMessage)}
+	server := httptest.NewServer(table)
+	t.Cleanup(server.Close)
+	return table, dynamodb.New(dynamodb.Options{
+		Region:           "us-west-2",
+		BaseEndpoint:     aws.String(server.URL),
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+	})
+}
+
+// Len returns the number of items held
+func (f *TimelineTable) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.items)
+}
+
+// Queries returns the number of Query calls served
+func (f *TimelineTable) Queries() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.queries
+}
+
+func (f *TimelineTable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch target := r.Header.Get("X-Amz-Target"); {
+	case strings.HasSuffix(target, ".BatchWriteItem"):
+		f.batchWrite(w, r)
+	case strings.HasSuffix(target, ".Query"):
+		f.queries++
+		f.query(w, r)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazon.coral.validate#ValidationException", "message": "unsupported: " + target})
+	}
+}
+
+func (f *TimelineTable) batchWrite(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RequestItems map[string][]struct {
+			PutRequest    *struct{ Item map[string]json.RawMessage }
+			DeleteRequest *struct{ Key map[string]json.RawMessage }
+		}
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	for _, writes := range req.RequestItems {
+		for _, write := range writes {
+			switch {
+			case write.PutRequest != nil:
+				f.items[value(write.PutRequest.Item, "post_id").S] = write.PutRequest.Item
+			case write.DeleteRequest != nil:
+				delete(f.items, value(write.DeleteRequest.Key, "post_id").S)
+			}
+		}
+	}
+	w.Write([]byte(`{"UnprocessedItems":{}}`))
+}
+
+func (f *TimelineTable) query(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IndexName                 string
+		ExpressionAttributeValues map[string]attr
+		ExclusiveStartKey         map[string]json.RawMessage
+		Limit                     *int32
+		ScanIndexForward          *bool
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	if req.IndexName != "UserPostsIndex" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazon.coral.validate#ValidationException", "message": "unsupported index " + req.IndexName})
+		return
+	}
+	userID := req.ExpressionAttributeValues[":userId"].N
+
+	var items []map[string]json.RawMessage
+	for _, item := range f.items {
+		if value(item, "user_id").N == userID {
+			items = append(items, item)
+		}
+	}
+	// Index order is created_at; items sharing one are ordered by post_id here
+	forward := req.ScanIndexForward == nil || *req.ScanIndexForward
+	before := func(a, b map[string]json.RawMessage) bool {
+		ka, kb := indexKey(a), indexKey(b)
+		if forward {
+			return ka < kb
+		}
+		return ka > kb
+	}
+	sort.Slice(items, func(i, j int) bool { return before(items[i], items[j]) })
+
+	if req.ExclusiveStartKey != nil {
+		start := 0
+		for start < len(items) && !before(req.ExclusiveStartKey, items[start]) {
+			start++
+		}
+		items = items[start:]
+	}
+
+	response := map[string]any{}
+	if req.Limit != nil && *req.Limit > 0 && len(items) >= int(*req.Limit) {
+		items = items[:*req.Limit]
+		last := items[len(items)-1]
+		response["LastEvaluatedKey"] = map[string]json.RawMessage{
+			"post_id": last["post_id"], "user_id": last["user_id"], "created_at": last["created_at"],
+		}
+	}
+	if items == nil {
+		items = []map[string]json.RawMessage{}
+	}
+	response["Items"] = items
+	response["Count"] = len(items)
+	response["ScannedCount"] = len(items)
+	json.NewEncoder(w).Encode(response)
+}
+
+// indexKey orders items as UserPostsIndex does: by created_at, then post_id.
+// created_at is RFC 3339 in UTC, so it sorts as a string.
+func indexKey(item map[string]json.RawMessage) string {
+	return value(item, "created_at").S + "\x00" + value(item, "post_id").S
+}
+
+func value(item map[string]json.RawMessage, name string) attr {
+	var a attr
+	json.Unmarshal(item[name], &a)
+	return a
+}
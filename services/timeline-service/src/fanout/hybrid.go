@@ -2,6 +2,7 @@ package fanout
 
 import (
 	"container/heap"
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -14,12 +15,16 @@ import (
 type HybridStrategy struct {
 	pushStrategy *PushStrategy
 	pullStrategy *PullStrategy
+	allowPartial bool // serve one branch with a warning when the other fails
+	maxMerged    int  // posts held while merging the two branches; 0 = the timeline limit
 }
 
-func NewHybridStrategy(dynamoClient *dynamodb.Client, postsTableName string, postServiceClient grpc.PostServiceClient, socialGraphServiceClient grpc.SocialGraphServiceClient) *HybridStrategy {
+func NewHybridStrategy(dynamoClient *dynamodb.Client, postsTableName string, postServiceClient grpc.PostServiceClient, socialGraphServiceClient grpc.SocialGraphServiceClient, entryTTL time.Duration, allowPartial bool, maxMerged int, pullWorkers int, pullPostsPerUser int32, pullMaxFollowing int, includeOwn bool) *HybridStrategy {
 	return &HybridStrategy{
-		pushStrategy: NewPushStrategy(dynamoClient, postsTableName),
-		pullStrategy: NewPullStrategy(postServiceClient, socialGraphServiceClient),
+		pushStrategy: NewPushStrategy(dynamoClient, postsTableName, entryTTL, includeOwn),
+		pullStrategy: NewPullStrategy(postServiceClient, socialGraphServiceClient, pullWorkers, pullPostsPerUser, pullMaxFollowing, includeOwn),
+		allowPartial: allowPartial,
+		maxMerged:    maxMerged,
 	}
 }
 
@@ -29,16 +34,33 @@ func (s *HybridStrategy) GetName() string {
 
 // FanoutPost uses push strategy to store posts in DynamoDB cache
 // In hybrid mode, we always cache posts for quick access while also supporting on-demand fetching
-func (s *HybridStrategy) FanoutPost(req *models.FanoutRequest, followerIDs []int64) error {
+func (s *HybridStrategy) FanoutPost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error {
 	// Use push strategy to cache the post in followers' timelines for fast access
-	return s.pushStrategy.FanoutPost(req, followerIDs)
+	return s.pushStrategy.FanoutPost(ctx, req, followerIDs)
 }
 
-// GetTimeline implements hybrid approach: concurrently fetch from both strategies and merge results
-func (s *HybridStrategy) GetTimeline(userID int64, limit int) (*models.TimelineResponse, error) {
+// RemovePost removes the copies FanoutPost pushed
+func (s *HybridStrategy) RemovePost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error {
+	return s.pushStrategy.RemovePost(ctx, req, followerIDs)
+}
+
+// GetTimeline implements hybrid approach: concurrently fetch from both strategies and merge results.
+// The cursor is composite, holding each branch's own position: a branch resumes after the
+// last of its posts that made it into the merged page, so posts merged out of one page
+// are served on the next.
+func (s *HybridStrategy) GetTimeline(ctx context.Context, userID int64, limit int, cursor string) (*models.TimelineResponse, error) {
+	var position hybridCursor
+	if cursor != "" {
+		if err := decodeCursor(cursor, s.GetName(), &position); err != nil {
+			return nil, err
+		}
+	}
+
 	// Use channels to collect results from both strategies concurrently
 	type result struct {
 		timeline *models.TimelineResponse
+		push     *pushCursor
+		pull     *pullCursor
 		err      error
 		source   string
 		duration time.Duration
@@ -47,20 +69,28 @@ func (s *HybridStrategy) GetTimeline(userID int64, limit int) (*models.TimelineR
 	pushChan := make(chan result, 1)
 	pullChan := make(chan result, 1)
 
-	// Execute push strategy concurrently (fetch from database)
+	// Execute push strategy concurrently (fetch from database); a finished branch is not read again
 	go func() {
+		if position.PushDone {
+			pushChan <- result{source: "push"}
+			return
+		}
 		startTime := time.Now()
-		timeline, err := s.pushStrategy.GetTimeline(userID, limit)
+		timeline, next, err := s.pushStrategy.readTimeline(ctx, userID, limit, position.Push)
 		duration := time.Since(startTime)
-		pushChan <- result{timeline: timeline, err: err, source: "push", duration: duration}
+		pushChan <- result{timeline: timeline, push: next, err: err, source: "push", duration: duration}
 	}()
 
 	// Execute pull strategy concurrently (fetch from gRPC)
 	go func() {
+		if position.PullDone {
+			pullChan <- result{source: "pull"}
+			return
+		}
 		startTime := time.Now()
-		timeline, err := s.pullStrategy.GetTimeline(userID, limit)
+		timeline, next, err := s.pullStrategy.readTimeline(ctx, userID, limit, position.Pull)
 		duration := time.Since(startTime)
-		pullChan <- result{timeline: timeline, err: err, source: "pull", duration: duration}
+		pullChan <- result{timeline: timeline, pull: next, err: err, source: "pull", duration: duration}
 	}()
 
 	// Wait for both results
@@ -91,7 +121,74 @@ func (s *HybridStrategy) GetTimeline(userID int64, limit int) (*models.TimelineR
 		}())
 
 	// Merge results - combine posts from both strategies
-	return s.mergeTimelines(pushResult.timeline, pullResult.timeline, pushResult.err, pullResult.err, limit)
+	merged, err := s.mergeTimelines(pushResult.timeline, pullResult.timeline, pushResult.err, pullResult.err, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// A branch with posts beyond those it returned bounds the page at its last one, as the
+	// pull horizon does: older posts from the other branch could skip ahead of its next ones
+	var horizon *models.TimelinePost
+	for _, end := range []*models.TimelinePost{branchEnd(pushResult.timeline, pushResult.push != nil), branchEnd(pullResult.timeline, pullResult.pull != nil)} {
+		if end != nil && (horizon == nil || newerThan(*end, *horizon)) {
+			horizon = end
+		}
+	}
+	for len(merged.Timeline) > 0 && horizon != nil && newerThan(*horizon, merged.Timeline[len(merged.Timeline)-1]) {
+		merged.Timeline = merged.Timeline[:len(merged.Timeline)-1]
+	}
+	merged.TotalCount = len(merged.Timeline)
+
+	// Advance each branch past the posts it contributed. A branch that failed keeps its
+	// position, so a partial page is followed by that branch's posts from where it was.
+	served := make(map[string]bool, len(merged.Timeline))
+	for _, post := range merged.Timeline {
+		served[post.PostID] = true
+	}
+	next := position
+	if pushResult.err == nil && !position.PushDone {
+		if last, all := servedPrefix(pushResult.timeline, served); all {
+			next.Push, next.PushDone = pushResult.push, pushResult.push == nil
+		} else if last != nil {
+			next.Push = pushCursorAfter(*last)
+		}
+	}
+	if pullResult.err == nil && !position.PullDone {
+		if last, all := servedPrefix(pullResult.timeline, served); all {
+			next.Pull, next.PullDone = pullResult.pull, pullResult.pull == nil
+		} else if last != nil {
+			next.Pull = pullCursorAfter(*last)
+		}
+	}
+	if !next.PushDone || !next.PullDone {
+		merged.NextCursor = encodeCursor(s.GetName(), next)
+	}
+	return merged, nil
+}
+
+// branchEnd returns the last post of a branch's timeline when more follow it, or nil
+func branchEnd(timeline *models.TimelineResponse, more bool) *models.TimelinePost {
+	if timeline == nil || !more || len(timeline.Timeline) == 0 {
+		return nil
+	}
+	return &timeline.Timeline[len(timeline.Timeline)-1]
+}
+
+// servedPrefix returns the last post of a branch's timeline, in order, up to which every
+// post was served, and whether that was all of them. Both branches return posts newest
+// first, so merging keeps a prefix of each.
+func servedPrefix(timeline *models.TimelineResponse, served map[string]bool) (*models.TimelinePost, bool) {
+	if timeline == nil {
+		return nil, true
+	}
+	var last *models.TimelinePost
+	for i := range timeline.Timeline {
+		if !served[timeline.Timeline[i].PostID] {
+			return last, false
+		}
+		last = &timeline.Timeline[i]
+	}
+	return last, true
 }
 
 // mergeTimelines combines results from push and pull strategies
@@ -101,67 +198,75 @@ func (s *HybridStrategy) mergeTimelines(pushTimeline, pullTimeline *models.Timel
 		return nil, fmt.Errorf("both strategies failed - push: %v, pull: %v", pushErr, pullErr)
 	}
 
-	// If only one strategy succeeded, return its result
+	// If only one strategy succeeded, return its result flagged as partial,
+	// or fail outright when partial results are disabled
 	if pushErr != nil && pullErr == nil {
+		if !s.allowPartial {
+			return nil, fmt.Errorf("push strategy failed: %w", pushErr)
+		}
+		log.Printf("[HYBRID] push branch failed, serving pull results only: %v", pushErr)
+		pullTimeline.AddWarning("Cached timeline unavailable, showing live posts only")
 		return pullTimeline, nil
 	}
 	if pullErr != nil && pushErr == nil {
-		return pushTimeline, nil
-	}
-
-	// Both strategies succeeded - merge their timelines using heap for efficient top-k selection
-	postMap := make(map[string]models.TimelinePost) // Use map to deduplicate by PostID
-
-	// Add posts from push strategy (cached posts)
-	if pushTimeline != nil {
-		for _, post := range pushTimeline.Timeline {
-			postMap[post.PostID] = post
+		if !s.allowPartial {
+			return nil, fmt.Errorf("pull strategy failed: %w", pullErr)
 		}
+		log.Printf("[HYBRID] pull branch failed, serving push results only: %v", pullErr)
+		pushTimeline.AddWarning("Live posts unavailable, showing cached timeline only")
+		return pushTimeline, nil
 	}
 
-	// Add posts from pull strategy (real-time posts) - these will overwrite cached ones if duplicate
-	if pullTimeline != nil {
-		for _, post := range pullTimeline.Timeline {
-			postMap[post.PostID] = post
-		}
+	// Both strategies succeeded - stream both timelines through a min-heap for top-k selection.
+	// Only posts currently in the heap are kept, so memory is bounded by its size no matter
+	// how many posts the branches return. The heap holds the timeline limit, or maxMerged
+	// when that is smaller; in that case the result is an approximation holding only the
+	// newest maxMerged posts, even if more than that would fit the requested limit.
+	size := limit
+	if s.maxMerged > 0 && s.maxMerged < size {
+		size = s.maxMerged
 	}
-
-	// Use min-heap to efficiently select top limit posts by creation time
 	postHeap := &PostHeap{}
 	heap.Init(postHeap)
+	inHeap := make(map[string]models.TimelinePost, size) // Deduplicates by PostID
 
-	for _, post := range postMap {
-		if postHeap.Len() < limit {
-			// Heap not full, add the post
-			heap.Push(postHeap, post)
-		} else if post.CreatedAt.After((*postHeap)[0].CreatedAt) {
-			// Post is newer than oldest in heap, replace oldest
-			heap.Pop(postHeap)
-			heap.Push(postHeap, post)
+	merge := func(timeline *models.TimelineResponse) {
+		if timeline == nil {
+			return
+		}
+		for _, post := range timeline.Timeline {
+			if _, ok := inHeap[post.PostID]; ok {
+				// Later branches (pull, real-time) overwrite cached copies of the same post
+				inHeap[post.PostID] = post
+				continue
+			}
+			if postHeap.Len() < size {
+				// Heap not full, add the post
+				heap.Push(postHeap, post)
+			} else if newerThan(post, (*postHeap)[0]) {
+				// Post is newer than oldest in heap, replace oldest
+				delete(inHeap, heap.Pop(postHeap).(models.TimelinePost).PostID)
+				heap.Push(postHeap, post)
+			} else {
+				continue
+			}
+			inHeap[post.PostID] = post
 		}
 	}
+	merge(pushTimeline) // Cached posts
+	merge(pullTimeline) // Real-time posts
 
 	// Extract posts from heap and store them, then reverse in place
 	heapSize := postHeap.Len()
 	mergedPosts := make([]models.TimelinePost, heapSize)
 	for i := heapSize - 1; i >= 0; i-- {
-		mergedPosts[i] = heap.Pop(postHeap).(models.TimelinePost)
-	}
-
-	// Calculate total count
-	totalCount := len(mergedPosts)
-	if pushTimeline != nil && pullTimeline != nil {
-		// Use the maximum count from both strategies as an approximation
-		if pushTimeline.TotalCount > totalCount {
-			totalCount = pushTimeline.TotalCount
-		}
-		if pullTimeline.TotalCount > totalCount {
-			totalCount = pullTimeline.TotalCount
-		}
+		mergedPosts[i] = inHeap[heap.Pop(postHeap).(models.TimelinePost).PostID]
 	}
 
+	// TotalCount is the number of posts returned, as for the other strategies: the
+	// branches' counts overlap and neither is a total available, so they are not combined
 	return &models.TimelineResponse{
 		Timeline:   mergedPosts,
-		TotalCount: totalCount,
+		TotalCount: len(mergedPosts),
 	}, nil
 }
@@ -1,6 +1,8 @@
 package fanout
 
 import (
+	"context"
+
 	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
 )
 
@@ -9,9 +11,33 @@ type Strategy interface {
 	// GetName returns the strategy name
 	GetName() string
 
-	// FanoutPost distributes a post to followers' timelines
-	FanoutPost(req *models.FanoutRequest, followerIDs []int64) error
+	// FanoutPost distributes a post to followers' timelines; writes are bound to ctx
+	FanoutPost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error
+
+	// RemovePost deletes a post's copies from followers' timelines; copies that do not exist are ignored
+	RemovePost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error
+
+	// GetTimeline retrieves a page of the timeline for a user, starting after cursor
+	// (empty for the first page). The response's NextCursor continues it, and is empty
+	// on the last page; cursors the strategy cannot read fail with ErrInvalidCursor.
+	// Downstream calls are bound to ctx, so a cancelled request stops them
+	GetTimeline(ctx context.Context, userID int64, limit int, cursor string) (*models.TimelineResponse, error)
+}
 
-	// GetTimeline retrieves the timeline for a user
-	GetTimeline(userID int64, limit int) (*models.TimelineResponse, error)
+// CompatibleWithWriteStrategy reports whether timelines read with readStrategy see
+// posts written by the Post Service with writeStrategy (its POST_STRATEGY)
+func CompatibleWithWriteStrategy(readStrategy, writeStrategy string) bool {
+	switch readStrategy {
+	case "hybrid":
+		// Hybrid merges the pushed timeline with a pull over the full following list,
+		// so it sees posts however they were written
+		return true
+	case "pull":
+		// Pull reads the posts table, which pull and hybrid writers keep every post in
+		// (hybrid's pushed posts through PUSH_WRITE_THROUGH, on by default)
+		return writeStrategy == "pull" || writeStrategy == "hybrid"
+	default:
+		// Push reads only the pushed timeline, which only a push writer fills completely
+		return readStrategy == writeStrategy
+	}
 }
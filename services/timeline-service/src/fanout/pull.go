@@ -5,17 +5,19 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/grpc"
 	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
 )
 
 // PostHeap implements heap.Interface for models.TimelinePost
-// This is a min-heap based on creation time (oldest posts at top)
+// This is a min-heap in timeline order (oldest posts at top)
 type PostHeap []models.TimelinePost
 
 func (h PostHeap) Len() int           { return len(h) }
-func (h PostHeap) Less(i, j int) bool { return h[i].CreatedAt.Before(h[j].CreatedAt) } // Min-heap: oldest first
+func (h PostHeap) Less(i, j int) bool { return newerThan(h[j], h[i]) } // Min-heap: oldest first
 func (h PostHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
 
 func (h *PostHeap) Push(x interface{}) {
@@ -30,15 +32,30 @@ func (h *PostHeap) Pop() interface{} {
 	return x
 }
 
+// pullReadTimeout bounds the Social Graph and Post Service calls behind one pull read
+const pullReadTimeout = 5 * time.Second
+
+// pullFetchChunkSize is how many followed users each BatchGetPosts call covers.
+// Smaller chunks let the heap start filling sooner at the cost of more calls.
+const pullFetchChunkSize = 25
+
 type PullStrategy struct {
 	postServiceClient        grpc.PostServiceClient
 	socialGraphServiceClient grpc.SocialGraphServiceClient
+	fetchWorkers             int   // BatchGetPosts calls in flight per timeline read
+	postsPerUser             int32 // Posts fetched per followed user; 0 uses the timeline limit (min 10)
+	maxFollowing             int   // Followed users considered per read; 0 = all
+	includeOwn               bool  // also pull the user's own posts
 }
 
-func NewPullStrategy(postServiceClient grpc.PostServiceClient, socialGraphServiceClient grpc.SocialGraphServiceClient) *PullStrategy {
+func NewPullStrategy(postServiceClient grpc.PostServiceClient, socialGraphServiceClient grpc.SocialGraphServiceClient, fetchWorkers int, postsPerUser int32, maxFollowing int, includeOwn bool) *PullStrategy {
 	return &PullStrategy{
 		postServiceClient:        postServiceClient,
 		socialGraphServiceClient: socialGraphServiceClient,
+		fetchWorkers:             fetchWorkers,
+		postsPerUser:             postsPerUser,
+		maxFollowing:             maxFollowing,
+		includeOwn:               includeOwn,
 	}
 }
 
@@ -47,19 +64,54 @@ func (s *PullStrategy) GetName() string {
 }
 
 // FanoutPost does nothing for pull strategy - posts are not pre-distributed
-func (s *PullStrategy) FanoutPost(req *models.FanoutRequest, followerIDs []int64) error {
+func (s *PullStrategy) FanoutPost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error {
 	// No fan-out needed for pull strategy
 	return nil
 }
 
-// GetTimeline retrieves posts from followed users in real-time via gRPC calls
-func (s *PullStrategy) GetTimeline(userID int64, limit int) (*models.TimelineResponse, error) {
-	ctx := context.Background()
+// RemovePost does nothing for pull strategy - there are no copies to remove
+func (s *PullStrategy) RemovePost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error {
+	return nil
+}
+
+// GetTimeline retrieves posts from followed users in real-time via gRPC calls.
+// The cursor is the creation time and ID of the last post served; later pages ask the
+// Post Service for posts created before it.
+func (s *PullStrategy) GetTimeline(ctx context.Context, userID int64, limit int, cursor string) (*models.TimelineResponse, error) {
+	var after *pullCursor
+	if cursor != "" {
+		after = &pullCursor{}
+		if err := decodeCursor(cursor, s.GetName(), after); err != nil {
+			return nil, err
+		}
+	}
+
+	response, next, err := s.readTimeline(ctx, userID, limit, after)
+	if err != nil {
+		return nil, err
+	}
+	if next != nil {
+		response.NextCursor = encodeCursor(s.GetName(), next)
+	}
+	return response, nil
+}
+
+// readTimeline reads up to limit posts older than the given position (nil for the
+// newest), returning the position to continue from, or nil when no posts remain
+func (s *PullStrategy) readTimeline(ctx context.Context, userID int64, limit int, after *pullCursor) (*models.TimelineResponse, *pullCursor, error) {
+	// Whichever comes first, the caller going away or the timeout, cancels the calls below
+	ctx, cancel := context.WithTimeout(ctx, pullReadTimeout)
+	defer cancel()
 
 	// Step 1: Get list of users this user follows from Social Graph Service
-	followingList, err := s.socialGraphServiceClient.GetFollowing(ctx, userID)
+	// Capped so power users don't turn one read into thousands of post lookups
+	followingList, err := s.socialGraphServiceClient.GetFollowingUpTo(ctx, userID, s.maxFollowing)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get following list from Social Graph Service: %w", err)
+		return nil, nil, fmt.Errorf("failed to get following list from Social Graph Service: %w", err)
+	}
+	// Matches push, which writes the author's own timeline when includeOwn is set
+	if s.includeOwn {
+		followingList = withUser(followingList, userID)
 	}
 
 	// If user doesn't follow anyone, return empty timeline
@@ -67,48 +119,105 @@ func (s *PullStrategy) GetTimeline(userID int64, limit int) (*models.TimelineRes
 		return &models.TimelineResponse{
 			Timeline:   []models.TimelinePost{},
 			TotalCount: 0,
-		}, nil
+		}, nil, nil
 	}
 
 	// Step 2: Get recent posts from each followed user via Post Service
 	// Request more posts per user to ensure we have enough for sorting and limiting
-	postsPerUser := int32(limit) // Request 'limit' posts from each user
-	if postsPerUser < 10 {
-		postsPerUser = 10 // Minimum 10 posts per user to ensure good coverage
+	postsPerUser := s.postsPerUser
+	if postsPerUser <= 0 {
+		postsPerUser = int32(limit) // Request 'limit' posts from each user
+		if postsPerUser < 10 {
+			postsPerUser = 10 // Minimum 10 posts per user to ensure good coverage
+		}
 	}
 
-	userPostsMap, err := s.postServiceClient.BatchGetPosts(ctx, followingList, postsPerUser)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get posts from Post Service: %w", err)
+	if limit <= 0 {
+		limit = 10 // Default to 10 if limit is invalid
 	}
 
-	// Step 3: Use heap to efficiently get the newest 'limit' posts
-	var topPosts []models.TimelinePost
+	// Later pages ask for posts up to and including the cursor's second, since
+	// timestamps are whole seconds, and drop those already served
+	var before int64
+	if after != nil {
+		before = after.CreatedAt + 1
+	}
+	topPosts, moreAvailable, err := s.newestPosts(ctx, followingList, postsPerUser, before, after, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Every post fetched from a user with more was served already: they posted over
+	// postsPerUser times in the cursor's second. Go on from the second before, skipping
+	// the rest of that second, rather than end the timeline here.
+	if len(topPosts) == 0 && moreAvailable && after != nil {
+		topPosts, moreAvailable, err = s.newestPosts(ctx, followingList, postsPerUser, after.CreatedAt, after, limit)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 
-	if limit <= 0 {
-		limit = 10 // Default to 10 if limit is invalid
+	// A full page may have more behind it; otherwise only users cut off by postsPerUser do
+	var next *pullCursor
+	if len(topPosts) > 0 && (len(topPosts) == limit || moreAvailable) {
+		next = pullCursorAfter(topPosts[len(topPosts)-1])
 	}
 
-	// Use a min-heap to maintain the top 'limit' newest posts
+	return &models.TimelineResponse{
+		Timeline:   topPosts,
+		TotalCount: len(topPosts),
+	}, next, nil
+}
+
+// newestPosts returns the newest posts, up to limit, that followingList posted before the
+// Unix time before (any time when 0) and after the position after, newest first, and
+// whether some user has posts beyond those fetched
+func (s *PullStrategy) newestPosts(ctx context.Context, followingList []int64, postsPerUser int32, before int64, after *pullCursor, limit int) ([]models.TimelinePost, bool, error) {
+	// Step 3: Use a min-heap to maintain the top 'limit' newest posts, filled
+	// as each chunk of followed users arrives rather than after all of them
 	minHeap := &PostHeap{}
 	heap.Init(minHeap)
 
-	// Process all posts from all users
-	for _, userPosts := range userPostsMap {
-		for _, post := range userPosts {
-			if minHeap.Len() < limit {
-				// Heap not full, add the post
-				heap.Push(minHeap, post)
-			} else if post.CreatedAt.After((*minHeap)[0].CreatedAt) {
-				// This post is newer than the oldest post in heap
-				heap.Pop(minHeap)        // Remove oldest
-				heap.Push(minHeap, post) // Add newer post
+	// A user who returned a full batch may have older posts beyond this page. The page
+	// stops at the oldest post fetched from such a user, the horizon: past it, that user's
+	// unfetched posts could be newer than posts served from others, and would be skipped.
+	moreAvailable := false
+	var horizon *models.TimelinePost
+
+	// Returning on a failed chunk stops outstanding fetches through the deferred cancel
+	for chunk := range s.streamPosts(ctx, followingList, postsPerUser, before) {
+		if chunk.err != nil {
+			return nil, false, fmt.Errorf("failed to get posts from Post Service: %w", chunk.err)
+		}
+		for _, userPosts := range chunk.posts {
+			full := len(userPosts) >= int(postsPerUser)
+			if full {
+				moreAvailable = true
+			}
+			var oldest *models.TimelinePost
+			for _, post := range userPosts {
+				if after != nil && !newerThan(after.post(), post) {
+					continue // Served on an earlier page
+				}
+				if full && (oldest == nil || newerThan(*oldest, post)) {
+					oldest = &post
+				}
+				if minHeap.Len() < limit {
+					// Heap not full, add the post
+					heap.Push(minHeap, post)
+				} else if newerThan(post, (*minHeap)[0]) {
+					// This post is newer than the oldest post in heap
+					heap.Pop(minHeap)        // Remove oldest
+					heap.Push(minHeap, post) // Add newer post
+				}
+			}
+			if oldest != nil && (horizon == nil || newerThan(*oldest, *horizon)) {
+				horizon = oldest
 			}
 		}
 	}
 
 	// Extract posts from heap and convert to slice
-	topPosts = make([]models.TimelinePost, minHeap.Len())
+	topPosts := make([]models.TimelinePost, minHeap.Len())
 	for i := len(topPosts) - 1; i >= 0; i-- {
 		topPosts[i] = heap.Pop(minHeap).(models.TimelinePost)
 	}
@@ -116,11 +225,59 @@ func (s *PullStrategy) GetTimeline(userID int64, limit int) (*models.TimelineRes
 	// Final sort of the top posts (newest first)
 	// This is efficient since we only sort 'limit' posts, not all posts
 	sort.Slice(topPosts, func(i, j int) bool {
-		return topPosts[i].CreatedAt.After(topPosts[j].CreatedAt)
+		return newerThan(topPosts[i], topPosts[j])
 	})
 
-	return &models.TimelineResponse{
-		Timeline:   topPosts,
-		TotalCount: len(topPosts),
-	}, nil
+	for len(topPosts) > 0 && horizon != nil && newerThan(*horizon, topPosts[len(topPosts)-1]) {
+		topPosts = topPosts[:len(topPosts)-1]
+	}
+	return topPosts, moreAvailable, nil
+}
+
+// pullChunk is the result of one BatchGetPosts call
+type pullChunk struct {
+	posts map[int64][]models.TimelinePost
+	err   error
+}
+
+// streamPosts fetches posts for userIDs in chunks of pullFetchChunkSize with up to
+// s.fetchWorkers calls in flight, sending each chunk as soon as it completes.
+// A positive before limits them to posts created before that Unix time.
+// The channel is closed once every chunk has been sent.
+func (s *PullStrategy) streamPosts(ctx context.Context, userIDs []int64, postsPerUser int32, before int64) <-chan pullChunk {
+	var chunks [][]int64
+	for i := 0; i < len(userIDs); i += pullFetchChunkSize {
+		chunks = append(chunks, userIDs[i:min(i+pullFetchChunkSize, len(userIDs))])
+	}
+
+	jobs := make(chan []int64, len(chunks))
+	for _, chunk := range chunks {
+		jobs <- chunk
+	}
+	close(jobs)
+
+	// Buffered for every chunk so workers never block if the reader stops early
+	results := make(chan pullChunk, len(chunks))
+
+	var wg sync.WaitGroup
+	for i := 0; i < min(max(s.fetchWorkers, 1), len(chunks)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				posts, err := s.postServiceClient.BatchGetPosts(ctx, chunk, postsPerUser, before)
+				results <- pullChunk{posts: posts, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
 }
@@ -0,0 +1,118 @@
+package fanout
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
+)
+
+// fakeFollowing follows a fixed list of users
+type fakeFollowing struct {
+	following []int64
+}
+
+func (f *fakeFollowing) GetFollowing(ctx context.Context, userID int64) ([]int64, error) {
+	return f.following, nil
+}
+
+func (f *fakeFollowing) GetFollowingUpTo(ctx context.Context, userID int64, maxFollowing int) ([]int64, error) {
+	if maxFollowing > 0 && maxFollowing < len(f.following) {
+		return f.following[:maxFollowing], nil
+	}
+	return f.following, nil
+}
+
+func (f *fakeFollowing) CheckFollowing(ctx context.Context, followerID int64, targetIDs []int64) (map[int64]bool, error) {
+	return map[int64]bool{}, nil
+}
+
+// slowPosts serves each user's posts after a fixed per-call latency, like a remote Post Service
+type slowPosts struct {
+	latency time.Duration
+}
+
+func (s *slowPosts) BatchGetPosts(ctx context.Context, userIDs []int64, limit int32, before int64) (map[int64][]models.TimelinePost, error) {
+	select {
+	case <-time.After(s.latency):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	base := time.Unix(1_700_000_000, 0).UTC()
+	posts := make(map[int64][]models.TimelinePost, len(userIDs))
+	for _, userID := range userIDs {
+		for i := int32(0); i < limit; i++ {
+			posts[userID] = append(posts[userID], models.TimelinePost{
+				PostID:    fmt.Sprintf("%d-%d", userID, i),
+				AuthorID:  userID,
+				CreatedAt: base.Add(time.Duration(userID*7+int64(i)*13) * time.Second),
+			})
+		}
+	}
+	return posts, nil
+}
+
+func (s *slowPosts) GetWriteStrategy(ctx context.Context) (string, error) {
+	return "pull", nil
+}
+
+func following(n int) []int64 {
+	ids := make([]int64, n)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	return ids
+}
+
+// Streaming chunks through several workers must pick the same page as one serial worker
+func TestPullStreamedMatchesSerial(t *testing.T) {
+	social := &fakeFollowing{following: following(200)}
+	posts := &slowPosts{}
+
+	serial, err := NewPullStrategy(posts, social, 1, 30, 0, false).GetTimeline(context.Background(), 1, 30, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamed, err := NewPullStrategy(posts, social, 8, 30, 0, false).GetTimeline(context.Background(), 1, 30, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(serial.Timeline) != 30 || len(streamed.Timeline) != len(serial.Timeline) {
+		t.Fatalf("got %d streamed and %d serial posts, want 30 each", len(streamed.Timeline), len(serial.Timeline))
+	}
+	for i := range serial.Timeline {
+		if streamed.Timeline[i].PostID != serial.Timeline[i].PostID {
+			t.Fatalf("post %d: streamed %s, serial %s", i, streamed.Timeline[i].PostID, serial.Timeline[i].PostID)
+		}
+		if i > 0 && newerThan(serial.Timeline[i], serial.Timeline[i-1]) {
+			t.Fatalf("post %d is newer than post %d", i, i-1)
+		}
+	}
+}
+
+// BenchmarkPullFetch compares one BatchGetPosts call at a time with calls streamed
+// into the heap from several workers, for a reader following 500 users
+func BenchmarkPullFetch(b *testing.B) {
+	social := &fakeFollowing{following: following(500)}
+	posts := &slowPosts{latency: 2 * time.Millisecond}
+
+	for _, bm := range []struct {
+		name    string
+		workers int
+	}{
+		{name: "serial", workers: 1},
+		{name: "streamed", workers: 8},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			s := NewPullStrategy(posts, social, bm.workers, 10, 0, false)
+			for i := 0; i < b.N; i++ {
+				if _, err := s.GetTimeline(context.Background(), 1, 50, ""); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -3,26 +3,33 @@ package fanout
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
+// maxUnprocessedRetries bounds how many times unprocessed batch items are resent
+const maxUnprocessedRetries = 3
+
 type PushStrategy struct {
 	dynamoClient   *dynamodb.Client
 	postsTableName string
 	batchSize      int
+	entryTTL       time.Duration // 0 keeps timeline entries forever
+	includeOwn     bool          // also write each post to its author's timeline
 }
 
-func NewPushStrategy(dynamoClient *dynamodb.Client, postsTableName string) *PushStrategy {
+func NewPushStrategy(dynamoClient *dynamodb.Client, postsTableName string, entryTTL time.Duration, includeOwn bool) *PushStrategy {
 	return &PushStrategy{
 		dynamoClient:   dynamoClient,
 		postsTableName: postsTableName,
 		batchSize:      25, // DynamoDB batch write limit
+		entryTTL:       entryTTL,
+		includeOwn:     includeOwn,
 	}
 }
 
@@ -30,8 +37,14 @@ func (s *PushStrategy) GetName() string {
 	return "push"
 }
 
-// FanoutPost writes the post to all followers' timelines
-func (s *PushStrategy) FanoutPost(req *models.FanoutRequest, followerIDs []int64) error {
+// FanoutPost writes the post to all followers' timelines, and to the author's own
+// when includeOwn is set
+func (s *PushStrategy) FanoutPost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error {
+	if s.includeOwn && !slices.Contains(followerIDs, req.AuthorID) {
+		if err := s.writeOwn(ctx, req); err != nil {
+			return fmt.Errorf("failed to write author's timeline: %w", err)
+		}
+	}
 	if len(followerIDs) == 0 {
 		return nil
 	}
@@ -44,7 +57,7 @@ func (s *PushStrategy) FanoutPost(req *models.FanoutRequest, followerIDs []int64
 		}
 
 		batch := followerIDs[i:end]
-		if err := s.writeBatch(req, batch); err != nil {
+		if err := s.writeBatch(ctx, req, batch); err != nil {
 			return fmt.Errorf("failed to write batch: %w", err)
 		}
 	}
@@ -52,43 +65,280 @@ func (s *PushStrategy) FanoutPost(req *models.FanoutRequest, followerIDs []int64
 	return nil
 }
 
-func (s *PushStrategy) writeBatch(req *models.FanoutRequest, followerIDs []int64) error {
+func (s *PushStrategy) writeBatch(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error {
 	writeRequests := make([]types.WriteRequest, 0, len(followerIDs))
 
-	// Use the create time from the request in ISO 8601 format
-	timeString := req.CreatedAt.Format(time.RFC3339)
-
 	for _, followerID := range followerIDs {
 		// Create timeline entry for each follower
-		timelinePostID := fmt.Sprintf("%s_%d", req.PostID, followerID)
-
-		item := map[string]types.AttributeValue{
-			"post_id":    &types.AttributeValueMemberS{Value: timelinePostID},
-			"user_id":    &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", followerID)},
-			"author_id":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", req.AuthorID)},
-			"username":   &types.AttributeValueMemberS{Value: req.AuthorName},
-			"content":    &types.AttributeValueMemberS{Value: req.Content},
-			"created_at": &types.AttributeValueMemberS{Value: timeString},
-		}
-
 		writeRequests = append(writeRequests, types.WriteRequest{
 			PutRequest: &types.PutRequest{
-				Item: item,
+				Item: s.timelineItem(req, followerID),
 			},
 		})
 	}
 
-	_, err := s.dynamoClient.BatchWriteItem(context.Background(), &dynamodb.BatchWriteItemInput{
-		RequestItems: map[string][]types.WriteRequest{
-			s.postsTableName: writeRequests,
-		},
+	return s.batchWrite(ctx, writeRequests)
+}
+
+// RemovePost deletes the post's entries from the followers' timelines and, when own
+// posts are included, from the author's. Entries are keyed on the post and timeline
+// owner, so no lookup is needed and deleting an entry that was never written is a no-op.
+func (s *PushStrategy) RemovePost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error {
+	keys := make([]string, 0, len(followerIDs)+1)
+	for _, followerID := range followerIDs {
+		keys = append(keys, timelineKey(req.PostID, followerID))
+	}
+	if s.includeOwn {
+		keys = append(keys, timelineKey(req.PostID, req.AuthorID))
+	}
+
+	for start := 0; start < len(keys); start += s.batchSize {
+		end := min(start+s.batchSize, len(keys))
+		deleteRequests := make([]types.WriteRequest, 0, end-start)
+		for _, key := range keys[start:end] {
+			deleteRequests = append(deleteRequests, types.WriteRequest{
+				DeleteRequest: &types.DeleteRequest{
+					Key: map[string]types.AttributeValue{
+						"post_id": &types.AttributeValueMemberS{Value: key},
+					},
+				},
+			})
+		}
+		if err := s.batchWrite(ctx, deleteRequests); err != nil {
+			return fmt.Errorf("failed to remove timeline entries: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeOwn writes the post to its author's timeline. A post with many followers
+// arrives as several messages that all carry the Post Service's ID as PostID, so
+// every message writes the same entry.
+func (s *PushStrategy) writeOwn(ctx context.Context, req *models.FanoutRequest) error {
+	return s.batchWrite(ctx, []types.WriteRequest{
+		{PutRequest: &types.PutRequest{Item: s.timelineItem(req, req.AuthorID)}},
 	})
+}
+
+// withUser returns userIDs with userID added if it is not already present
+func withUser(userIDs []int64, userID int64) []int64 {
+	if slices.Contains(userIDs, userID) {
+		return userIDs
+	}
+	return append(slices.Clip(userIDs), userID)
+}
 
-	return err
+// timelineKey is the post_id of a post's entry in one user's timeline
+func timelineKey(postID string, userID int64) string {
+	return fmt.Sprintf("%s_%d", postID, userID)
 }
 
-// GetTimeline retrieves posts from a user's timeline
-func (s *PushStrategy) GetTimeline(userID int64, limit int) (*models.TimelineResponse, error) {
+// timelineItem builds the timeline entry for one post in one user's timeline
+func (s *PushStrategy) timelineItem(req *models.FanoutRequest, userID int64) map[string]types.AttributeValue {
+	item := map[string]types.AttributeValue{
+		"post_id":    &types.AttributeValueMemberS{Value: timelineKey(req.PostID, userID)},
+		"user_id":    &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", userID)},
+		"author_id":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", req.AuthorID)},
+		"username":   &types.AttributeValueMemberS{Value: req.AuthorName},
+		"content":    &types.AttributeValueMemberS{Value: req.Content},
+		"created_at": &types.AttributeValueMemberS{Value: req.CreatedAt.Format(time.RFC3339)}, // ISO 8601
+	}
+	// Lets reads recognize entries for the same post without parsing post_id
+	if req.OriginalPostID != "" {
+		item["original_post_id"] = &types.AttributeValueMemberS{Value: req.OriginalPostID}
+	}
+	// Timeline entries are copies; the canonical post lives in the Post Service
+	if s.entryTTL > 0 {
+		item["expires_at"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", req.CreatedAt.Add(s.entryTTL).Unix())}
+	}
+	return item
+}
+
+// batchWrite sends a batch and resends any unprocessed items with backoff
+func (s *PushStrategy) batchWrite(ctx context.Context, writeRequests []types.WriteRequest) error {
+	requestItems := map[string][]types.WriteRequest{
+		s.postsTableName: writeRequests,
+	}
+
+	for attempt := 0; ; attempt++ {
+		result, err := s.dynamoClient.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: requestItems,
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(result.UnprocessedItems) == 0 {
+			return nil
+		}
+		if attempt >= maxUnprocessedRetries {
+			return fmt.Errorf("%d items still unprocessed after %d retries", len(result.UnprocessedItems[s.postsTableName]), maxUnprocessedRetries)
+		}
+
+		requestItems = result.UnprocessedItems
+		select {
+		case <-time.After(time.Duration(50*(1<<attempt)) * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// BackfillTimelines writes an author's recent posts into each follower's timeline.
+// Timeline entries are keyed by post and follower, so rerunning a backfill overwrites
+// rather than duplicates. It returns the number of timeline entries written.
+func (s *PushStrategy) BackfillTimelines(ctx context.Context, authorID int64, recentPosts []models.TimelinePost, followerIDs []int64) (int, error) {
+	written := 0
+	for _, post := range recentPosts {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		req := &models.FanoutRequest{
+			PostID:         post.PostID,
+			OriginalPostID: post.PostID,
+			AuthorID:       authorID,
+			AuthorName:     post.AuthorName,
+			Content:        post.Content,
+			CreatedAt:      post.CreatedAt,
+		}
+		if err := s.FanoutPost(ctx, req, followerIDs); err != nil {
+			return written, fmt.Errorf("failed to backfill post %s: %w", post.PostID, err)
+		}
+		written += len(followerIDs)
+	}
+
+	return written, nil
+}
+
+// RebuildTimeline replaces a user's timeline with the given posts. New entries are
+// written before stale ones are removed, so a failed rebuild never leaves the
+// timeline emptier than before and rerunning it converges on the same result.
+// It returns the number of entries written and removed.
+func (s *PushStrategy) RebuildTimeline(ctx context.Context, userID int64, posts []models.TimelinePost) (int, int, error) {
+	existingKeys, err := s.timelineKeys(ctx, userID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	keep := make(map[string]bool, len(posts))
+	written := 0
+	for start := 0; start < len(posts); start += s.batchSize {
+		end := min(start+s.batchSize, len(posts))
+
+		writeRequests := make([]types.WriteRequest, 0, end-start)
+		for _, post := range posts[start:end] {
+			req := &models.FanoutRequest{
+				PostID:         post.PostID,
+				OriginalPostID: post.PostID,
+				AuthorID:       post.AuthorID,
+				AuthorName:     post.AuthorName,
+				Content:        post.Content,
+				CreatedAt:      post.CreatedAt,
+			}
+			keep[timelineKey(post.PostID, userID)] = true
+			writeRequests = append(writeRequests, types.WriteRequest{
+				PutRequest: &types.PutRequest{Item: s.timelineItem(req, userID)},
+			})
+		}
+		if err := s.batchWrite(ctx, writeRequests); err != nil {
+			return written, 0, fmt.Errorf("failed to write timeline entries: %w", err)
+		}
+		written += len(writeRequests)
+	}
+
+	stale := make([]types.WriteRequest, 0)
+	for _, key := range existingKeys {
+		if !keep[key] {
+			stale = append(stale, types.WriteRequest{
+				DeleteRequest: &types.DeleteRequest{
+					Key: map[string]types.AttributeValue{
+						"post_id": &types.AttributeValueMemberS{Value: key},
+					},
+				},
+			})
+		}
+	}
+
+	removed := 0
+	for start := 0; start < len(stale); start += s.batchSize {
+		end := min(start+s.batchSize, len(stale))
+		if err := s.batchWrite(ctx, stale[start:end]); err != nil {
+			return written, removed, fmt.Errorf("failed to remove stale timeline entries: %w", err)
+		}
+		removed += end - start
+	}
+
+	return written, removed, nil
+}
+
+// timelineKeys lists the post_id of every entry in a user's timeline
+func (s *PushStrategy) timelineKeys(ctx context.Context, userID int64) ([]string, error) {
+	keys := make([]string, 0)
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := s.dynamoClient.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(s.postsTableName),
+			IndexName:              aws.String("UserPostsIndex"),
+			KeyConditionExpression: aws.String("user_id = :userId"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":userId": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", userID)},
+			},
+			ProjectionExpression: aws.String("post_id"),
+			ExclusiveStartKey:    startKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list timeline entries: %w", err)
+		}
+
+		for _, item := range result.Items {
+			if key, ok := item["post_id"].(*types.AttributeValueMemberS); ok {
+				keys = append(keys, key.Value)
+			}
+		}
+		if len(result.LastEvaluatedKey) == 0 {
+			return keys, nil
+		}
+		startKey = result.LastEvaluatedKey
+	}
+}
+
+// GetTimeline retrieves a page of posts from a user's timeline; the cursor is the
+// query's LastEvaluatedKey
+func (s *PushStrategy) GetTimeline(ctx context.Context, userID int64, limit int, cursor string) (*models.TimelineResponse, error) {
+	var after *pushCursor
+	if cursor != "" {
+		after = &pushCursor{}
+		if err := decodeCursor(cursor, s.GetName(), after); err != nil {
+			return nil, err
+		}
+	}
+
+	response, next, err := s.readTimeline(ctx, userID, limit, after)
+	if err != nil {
+		return nil, err
+	}
+	if next != nil {
+		response.NextCursor = encodeCursor(s.GetName(), next)
+	}
+	return response, nil
+}
+
+// maxTimelineQueries bounds the queries one timeline page may take to fill up
+const maxTimelineQueries = 4
+
+// readTimeline reads up to limit posts of a user's timeline after the given position
+// (nil for the newest), returning the position to continue from, or nil at the end.
+//
+// Fan-out running twice for a post (SQS redelivery) can leave it under several entry
+// IDs. Repeats are dropped before the page is counted, keeping the first (newest)
+// entry: each query asks for the posts still missing plus one entry of lookahead, and
+// the timeline is queried again from where the last query stopped until the page is
+// full, the timeline ends or maxTimelineQueries have run. The page ends just before the
+// first entry of a post it has no room for, so repeats of its own posts that follow it
+// are consumed here rather than served again on the next page; the cursor also names
+// the last post served, so the next page skips its repeats when a page stops early.
+func (s *PushStrategy) readTimeline(ctx context.Context, userID int64, limit int, after *pushCursor) (*models.TimelineResponse, *pushCursor, error) {
 	// Query posts table using UserPostsIndex to get user's timeline
 	input := &dynamodb.QueryInput{
 		TableName:              aws.String(s.postsTableName),
@@ -98,30 +348,63 @@ func (s *PushStrategy) GetTimeline(userID int64, limit int) (*models.TimelineRes
 			":userId": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", userID)},
 		},
 		ScanIndexForward: aws.Bool(false), // DESC order (newest first)
-		Limit:            aws.Int32(int32(limit)),
 	}
-
-	result, err := s.dynamoClient.Query(context.Background(), input)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query timeline: %w", err)
+	if after != nil {
+		input.ExclusiveStartKey = after.startKey(userID)
 	}
 
-	if result.Count == 0 {
-		return &models.TimelineResponse{
-			Timeline:   []models.TimelinePost{},
-			TotalCount: 0,
-		}, nil
+	// A successful query with no items is a genuinely empty timeline, not an error
+	response := &models.TimelineResponse{Timeline: []models.TimelinePost{}}
+	seen := make(map[string]bool, limit+1)
+	served := ""
+	if after != nil && after.Served != "" {
+		seen[after.Served] = true
+		served = after.Served
 	}
+	skipped := 0
+	var next *pushCursor
+	for queries := 0; queries < maxTimelineQueries; queries++ {
+		input.Limit = aws.Int32(int32(limit - len(response.Timeline) + 1))
+		result, err := s.dynamoClient.Query(ctx, input)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to query timeline: %w", err)
+		}
 
-	// Unmarshal items to TimelinePost
-	var timelinePosts []models.TimelinePost
-	err = attributevalue.UnmarshalListOfMaps(result.Items, &timelinePosts)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal posts: %w", err)
+		// Unmarshal items to TimelinePost, skipping any that fail to decode
+		posts, failed := decodeTimelinePosts(result.Items, fmt.Sprintf("push GetTimeline user_id=%d", userID))
+		skipped += failed
+		full := false
+		for _, post := range posts {
+			if seen[post.PostID] {
+				next = pushCursorAfter(post)
+				continue
+			}
+			if len(response.Timeline) == limit {
+				full = true
+				break
+			}
+			seen[post.PostID] = true
+			served = post.PostID
+			response.Timeline = append(response.Timeline, post)
+			next = pushCursorAfter(post)
+		}
+		if full {
+			break
+		}
+
+		next = pushCursorFromKey(result.LastEvaluatedKey)
+		if next == nil {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
 	}
 
-	return &models.TimelineResponse{
-		Timeline:   timelinePosts,
-		TotalCount: int(result.Count),
-	}, nil
+	if next != nil {
+		next.Served = served
+	}
+	response.TotalCount = len(response.Timeline)
+	if skipped > 0 {
+		response.Warning = fmt.Sprintf("%d posts could not be decoded and were skipped", skipped)
+	}
+	return response, next, nil
 }
@@ -0,0 +1,155 @@
+package fanout
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/fanout/fanouttest"
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestTimelineItemExpiry(t *testing.T) {
+	req := &models.FanoutRequest{
+		PostID:     "42",
+		AuthorID:   3,
+		AuthorName: "alice",
+		Content:    "hello",
+		CreatedAt:  time.Unix(1_700_000_000, 0).UTC(),
+	}
+	tests := []struct {
+		name        string
+		entryTTL    time.Duration
+		wantExpires string // empty means no expires_at attribute
+	}{
+		{name: "expiry disabled", entryTTL: 0},
+		{name: "one week", entryTTL: 7 * 24 * time.Hour, wantExpires: "1700604800"},
+	}
+	for _, tt := range tests {
+		s := &PushStrategy{entryTTL: tt.entryTTL}
+		item := s.timelineItem(req, 9)
+
+		expires, ok := item["expires_at"].(*types.AttributeValueMemberN)
+		switch {
+		case tt.wantExpires == "" && ok:
+			t.Errorf("%s: expires_at = %s, want none", tt.name, expires.Value)
+		case tt.wantExpires != "" && (!ok || expires.Value != tt.wantExpires):
+			t.Errorf("%s: expires_at = %v, want %s", tt.name, item["expires_at"], tt.wantExpires)
+		}
+		if user, ok := item["user_id"].(*types.AttributeValueMemberN); !ok || user.Value != "9" {
+			t.Errorf("%s: user_id = %v, want the follower 9", tt.name, item["user_id"])
+		}
+	}
+}
+
+// timelineEntry is one entry to seed a push timeline with
+type timelineEntry struct {
+	postID  string
+	entryID string // Distinct entry IDs for one post stand in for redelivered fan-outs
+	at      int    // Seconds after a fixed base time
+}
+
+// seedTimeline writes entries into userID's timeline through the push strategy
+func seedTimeline(t *testing.T, push *PushStrategy, userID int64, entries []timelineEntry) {
+	t.Helper()
+	base := time.Unix(1_700_000_000, 0).UTC()
+	for _, e := range entries {
+		req := &models.FanoutRequest{
+			PostID:         e.entryID,
+			OriginalPostID: e.postID,
+			AuthorID:       99,
+			AuthorName:     "author",
+			Content:        "post " + e.postID,
+			CreatedAt:      base.Add(time.Duration(e.at) * time.Second),
+		}
+		if err := push.FanoutPost(context.Background(), req, []int64{userID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readAllPages pages through a timeline, returning the post IDs of each page
+func readAllPages(t *testing.T, strategy Strategy, userID int64, limit int) [][]string {
+	t.Helper()
+	var pages [][]string
+	cursor := ""
+	for i := 0; ; i++ {
+		if i > 50 {
+			t.Fatal("timeline never ended")
+		}
+		page, err := strategy.GetTimeline(context.Background(), userID, limit, cursor)
+		if err != nil {
+			t.Fatalf("page %d: %v", i+1, err)
+		}
+		ids := make([]string, len(page.Timeline))
+		for j, post := range page.Timeline {
+			ids[j] = post.PostID
+		}
+		pages = append(pages, ids)
+		if page.TotalCount != len(page.Timeline) {
+			t.Errorf("page %d: TotalCount %d for %d posts", i+1, page.TotalCount, len(page.Timeline))
+		}
+		if page.NextCursor == "" {
+			return pages
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func TestPushPagesFillUpAfterDroppingRepeats(t *testing.T) {
+	_, client := fanouttest.NewTimelineTable(t)
+	push := NewPushStrategy(client, "timeline", 0, false)
+	// Posts p10 (newest) to p1; p8 was fanned out three times and p5 twice
+	seedTimeline(t, push, 1, []timelineEntry{
+		{"p10", "e10", 10}, {"p9", "e9", 9},
+		{"p8", "e8a", 8}, {"p8", "e8b", 8}, {"p8", "e8c", 8},
+		{"p7", "e7", 7}, {"p6", "e6", 6},
+		{"p5", "e5a", 5}, {"p5", "e5b", 5},
+		{"p4", "e4", 4}, {"p3", "e3", 3}, {"p2", "e2", 2}, {"p1", "e1", 1},
+	})
+
+	tests := []struct {
+		limit int
+		want  [][]string
+	}{
+		{limit: 3, want: [][]string{{"p10", "p9", "p8"}, {"p7", "p6", "p5"}, {"p4", "p3", "p2"}, {"p1"}}},
+		{limit: 5, want: [][]string{{"p10", "p9", "p8", "p7", "p6"}, {"p5", "p4", "p3", "p2", "p1"}}},
+		{limit: 20, want: [][]string{{"p10", "p9", "p8", "p7", "p6", "p5", "p4", "p3", "p2", "p1"}}},
+	}
+	for _, tt := range tests {
+		got := readAllPages(t, push, 1, tt.limit)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("limit %d: pages = %v, want %v", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestPushPageStopsAtQueryBound(t *testing.T) {
+	table, client := fanouttest.NewTimelineTable(t)
+	push := NewPushStrategy(client, "timeline", 0, false)
+	// One post fanned out many times, then another
+	entries := []timelineEntry{{"p2", "e2", 2}}
+	for i := 0; i < 10; i++ {
+		entries = append(entries, timelineEntry{"p1", fmt.Sprintf("e1-%02d", i), 1})
+	}
+	entries = append(entries, timelineEntry{"p0", "e0", 0})
+	seedTimeline(t, push, 1, entries)
+
+	page, err := push.GetTimeline(context.Background(), 1, 3, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Queries() != maxTimelineQueries {
+		t.Errorf("%d queries, want the bound of %d", table.Queries(), maxTimelineQueries)
+	}
+	// A short page, but the rest is still reachable and nothing repeats
+	if len(page.Timeline) != 2 || page.NextCursor == "" {
+		t.Fatalf("first page = %d posts, cursor %q; want p2 and p1 with a cursor", len(page.Timeline), page.NextCursor)
+	}
+	rest := readAllPages(t, push, 1, 3)
+	if fmt.Sprint(rest) != fmt.Sprint([][]string{{"p2", "p1"}, {"p0"}}) {
+		t.Errorf("pages = %v, want [[p2 p1] [p0]]", rest)
+	}
+}
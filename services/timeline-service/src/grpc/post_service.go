@@ -6,15 +6,19 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 
 	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
+	"github.com/cs6650/proto/grpcdial"
 	postpb "github.com/cs6650/proto/post"
 )
 
 // PostServiceClient defines the interface for calling Post Service
 type PostServiceClient interface {
-	BatchGetPosts(ctx context.Context, userIDs []int64, limit int32) (map[int64][]models.TimelinePost, error)
+	// BatchGetPosts returns up to limit of each user's newest posts, only those created
+	// before the Unix time before when it is positive
+	BatchGetPosts(ctx context.Context, userIDs []int64, limit int32, before int64) (map[int64][]models.TimelinePost, error)
+	GetWriteStrategy(ctx context.Context) (string, error)
 }
 
 // GRPCPostServiceClient implements PostServiceClient using gRPC calls
@@ -24,14 +28,15 @@ type GRPCPostServiceClient struct {
 }
 
 // BatchGetPosts makes gRPC call to Post Service's BatchGetPosts method
-func (c *GRPCPostServiceClient) BatchGetPosts(ctx context.Context, userIDs []int64, limit int32) (map[int64][]models.TimelinePost, error) {
+func (c *GRPCPostServiceClient) BatchGetPosts(ctx context.Context, userIDs []int64, limit int32, before int64) (map[int64][]models.TimelinePost, error) {
 	if c.client == nil {
 		return nil, fmt.Errorf("post service client not initialized - connection failed at startup")
 	}
 	// Create gRPC request
 	req := &postpb.BatchGetPostsRequest{
-		UserIds: userIDs,
-		Limit:   limit,
+		UserIds:         userIDs,
+		Limit:           limit,
+		BeforeTimestamp: before,
 	}
 
 	// Make gRPC call
@@ -58,7 +63,7 @@ func (c *GRPCPostServiceClient) BatchGetPosts(ctx context.Context, userIDs []int
 				PostID:     fmt.Sprintf("%d", post.PostId), // Convert int64 to string
 				UserID:     0,                              // Timeline owner - will be set by caller
 				AuthorID:   post.UserId,
-				AuthorName: "", // Will be filled by user service
+				AuthorName: "", // Filled in by TimelineHandler.enrichAuthors for every strategy
 				Content:    post.Content,
 				CreatedAt:  createdAt,
 			})
@@ -70,6 +75,19 @@ func (c *GRPCPostServiceClient) BatchGetPosts(ctx context.Context, userIDs []int
 	return result, nil
 }
 
+// GetWriteStrategy returns the Post Service's active write strategy
+func (c *GRPCPostServiceClient) GetWriteStrategy(ctx context.Context) (string, error) {
+	if c.client == nil {
+		return "", fmt.Errorf("post service client not initialized - connection failed at startup")
+	}
+
+	resp, err := c.client.GetWriteStrategy(ctx, &postpb.GetWriteStrategyRequest{})
+	if err != nil {
+		return "", fmt.Errorf("failed to call post service: %w", err)
+	}
+	return resp.Strategy, nil
+}
+
 // Close closes the gRPC connection
 func (c *GRPCPostServiceClient) Close() error {
 	if c.conn != nil {
@@ -79,7 +97,7 @@ func (c *GRPCPostServiceClient) Close() error {
 }
 
 // NewPostServiceClient creates a new Post Service client
-func NewPostServiceClient(endpoint string) PostServiceClient {
+func NewPostServiceClient(endpoint string, keepaliveParams keepalive.ClientParameters) PostServiceClient {
 	// Use Dial with Block to ensure connection is established and DNS is resolved
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -87,8 +105,9 @@ func NewPostServiceClient(endpoint string) PostServiceClient {
 	conn, err := grpc.DialContext(
 		ctx,
 		endpoint,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(), // Block until connection is established
+		append(grpcdial.Options(keepaliveParams),
+			grpc.WithBlock(), // Block until connection is established
+		)...,
 	)
 	if err != nil {
 		// Return a client that will fail on first use, but allow service to start
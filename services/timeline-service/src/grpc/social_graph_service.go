@@ -5,42 +5,112 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/cs6650/proto/grpcdial"
 	socialgraphpb "github.com/cs6650/proto/social_graph"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 )
 
 // SocialGraphServiceClient defines the interface for calling Social Graph Service
 type SocialGraphServiceClient interface {
 	GetFollowing(ctx context.Context, userID int64) ([]int64, error)
+	GetFollowingUpTo(ctx context.Context, userID int64, maxFollowing int) ([]int64, error)
+	CheckFollowing(ctx context.Context, followerID int64, targetIDs []int64) (map[int64]bool, error)
 }
 
+// followingPageSize is how many IDs GetFollowingUpTo requests per GetFollowingIDs call
+const followingPageSize = 500
+
+// batchCheckSize is the most target IDs SocialGraphService accepts per BatchCheckFollowRelationship call
+const batchCheckSize = 500
+
 // GRPCSocialGraphServiceClient implements SocialGraphServiceClient using gRPC calls
 type GRPCSocialGraphServiceClient struct {
 	client socialgraphpb.SocialGraphServiceClient
 	conn   *grpc.ClientConn
 }
 
-// GetFollowing calls GetFollowingList from SocialGraphService
+// GetFollowing calls GetFollowingIDs from SocialGraphService
 func (c *GRPCSocialGraphServiceClient) GetFollowing(ctx context.Context, userID int64) ([]int64, error) {
 	if c.client == nil {
 		return nil, fmt.Errorf("social graph service client not initialized - connection failed at startup")
 	}
-	req := &socialgraphpb.GetFollowingListRequest{
+	req := &socialgraphpb.GetFollowingIDsRequest{
 		UserId: userID,
 	}
-	resp, err := c.client.GetFollowingList(ctx, req)
+	resp, err := c.client.GetFollowingIDs(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call GetFollowingList: %w", err)
+		return nil, fmt.Errorf("failed to call GetFollowingIDs: %w", err)
 	}
 	if resp.ErrorCode != "" {
 		return nil, fmt.Errorf("social graph service error [%s]: %s", resp.ErrorCode, resp.ErrorMessage)
 	}
-	return resp.FollowingUserIds, nil
+	return resp.UserIds, nil
+}
+
+// GetFollowingUpTo returns at most maxFollowing of the users userID follows, in follow
+// order, paging through GetFollowingIDs and stopping once the cap is reached
+// A maxFollowing of 0 or less fetches the whole list in one call, like GetFollowing
+func (c *GRPCSocialGraphServiceClient) GetFollowingUpTo(ctx context.Context, userID int64, maxFollowing int) ([]int64, error) {
+	if maxFollowing <= 0 {
+		return c.GetFollowing(ctx, userID)
+	}
+	if c.client == nil {
+		return nil, fmt.Errorf("social graph service client not initialized - connection failed at startup")
+	}
+
+	following := make([]int64, 0, min(maxFollowing, followingPageSize))
+	for len(following) < maxFollowing {
+		req := &socialgraphpb.GetFollowingIDsRequest{
+			UserId: userID,
+			Limit:  int32(min(followingPageSize, maxFollowing-len(following))),
+			Offset: int32(len(following)),
+		}
+		resp, err := c.client.GetFollowingIDs(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call GetFollowingIDs: %w", err)
+		}
+		if resp.ErrorCode != "" {
+			return nil, fmt.Errorf("social graph service error [%s]: %s", resp.ErrorCode, resp.ErrorMessage)
+		}
+		following = append(following, resp.UserIds...)
+		// An empty page guards against a server that ignores limit and offset
+		if !resp.HasMore || len(resp.UserIds) == 0 {
+			break
+		}
+	}
+	return following[:min(len(following), maxFollowing)], nil
+}
+
+// CheckFollowing reports, for each of targetIDs, whether followerID follows it, using
+// BatchCheckFollowRelationship so only the given targets are read, never the whole following list
+func (c *GRPCSocialGraphServiceClient) CheckFollowing(ctx context.Context, followerID int64, targetIDs []int64) (map[int64]bool, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("social graph service client not initialized - connection failed at startup")
+	}
+
+	following := make(map[int64]bool, len(targetIDs))
+	for start := 0; start < len(targetIDs); start += batchCheckSize {
+		req := &socialgraphpb.BatchCheckFollowRelationshipRequest{
+			FollowerUserId: followerID,
+			TargetUserIds:  targetIDs[start:min(start+batchCheckSize, len(targetIDs))],
+		}
+		resp, err := c.client.BatchCheckFollowRelationship(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call BatchCheckFollowRelationship: %w", err)
+		}
+		if resp.ErrorCode != "" {
+			return nil, fmt.Errorf("social graph service error [%s]: %s", resp.ErrorCode, resp.ErrorMessage)
+		}
+		for targetID, follows := range resp.IsFollowing {
+			following[targetID] = follows
+		}
+	}
+	return following, nil
 }
 
 // NewSocialGraphServiceClient creates a new Social Graph Service client
-func NewSocialGraphServiceClient(endpoint string) SocialGraphServiceClient {
+func NewSocialGraphServiceClient(endpoint string, keepaliveParams keepalive.ClientParameters) SocialGraphServiceClient {
 	// Use Dial with Block to ensure connection is established and DNS is resolved
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -49,8 +119,9 @@ func NewSocialGraphServiceClient(endpoint string) SocialGraphServiceClient {
 	conn, err := grpc.DialContext(
 		ctx,
 		endpoint,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(), // Block until connection is established
+		append(grpcdial.Options(keepaliveParams),
+			grpc.WithBlock(), // Block until connection is established
+		)...,
 	)
 	if err != nil {
 		fmt.Printf("Warning: Failed to connect to social graph service at %s: %v. Service will retry on first use.\n", endpoint, err)
@@ -4,13 +4,23 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	pb "github.com/cs6650/proto"
+	"github.com/cs6650/proto/grpcdial"
+	"github.com/cs6650/proto/logthrottle"
+	"github.com/cs6650/proto/usercache"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 )
 
+// reconnectLogs throttles reconnect attempts, which every request makes while the
+// User Service is down
+var reconnectLogs = logthrottle.New(5, time.Minute)
+
 // UserInfo represents basic user information
 type UserInfo struct {
 	UserID   int64  `json:"user_id"`
@@ -26,15 +36,22 @@ type BatchGetUserInfoResponse struct {
 }
 
 // UserServiceClient interface for User Service gRPC operations
+// Missing users are not errors: BatchGetUserInfo lists them in NotFound and GetUserInfo
+// returns found=false, so each caller decides whether to skip, substitute or fail
 type UserServiceClient interface {
 	BatchGetUserInfo(ctx context.Context, userIDs []int64) (*BatchGetUserInfoResponse, error)
+	GetUserInfo(ctx context.Context, userID int64) (*UserInfo, bool, error)
 }
 
 // userServiceClient implements UserServiceClient with actual gRPC calls
 type userServiceClient struct {
-	client   pb.UserServiceClient
-	conn     *grpc.ClientConn
-	endpoint string
+	mu               sync.Mutex // Guards client and conn across reconnects
+	client           pb.UserServiceClient
+	conn             *grpc.ClientConn
+	endpoint         string
+	keepaliveParams  keepalive.ClientParameters
+	retryUnavailable bool             // Reconnect and retry once when a call fails with Unavailable
+	cache            *usercache.Cache // Usernames served without a call; nil disables
 }
 
 const (
@@ -44,6 +61,7 @@ const (
 )
 
 // ensureConnection ensures the gRPC connection is established, retrying if needed
+// Callers must hold c.mu
 func (c *userServiceClient) ensureConnection(ctx context.Context) error {
 	if c.client != nil && c.conn != nil {
 		// Connection already established
@@ -53,14 +71,13 @@ func (c *userServiceClient) ensureConnection(ctx context.Context) error {
 	// Try to reconnect with retries and exponential backoff
 	var lastErr error
 	for attempt := 1; attempt <= userServiceReconnectMaxAttempts; attempt++ {
-		log.Printf("Attempting to reconnect to User Service at %s (attempt %d/%d)...", c.endpoint, attempt, userServiceReconnectMaxAttempts)
+		reconnectLogs.Printf("Attempting to reconnect to User Service at %s (attempt %d/%d)...", c.endpoint, attempt, userServiceReconnectMaxAttempts)
 
 		connCtx, cancel := context.WithTimeout(ctx, 15*time.Second) // Increased timeout from 10s to 15s
 		conn, err := grpc.DialContext(
 			connCtx,
 			c.endpoint,
-			grpc.WithTransportCredentials(insecure.NewCredentials()),
-			grpc.WithBlock(),
+			append(grpcdial.Options(c.keepaliveParams), grpc.WithBlock())...,
 		)
 		cancel()
 
@@ -77,14 +94,14 @@ func (c *userServiceClient) ensureConnection(ctx context.Context) error {
 		}
 
 		lastErr = err
-		log.Printf("Failed to reconnect to User Service (attempt %d/%d): %v", attempt, userServiceReconnectMaxAttempts, err)
+		reconnectLogs.Printf("Failed to reconnect to User Service (attempt %d/%d): %v", attempt, userServiceReconnectMaxAttempts, err)
 
 		// Calculate exponential backoff delay with cap
 		delay := userServiceReconnectBaseDelay * time.Duration(1<<uint(attempt-1)) // Exponential: 1s, 2s, 4s, 8s...
 		if delay > userServiceReconnectMaxDelay {
 			delay = userServiceReconnectMaxDelay
 		}
-		log.Printf("Waiting %v before next retry...", delay)
+		reconnectLogs.Printf("Waiting %v before next retry...", delay)
 
 		// Respect context cancellation
 		select {
@@ -98,23 +115,109 @@ func (c *userServiceClient) ensureConnection(ctx context.Context) error {
 	return fmt.Errorf("failed to reconnect to user service after %d attempts: %w", userServiceReconnectMaxAttempts, lastErr)
 }
 
-// BatchGetUserInfo calls the real User Service via gRPC
-func (c *userServiceClient) BatchGetUserInfo(ctx context.Context, userIDs []int64) (*BatchGetUserInfoResponse, error) {
-	// Ensure connection is established, retry if needed
+// connect returns the current client and connection, establishing them if needed
+func (c *userServiceClient) connect(ctx context.Context) (pb.UserServiceClient, *grpc.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if err := c.ensureConnection(ctx); err != nil {
-		return nil, fmt.Errorf("user service client not initialized - connection failed: %w", err)
+		return nil, nil, fmt.Errorf("user service client not initialized - connection failed: %w", err)
+	}
+	return c.client, c.conn, nil
+}
+
+// dropConnection discards a connection that failed mid-call so the next connect redials
+// Another caller may already have replaced it, in which case the new one is kept
+func (c *userServiceClient) dropConnection(failed *grpc.ClientConn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != failed {
+		return
+	}
+	_ = c.conn.Close()
+	c.conn = nil
+	c.client = nil
+}
+
+// invoke runs rpc with a 5s timeout. If it fails with Unavailable, for example while
+// the User Service restarts, the connection is re-established and rpc retried once.
+func (c *userServiceClient) invoke(ctx context.Context, rpc func(ctx context.Context, client pb.UserServiceClient) error) error {
+	attempt := func() (*grpc.ClientConn, error) {
+		client, conn, err := c.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		return conn, rpc(callCtx, client)
+	}
+
+	conn, err := attempt()
+	if err == nil || conn == nil || !c.retryUnavailable || status.Code(err) != codes.Unavailable {
+		return err
+	}
+
+	reconnectLogs.Printf("User Service unavailable mid-call, reconnecting and retrying once: %v", err)
+	c.dropConnection(conn)
+	_, err = attempt()
+	return err
+}
+
+// GetUserInfo looks up a single user, from the cache or via gRPC; found is false when the user does not exist
+func (c *userServiceClient) GetUserInfo(ctx context.Context, userID int64) (*UserInfo, bool, error) {
+	if username, ok := c.cache.Get(userID); ok {
+		return &UserInfo{UserID: userID, Username: username}, true, nil
+	}
+
+	var resp *pb.GetUserInfoResponse
+	err := c.invoke(ctx, func(ctx context.Context, client pb.UserServiceClient) error {
+		var err error
+		resp, err = client.GetUserInfo(ctx, &pb.GetUserInfoRequest{UserId: userID})
+		return err
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to call GetUserInfo: %w", err)
+	}
+
+	switch resp.ErrorCode {
+	case "":
+	case "NOT_FOUND":
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf("user service error: %s - %s", resp.ErrorCode, resp.ErrorMessage)
+	}
+
+	c.cache.Set(resp.User.UserId, resp.User.Username)
+	return &UserInfo{
+		UserID:   resp.User.UserId,
+		Username: resp.User.Username,
+	}, true, nil
+}
+
+// BatchGetUserInfo serves cached usernames directly and calls the real User Service
+// via gRPC only for the rest
+func (c *userServiceClient) BatchGetUserInfo(ctx context.Context, userIDs []int64) (*BatchGetUserInfoResponse, error) {
+	cached, misses := c.cache.Lookup(userIDs)
+	users := make(map[int64]UserInfo, len(userIDs))
+	for userID, username := range cached {
+		users[userID] = UserInfo{UserID: userID, Username: username}
+	}
+	if len(misses) == 0 {
+		return &BatchGetUserInfoResponse{Users: users, NotFound: []int64{}}, nil
 	}
 
 	// Create gRPC request
 	req := &pb.BatchGetUserInfoRequest{
-		UserIds: userIDs,
+		UserIds: misses,
 	}
 
-	// Call gRPC service with timeout
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	resp, err := c.client.BatchGetUserInfo(ctx, req)
+	var resp *pb.BatchGetUserInfoResponse
+	err := c.invoke(ctx, func(ctx context.Context, client pb.UserServiceClient) error {
+		var err error
+		resp, err = client.BatchGetUserInfo(ctx, req)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to call BatchGetUserInfo: %w", err)
 	}
@@ -125,12 +228,12 @@ func (c *userServiceClient) BatchGetUserInfo(ctx context.Context, userIDs []int6
 	}
 
 	// Convert protobuf response to internal format
-	users := make(map[int64]UserInfo)
 	for userID, userInfo := range resp.Users {
 		users[userID] = UserInfo{
 			UserID:   userInfo.UserId,
 			Username: userInfo.Username,
 		}
+		c.cache.Set(userID, userInfo.Username)
 	}
 
 	return &BatchGetUserInfoResponse{
@@ -142,7 +245,9 @@ func (c *userServiceClient) BatchGetUserInfo(ctx context.Context, userIDs []int6
 }
 
 // NewUserServiceClient creates a new User Service client
-func NewUserServiceClient(endpoint string) UserServiceClient {
+// retryUnavailable enables one reconnect-and-retry for calls that fail with Unavailable
+// cache, when non-nil, serves usernames without a call
+func NewUserServiceClient(endpoint string, keepaliveParams keepalive.ClientParameters, retryUnavailable bool, cache *usercache.Cache) UserServiceClient {
 	// Use Dial with Block to ensure connection is established and DNS is resolved
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -151,29 +256,38 @@ func NewUserServiceClient(endpoint string) UserServiceClient {
 	conn, err := grpc.DialContext(
 		ctx,
 		endpoint,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(), // Block until connection is established
+		append(grpcdial.Options(keepaliveParams),
+			grpc.WithBlock(), // Block until connection is established
+		)...,
 	)
 	if err != nil {
 		// Return a client that will retry on first use, but allow service to start
 		log.Printf("Warning: Failed to connect to user service at %s: %v. Service will retry on first use.", endpoint, err)
 		return &userServiceClient{
-			client:   nil,
-			conn:     nil,
-			endpoint: endpoint,
+			client:           nil,
+			conn:             nil,
+			endpoint:         endpoint,
+			keepaliveParams:  keepaliveParams,
+			retryUnavailable: retryUnavailable,
+			cache:            cache,
 		}
 	}
 
 	log.Printf("User Service client created for %s", endpoint)
 	return &userServiceClient{
-		client:   pb.NewUserServiceClient(conn),
-		conn:     conn,
-		endpoint: endpoint,
+		client:           pb.NewUserServiceClient(conn),
+		conn:             conn,
+		endpoint:         endpoint,
+		keepaliveParams:  keepaliveParams,
+		retryUnavailable: retryUnavailable,
+		cache:            cache,
 	}
 }
 
 // Close closes the gRPC connection
 func (c *userServiceClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.conn != nil {
 		return c.conn.Close()
 	}
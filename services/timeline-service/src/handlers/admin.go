@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/fanout"
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/grpc"
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultBackfillPostLimit is how many recent posts are backfilled when no limit is given
+const defaultBackfillPostLimit = 20
+
+const (
+	// rebuildAuthorPageSize bounds the followed authors fetched per Post Service and User Service call
+	rebuildAuthorPageSize = 100
+	// rebuildMaxPosts bounds how many of the newest posts a rebuilt timeline keeps
+	rebuildMaxPosts = 1000
+)
+
+type AdminHandler struct {
+	pushStrategy             *fanout.PushStrategy
+	postServiceClient        grpc.PostServiceClient
+	socialGraphServiceClient grpc.SocialGraphServiceClient
+	userServiceClient        grpc.UserServiceClient
+	timelineCache            *fanout.TimelineCache // Invalidated for timelines written here
+}
+
+func NewAdminHandler(pushStrategy *fanout.PushStrategy, postServiceClient grpc.PostServiceClient, socialGraphServiceClient grpc.SocialGraphServiceClient, userServiceClient grpc.UserServiceClient, timelineCache *fanout.TimelineCache) *AdminHandler {
+	return &AdminHandler{
+		pushStrategy:             pushStrategy,
+		postServiceClient:        postServiceClient,
+		socialGraphServiceClient: socialGraphServiceClient,
+		userServiceClient:        userServiceClient,
+		timelineCache:            timelineCache,
+	}
+}
+
+// BackfillRequest describes a pull-to-push migration for one author
+type BackfillRequest struct {
+	AuthorID    int64   `json:"author_id" binding:"required"`
+	AuthorName  string  `json:"author_name"`
+	FollowerIDs []int64 `json:"follower_ids" binding:"required"`
+	PostLimit   int32   `json:"post_limit"`
+}
+
+// BackfillTimelines handles POST /api/admin/backfill on the admin listener
+// It fetches the author's recent posts from Post Service and writes them into
+// the given followers' push timelines so switching the author to push leaves no gap
+func (h *AdminHandler) BackfillTimelines(c *gin.Context) {
+	var req BackfillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.PostLimit <= 0 {
+		req.PostLimit = defaultBackfillPostLimit
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	postsByUser, err := h.postServiceClient.BatchGetPosts(ctx, []int64{req.AuthorID}, req.PostLimit, 0)
+	if err != nil {
+		log.Printf("Backfill for author %d failed to fetch posts: %v", req.AuthorID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch recent posts", "error_code": "POST_SERVICE_ERROR"})
+		return
+	}
+
+	recentPosts := postsByUser[req.AuthorID]
+	for i := range recentPosts {
+		recentPosts[i].AuthorName = req.AuthorName
+	}
+
+	written, err := h.pushStrategy.BackfillTimelines(ctx, req.AuthorID, recentPosts, req.FollowerIDs)
+	h.timelineCache.Invalidate(req.FollowerIDs...)
+	if err != nil {
+		log.Printf("Backfill for author %d failed after %d entries: %v", req.AuthorID, written, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":           err.Error(),
+			"error_code":      "BACKFILL_FAILED",
+			"entries_written": written,
+		})
+		return
+	}
+
+	log.Printf("Backfilled %d posts by author %d into %d timelines", len(recentPosts), req.AuthorID, len(req.FollowerIDs))
+	c.JSON(http.StatusOK, gin.H{
+		"author_id":       req.AuthorID,
+		"posts":           len(recentPosts),
+		"followers":       len(req.FollowerIDs),
+		"entries_written": written,
+	})
+}
+
+// RebuildTimeline handles POST /api/admin/timeline/:user_id/rebuild on the admin listener
+// It rebuilds a user's push timeline from the accounts they follow, for repairing
+// timelines with missing or duplicated entries. Followed authors are processed in
+// pages; rerunning the rebuild is safe.
+func (h *AdminHandler) RebuildTimeline(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID", "error_code": "INVALID_REQUEST"})
+		return
+	}
+
+	postsPerAuthor, err := strconv.Atoi(c.DefaultQuery("posts_per_author", strconv.Itoa(defaultBackfillPostLimit)))
+	if err != nil || postsPerAuthor <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "posts_per_author must be a positive integer", "error_code": "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 120*time.Second)
+	defer cancel()
+
+	followingIDs, err := h.socialGraphServiceClient.GetFollowing(ctx, userID)
+	if err != nil {
+		log.Printf("Rebuild for user %d failed to fetch following: %v", userID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch following list", "error_code": "SOCIAL_GRAPH_SERVICE_ERROR"})
+		return
+	}
+
+	posts := make([]models.TimelinePost, 0)
+	for start := 0; start < len(followingIDs); start += rebuildAuthorPageSize {
+		page := followingIDs[start:min(start+rebuildAuthorPageSize, len(followingIDs))]
+
+		pagePosts, err := h.fetchAuthorPosts(ctx, page, int32(postsPerAuthor))
+		if err != nil {
+			log.Printf("Rebuild for user %d failed at authors %d-%d: %v", userID, start, start+len(page), err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "error_code": "DEPENDENCY_FAILED"})
+			return
+		}
+		posts = append(posts, pagePosts...)
+	}
+
+	// Keep only the newest posts so a user following many accounts gets a bounded timeline
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].CreatedAt.After(posts[j].CreatedAt)
+	})
+	if len(posts) > rebuildMaxPosts {
+		posts = posts[:rebuildMaxPosts]
+	}
+
+	written, removed, err := h.pushStrategy.RebuildTimeline(ctx, userID, posts)
+	h.timelineCache.Invalidate(userID)
+	if err != nil {
+		log.Printf("Rebuild for user %d failed after writing %d and removing %d entries: %v", userID, written, removed, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":           err.Error(),
+			"error_code":      "REBUILD_FAILED",
+			"entries_written": written,
+			"entries_removed": removed,
+		})
+		return
+	}
+
+	log.Printf("Rebuilt timeline for user %d: %d following, %d entries written, %d removed", userID, len(followingIDs), written, removed)
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":         userID,
+		"following":       len(followingIDs),
+		"entries_written": written,
+		"entries_removed": removed,
+	})
+}
+
+// fetchAuthorPosts returns recent posts by the given authors with author names filled in
+func (h *AdminHandler) fetchAuthorPosts(ctx context.Context, authorIDs []int64, limit int32) ([]models.TimelinePost, error) {
+	postsByAuthor, err := h.postServiceClient.BatchGetPosts(ctx, authorIDs, limit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch posts: %w", err)
+	}
+
+	userInfo, err := h.userServiceClient.BatchGetUserInfo(ctx, authorIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch author names: %w", err)
+	}
+
+	posts := make([]models.TimelinePost, 0)
+	for authorID, authorPosts := range postsByAuthor {
+		for _, post := range authorPosts {
+			post.AuthorID = authorID
+			post.AuthorName = userInfo.Users[authorID].Username
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
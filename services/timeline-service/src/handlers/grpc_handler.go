@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/fanout"
+	timelinepb "github.com/cs6650/proto/timeline"
+)
+
+// GRPCHandler serves TimelineService over gRPC using the same strategy logic as the HTTP API
+type GRPCHandler struct {
+	timelinepb.UnimplementedTimelineServiceServer
+	timelineHandler *TimelineHandler
+}
+
+func NewGRPCHandler(timelineHandler *TimelineHandler) *GRPCHandler {
+	return &GRPCHandler{
+		timelineHandler: timelineHandler,
+	}
+}
+
+// GetTimeline returns a page of a user's timeline; next_cursor continues it
+func (h *GRPCHandler) GetTimeline(ctx context.Context, req *timelinepb.GetTimelineRequest) (*timelinepb.GetTimelineResponse, error) {
+	if req.UserId <= 0 {
+		return &timelinepb.GetTimelineResponse{
+			ErrorCode:    "INVALID_ARGUMENT",
+			ErrorMessage: "user_id must be positive",
+		}, nil
+	}
+
+	if req.Limit < 0 {
+		return &timelinepb.GetTimelineResponse{
+			ErrorCode:    "INVALID_ARGUMENT",
+			ErrorMessage: "limit must not be negative",
+		}, nil
+	}
+
+	// A limit of 0 gets the HTTP endpoint's default page size
+	timeline, err := h.timelineHandler.loadTimeline(ctx, req.UserId, int(req.Limit), req.Cursor, h.timelineHandler.config.FanoutStrategy)
+	if errors.Is(err, fanout.ErrInvalidCursor) {
+		return &timelinepb.GetTimelineResponse{
+			ErrorCode:    "INVALID_CURSOR",
+			ErrorMessage: err.Error(),
+		}, nil
+	}
+	if err != nil {
+		log.Printf("gRPC GetTimeline failed for user %d: %v", req.UserId, err)
+		errorCode := "DEPENDENCY_FAILED"
+		if errors.Is(err, errStrategyUnavailable) {
+			errorCode = "STRATEGY_UNAVAILABLE"
+		}
+		return &timelinepb.GetTimelineResponse{
+			ErrorCode:    errorCode,
+			ErrorMessage: "Failed to get timeline",
+		}, nil
+	}
+
+	posts := make([]*timelinepb.TimelinePost, 0, len(timeline.Timeline))
+	for _, post := range timeline.Timeline {
+		posts = append(posts, &timelinepb.TimelinePost{
+			PostId:     post.PostID,
+			AuthorId:   post.AuthorID,
+			AuthorName: post.AuthorName,
+			Content:    post.Content,
+			CreatedAt:  post.CreatedAt.Unix(),
+		})
+	}
+
+	return &timelinepb.GetTimelineResponse{
+		Posts:      posts,
+		TotalCount: int32(timeline.TotalCount),
+		NextCursor: timeline.NextCursor,
+	}, nil
+}
@@ -1,23 +1,50 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/config"
 	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/fanout"
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/grpc"
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
+	"github.com/cs6650/proto/dynamotimeout"
+	"github.com/cs6650/proto/usercache"
 	"github.com/gin-gonic/gin"
 )
 
+// defaultTimelineLimit is the page size when a request gives none, 0, or an unreadable one
+const defaultTimelineLimit = 50
+
+// errStrategyUnavailable means the configured fan-out strategy is not registered
+var errStrategyUnavailable = errors.New("configured strategy not available")
+
 type TimelineHandler struct {
-	strategies map[string]fanout.Strategy
-	config     *config.Config
+	strategies               map[string]fanout.Strategy
+	socialGraphServiceClient grpc.SocialGraphServiceClient
+	userServiceClient        grpc.UserServiceClient
+	config                   *config.Config
+	version                  string
+	startTime                time.Time
+	userCache                *usercache.Cache      // Shared with userServiceClient; reported by Health
+	timelineCache            *fanout.TimelineCache // Behind strategies; reported by Health
 }
 
-func NewTimelineHandler(strategies map[string]fanout.Strategy, cfg *config.Config) *TimelineHandler {
+func NewTimelineHandler(strategies map[string]fanout.Strategy, socialGraphServiceClient grpc.SocialGraphServiceClient, userServiceClient grpc.UserServiceClient, cfg *config.Config, version string, userCache *usercache.Cache, timelineCache *fanout.TimelineCache) *TimelineHandler {
 	return &TimelineHandler{
-		strategies: strategies,
-		config:     cfg,
+		strategies:               strategies,
+		socialGraphServiceClient: socialGraphServiceClient,
+		userServiceClient:        userServiceClient,
+		config:                   cfg,
+		version:                  version,
+		startTime:                time.Now(),
+		userCache:                userCache,
+		timelineCache:            timelineCache,
 	}
 }
 
@@ -30,23 +57,155 @@ func (h *TimelineHandler) GetTimeline(c *gin.Context) {
 		return
 	}
 
-	// Use algorithm from environment config
-	algorithm := h.config.FanoutStrategy
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil {
+		limit = defaultTimelineLimit
+	}
+	if limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must not be negative", "error_code": "INVALID_LIMIT"})
+		return
+	}
 
+	// ?strategy= overrides FANOUT_STRATEGY for this request, so strategies can be compared
+	// against one deployment; cursors only continue pages of the strategy that issued them
+	algorithm := c.DefaultQuery("strategy", h.config.FanoutStrategy)
+	switch algorithm {
+	case "push", "pull", "hybrid":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "strategy must be 'push', 'pull', or 'hybrid'", "error_code": "INVALID_STRATEGY"})
+		return
+	}
+
+	// An empty timeline is a 200 with an empty array; failures are never masked as empty
+	timeline, err := h.loadTimeline(c.Request.Context(), userID, limit, c.Query("cursor"), algorithm)
+	if errors.Is(err, fanout.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "error_code": "INVALID_CURSOR"})
+		return
+	}
+	if errors.Is(err, errStrategyUnavailable) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "error_code": "STRATEGY_UNAVAILABLE"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to get timeline for user %d: %v", userID, err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error(), "error_code": "DEPENDENCY_FAILED"})
+		return
+	}
+
+	// Optionally flag whether the viewer follows each post's author
+	if c.Query("enrich_relationships") == "true" {
+		h.enrichRelationships(c.Request.Context(), userID, timeline)
+	}
+
+	c.JSON(http.StatusOK, timeline)
+}
+
+// loadTimeline reads a page of a timeline with the named strategy and fills in authors
+// It is shared by the HTTP and gRPC entry points, which reject negative limits; here a
+// limit of 0 becomes the default and larger ones are clamped, so every strategy gets
+// the same page size for the same request
+func (h *TimelineHandler) loadTimeline(ctx context.Context, userID int64, limit int, cursor, algorithm string) (*models.TimelineResponse, error) {
 	strategy, ok := h.strategies[algorithm]
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Configured strategy not available: " + algorithm})
+		return nil, fmt.Errorf("%w: %s", errStrategyUnavailable, algorithm)
+	}
+
+	if limit == 0 {
+		limit = defaultTimelineLimit
+	}
+	limit = min(limit, h.config.TimelineMaxLimit)
+
+	timeline, err := strategy.GetTimeline(ctx, userID, limit, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	h.enrichAuthors(ctx, timeline)
+	return timeline, nil
+}
+
+// enrichAuthors sets Author on each post from a single BatchGetUserInfo call
+// If the User Service is unavailable, Author falls back to the stored author fields with a warning
+func (h *TimelineHandler) enrichAuthors(ctx context.Context, timeline *models.TimelineResponse) {
+	if len(timeline.Timeline) == 0 {
+		return
+	}
+
+	seen := make(map[int64]bool)
+	authorIDs := make([]int64, 0)
+	for _, post := range timeline.Timeline {
+		if !seen[post.AuthorID] {
+			seen[post.AuthorID] = true
+			authorIDs = append(authorIDs, post.AuthorID)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	users := map[int64]grpc.UserInfo{}
+	resp, err := h.userServiceClient.BatchGetUserInfo(ctx, authorIDs)
+	if err != nil {
+		log.Printf("Failed to enrich authors: %v", err)
+		timeline.AddWarning("Author information unavailable, author usernames may be missing")
+	} else {
+		users = resp.Users
+	}
+
+	for i := range timeline.Timeline {
+		post := &timeline.Timeline[i]
+		if user, ok := users[post.AuthorID]; ok {
+			post.AuthorName = user.Username
+		}
+		post.Author = &models.Author{
+			ID:       post.AuthorID,
+			Username: post.AuthorName,
+		}
+	}
+}
+
+// enrichRelationships sets ViewerFollowsAuthor on each post with one batch check of the
+// page's distinct authors, so the cost follows the page size rather than the viewer's following count
+// At most RelationshipMaxAuthors distinct authors are checked; posts by further authors are left unflagged
+// If the Social Graph Service is unavailable the timeline is returned as-is with a warning
+func (h *TimelineHandler) enrichRelationships(ctx context.Context, viewerID int64, timeline *models.TimelineResponse) {
+	authorIDs := relationshipAuthors(timeline.Timeline, h.config.RelationshipMaxAuthors)
+	if len(authorIDs) == 0 {
 		return
 	}
 
-	timeline, err := strategy.GetTimeline(userID, limit)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	following, err := h.socialGraphServiceClient.CheckFollowing(ctx, viewerID, authorIDs)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Printf("Failed to enrich relationships for user %d: %v", viewerID, err)
+		timeline.AddWarning("Relationship information unavailable, viewer_follows_author will be omitted")
 		return
 	}
 
-	c.JSON(http.StatusOK, timeline)
+	for i := range timeline.Timeline {
+		post := &timeline.Timeline[i]
+		if follows, ok := following[post.AuthorID]; ok {
+			post.ViewerFollowsAuthor = &follows
+		}
+	}
+}
+
+// relationshipAuthors returns the distinct author IDs of posts in page order, at most maxAuthors of them
+func relationshipAuthors(posts []models.TimelinePost, maxAuthors int) []int64 {
+	seen := make(map[int64]bool)
+	var authorIDs []int64
+	for _, post := range posts {
+		if len(authorIDs) >= maxAuthors {
+			break
+		}
+		if !seen[post.AuthorID] {
+			seen[post.AuthorID] = true
+			authorIDs = append(authorIDs, post.AuthorID)
+		}
+	}
+	return authorIDs
 }
 
 // Health check endpoint
@@ -54,11 +213,18 @@ func (h *TimelineHandler) Health(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":               "healthy",
 		"service":              "timeline-service",
+		"version":              h.version,
+		"uptime":               time.Since(h.startTime).Round(time.Second).String(),
+		"timestamp":            time.Now().UTC().Format(time.RFC3339),
 		"current_strategy":     h.config.FanoutStrategy,
 		"available_strategies": []string{"push", "pull", "hybrid"},
 		"message_processing":   "SQS-based async processing",
+		"decode_failures":      fanout.DecodeFailures(),
+		"dynamodb_timeouts":    dynamotimeout.OperationTimeouts(),
+		"user_cache":           h.userCache.Stats(),
+		"timeline_cache":       h.timelineCache.Stats(),
 		"endpoints": gin.H{
-			"timeline": "GET /api/timeline/:user_id",
+			"timeline": "GET /api/timeline/:user_id?limit=&cursor=&strategy=",
 			"health":   "GET /api/health",
 		},
 	})
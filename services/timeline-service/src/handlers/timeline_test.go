@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/config"
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/fanout"
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
+	timelinepb "github.com/cs6650/proto/timeline"
+	"github.com/gin-gonic/gin"
+)
+
+// limitRecorder is a strategy that serves empty pages and records the limit it was asked for
+type limitRecorder struct {
+	name  string
+	limit int
+	calls int
+}
+
+func (s *limitRecorder) GetName() string { return s.name }
+
+func (s *limitRecorder) FanoutPost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error {
+	return nil
+}
+
+func (s *limitRecorder) RemovePost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error {
+	return nil
+}
+
+func (s *limitRecorder) GetTimeline(ctx context.Context, userID int64, limit int, cursor string) (*models.TimelineResponse, error) {
+	s.limit = limit
+	s.calls++
+	return &models.TimelineResponse{Timeline: []models.TimelinePost{}}, nil
+}
+
+func newLimitTestHandler() (*TimelineHandler, map[string]*limitRecorder) {
+	recorders := map[string]*limitRecorder{}
+	strategies := map[string]fanout.Strategy{}
+	for _, name := range []string{"push", "pull", "hybrid"} {
+		recorders[name] = &limitRecorder{name: name}
+		strategies[name] = recorders[name]
+	}
+	cfg := &config.Config{FanoutStrategy: "push", TimelineMaxLimit: 100}
+	return NewTimelineHandler(strategies, nil, nil, cfg, "test", nil, nil), recorders
+}
+
+func TestGetTimelineLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		limit      string
+		wantStatus int
+		wantLimit  int
+	}{
+		{name: "missing", limit: "", wantStatus: http.StatusOK, wantLimit: defaultTimelineLimit},
+		{name: "non-numeric", limit: "abc", wantStatus: http.StatusOK, wantLimit: defaultTimelineLimit},
+		{name: "zero", limit: "0", wantStatus: http.StatusOK, wantLimit: defaultTimelineLimit},
+		{name: "negative", limit: "-5", wantStatus: http.StatusBadRequest},
+		{name: "in range", limit: "20", wantStatus: http.StatusOK, wantLimit: 20},
+		{name: "oversized", limit: "100000", wantStatus: http.StatusOK, wantLimit: 100},
+	}
+
+	// Every strategy gets the same sanitized limit
+	for _, strategy := range []string{"push", "pull", "hybrid"} {
+		for _, tt := range tests {
+			t.Run(strategy+"/"+tt.name, func(t *testing.T) {
+				h, recorders := newLimitTestHandler()
+				router := gin.New()
+				router.GET("/api/timeline/:user_id", h.GetTimeline)
+
+				w := httptest.NewRecorder()
+				url := fmt.Sprintf("/api/timeline/1?strategy=%s&limit=%s", strategy, tt.limit)
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+
+				if w.Code != tt.wantStatus {
+					t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body)
+				}
+				recorder := recorders[strategy]
+				if tt.wantStatus != http.StatusOK {
+					if recorder.calls != 0 {
+						t.Errorf("strategy called for a rejected limit")
+					}
+					return
+				}
+				if recorder.limit != tt.wantLimit {
+					t.Errorf("strategy limit = %d, want %d", recorder.limit, tt.wantLimit)
+				}
+			})
+		}
+	}
+}
+
+func TestGRPCGetTimelineLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         int32
+		wantErrorCode string
+		wantLimit     int
+	}{
+		{name: "zero", limit: 0, wantLimit: defaultTimelineLimit},
+		{name: "negative", limit: -1, wantErrorCode: "INVALID_ARGUMENT"},
+		{name: "in range", limit: 20, wantLimit: 20},
+		{name: "oversized", limit: 100000, wantLimit: 100},
+	}
+	for _, tt := range tests {
+		h, recorders := newLimitTestHandler()
+		resp, err := NewGRPCHandler(h).GetTimeline(context.Background(), &timelinepb.GetTimelineRequest{UserId: 1, Limit: tt.limit})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if resp.ErrorCode != tt.wantErrorCode {
+			t.Errorf("%s: error code = %q, want %q", tt.name, resp.ErrorCode, tt.wantErrorCode)
+		}
+		if tt.wantErrorCode == "" && recorders["push"].limit != tt.wantLimit {
+			t.Errorf("%s: strategy limit = %d, want %d", tt.name, recorders["push"].limit, tt.wantLimit)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -17,9 +18,18 @@ import (
 	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/handlers"
 	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/processor"
 	sqsClient "github.com/PCBZ/CS6650-Project/services/timeline-service/src/sqs"
+	"github.com/cs6650/proto/grpcdial"
+	"github.com/cs6650/proto/grpcreflection"
+	timelinepb "github.com/cs6650/proto/timeline"
+	"github.com/cs6650/proto/usercache"
 	"github.com/gin-gonic/gin"
+	googlegrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 )
 
+// version is injected at build time via -ldflags "-X main.version=<version>"
+var version = "dev"
+
 // corsMiddleware handles CORS for requests from API Gateway
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -41,6 +51,9 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 	log.Printf("Loaded config: %+v", cfg)
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
 	log.Printf("Timeline Service starting - Environment: %s, Strategy: %s, Port: %d",
 		cfg.Env, cfg.FanoutStrategy, cfg.Port)
@@ -50,7 +63,7 @@ func main() {
 	defer cancel()
 
 	// Connect to DynamoDB
-	dynamoClient, err := db.NewDynamoDBClient(ctx, cfg.AWSRegion)
+	dynamoClient, err := db.NewDynamoDBClient(ctx, cfg.AWSRegion, cfg.DynamoDBOperationTimeout)
 	if err != nil {
 		log.Fatalf("Failed to create DynamoDB client: %v", err)
 	}
@@ -65,28 +78,43 @@ func main() {
 
 	// Initialize service clients
 	// Create clients - they will fail gracefully on first use if connection fails during startup
-	userServiceClient := grpc.NewUserServiceClient(cfg.UserServiceEndpoint)
-	postServiceClient := grpc.NewPostServiceClient(cfg.PostServiceEndpoint)
-	socialGraphServiceClient := grpc.NewSocialGraphServiceClient(cfg.SocialGraphServiceEndpoint)
+	keepaliveParams := cfg.KeepaliveParams()
+	userCache := usercache.New(cfg.UserCacheSize, cfg.UserCacheTTL)
+	userServiceClient := grpc.NewUserServiceClient(cfg.UserServiceEndpoint, keepaliveParams, cfg.RetryOnUnavailable, userCache)
+	postServiceClient := grpc.NewPostServiceClient(cfg.PostServiceEndpoint, keepaliveParams)
+	socialGraphServiceClient := grpc.NewSocialGraphServiceClient(cfg.SocialGraphServiceEndpoint, keepaliveParams)
 
 	// Initialize strategies
+	timelineTTL := time.Duration(cfg.TimelineTTLDays) * 24 * time.Hour
+	pushStrategy := fanout.NewPushStrategy(dynamoClient.GetClient(), cfg.PostsTableName, timelineTTL, cfg.IncludeOwnPosts)
+	// Timelines are served from the cache for TimelineCacheTTL; fan-outs through cachedPush invalidate them
+	timelineCache := fanout.NewTimelineCache(cfg.TimelineCacheSize, cfg.TimelineCacheTTL)
+	cachedPush := fanout.WithCache(pushStrategy, timelineCache)
 	strategies := map[string]fanout.Strategy{
-		"push":   fanout.NewPushStrategy(dynamoClient.GetClient(), cfg.PostsTableName),
-		"pull":   fanout.NewPullStrategy(postServiceClient, socialGraphServiceClient),
-		"hybrid": fanout.NewHybridStrategy(dynamoClient.GetClient(), cfg.PostsTableName, postServiceClient, socialGraphServiceClient),
+		"push":   cachedPush,
+		"pull":   fanout.WithCache(fanout.NewPullStrategy(postServiceClient, socialGraphServiceClient, cfg.PullFetchWorkers, int32(cfg.PullPostsPerUser), cfg.PullMaxFollowing, cfg.IncludeOwnPosts), timelineCache),
+		"hybrid": fanout.WithCache(fanout.NewHybridStrategy(dynamoClient.GetClient(), cfg.PostsTableName, postServiceClient, socialGraphServiceClient, timelineTTL, cfg.HybridAllowPartial, cfg.HybridMaxMerged, cfg.PullFetchWorkers, int32(cfg.PullPostsPerUser), cfg.PullMaxFollowing, cfg.IncludeOwnPosts), timelineCache),
 	}
 
 	// Initialize SQS processor for handling feed write messages
-	pushStrategy := strategies["push"]
 	sqsProcessor := processor.NewSQSProcessor(
 		sqsClientWrapper.GetClient(),
 		cfg.SQSQueueURL,
-		pushStrategy,
+		cachedPush,
 		userServiceClient,
+		cfg.SQSBatchDelete,
+		cfg.SQSWorkers,
+		cfg.DLQURL,
+		cfg.SQSMaxReceives,
+		cfg.SQSVisibilityHeartbeat,
+		cfg.SQSMaxVisibilityExtension,
+		cfg.SQSDrainTimeout,
 	)
 
 	// Setup handlers
-	timelineHandler := handlers.NewTimelineHandler(strategies, cfg)
+	timelineHandler := handlers.NewTimelineHandler(strategies, socialGraphServiceClient, userServiceClient, cfg, version, userCache, timelineCache)
+	adminHandler := handlers.NewAdminHandler(pushStrategy, postServiceClient, socialGraphServiceClient, userServiceClient, timelineCache)
+	grpcHandler := handlers.NewGRPCHandler(timelineHandler)
 
 	// Setup Gin router
 	router := gin.Default()
@@ -108,6 +136,17 @@ func main() {
 	router.GET("/timeline/:user_id", timelineHandler.GetTimeline)
 	router.GET("/health", timelineHandler.Health)
 
+	// Admin routes get their own router, served only on the internal admin listener
+	adminRouter := gin.Default()
+	admin := adminRouter.Group("/api/admin")
+	{
+		// Backfill followers' timelines when migrating an author from pull to push
+		admin.POST("/backfill", adminHandler.BackfillTimelines)
+
+		// Repair a user's timeline from their following list
+		admin.POST("/timeline/:user_id/rebuild", adminHandler.RebuildTimeline)
+	}
+
 	// Server configuration
 	server := &http.Server{
 		Addr:           fmt.Sprintf(":%d", cfg.Port),
@@ -116,14 +155,55 @@ func main() {
 		WriteTimeout:   15 * time.Second,
 		MaxHeaderBytes: 1 << 20,
 	}
+	// A rebuild runs for up to two minutes, so the write timeout leaves room for it
+	adminServer := &http.Server{
+		Addr:           cfg.AdminAddr,
+		Handler:        adminRouter,
+		ReadTimeout:    15 * time.Second,
+		WriteTimeout:   150 * time.Second,
+		MaxHeaderBytes: 1 << 20,
+	}
 
-	// Start SQS processor in a goroutine
+	// Warn if the Post Service writes in a way this service's reads won't see
+	go checkWriteStrategy(postServiceClient, cfg.FanoutStrategy)
+
+	// Start SQS processor in a goroutine; cancelling consumeCtx on shutdown stops polling
+	consumeCtx, stopConsuming := context.WithCancel(context.Background())
+	defer stopConsuming()
+	processorDone := make(chan struct{})
 	go func() {
-		if err := sqsProcessor.ProcessMessages(context.Background()); err != nil {
+		defer close(processorDone)
+		if err := sqsProcessor.ProcessMessages(consumeCtx); err != nil {
 			log.Printf("SQS processor failed: %v", err)
 		}
 	}()
 
+	// Start gRPC server for internal consumers
+	grpcServer := googlegrpc.NewServer(
+		// Accept keepalive pings from clients, including on idle connections
+		googlegrpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             grpcdial.MinKeepaliveTime,
+			PermitWithoutStream: true,
+		}),
+	)
+	timelinepb.RegisterTimelineServiceServer(grpcServer, grpcHandler)
+
+	// Reflection for grpcurl, on in dev; GRPC_REFLECTION overrides
+	if grpcreflection.Register(grpcServer) {
+		log.Println("gRPC reflection enabled")
+	}
+
+	go func() {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
+		if err != nil {
+			log.Fatalf("Failed to listen on gRPC port %d: %v", cfg.GRPCPort, err)
+		}
+		log.Printf("gRPC server starting on :%d", cfg.GRPCPort)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("gRPC server failed: %v", err)
+		}
+	}()
+
 	// Start server in a goroutine
 	go func() {
 		log.Printf("Server starting on %s", server.Addr)
@@ -132,6 +212,13 @@ func main() {
 		}
 	}()
 
+	go func() {
+		log.Printf("Admin server starting on %s", adminServer.Addr)
+		if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Admin server failed to start: %v", err)
+		}
+	}()
+
 	// Wait for interrupt signal
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -139,6 +226,9 @@ func main() {
 
 	log.Println("Shutdown signal received")
 
+	// Stop taking messages now; the processor drains those in flight while the servers stop
+	stopConsuming()
+
 	// Graceful shutdown with timeout
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer shutdownCancel()
@@ -146,6 +236,38 @@ func main() {
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Fatalf("Server shutdown failed: %v", err)
 	}
+	if err := adminServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Admin server shutdown failed: %v", err)
+	}
+	grpcServer.GracefulStop()
+
+	// The processor interrupts its messages at the drain deadline; allow a little longer
+	// for them to unwind, but never hang shutdown on a stuck call
+	select {
+	case <-processorDone:
+	case <-time.After(cfg.SQSDrainTimeout + 5*time.Second):
+		log.Println("SQS processor did not stop in time, exiting anyway")
+	}
 
 	log.Println("Server gracefully stopped")
 }
+
+// checkWriteStrategy compares the Post Service's POST_STRATEGY with FANOUT_STRATEGY
+// and logs a warning on mismatch, which otherwise shows up as empty or missing feeds
+func checkWriteStrategy(postServiceClient grpc.PostServiceClient, readStrategy string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	writeStrategy, err := postServiceClient.GetWriteStrategy(ctx)
+	if err != nil {
+		log.Printf("WARNING: could not verify Post Service write strategy: %v", err)
+		return
+	}
+
+	if !fanout.CompatibleWithWriteStrategy(readStrategy, writeStrategy) {
+		log.Printf("WARNING: strategy mismatch: Post Service writes with %q (POST_STRATEGY) but timelines are read with %q (FANOUT_STRATEGY); feeds will be incomplete",
+			writeStrategy, readStrategy)
+		return
+	}
+	log.Printf("Write strategy check passed: Post Service writes with %q, timelines read with %q", writeStrategy, readStrategy)
+}
@@ -10,25 +10,58 @@ type Post struct {
 	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at"`
 }
 
+// TimelinePost is a post in a timeline. A pushed post is stored once per timeline, so
+// it has two IDs: PostID names the post and EntryID names its copy in one timeline.
 type TimelinePost struct {
-	PostID     string    `json:"post_id" dynamodbav:"post_id"`
+	// PostID is the Post Service's ID for the post, the same under every strategy; clients
+	// and merges compare posts by it. Entries written before the Post Service's ID was
+	// stored have none and fall back to their EntryID.
+	PostID string `json:"post_id" dynamodbav:"original_post_id,omitempty"`
+
+	// EntryID is the key of the push timeline entry holding the post ("{post_id}_{user_id}");
+	// empty for posts read from the Post Service. It only locates the entry, e.g. for paging.
+	EntryID string `json:"entry_id,omitempty" dynamodbav:"post_id"`
+
 	UserID     int64     `json:"user_id" dynamodbav:"user_id"`
 	AuthorID   int64     `json:"author_id" dynamodbav:"author_id"`
 	AuthorName string    `json:"author_name" dynamodbav:"username"`
 	Content    string    `json:"content" dynamodbav:"content"`
 	CreatedAt  time.Time `json:"created_at" dynamodbav:"created_at"`
+
+	// Author is filled uniformly by the handler regardless of which strategy served the post
+	Author *Author `json:"author,omitempty" dynamodbav:"-"`
+
+	// ViewerFollowsAuthor is only set when relationship enrichment is requested
+	ViewerFollowsAuthor *bool `json:"viewer_follows_author,omitempty" dynamodbav:"-"`
+}
+
+// Author is the post author as returned to clients
+type Author struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
 }
 
 type TimelineResponse struct {
 	Timeline   []TimelinePost `json:"timeline"`
-	TotalCount int            `json:"total_count"`
+	TotalCount int            `json:"total_count"`           // Posts in this page after deduplication, not the total available
+	NextCursor string         `json:"next_cursor,omitempty"` // Pass as ?cursor= for the next page; empty on the last
+	Warning    string         `json:"warning,omitempty"`
+}
+
+// AddWarning appends a warning, keeping any earlier ones
+func (r *TimelineResponse) AddWarning(warning string) {
+	if r.Warning != "" {
+		warning = r.Warning + "; " + warning
+	}
+	r.Warning = warning
 }
 
 type FanoutRequest struct {
-	PostID      string    `json:"post_id" binding:"required"`
-	AuthorID    int64     `json:"author_id" binding:"required"`   // 帖子作者ID
-	AuthorName  string    `json:"author_name" binding:"required"` // 作者用户名
-	Content     string    `json:"content" binding:"required"`
-	FollowerIDs []int64   `json:"follower_ids" binding:"required"`
-	CreatedAt   time.Time `json:"created_at" binding:"required"`
+	PostID         string    `json:"post_id" binding:"required"`
+	OriginalPostID string    `json:"original_post_id,omitempty"`     // Post Service ID, when known
+	AuthorID       int64     `json:"author_id" binding:"required"`   // 帖子作者ID
+	AuthorName     string    `json:"author_name" binding:"required"` // 作者用户名
+	Content        string    `json:"content" binding:"required"`
+	FollowerIDs    []int64   `json:"follower_ids" binding:"required"`
+	CreatedAt      time.Time `json:"created_at" binding:"required"`
 }
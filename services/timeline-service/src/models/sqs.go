@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,6 +10,7 @@ import (
 // SQSFeedMessage represents the SQS message from Post Service
 type SQSFeedMessage struct {
 	EventType     string    `json:"event_type"`
+	PostID        int64     `json:"post_id"` // 0 from publishers that predate it
 	AuthorID      int64     `json:"author_id"`
 	TargetUserIDs []int64   `json:"target_user_ids"`
 	Content       string    `json:"content"`
@@ -17,15 +19,22 @@ type SQSFeedMessage struct {
 
 // ToFanoutRequest converts SQS message to FanoutRequest
 func (msg *SQSFeedMessage) ToFanoutRequest(authorName string) *FanoutRequest {
-	// Generate a new UUID for the post ID
+	// Key entries on the Post Service's ID so a redelivered message overwrites its
+	// earlier writes; messages without one get a fresh UUID as before
+	var originalPostID string
 	postID := uuid.New().String()
+	if msg.PostID > 0 {
+		originalPostID = strconv.FormatInt(msg.PostID, 10)
+		postID = originalPostID
+	}
 
 	return &FanoutRequest{
-		PostID:      postID,
-		AuthorID:    msg.AuthorID,
-		AuthorName:  authorName,
-		Content:     msg.Content,
-		FollowerIDs: msg.TargetUserIDs,
-		CreatedAt:   msg.CreatedTime,
+		PostID:         postID,
+		OriginalPostID: originalPostID,
+		AuthorID:       msg.AuthorID,
+		AuthorName:     authorName,
+		Content:        msg.Content,
+		FollowerIDs:    msg.TargetUserIDs,
+		CreatedAt:      msg.CreatedTime,
 	}
 }
@@ -5,67 +5,182 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/fanout"
 	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/grpc"
 	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/cs6650/proto/logthrottle"
 )
 
+// errorLogs throttles the failures logged for every message or poll, which repeat in a
+// tight loop while SQS or a downstream service is down
+var errorLogs = logthrottle.New(5, time.Minute)
+
 type SQSProcessor struct {
 	sqsClient         *sqs.Client
 	queueURL          string
 	pushStrategy      fanout.Strategy
 	userServiceClient grpc.UserServiceClient
+	batchDelete       bool   // delete each poll's processed messages in one DeleteMessageBatch call
+	workers           int    // messages processed concurrently
+	dlqURL            string // failed messages go here after maxReceives attempts; empty = retry forever
+	maxReceives       int
+
+	// While a message is processed, its visibility timeout is extended every heartbeat
+	// (0 = never) for at most maxExtension, so long fan-outs are not redelivered mid-way
+	heartbeat    time.Duration
+	maxExtension time.Duration
+
+	drainTimeout time.Duration // on shutdown, how long messages in flight may take to finish
 }
 
-func NewSQSProcessor(sqsClient *sqs.Client, queueURL string, pushStrategy fanout.Strategy, userServiceClient grpc.UserServiceClient) *SQSProcessor {
+func NewSQSProcessor(sqsClient *sqs.Client, queueURL string, pushStrategy fanout.Strategy, userServiceClient grpc.UserServiceClient, batchDelete bool, workers int, dlqURL string, maxReceives int, heartbeat, maxExtension, drainTimeout time.Duration) *SQSProcessor {
 	return &SQSProcessor{
 		sqsClient:         sqsClient,
 		queueURL:          queueURL,
 		pushStrategy:      pushStrategy,
 		userServiceClient: userServiceClient,
+		batchDelete:       batchDelete,
+		workers:           workers,
+		dlqURL:            dlqURL,
+		maxReceives:       maxReceives,
+		heartbeat:         heartbeat,
+		maxExtension:      maxExtension,
+		drainTimeout:      drainTimeout,
 	}
 }
 
-// ProcessMessages polls SQS and processes incoming messages
+// ProcessMessages polls SQS and processes incoming messages on up to p.workers goroutines.
+// Polling continues while messages are processed, waiting only when every worker is busy,
+// so one slow message does not hold up the rest. Each message is deleted only after it
+// was processed successfully or moved to the dead-letter queue.
+//
+// When ctx is cancelled, polling stops and the messages in flight are given up to
+// p.drainTimeout to finish before ProcessMessages returns. Messages still processing
+// at the deadline are interrupted and left undeleted, so SQS redelivers them.
 func (p *SQSProcessor) ProcessMessages(ctx context.Context) error {
-	log.Println("SQS Processor started, polling for messages...")
-	
+	log.Printf("SQS Processor started with %d workers, polling for messages...", p.workers)
+
+	// Messages are processed under work, which outlives ctx until the drain deadline
+	work, interrupt := context.WithCancel(context.WithoutCancel(ctx))
+	defer interrupt()
+
+	slots := make(chan struct{}, p.workers)
+	var inFlight sync.WaitGroup
+
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("SQS Processor shutting down")
-			return ctx.Err()
+			log.Printf("SQS Processor shutting down, draining messages in flight for up to %s", p.drainTimeout)
+			deadline := time.AfterFunc(p.drainTimeout, interrupt)
+			inFlight.Wait()
+			if !deadline.Stop() {
+				log.Println("SQS Processor drain deadline passed, interrupted messages will be redelivered")
+			}
+			log.Println("SQS Processor stopped")
+			return nil
 		default:
 			// Poll for messages
 			result, err := p.sqsClient.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
 				QueueUrl:            &p.queueURL,
 				MaxNumberOfMessages: int32(10),
 				WaitTimeSeconds:     int32(20), // Long polling
+				MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+					types.MessageSystemAttributeNameApproximateReceiveCount,
+				},
 			})
 			if err != nil {
-				log.Printf("Failed to receive SQS messages: %v", err)
+				if ctx.Err() == nil {
+					errorLogs.Printf("Failed to receive SQS messages: %v", err)
+				}
 				continue
 			}
 
-			// Process each message
+			// With batch delete, a batch's successes are deleted together once all of it is done
+			var batch *processedBatch
+			if p.batchDelete && len(result.Messages) > 0 {
+				batch = &processedBatch{}
+				batch.wg.Add(len(result.Messages))
+				inFlight.Add(1)
+				go func() {
+					defer inFlight.Done()
+					batch.wg.Wait()
+					if len(batch.messages) > 0 && work.Err() == nil {
+						p.deleteMessages(work, batch.messages)
+					}
+				}()
+			}
+
 			for _, message := range result.Messages {
-				if err := p.processMessage(ctx, message); err != nil {
-					log.Printf("Failed to process message %s: %v", *message.MessageId, err)
-					continue
-				}
-				
-				// Delete message after successful processing
-				if err := p.deleteMessage(ctx, message); err != nil {
-					log.Printf("Failed to delete message %s: %v", *message.MessageId, err)
-				}
+				slots <- struct{}{} // Wait for a free worker
+				inFlight.Add(1)
+				go func() {
+					defer func() {
+						<-slots
+						inFlight.Done()
+					}()
+					p.handleMessage(work, message, batch)
+				}()
 			}
 		}
 	}
 }
 
+// processedBatch collects the messages of one receive that are ready to delete
+type processedBatch struct {
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	messages []types.Message
+}
+
+// handleMessage processes one message and deletes it, or adds it to batch for deletion
+// when batch deletes are enabled. Failed messages are left for redelivery until they have
+// been received maxReceives times; then they are moved to the dead-letter queue, if set.
+// Messages whose processing was interrupted by shutdown are left as they are, for redelivery.
+func (p *SQSProcessor) handleMessage(ctx context.Context, message types.Message, batch *processedBatch) {
+	if batch != nil {
+		defer batch.wg.Done()
+	}
+
+	stopHeartbeat := p.startHeartbeat(ctx, message)
+	err := p.processMessage(ctx, message)
+	stopHeartbeat()
+	if ctx.Err() != nil {
+		log.Printf("Processing of message %s interrupted by shutdown, leaving it for redelivery", *message.MessageId)
+		return
+	}
+	if err != nil {
+		errorLogs.Printf("Failed to process message %s: %v", *message.MessageId, err)
+		if !p.exhausted(message) {
+			return
+		}
+		if err := p.sendToDLQ(ctx, message); err != nil {
+			log.Printf("Failed to move message %s to the dead-letter queue: %v", *message.MessageId, err)
+			return
+		}
+		log.Printf("Moved message %s to the dead-letter queue after %d receives", *message.MessageId, receiveCount(message))
+	}
+
+	if batch != nil {
+		batch.mu.Lock()
+		batch.messages = append(batch.messages, message)
+		batch.mu.Unlock()
+		return
+	}
+
+	// Delete message after successful processing or dead-lettering
+	if err := p.deleteMessage(ctx, message); err != nil {
+		log.Printf("Failed to delete message %s: %v", *message.MessageId, err)
+	}
+}
+
 // processMessage processes a single SQS message
 func (p *SQSProcessor) processMessage(ctx context.Context, message types.Message) error {
 	// Parse the SQS message
@@ -74,9 +189,14 @@ func (p *SQSProcessor) processMessage(ctx context.Context, message types.Message
 		return fmt.Errorf("failed to unmarshal SQS message: %w", err)
 	}
 
-	// Validate message
-	if sqsMessage.EventType != "FeedWrite" {
-		return fmt.Errorf("unsupported event type: %s", sqsMessage.EventType)
+	// Other events share the post topic (e.g. UserMentioned); they are not ours to retry
+	switch sqsMessage.EventType {
+	case "FeedWrite":
+	case "FeedDelete":
+		return p.removePost(ctx, sqsMessage)
+	default:
+		log.Printf("Skipping %s event in message %s", sqsMessage.EventType, *message.MessageId)
+		return nil
 	}
 
 	// Check if user service client is available
@@ -85,13 +205,12 @@ func (p *SQSProcessor) processMessage(ctx context.Context, message types.Message
 	}
 
 	// Get author name from User Service via gRPC
-	userInfoResponse, err := p.userServiceClient.BatchGetUserInfo(ctx, []int64{sqsMessage.AuthorID})
+	authorInfo, found, err := p.userServiceClient.GetUserInfo(ctx, sqsMessage.AuthorID)
 	if err != nil {
 		return fmt.Errorf("failed to get author info: %w", err)
 	}
 
 	// Check if author was found
-	authorInfo, found := userInfoResponse.Users[sqsMessage.AuthorID]
 	if !found {
 		return fmt.Errorf("author not found: %d", sqsMessage.AuthorID)
 	}
@@ -100,13 +219,126 @@ func (p *SQSProcessor) processMessage(ctx context.Context, message types.Message
 	fanoutReq := sqsMessage.ToFanoutRequest(authorInfo.Username)
 
 	// Process through push strategy (fan-out to DynamoDB)
-	if err := p.pushStrategy.FanoutPost(fanoutReq, sqsMessage.TargetUserIDs); err != nil {
+	if err := p.pushStrategy.FanoutPost(ctx, fanoutReq, sqsMessage.TargetUserIDs); err != nil {
 		return fmt.Errorf("failed to fanout post: %w", err)
 	}
 
 	return nil
 }
 
+// startHeartbeat keeps a message invisible while it is processed by extending its
+// visibility timeout to two heartbeats on every heartbeat, until the returned stop is
+// called or maxExtension has passed. Each extension outlives the next beat, so a slow
+// ChangeMessageVisibility call does not expose the message.
+func (p *SQSProcessor) startHeartbeat(ctx context.Context, message types.Message) (stop func()) {
+	if p.heartbeat <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(p.heartbeat)
+		defer ticker.Stop()
+		deadline := time.Now().Add(p.maxExtension)
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if now.After(deadline) {
+					log.Printf("Message %s still processing after %s, no longer extending its visibility", *message.MessageId, p.maxExtension)
+					return
+				}
+				_, err := p.sqsClient.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+					QueueUrl:          &p.queueURL,
+					ReceiptHandle:     message.ReceiptHandle,
+					VisibilityTimeout: visibilityExtension(p.heartbeat),
+				})
+				if err != nil {
+					log.Printf("Failed to extend visibility of message %s: %v", *message.MessageId, err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// visibilityExtension is the visibility timeout set on each heartbeat: two heartbeats,
+// rounded up to whole seconds, as a timeout of 0 would expose the message at once
+func visibilityExtension(heartbeat time.Duration) int32 {
+	return int32(max(1, math.Ceil((2 * heartbeat).Seconds())))
+}
+
+// removePost deletes a deleted post's copies from the message's target timelines
+func (p *SQSProcessor) removePost(ctx context.Context, sqsMessage models.SQSFeedMessage) error {
+	// Copies are keyed on the Post Service's ID; without one there is nothing to match
+	if sqsMessage.PostID <= 0 {
+		return fmt.Errorf("FeedDelete message without a post ID")
+	}
+
+	if err := p.pushStrategy.RemovePost(ctx, sqsMessage.ToFanoutRequest(""), sqsMessage.TargetUserIDs); err != nil {
+		return fmt.Errorf("failed to remove post: %w", err)
+	}
+	return nil
+}
+
+// exhausted reports whether a failed message should stop being retried: a dead-letter
+// queue is configured and the message has been received maxReceives times
+func (p *SQSProcessor) exhausted(message types.Message) bool {
+	return p.dlqURL != "" && receiveCount(message) >= p.maxReceives
+}
+
+// receiveCount returns a message's ApproximateReceiveCount, or 0 when it is missing
+func receiveCount(message types.Message) int {
+	count, _ := strconv.Atoi(message.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+	return count
+}
+
+// sendToDLQ copies a message's body to the dead-letter queue; the caller deletes the original
+func (p *SQSProcessor) sendToDLQ(ctx context.Context, message types.Message) error {
+	_, err := p.sqsClient.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    &p.dlqURL,
+		MessageBody: message.Body,
+	})
+	return err
+}
+
+// deleteMessages deletes processed and dead-lettered messages in a single batch call
+// (a receive returns at most 10, the DeleteMessageBatch limit). Messages that
+// fail to delete are logged and will be redelivered after the visibility timeout.
+func (p *SQSProcessor) deleteMessages(ctx context.Context, messages []types.Message) {
+	entries := make([]types.DeleteMessageBatchRequestEntry, 0, len(messages))
+	for i, message := range messages {
+		entries = append(entries, types.DeleteMessageBatchRequestEntry{
+			Id:            aws.String(strconv.Itoa(i)),
+			ReceiptHandle: message.ReceiptHandle,
+		})
+	}
+
+	result, err := p.sqsClient.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+		QueueUrl: &p.queueURL,
+		Entries:  entries,
+	})
+	if err != nil {
+		log.Printf("Failed to delete batch of %d messages: %v", len(messages), err)
+		return
+	}
+
+	for _, failed := range result.Failed {
+		i, _ := strconv.Atoi(aws.ToString(failed.Id))
+		log.Printf("Failed to delete message %s: %s %s", aws.ToString(messages[i].MessageId), aws.ToString(failed.Code), aws.ToString(failed.Message))
+	}
+}
+
 // deleteMessage deletes a message from SQS queue
 func (p *SQSProcessor) deleteMessage(ctx context.Context, message types.Message) error {
 	_, err := p.sqsClient.DeleteMessage(ctx, &sqs.DeleteMessageInput{
@@ -0,0 +1,201 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/fanout"
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/fanout/fanouttest"
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/grpc"
+	"github.com/PCBZ/CS6650-Project/services/timeline-service/src/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// missingAuthors is a User Service that never finds anyone
+type missingAuthors struct{}
+
+func (missingAuthors) BatchGetUserInfo(ctx context.Context, userIDs []int64) (*grpc.BatchGetUserInfoResponse, error) {
+	return &grpc.BatchGetUserInfoResponse{Users: map[int64]grpc.UserInfo{}, NotFound: userIDs}, nil
+}
+
+func (missingAuthors) GetUserInfo(ctx context.Context, userID int64) (*grpc.UserInfo, bool, error) {
+	return nil, false, nil
+}
+
+// countingStrategy counts fan-outs; a message whose author is missing must not reach it
+type countingStrategy struct {
+	fanouts int
+}
+
+func (s *countingStrategy) GetName() string { return "push" }
+
+func (s *countingStrategy) FanoutPost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error {
+	s.fanouts++
+	return nil
+}
+
+func (s *countingStrategy) RemovePost(ctx context.Context, req *models.FanoutRequest, followerIDs []int64) error {
+	return nil
+}
+
+func (s *countingStrategy) GetTimeline(ctx context.Context, userID int64, limit int, cursor string) (*models.TimelineResponse, error) {
+	return &models.TimelineResponse{}, nil
+}
+
+// fakeSQS records the SQS operations called and the bodies sent
+type fakeSQS struct {
+	mu         sync.Mutex
+	operations []string
+	bodies     []string
+}
+
+func (f *fakeSQS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "AmazonSQS.")
+
+	f.mu.Lock()
+	f.operations = append(f.operations, operation)
+	if operation == "SendMessage" {
+		f.bodies = append(f.bodies, string(body))
+	}
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	if operation == "SendMessage" {
+		io.WriteString(w, `{"MessageId":"dlq-1"}`)
+		return
+	}
+	io.WriteString(w, `{}`)
+}
+
+func newTestProcessor(t *testing.T, dlqURL string, maxReceives int) (*SQSProcessor, *fakeSQS, *countingStrategy) {
+	fake := &fakeSQS{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client := sqs.New(sqs.Options{
+		Region:                           "us-west-2",
+		BaseEndpoint:                     aws.String(server.URL),
+		Credentials:                      aws.AnonymousCredentials{},
+		DisableMessageChecksumValidation: true,
+	})
+	strategy := &countingStrategy{}
+	p := NewSQSProcessor(client, server.URL+"/queue", strategy, missingAuthors{}, false, 1, dlqURL, maxReceives, 0, 0, time.Second)
+	return p, fake, strategy
+}
+
+func feedMessage(receives string) types.Message {
+	return types.Message{
+		MessageId:     aws.String("m-1"),
+		ReceiptHandle: aws.String("r-1"),
+		Body:          aws.String(`{"event_type":"FeedWrite","post_id":7,"author_id":404,"target_user_ids":[1,2],"content":"hi"}`),
+		Attributes: map[string]string{
+			string(types.MessageSystemAttributeNameApproximateReceiveCount): receives,
+		},
+	}
+}
+
+// A message whose author is never found is retried until maxReceives, then dead-lettered and deleted
+func TestHandleMessageAuthorNeverFound(t *testing.T) {
+	tests := []struct {
+		name           string
+		dlqURL         string
+		receives       string
+		wantOperations []string
+	}{
+		{name: "first receive is left for redelivery", dlqURL: "http://dlq", receives: "1"},
+		{name: "below max receives is left for redelivery", dlqURL: "http://dlq", receives: "2"},
+		{name: "at max receives is dead-lettered", dlqURL: "http://dlq", receives: "3", wantOperations: []string{"SendMessage", "DeleteMessage"}},
+		{name: "past max receives is dead-lettered", dlqURL: "http://dlq", receives: "9", wantOperations: []string{"SendMessage", "DeleteMessage"}},
+		{name: "without a DLQ it is retried forever", receives: "9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, fake, strategy := newTestProcessor(t, tt.dlqURL, 3)
+			p.handleMessage(context.Background(), feedMessage(tt.receives), nil)
+
+			if strategy.fanouts != 0 {
+				t.Errorf("fanned out %d times, want 0 for a missing author", strategy.fanouts)
+			}
+			if strings.Join(fake.operations, ",") != strings.Join(tt.wantOperations, ",") {
+				t.Errorf("SQS operations = %v, want %v", fake.operations, tt.wantOperations)
+			}
+			if len(tt.wantOperations) > 0 && (len(fake.bodies) != 1 || !strings.Contains(fake.bodies[0], `\"author_id\":404`)) {
+				t.Errorf("dead-lettered bodies = %v, want the original message", fake.bodies)
+			}
+		})
+	}
+}
+
+func TestReceiveCount(t *testing.T) {
+	tests := []struct {
+		attributes map[string]string
+		want       int
+	}{
+		{attributes: nil, want: 0},
+		{attributes: map[string]string{"ApproximateReceiveCount": "4"}, want: 4},
+		{attributes: map[string]string{"ApproximateReceiveCount": "x"}, want: 0},
+	}
+	for _, tt := range tests {
+		if got := receiveCount(types.Message{Attributes: tt.attributes}); got != tt.want {
+			t.Errorf("receiveCount(%v) = %d, want %d", tt.attributes, got, tt.want)
+		}
+	}
+}
+
+// knownAuthors is a User Service that finds every user, named after their ID
+type knownAuthors struct{}
+
+func (knownAuthors) BatchGetUserInfo(ctx context.Context, userIDs []int64) (*grpc.BatchGetUserInfoResponse, error) {
+	users := make(map[int64]grpc.UserInfo, len(userIDs))
+	for _, id := range userIDs {
+		users[id] = grpc.UserInfo{UserID: id, Username: fmt.Sprintf("user%d", id)}
+	}
+	return &grpc.BatchGetUserInfoResponse{Users: users}, nil
+}
+
+func (knownAuthors) GetUserInfo(ctx context.Context, userID int64) (*grpc.UserInfo, bool, error) {
+	return &grpc.UserInfo{UserID: userID, Username: fmt.Sprintf("user%d", userID)}, true, nil
+}
+
+// A FeedWrite message, as the Post Service publishes it, lands in every target's timeline
+func TestFeedWriteReachesFollowerTimelines(t *testing.T) {
+	table, client := fanouttest.NewTimelineTable(t)
+	push := fanout.NewPushStrategy(client, "timeline", 30*24*time.Hour, false)
+	p := NewSQSProcessor(nil, "", push, knownAuthors{}, false, 1, "", 5, 0, 0, time.Second)
+
+	created := time.Unix(1_700_000_000, 0).UTC()
+	body := fmt.Sprintf(`{"event_type":"FeedWrite","post_id":7,"author_id":3,"target_user_ids":[10,11],"content":"hello","created_time":%q,"strategy":"push"}`, created.Format(time.RFC3339))
+	if err := p.processMessage(context.Background(), types.Message{MessageId: aws.String("m-1"), Body: aws.String(body)}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, follower := range []int64{10, 11} {
+		timeline, err := push.GetTimeline(context.Background(), follower, 20, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(timeline.Timeline) != 1 {
+			t.Fatalf("follower %d timeline has %d posts, want the one fanned out", follower, len(timeline.Timeline))
+		}
+		got := timeline.Timeline[0]
+		if got.PostID != "7" || got.AuthorID != 3 || got.AuthorName != "user3" || got.Content != "hello" || !got.CreatedAt.Equal(created) {
+			t.Errorf("follower %d timeline post = %+v, want post 7 by user3", follower, got)
+		}
+	}
+	if timeline, _ := push.GetTimeline(context.Background(), 3, 20, ""); len(timeline.Timeline) != 0 {
+		t.Errorf("author's own timeline has %d posts, want none without includeOwn", len(timeline.Timeline))
+	}
+	if table.Len() != 2 {
+		t.Errorf("table holds %d entries, want one per follower", table.Len())
+	}
+}
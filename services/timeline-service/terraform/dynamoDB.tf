@@ -33,6 +33,12 @@ resource "aws_dynamodb_table" "posts" {
     write_capacity  = 0
   }
 
+  # Fanned-out entries expire; the canonical post stays in the Post Service
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
+
   point_in_time_recovery {
     enabled = var.enable_pitr
   }
@@ -148,6 +148,7 @@ module "ecs" {
   user_service_url          = var.user_service_url
   fanout_strategy           = var.fanout_strategy
   celebrity_threshold       = var.celebrity_threshold
+  timeline_ttl_days         = var.timeline_ttl_days
 
   # Auto-scaling configuration
   min_capacity                 = var.min_capacity
@@ -48,6 +48,10 @@ resource "aws_ecs_task_definition" "app" {
           name  = "AWS_REGION"
           value = var.region
         },
+        {
+          name  = "GRPC_REFLECTION"
+          value = "false"
+        },
         {
           name  = "DYNAMODB_TABLE_NAME"
           value = var.dynamodb_table_name
@@ -75,6 +79,10 @@ resource "aws_ecs_task_definition" "app" {
         {
           name  = "CELEBRITY_THRESHOLD"
           value = tostring(var.celebrity_threshold)
+        },
+        {
+          name  = "TIMELINE_TTL_DAYS"
+          value = tostring(var.timeline_ttl_days)
         }
       ]
 
@@ -177,3 +177,9 @@ variable "service_discovery_namespace_name" {
   type        = string
   default     = ""
 }
+
+variable "timeline_ttl_days" {
+  type        = number
+  description = "Days before a fanned-out timeline entry expires (0 disables expiry)"
+  default     = 30
+}
@@ -46,4 +46,10 @@ resource "aws_sns_topic_subscription" "timeline_subscription" {
 
   # Enable raw message delivery to avoid SNS wrapper
   raw_message_delivery = true
+
+  # PostCreated events are for analytics only; messages without an event_type
+  # attribute (older publishers) still arrive
+  filter_policy = jsonencode({
+    event_type = [{ "anything-but" = ["PostCreated"] }, { exists = false }]
+  })
 }
@@ -117,6 +117,12 @@ variable "fanout_strategy" {
   }
 }
 
+variable "timeline_ttl_days" {
+  description = "Days before a fanned-out timeline entry expires (0 disables expiry)"
+  type        = number
+  default     = 30
+}
+
 variable "celebrity_threshold" {
   description = "Follower count threshold to determine celebrity status for hybrid strategy"
   type        = number
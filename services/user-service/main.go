@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -11,13 +13,18 @@ import (
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	pb "github.com/cs6650/proto"
+	"github.com/cs6650/proto/lru"
 
 	"github.com/gorilla/mux"
 	"github.com/lib/pq"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 )
 
 // User represents a user in the system
@@ -39,10 +46,34 @@ type CreateUserResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// BatchCreateUsersRequest represents the request body for creating many users at once
+type BatchCreateUsersRequest struct {
+	Usernames []string `json:"usernames"`
+}
+
+// BatchCreateUsersResponse lists the users created and the usernames skipped as duplicates
+type BatchCreateUsersResponse struct {
+	Created []CreateUserResponse `json:"created"`
+	Skipped []string             `json:"skipped"`
+}
+
+// maxBatchCreateUsers bounds the usernames accepted by one batch create
+const maxBatchCreateUsers = 1000
+
 // GetUsersResponse represents the response for getting all users
+// TotalCount is only included when requested (by default on the first page)
+// NextCursor is set whenever HasMore is, and can be passed as ?cursor= instead of ?page=
 type GetUsersResponse struct {
 	Users      []User `json:"users"`
-	TotalCount int    `json:"total_count"`
+	TotalCount *int   `json:"total_count,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// usersCursor is the last user seen on a page, in (created_at, user_id) order
+type usersCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	UserID    int       `json:"user_id"`
 }
 
 // ErrorResponse represents an error response
@@ -50,8 +81,18 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// version is injected at build time via -ldflags "-X main.version=<version>"
+var version = "dev"
+
+// startTime is used to report process uptime in health checks
+var startTime = time.Now()
+
+// usernamePattern whitelists the characters allowed in new usernames
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
 type Server struct {
-	db *sql.DB
+	db    *sql.DB
+	users UserStore // Lookups by ID and creation; may be cached in front of db
 	pb.UnimplementedUserServiceServer
 }
 
@@ -79,6 +120,16 @@ func main() {
 	}
 	defer db.Close()
 
+	// Size the pool so concurrent requests don't exhaust Postgres's max_connections
+	maxOpenConns := getEnvInt("DB_MAX_OPEN_CONNS", 25)
+	maxIdleConns := getEnvInt("DB_MAX_IDLE_CONNS", 10)
+	connMaxLifetime := getEnvDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute)
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
+	db.SetConnMaxLifetime(connMaxLifetime)
+	log.Printf("Database pool: max open conns=%d, max idle conns=%d, conn max lifetime=%s",
+		maxOpenConns, min(maxIdleConns, maxOpenConns), connMaxLifetime)
+
 	// Test database connection
 	if err := db.Ping(); err != nil {
 		log.Fatal("Failed to ping database:", err)
@@ -89,13 +140,27 @@ func main() {
 		log.Fatal("Failed to initialize database schema:", err)
 	}
 
-	server := &Server{db: db}
+	// Optionally cache hot ID lookups (timeline enrichment) in front of Postgres. Off by
+	// default: with several replicas a deleted user is served by the others for up to the TTL.
+	var users UserStore = &postgresUserStore{db: db}
+	if getEnvBool("USER_CACHE_ENABLED", false) {
+		cacheTTL := getEnvDuration("USER_CACHE_TTL", 10*time.Second)
+		cacheSize := getEnvInt("USER_CACHE_SIZE", 10000)
+		users = newCachingUserStore(users, cacheTTL, cacheSize)
+		log.Printf("User cache: ttl=%s, size=%d", cacheTTL, cacheSize)
+	}
+
+	server := &Server{db: db, users: users}
 
 	// Setup HTTP routes
 	router := mux.NewRouter()
-	router.HandleFunc("/health", healthHandler).Methods("GET")
+	router.HandleFunc("/health", server.healthHandler).Methods("GET")
 	router.HandleFunc("/api/users", server.createUserHandler).Methods("POST")
 	router.HandleFunc("/api/users", server.getUsersHandler).Methods("GET")
+	router.HandleFunc("/api/users/batch", server.batchCreateUsersHandler).Methods("POST")
+	router.HandleFunc("/api/users/by-username/{username}", server.getUserByUsernameHandler).Methods("GET")
+	router.HandleFunc("/api/users/{id}", server.getUserHandler).Methods("GET")
+	router.HandleFunc("/api/users/{id}", server.deleteUserHandler).Methods("DELETE")
 
 	// Enable CORS
 	router.Use(corsMiddleware)
@@ -114,15 +179,57 @@ func main() {
 		log.Fatalf("Failed to listen on port %s: %v", grpcPort, err)
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		// Accept keepalive pings from clients, including on idle connections
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             10 * time.Second,
+			PermitWithoutStream: true,
+		}),
+	)
 	pb.RegisterUserServiceServer(grpcServer, server)
 
+	// Report readiness to gRPC health probes, following the database
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthServer.SetServingStatus(pb.UserService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	go watchDatabaseHealth(db, healthServer)
+
 	log.Printf("User Service gRPC server starting on port %s", grpcPort)
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("Failed to serve gRPC server: %v", err)
 	}
 }
 
+// dbHealthCheckInterval is how often the gRPC health status is refreshed from a database ping
+const dbHealthCheckInterval = 10 * time.Second
+
+// watchDatabaseHealth reports SERVING while the database answers pings and NOT_SERVING otherwise
+func watchDatabaseHealth(db *sql.DB, healthServer *health.Server) {
+	ticker := time.NewTicker(dbHealthCheckInterval)
+	defer ticker.Stop()
+
+	current := healthpb.HealthCheckResponse_NOT_SERVING
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		err := db.PingContext(ctx)
+		cancel()
+
+		status := healthpb.HealthCheckResponse_SERVING
+		if err != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		if status != current {
+			log.Printf("gRPC health status: %s (db ping error: %v)", status, err)
+			healthServer.SetServingStatus("", status)
+			healthServer.SetServingStatus(pb.UserService_ServiceDesc.ServiceName, status)
+			current = status
+		}
+
+		<-ticker.C
+	}
+}
+
 // initializeServiceDatabase creates the service database if it doesn't exist
 func initializeServiceDatabase(host, port, masterUser, masterPassword, sslMode, dbName string) error {
 	// Validate database name to prevent SQL injection (alphanumeric and underscores only)
@@ -183,6 +290,7 @@ func initializeSchema(db *sql.DB) error {
 
 	CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
 	CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at);
+	CREATE INDEX IF NOT EXISTS idx_users_created_at_user_id ON users(created_at, user_id);
 	`
 
 	_, err := db.Exec(createTableQuery)
@@ -194,6 +302,168 @@ func initializeSchema(db *sql.DB) error {
 	return nil
 }
 
+// errUserNotFound is returned by a UserStore when no user has the requested ID
+var errUserNotFound = errors.New("user not found")
+
+// errUsernameTaken is returned by UserStore.Create when the username is already in use
+var errUsernameTaken = errors.New("username already exists")
+
+// UserStore is the storage the ID-based handlers and RPCs use, so they do not depend on
+// Postgres directly. Listing, username lookups and bulk seeding still query the database.
+type UserStore interface {
+	// Get returns errUserNotFound when the user does not exist
+	Get(ctx context.Context, userID int64) (*User, error)
+	// BatchGet returns the users that exist, keyed by ID; missing IDs are simply absent
+	BatchGet(ctx context.Context, userIDs []int64) (map[int64]*User, error)
+	// Create returns errUsernameTaken when the username is in use
+	Create(ctx context.Context, username string) (*User, error)
+	// Delete reports whether the user existed
+	Delete(ctx context.Context, userID int64) (bool, error)
+}
+
+// postgresUserStore is the UserStore backed by the users table
+type postgresUserStore struct {
+	db *sql.DB
+}
+
+func (p *postgresUserStore) Get(ctx context.Context, userID int64) (*User, error) {
+	query := `
+		SELECT user_id, username, created_at
+		FROM users
+		WHERE user_id = $1
+	`
+
+	var user User
+	err := p.db.QueryRowContext(ctx, query, userID).Scan(&user.UserID, &user.Username, &user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (p *postgresUserStore) BatchGet(ctx context.Context, userIDs []int64) (map[int64]*User, error) {
+	query := `
+		SELECT user_id, username, created_at
+		FROM users
+		WHERE user_id = ANY($1)
+	`
+
+	rows, err := p.db.QueryContext(ctx, query, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[int64]*User, len(userIDs))
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.UserID, &user.Username, &user.CreatedAt); err != nil {
+			return nil, err
+		}
+		users[int64(user.UserID)] = &user
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (p *postgresUserStore) Create(ctx context.Context, username string) (*User, error) {
+	query := `
+		INSERT INTO users (username) 
+		VALUES ($1) 
+		RETURNING user_id, username, created_at
+	`
+
+	var user User
+	err := p.db.QueryRowContext(ctx, query, username).Scan(&user.UserID, &user.Username, &user.CreatedAt)
+	if err != nil {
+		if err.Error() == `pq: duplicate key value violates unique constraint "users_username_key"` {
+			return nil, errUsernameTaken
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (p *postgresUserStore) Delete(ctx context.Context, userID int64) (bool, error) {
+	result, err := p.db.ExecContext(ctx, "DELETE FROM users WHERE user_id = $1", userID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// cachingUserStore serves ID lookups from memory for up to ttl, fetching only misses from next.
+// Users are never renamed, so the only staleness is a deleted user: this replica drops it at
+// once, but other replicas keep serving it until their entry expires, for at most ttl.
+type cachingUserStore struct {
+	next  UserStore
+	users *lru.Cache[int64, *User]
+}
+
+func newCachingUserStore(next UserStore, ttl time.Duration, maxEntries int) *cachingUserStore {
+	return &cachingUserStore{
+		next:  next,
+		users: lru.New[int64, *User](maxEntries, ttl),
+	}
+}
+
+func (c *cachingUserStore) Get(ctx context.Context, userID int64) (*User, error) {
+	if user, ok := c.users.Get(userID); ok {
+		return user, nil
+	}
+	user, err := c.next.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	c.users.Set(int64(user.UserID), user)
+	return user, nil
+}
+
+func (c *cachingUserStore) BatchGet(ctx context.Context, userIDs []int64) (map[int64]*User, error) {
+	users := make(map[int64]*User, len(userIDs))
+	var misses []int64
+	for _, id := range userIDs {
+		if user, ok := c.users.Get(id); ok {
+			users[id] = user
+		} else {
+			misses = append(misses, id)
+		}
+	}
+	if len(misses) == 0 {
+		return users, nil
+	}
+
+	fetched, err := c.next.BatchGet(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+	for id, user := range fetched {
+		users[id] = user
+		c.users.Set(id, user)
+	}
+	return users, nil
+}
+
+func (c *cachingUserStore) Create(ctx context.Context, username string) (*User, error) {
+	return c.next.Create(ctx, username)
+}
+
+func (c *cachingUserStore) Delete(ctx context.Context, userID int64) (bool, error) {
+	deleted, err := c.next.Delete(ctx, userID)
+	c.users.Remove(userID)
+	return deleted, err
+}
+
 func (s *Server) createUserHandler(w http.ResponseWriter, r *http.Request) {
 	var req CreateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -202,33 +472,102 @@ func (s *Server) createUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate username
-	if len(req.Username) < 3 || len(req.Username) > 30 {
-		writeErrorResponse(w, "Username must be between 3 and 30 characters", http.StatusBadRequest)
+	if msg := validateNewUsername(req.Username); msg != "" {
+		writeErrorResponse(w, msg, http.StatusBadRequest)
 		return
 	}
 
 	// Insert user into database
-	var user CreateUserResponse
+	user, err := s.users.Create(r.Context(), req.Username)
+	if err == errUsernameTaken {
+		writeErrorResponse(w, "Username already exists", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Database error: %v", err)
+		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateUserResponse(*user))
+}
+
+// batchCreateUsersHandler creates up to maxBatchCreateUsers users in one INSERT for test data seeding
+// Usernames that already exist, or repeat within the batch, are skipped rather than failing the batch
+func (s *Server) batchCreateUsersHandler(w http.ResponseWriter, r *http.Request) {
+	var req BatchCreateUsersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Usernames) == 0 {
+		writeErrorResponse(w, "usernames cannot be empty", http.StatusBadRequest)
+		return
+	}
+	if len(req.Usernames) > maxBatchCreateUsers {
+		writeErrorResponse(w, fmt.Sprintf("At most %d usernames per batch", maxBatchCreateUsers), http.StatusBadRequest)
+		return
+	}
+
+	// Validate every name before inserting any, so a bad batch creates nothing
+	for i, username := range req.Usernames {
+		if msg := validateNewUsername(username); msg != "" {
+			writeErrorResponse(w, fmt.Sprintf("usernames[%d] %q: %s", i, username, msg), http.StatusBadRequest)
+			return
+		}
+	}
+
 	query := `
-		INSERT INTO users (username) 
-		VALUES ($1) 
+		INSERT INTO users (username)
+		SELECT unnest($1::varchar[])
+		ON CONFLICT (username) DO NOTHING
 		RETURNING user_id, username, created_at
 	`
 
-	err := s.db.QueryRow(query, req.Username).Scan(&user.UserID, &user.Username, &user.CreatedAt)
+	rows, err := s.db.QueryContext(r.Context(), query, pq.Array(req.Usernames))
 	if err != nil {
-		if err.Error() == `pq: duplicate key value violates unique constraint "users_username_key"` {
-			writeErrorResponse(w, "Username already exists", http.StatusBadRequest)
+		log.Printf("Database error: %v", err)
+		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	response := BatchCreateUsersResponse{
+		Created: make([]CreateUserResponse, 0, len(req.Usernames)),
+		Skipped: []string{},
+	}
+	created := make(map[string]bool, len(req.Usernames))
+	for rows.Next() {
+		var user CreateUserResponse
+		if err := rows.Scan(&user.UserID, &user.Username, &user.CreatedAt); err != nil {
+			log.Printf("Row scan error: %v", err)
+			writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
-		log.Printf("Database error: %v", err)
+		response.Created = append(response.Created, user)
+		created[user.Username] = true
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Rows iteration error: %v", err)
 		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
+	// Anything not returned already existed or appeared earlier in the batch
+	for _, username := range req.Usernames {
+		if created[username] {
+			delete(created, username) // Later repeats of a created name count as skipped
+			continue
+		}
+		response.Skipped = append(response.Skipped, username)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(user)
+	json.NewEncoder(w).Encode(response)
 }
 
 func (s *Server) getUsersHandler(w http.ResponseWriter, r *http.Request) {
@@ -250,24 +589,61 @@ func (s *Server) getUsersHandler(w http.ResponseWriter, r *http.Request) {
 
 	offset := (page - 1) * limit
 
+	// A cursor takes precedence over page: it is stable while users are being created
+	var cursor *usersCursor
+	if cursorStr := r.URL.Query().Get("cursor"); cursorStr != "" {
+		c, err := decodeUsersCursor(cursorStr)
+		if err != nil {
+			writeErrorResponse(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		cursor = c
+	}
+
+	// Only count on the first page unless the caller asks otherwise via with_total
+	withTotal := page == 1 && cursor == nil
+	if withTotalStr := r.URL.Query().Get("with_total"); withTotalStr != "" {
+		if wt, err := strconv.ParseBool(withTotalStr); err == nil {
+			withTotal = wt
+		}
+	}
+
 	// Get total count
-	var totalCount int
-	countQuery := "SELECT COUNT(*) FROM users"
-	if err := s.db.QueryRow(countQuery).Scan(&totalCount); err != nil {
-		log.Printf("Database error getting count: %v", err)
-		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
-		return
+	var totalCount *int
+	if withTotal {
+		var count int
+		countQuery := "SELECT COUNT(*) FROM users"
+		if err := s.db.QueryRow(countQuery).Scan(&count); err != nil {
+			log.Printf("Database error getting count: %v", err)
+			writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		totalCount = &count
 	}
 
 	// Get users with pagination (without follower/following counts)
-	query := `
-		SELECT user_id, username, created_at 
-		FROM users 
-		ORDER BY created_at DESC 
-		LIMIT $1 OFFSET $2
-	`
-
-	rows, err := s.db.Query(query, limit, offset)
+	// user_id breaks created_at ties so pages have a total order a cursor can resume from
+	// Fetch one extra row to determine has_more without counting
+	var rows *sql.Rows
+	var err error
+	if cursor != nil {
+		query := `
+			SELECT user_id, username, created_at
+			FROM users
+			WHERE (created_at, user_id) < ($1, $2)
+			ORDER BY created_at DESC, user_id DESC
+			LIMIT $3
+		`
+		rows, err = s.db.Query(query, cursor.CreatedAt, cursor.UserID, limit+1)
+	} else {
+		query := `
+			SELECT user_id, username, created_at 
+			FROM users 
+			ORDER BY created_at DESC, user_id DESC
+			LIMIT $1 OFFSET $2
+		`
+		rows, err = s.db.Query(query, limit+1, offset)
+	}
 	if err != nil {
 		log.Printf("Database error: %v", err)
 		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
@@ -292,93 +668,313 @@ func (s *Server) getUsersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	hasMore := len(users) > limit
+	var nextCursor string
+	if hasMore {
+		users = users[:limit]
+		last := users[len(users)-1]
+		nextCursor = encodeUsersCursor(usersCursor{CreatedAt: last.CreatedAt, UserID: last.UserID})
+	}
+
 	response := GetUsersResponse{
 		Users:      users,
 		TotalCount: totalCount,
+		HasMore:    hasMore,
+		NextCursor: nextCursor,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-func (s *Server) BatchGetUserInfo(ctx context.Context, req *pb.BatchGetUserInfoRequest) (*pb.BatchGetUserInfoResponse, error) {
-	if len(req.UserIds) == 0 {
-		return &pb.BatchGetUserInfoResponse{
+// encodeUsersCursor serializes a cursor as URL-safe base64 JSON
+func encodeUsersCursor(c usersCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeUsersCursor parses a cursor produced by encodeUsersCursor
+func decodeUsersCursor(s string) (*usersCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c usersCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	if c.UserID <= 0 || c.CreatedAt.IsZero() {
+		return nil, fmt.Errorf("incomplete cursor")
+	}
+	return &c, nil
+}
+
+// getUserHandler returns a single user by ID, or 404 when absent
+func (s *Server) getUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || userID <= 0 {
+		writeErrorResponse(w, "User ID must be a positive integer", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.users.Get(r.Context(), int64(userID))
+	if err == errUserNotFound {
+		writeErrorResponse(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Database error: %v", err)
+		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// deleteUserHandler deletes a user by ID, returning 204 on success and 404 when absent
+func (s *Server) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || userID <= 0 {
+		writeErrorResponse(w, "User ID must be a positive integer", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := s.deleteUser(r.Context(), int64(userID))
+	if err != nil {
+		log.Printf("Database error: %v", err)
+		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		writeErrorResponse(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteUser deletes the user row and reports whether one existed
+// Follow records in the social graph are not touched here; the log line is the hook for that cleanup
+func (s *Server) deleteUser(ctx context.Context, userID int64) (bool, error) {
+	deleted, err := s.users.Delete(ctx, userID)
+	if err != nil || !deleted {
+		return false, err
+	}
+
+	log.Printf("User deleted: user_id=%d (social graph follow records may need cleanup)", userID)
+	return true, nil
+}
+
+// DeleteUser deletes a user over gRPC
+func (s *Server) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	if req.UserId <= 0 {
+		return &pb.DeleteUserResponse{
 			ErrorCode:    "INVALID_ARGUMENT",
-			ErrorMessage: "UserIds cannot be empty",
+			ErrorMessage: "User ID must be a positive integer",
+		}, nil
+	}
+
+	deleted, err := s.deleteUser(ctx, req.UserId)
+	if err != nil {
+		log.Printf("Database error: %v", err)
+		return &pb.DeleteUserResponse{
+			ErrorCode:    "INTERNAL",
+			ErrorMessage: "Internal server error",
+		}, nil
+	}
+	if !deleted {
+		return &pb.DeleteUserResponse{
+			ErrorCode:    "NOT_FOUND",
+			ErrorMessage: "User not found",
 		}, nil
 	}
 
-	// Convert int64 slice to interface slice for pq.Array
-	userIDs := make([]interface{}, len(req.UserIds))
-	for i, id := range req.UserIds {
-		userIDs[i] = id
+	return &pb.DeleteUserResponse{}, nil
+}
+
+// getUserByUsernameHandler resolves a username to its user, returning 404 when absent
+func (s *Server) getUserByUsernameHandler(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+	if !isValidUsername(username) {
+		writeErrorResponse(w, "Username must be between 3 and 30 characters", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.findUserByUsername(r.Context(), username)
+	if err == sql.ErrNoRows {
+		writeErrorResponse(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Database error: %v", err)
+		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// findUserByUsername returns sql.ErrNoRows when no user has the given username
+func (s *Server) findUserByUsername(ctx context.Context, username string) (*User, error) {
 	query := `
-		SELECT user_id, username 
-		FROM users 
-		WHERE user_id = ANY($1)
+		SELECT user_id, username, created_at
+		FROM users
+		WHERE username = $1
 	`
 
-	rows, err := s.db.Query(query, pq.Array(userIDs))
+	var user User
+	if err := s.db.QueryRowContext(ctx, query, username).Scan(&user.UserID, &user.Username, &user.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUserByUsername resolves a username to its user over gRPC
+func (s *Server) GetUserByUsername(ctx context.Context, req *pb.GetUserByUsernameRequest) (*pb.GetUserByUsernameResponse, error) {
+	if !isValidUsername(req.Username) {
+		return &pb.GetUserByUsernameResponse{
+			ErrorCode:    "INVALID_ARGUMENT",
+			ErrorMessage: "Username must be between 3 and 30 characters",
+		}, nil
+	}
+
+	user, err := s.findUserByUsername(ctx, req.Username)
+	if err == sql.ErrNoRows {
+		return &pb.GetUserByUsernameResponse{
+			ErrorCode:    "NOT_FOUND",
+			ErrorMessage: "User not found",
+		}, nil
+	}
 	if err != nil {
 		log.Printf("Database error: %v", err)
-		return &pb.BatchGetUserInfoResponse{
+		return &pb.GetUserByUsernameResponse{
 			ErrorCode:    "INTERNAL",
 			ErrorMessage: "Internal server error",
 		}, nil
 	}
-	defer rows.Close()
 
-	users := make(map[int64]*pb.UserInfo)
-	notFound := []int64{}
+	return &pb.GetUserByUsernameResponse{
+		User: &pb.UserInfo{
+			UserId:   int64(user.UserID),
+			Username: user.Username,
+		},
+		CreatedAt: user.CreatedAt.Unix(),
+	}, nil
+}
 
-	for rows.Next() {
-		var userID int64
-		var username string
-		if err := rows.Scan(&userID, &username); err != nil {
-			log.Printf("Row scan error: %v", err)
-			return &pb.BatchGetUserInfoResponse{
-				ErrorCode:    "INTERNAL",
-				ErrorMessage: "Internal server error",
-			}, nil
-		}
-		users[userID] = &pb.UserInfo{
-			UserId:   userID,
-			Username: username,
-		}
+// GetUserInfo retrieves a single user over gRPC, returning NOT_FOUND when absent
+func (s *Server) GetUserInfo(ctx context.Context, req *pb.GetUserInfoRequest) (*pb.GetUserInfoResponse, error) {
+	if req.UserId <= 0 {
+		return &pb.GetUserInfoResponse{
+			ErrorCode:    "INVALID_ARGUMENT",
+			ErrorMessage: "User ID must be a positive integer",
+		}, nil
 	}
 
-	// Check for not found user IDs
-	for _, id := range req.UserIds {
-		if _, found := users[id]; !found {
-			notFound = append(notFound, id)
-		}
+	user, err := s.users.Get(ctx, req.UserId)
+	if err == errUserNotFound {
+		return &pb.GetUserInfoResponse{
+			ErrorCode:    "NOT_FOUND",
+			ErrorMessage: "User not found",
+		}, nil
+	}
+	if err != nil {
+		log.Printf("Database error: %v", err)
+		return &pb.GetUserInfoResponse{
+			ErrorCode:    "INTERNAL",
+			ErrorMessage: "Internal server error",
+		}, nil
 	}
 
-	if err := rows.Err(); err != nil {
-		log.Printf("Rows iteration error: %v", err)
+	return &pb.GetUserInfoResponse{User: &pb.UserInfo{
+		UserId:   int64(user.UserID),
+		Username: user.Username,
+	}}, nil
+}
+
+func (s *Server) BatchGetUserInfo(ctx context.Context, req *pb.BatchGetUserInfoRequest) (*pb.BatchGetUserInfoResponse, error) {
+	if len(req.UserIds) == 0 {
+		return &pb.BatchGetUserInfoResponse{
+			ErrorCode:    "INVALID_ARGUMENT",
+			ErrorMessage: "UserIds cannot be empty",
+		}, nil
+	}
+
+	found, err := s.users.BatchGet(ctx, req.UserIds)
+	if err != nil {
+		log.Printf("Database error: %v", err)
 		return &pb.BatchGetUserInfoResponse{
 			ErrorCode:    "INTERNAL",
 			ErrorMessage: "Internal server error",
 		}, nil
 	}
 
+	users := make(map[int64]*pb.UserInfo, len(found))
+	notFound := []int64{}
+	for _, id := range req.UserIds {
+		user, ok := found[id]
+		if !ok {
+			notFound = append(notFound, id)
+			continue
+		}
+		users[id] = &pb.UserInfo{
+			UserId:   id,
+			Username: user.Username,
+		}
+	}
+
 	return &pb.BatchGetUserInfoResponse{
 		Users:    users,
 		NotFound: notFound,
 	}, nil
 }
 
-func healthHandler(w http.ResponseWriter, r *http.Request) {
+// healthHandler reports healthy only while the database answers a ping
+func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	status, dbStatus, code := "healthy", "up", http.StatusOK
+	if err := s.db.PingContext(ctx); err != nil {
+		log.Printf("Health check database ping failed: %v", err)
+		status, dbStatus, code = "unhealthy", "down", http.StatusServiceUnavailable
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
 	json.NewEncoder(w).Encode(map[string]string{
-		"status":    "healthy",
+		"status":    status,
+		"db":        dbStatus,
+		"service":   "user-service",
+		"version":   version,
+		"uptime":    time.Since(startTime).Round(time.Second).String(),
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 	})
 }
 
+// isValidUsername checks the username length constraint enforced by the users table
+func isValidUsername(username string) bool {
+	return len(username) >= 3 && len(username) <= 30
+}
+
+// validateNewUsername returns a description of why a username cannot be registered,
+// or "" if it can. Whitespace-only or padded names would look blank or duplicate.
+func validateNewUsername(username string) string {
+	trimmed := strings.TrimSpace(username)
+	if !isValidUsername(trimmed) {
+		return "Username must be between 3 and 30 characters, excluding surrounding whitespace"
+	}
+	if trimmed != username {
+		return "Username must not have leading or trailing whitespace"
+	}
+	if !usernamePattern.MatchString(username) {
+		return "Username may only contain letters, digits, '_', '.' or '-'"
+	}
+	return ""
+}
+
 func writeErrorResponse(w http.ResponseWriter, message string, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -406,3 +1002,30 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intVal, err := strconv.Atoi(value); err == nil && intVal > 0 {
+			return intVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultValue
+}
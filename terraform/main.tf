@@ -121,6 +121,9 @@ module "web_service" {
   post_service_url = "http://post-service:8083"
   post_service_grpc_host = "post-service-grpc:50053"
   
+  # Social Graph Service gRPC (via Service Connect)
+  social_graph_service_grpc_host = "social-graph-service-grpc:50052"
+  
   # Timeline Service URL
   timeline_service_url = "http://timeline-service:8084"
   
@@ -166,6 +169,8 @@ module "post_service" {
   social_graph_url  = "social-graph-service-grpc:50052"
   post_strategy           = var.post_service_post_strategy
   hybrid_threshold        = var.post_service_hybrid_threshold
+  push_min_followers      = var.post_service_push_min_followers
+  environment             = var.environment
   # Auto-scaling settings
   min_capacity                = var.post_service_min_capacity
   max_capacity                = var.post_service_max_capacity
@@ -260,4 +265,27 @@ module "social_graph_service" {
   memory_target_value          = var.social_graph_service_memory_target_value
   enable_request_based_scaling = var.social_graph_service_enable_request_based_scaling
   request_count_target_value   = var.social_graph_service_request_count_target_value
-}
\ No newline at end of file
+}
+
+# Analytics Service
+module "analytics_service" {
+  source = "../services/analytics-service/terraform"
+
+  # Shared infrastructure values
+  vpc_id            = module.network.vpc_id
+  public_subnet_ids = module.network.public_subnet_ids
+
+  # IAM roles for ECS tasks
+  execution_role_arn = module.iam.ecs_task_execution_role_arn
+  task_role_arn      = module.iam.analytics_service_task_role_arn
+
+  # Pass through necessary variables
+  aws_region          = var.aws_region
+  service_name        = "analytics-service"
+  ecr_repository_name = "analytics-service"
+  ecs_count           = var.analytics_service_ecs_count
+  environment         = var.environment
+
+  # Auto-fetch SNS topic ARN from post-service module
+  post_service_sns_topic_arn = module.post_service.sns_topic_arn
+}
@@ -125,3 +125,37 @@ resource "aws_iam_role_policy_attachment" "social_graph_dynamodb" {
   role       = aws_iam_role.social_graph_service_task_role.name
   policy_arn = "arn:aws:iam::aws:policy/AmazonDynamoDBFullAccess"
 }
+
+# Analytics Service Task Role (for DynamoDB and SQS access)
+resource "aws_iam_role" "analytics_service_task_role" {
+  name = "${var.project_name}-${var.environment}-analytics-task-role"
+
+  assume_role_policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action = "sts:AssumeRole"
+        Effect = "Allow"
+        Principal = {
+          Service = "ecs-tasks.amazonaws.com"
+        }
+      }
+    ]
+  })
+
+  tags = {
+    Name        = "${var.project_name}-${var.environment}-analytics-task-role"
+    Environment = var.environment
+    ISBStudent  = "true"  # Required for Innovation Sandbox IAM role creation
+  }
+}
+
+resource "aws_iam_role_policy_attachment" "analytics_dynamodb" {
+  role       = aws_iam_role.analytics_service_task_role.name
+  policy_arn = "arn:aws:iam::aws:policy/AmazonDynamoDBFullAccess"
+}
+
+resource "aws_iam_role_policy_attachment" "analytics_sqs" {
+  role       = aws_iam_role.analytics_service_task_role.name
+  policy_arn = "arn:aws:iam::aws:policy/AmazonSQSFullAccess"
+}
@@ -17,3 +17,8 @@ output "social_graph_service_task_role_arn" {
   description = "ARN of the social graph service task role"
   value       = aws_iam_role.social_graph_service_task_role.arn
 }
+
+output "analytics_service_task_role_arn" {
+  description = "ARN of the analytics service task role"
+  value       = aws_iam_role.analytics_service_task_role.arn
+}
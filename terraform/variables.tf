@@ -262,6 +262,12 @@ variable "post_service_hybrid_threshold" {
   default     = 10000
 }
 
+variable "post_service_push_min_followers" {
+  description = "Minimum follower count for push in hybrid strategy (0 disables the lower bound)"
+  type        = number
+  default     = 0
+}
+
 variable "post_service_min_capacity" {
   description = "Minimum number of tasks for post service auto-scaling"
   type        = number
@@ -346,4 +352,11 @@ variable "social_graph_service_request_count_target_value" {
   description = "Target request count per task for social graph service scaling"
   type        = number
   default     = 1000
-}
\ No newline at end of file
+}
+
+# Analytics Service Configuration
+variable "analytics_service_ecs_count" {
+  description = "Number of ECS tasks for analytics service"
+  type        = number
+  default     = 1
+}
@@ -8,23 +8,46 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	pb "github.com/cs6650/proto"
+	"github.com/cs6650/proto/grpcdial"
+	socialgraphpb "github.com/cs6650/proto/social_graph"
+	"github.com/cs6650/proto/usercache"
 	"github.com/gorilla/mux"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 )
 
+// version is injected at build time via -ldflags "-X main.version=<version>"
+var version = "dev"
+
+// startTime is used to report process uptime in health checks
+var startTime = time.Now()
+
 type Gateway struct {
 	userServiceURL      string
 	userServiceGRPCHost string
 	postServiceURL      string
 	postServiceGRPCHost string
 	timelineServiceURL  string
+	socialGraphGRPCHost string
 	grpcClient          pb.UserServiceClient
 	grpcConn            *grpc.ClientConn
+	socialGraphClient   socialgraphpb.SocialGraphServiceClient
+	socialGraphConn     *grpc.ClientConn
+	userInfoBatchSize   int              // Max user IDs per BatchGetUserInfo call
+	userInfoConcurrency int              // Max concurrent BatchGetUserInfo calls per request
+	userCache           *usercache.Cache // Usernames served without a BatchGetUserInfo call
+	userTimeout         time.Duration    // Per-request budget for user-service calls
+	postTimeout         time.Duration    // Per-request budget for post-service calls
+	timelineTimeout     time.Duration    // Per-request budget for timeline-service calls
+	socialGraphTimeout  time.Duration    // Per-request budget for social-graph-service calls
+	grpcKeepalive       keepalive.ClientParameters
 }
 
 func main() {
@@ -33,6 +56,7 @@ func main() {
 	postServiceURL := getEnv("POST_SERVICE_URL", "http://localhost:8083")
 	postServiceGRPCHost := getEnv("POST_SERVICE_GRPC_HOST", "localhost:50053")
 	timelineServiceURL := getEnv("TIMELINE_SERVICE_URL", "http://localhost:8084")
+	socialGraphGRPCHost := getEnv("SOCIAL_GRAPH_SERVICE_GRPC_HOST", "localhost:50052")
 
 	gateway := &Gateway{
 		userServiceURL:      userServiceURL,
@@ -40,6 +64,24 @@ func main() {
 		postServiceURL:      postServiceURL,
 		postServiceGRPCHost: postServiceGRPCHost,
 		timelineServiceURL:  timelineServiceURL,
+		socialGraphGRPCHost: socialGraphGRPCHost,
+		userInfoBatchSize:   getEnvInt("USER_INFO_BATCH_SIZE", 100),
+		userInfoConcurrency: getEnvInt("USER_INFO_MAX_CONCURRENCY", 4),
+		userCache:           usercache.New(getEnvInt("USER_INFO_CACHE_SIZE", 10000), getEnvDuration("USER_INFO_CACHE_TTL", 5*time.Minute)),
+		userTimeout:         getEnvDuration("USER_SERVICE_TIMEOUT", 10*time.Second),
+		postTimeout:         getEnvDuration("POST_SERVICE_TIMEOUT", 10*time.Second),
+		timelineTimeout:     getEnvDuration("TIMELINE_SERVICE_TIMEOUT", 30*time.Second),
+		socialGraphTimeout:  getEnvDuration("SOCIAL_GRAPH_SERVICE_TIMEOUT", 5*time.Second),
+		grpcKeepalive: keepalive.ClientParameters{
+			Time:                getEnvDuration("GRPC_KEEPALIVE_TIME", 30*time.Second),
+			Timeout:             getEnvDuration("GRPC_KEEPALIVE_TIMEOUT", 10*time.Second),
+			PermitWithoutStream: getEnvBool("GRPC_KEEPALIVE_PERMIT_WITHOUT_STREAM", true),
+		},
+	}
+
+	// Servers close connections that ping more often than they allow
+	if err := grpcdial.ValidateKeepalive(gateway.grpcKeepalive); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
 	}
 
 	// Initialize gRPC connection if gRPC host is provided
@@ -52,10 +94,20 @@ func main() {
 		}
 	}
 
+	// Without a social-graph connection profile feeds are served without the viewer's relationship
+	if socialGraphGRPCHost != "" {
+		if err := gateway.initSocialGraphClient(); err != nil {
+			log.Printf("Warning: Failed to initialize social graph gRPC client: %v. Relationships will be unavailable.", err)
+		} else {
+			log.Printf("Social graph gRPC client initialized successfully for %s", socialGraphGRPCHost)
+			defer gateway.socialGraphConn.Close()
+		}
+	}
+
 	router := mux.NewRouter()
 
 	// Health check endpoint
-	router.HandleFunc("/health", healthHandler).Methods("GET")
+	router.HandleFunc("/health", gateway.healthHandler).Methods("GET")
 
 	// User service routes - support both /users and /api/users paths
 	router.HandleFunc("/users", gateway.createUserHandler).Methods("POST")
@@ -66,6 +118,8 @@ func main() {
 	// Post service routes - support both /posts and /api/posts paths
 	router.HandleFunc("/posts", gateway.createPostHandler).Methods("POST")
 	router.HandleFunc("/api/posts", gateway.createPostHandler).Methods("POST")
+	router.HandleFunc("/users/{id}/posts", gateway.getUserPostsHandler).Methods("GET")
+	router.HandleFunc("/api/users/{id}/posts", gateway.getUserPostsHandler).Methods("GET")
 
 
 	// Timeline service routes - support both /timeline and /api/timeline paths
@@ -82,26 +136,47 @@ func main() {
 	log.Printf("Post Service URL: %s", postServiceURL)
 	log.Printf("Post Service gRPC Host: %s", postServiceGRPCHost)
 	log.Printf("Timeline Service URL: %s", timelineServiceURL)
+	log.Printf("Social Graph Service gRPC Host: %s", socialGraphGRPCHost)
 	log.Fatal(http.ListenAndServe(":"+port, router))
 }
 
 // initGRPCClient establishes a connection to the user-service gRPC endpoint
 func (g *Gateway) initGRPCClient() error {
+	conn, err := g.dialGRPC(g.userServiceGRPCHost)
+	if err != nil {
+		return err
+	}
+
+	g.grpcConn = conn
+	g.grpcClient = pb.NewUserServiceClient(conn)
+	return nil
+}
+
+// initSocialGraphClient establishes a connection to the social-graph-service gRPC endpoint
+func (g *Gateway) initSocialGraphClient() error {
+	conn, err := g.dialGRPC(g.socialGraphGRPCHost)
+	if err != nil {
+		return err
+	}
+
+	g.socialGraphConn = conn
+	g.socialGraphClient = socialgraphpb.NewSocialGraphServiceClient(conn)
+	return nil
+}
+
+// dialGRPC connects to host with the shared dial options, waiting up to 10s for the connection
+func (g *Gateway) dialGRPC(host string) (*grpc.ClientConn, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	// Create gRPC connection with retry and keepalive
-	conn, err := grpc.DialContext(ctx, g.userServiceGRPCHost,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(),
+	conn, err := grpc.DialContext(ctx, host,
+		append(grpcdial.Options(g.grpcKeepalive), grpc.WithBlock())...,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to connect to gRPC server: %w", err)
+		return nil, fmt.Errorf("failed to connect to gRPC server: %w", err)
 	}
-
-	g.grpcConn = conn
-	g.grpcClient = pb.NewUserServiceClient(conn)
-	return nil
+	return conn, nil
 }
 
 // createUserHandler proxies POST /users requests to the user-service
@@ -117,9 +192,10 @@ func (g *Gateway) createUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Create endpoint URL
 	userServiceEndpoint := fmt.Sprintf("%s/api/users", g.userServiceURL)
 
-	// Make the request to user-service
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("POST", userServiceEndpoint, bytes.NewReader(body))
+	// Make the request to user-service, cancelled if the client goes away
+	ctx, cancel := context.WithTimeout(r.Context(), g.userTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", userServiceEndpoint, bytes.NewReader(body))
 	if err != nil {
 		log.Printf("Failed to create request to user-service: %v", err)
 		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
@@ -127,7 +203,7 @@ func (g *Gateway) createUserHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := client.Do(req)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		log.Printf("Failed to forward request to user-service: %v", err)
 		writeErrorResponse(w, "Failed to communicate with user service", http.StatusServiceUnavailable)
@@ -142,22 +218,31 @@ func (g *Gateway) createUserHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // getUsersHandler proxies GET /users requests to the user-service
+// Accepted pagination params: page >= 1, 1 <= limit <= 100 (out-of-range values are reset downstream)
+// with_total=true|false controls whether total_count is computed (default: first page only)
 func (g *Gateway) getUsersHandler(w http.ResponseWriter, r *http.Request) {
+	// Reject obviously malformed pagination params before forwarding
+	if err := validatePaginationParams(r.URL.Query()); err != nil {
+		writeErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Construct the full URL with query parameters
 	userServiceEndpoint := fmt.Sprintf("%s/api/users", g.userServiceURL)
 	if r.URL.RawQuery != "" {
 		userServiceEndpoint = fmt.Sprintf("%s/api/users?%s", g.userServiceURL, r.URL.RawQuery)
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("GET", userServiceEndpoint, nil)
+	ctx, cancel := context.WithTimeout(r.Context(), g.userTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", userServiceEndpoint, nil)
 	if err != nil {
 		log.Printf("Failed to create request to user-service: %v", err)
 		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	resp, err := client.Do(req)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		log.Printf("Failed to forward request to user-service: %v", err)
 		writeErrorResponse(w, "Failed to communicate with user service", http.StatusServiceUnavailable)
@@ -184,9 +269,10 @@ func (g *Gateway) createPostHandler(w http.ResponseWriter, r *http.Request) {
 	// Create endpoint URL
 	postServiceEndpoint := fmt.Sprintf("%s/api/posts", g.postServiceURL)
 
-	// Make the request to post-service
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("POST", postServiceEndpoint, bytes.NewReader(body))
+	// Make the request to post-service, cancelled if the client goes away
+	ctx, cancel := context.WithTimeout(r.Context(), g.postTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", postServiceEndpoint, bytes.NewReader(body))
 	if err != nil {
 		log.Printf("Failed to create request to post-service: %v", err)
 		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
@@ -194,7 +280,7 @@ func (g *Gateway) createPostHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	resp, err := client.Do(req)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		log.Printf("Failed to forward request to post-service: %v", err)
 		writeErrorResponse(w, "Failed to communicate with post service", http.StatusServiceUnavailable)
@@ -208,27 +294,286 @@ func (g *Gateway) createPostHandler(w http.ResponseWriter, r *http.Request) {
 	io.Copy(w, resp.Body)
 }
 
-// BatchGetUserInfo demonstrates using gRPC to call user-service
-// This can be used by other handlers that need to enrich data with user information
+// maxUserPostsLimit bounds the page size for profile feeds
+const maxUserPostsLimit = 50
+
+// getUserPostsHandler serves GET /users/{id}/posts: a user's recent posts from post-service
+// enriched with the author's profile and, when viewer_id is given, whether the viewer follows
+// the author. If enrichment fails the posts are still returned with a warning
+func (g *Gateway) getUserPostsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || userID <= 0 {
+		writeErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var viewerID int64
+	if viewerStr := r.URL.Query().Get("viewer_id"); viewerStr != "" {
+		viewerID, err = strconv.ParseInt(viewerStr, 10, 64)
+		if err != nil || viewerID <= 0 {
+			writeErrorResponse(w, "viewer_id must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			writeErrorResponse(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = min(limit, maxUserPostsLimit)
+	}
+
+	postServiceEndpoint := fmt.Sprintf("%s/api/users/%d/posts?limit=%d", g.postServiceURL, userID, limit)
+
+	ctx, cancel := context.WithTimeout(r.Context(), g.postTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", postServiceEndpoint, nil)
+	if err != nil {
+		log.Printf("Failed to create request to post-service: %v", err)
+		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("Failed to forward request to post-service: %v", err)
+		writeErrorResponse(w, "Failed to communicate with post service", http.StatusServiceUnavailable)
+		return
+	}
+	defer resp.Body.Close()
+
+	// Pass errors through untouched
+	if resp.StatusCode != http.StatusOK {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+		return
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	var payload map[string]interface{}
+	if err := decoder.Decode(&payload); err != nil {
+		log.Printf("Failed to decode post-service response: %v", err)
+		writeErrorResponse(w, "Invalid response from post service", http.StatusBadGateway)
+		return
+	}
+
+	userCtx, userCancel := context.WithTimeout(r.Context(), g.userTimeout)
+	users, err := g.BatchGetUserInfo(userCtx, []int64{userID})
+	userCancel()
+	if user, ok := users[userID]; err == nil && ok {
+		payload["author"] = map[string]interface{}{
+			"id":       userID,
+			"username": user.Username,
+		}
+	} else {
+		if err != nil {
+			log.Printf("Failed to enrich author for user %d: %v", userID, err)
+		}
+		addWarning(payload, "User information unavailable, author will be empty")
+	}
+
+	// Viewing one's own profile has no relationship to report
+	if viewerID != 0 && viewerID != userID {
+		relationship, err := g.checkFollowRelationship(r.Context(), viewerID, userID)
+		if err != nil {
+			log.Printf("Failed to enrich relationship of viewer %d to user %d: %v", viewerID, userID, err)
+			addWarning(payload, "Relationship information unavailable, relationship will be omitted")
+		} else {
+			payload["relationship"] = map[string]interface{}{
+				"viewer_id":      viewerID,
+				"is_following":   relationship.IsFollowing,
+				"is_followed_by": relationship.IsFollowedBy,
+				"is_mutual":      relationship.IsMutual,
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payload)
+}
+
+// checkFollowRelationship reports whether viewerID follows targetID, and the reverse, from social-graph-service
+func (g *Gateway) checkFollowRelationship(ctx context.Context, viewerID, targetID int64) (*socialgraphpb.CheckFollowRelationshipResponse, error) {
+	if g.socialGraphClient == nil {
+		return nil, fmt.Errorf("social graph gRPC client not initialized")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, g.socialGraphTimeout)
+	defer cancel()
+	resp, err := g.socialGraphClient.CheckFollowRelationship(ctx, &socialgraphpb.CheckFollowRelationshipRequest{
+		FollowerUserId: viewerID,
+		TargetUserId:   targetID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gRPC call failed: %w", err)
+	}
+	if resp.ErrorMessage != "" {
+		return nil, fmt.Errorf("social graph service error: %s", resp.ErrorMessage)
+	}
+	return resp, nil
+}
+
+// addWarning sets the payload's warning, keeping any earlier one
+func addWarning(payload map[string]interface{}, warning string) {
+	if earlier, ok := payload["warning"].(string); ok && earlier != "" {
+		warning = earlier + "; " + warning
+	}
+	payload["warning"] = warning
+}
+
+// BatchGetUserInfo resolves user information, serving cached usernames directly and
+// calling the user-service gRPC endpoint only for the rest
+// Large ID lists are split into batches of userInfoBatchSize, with at most userInfoConcurrency calls in flight
+// ctx should derive from the incoming request so a client disconnect cancels outstanding calls;
+// it is bounded by userTimeout when the caller set no deadline, and the first failure cancels the rest
 func (g *Gateway) BatchGetUserInfo(ctx context.Context, userIDs []int64) (map[int64]*pb.UserInfo, error) {
+	cached, userIDs := g.userCache.Lookup(userIDs)
+	users := make(map[int64]*pb.UserInfo, len(cached)+len(userIDs))
+	for userID, username := range cached {
+		users[userID] = &pb.UserInfo{UserId: userID, Username: username}
+	}
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+
 	if g.grpcClient == nil {
 		return nil, fmt.Errorf("gRPC client not initialized")
 	}
 
-	req := &pb.BatchGetUserInfoRequest{
-		UserIds: userIDs,
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, g.userTimeout)
+		defer cancelTimeout()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	usersMutex := &sync.Mutex{}
+	sem := make(chan struct{}, g.userInfoConcurrency)
+	errChan := make(chan error, (len(userIDs)+g.userInfoBatchSize-1)/g.userInfoBatchSize)
+	var wg sync.WaitGroup
+
+	for start := 0; start < len(userIDs); start += g.userInfoBatchSize {
+		end := min(start+g.userInfoBatchSize, len(userIDs))
+
+		wg.Add(1)
+		go func(batch []int64) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errChan <- fmt.Errorf("gRPC call not started: %w", ctx.Err())
+				return
+			}
+			defer func() { <-sem }()
+
+			resp, err := g.grpcClient.BatchGetUserInfo(ctx, &pb.BatchGetUserInfoRequest{
+				UserIds: batch,
+			})
+			if err != nil {
+				errChan <- fmt.Errorf("gRPC call failed: %w", err)
+				cancel()
+				return
+			}
+
+			if resp.ErrorCode != "" {
+				errChan <- fmt.Errorf("user service error: %s - %s", resp.ErrorCode, resp.ErrorMessage)
+				cancel()
+				return
+			}
+
+			usersMutex.Lock()
+			for userID, userInfo := range resp.Users {
+				users[userID] = userInfo
+				g.userCache.Set(userID, userInfo.Username)
+			}
+			usersMutex.Unlock()
+		}(userIDs[start:end])
+	}
+
+	wg.Wait()
+	close(errChan)
+
+	// Check for errors
+	for err := range errChan {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return users, nil
+}
+
+// enrichTimelineAuthors fills in author_name for timeline posts that only carry an author_id
+// The rest of the payload is passed through untouched
+func (g *Gateway) enrichTimelineAuthors(ctx context.Context, body []byte) ([]byte, error) {
+	var payload map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber() // Keep int64 IDs exact
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode timeline response: %w", err)
+	}
+
+	posts, ok := payload["timeline"].([]interface{})
+	if !ok || len(posts) == 0 {
+		return body, nil
+	}
+
+	// Collect distinct authors whose names are missing
+	missing := make(map[int64]struct{})
+	for _, p := range posts {
+		post, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _ := post["author_name"].(string); name != "" {
+			continue
+		}
+		if authorID, ok := post["author_id"].(json.Number); ok {
+			if id, err := authorID.Int64(); err == nil {
+				missing[id] = struct{}{}
+			}
+		}
+	}
+
+	if len(missing) == 0 {
+		return body, nil
+	}
+
+	authorIDs := make([]int64, 0, len(missing))
+	for id := range missing {
+		authorIDs = append(authorIDs, id)
 	}
 
-	resp, err := g.grpcClient.BatchGetUserInfo(ctx, req)
+	users, err := g.BatchGetUserInfo(ctx, authorIDs)
 	if err != nil {
-		return nil, fmt.Errorf("gRPC call failed: %w", err)
+		return nil, err
 	}
 
-	if resp.ErrorCode != "" {
-		return nil, fmt.Errorf("user service error: %s - %s", resp.ErrorCode, resp.ErrorMessage)
+	// Backfill author names; unresolved authors keep an empty name
+	for _, p := range posts {
+		post, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _ := post["author_name"].(string); name != "" {
+			continue
+		}
+		if authorID, ok := post["author_id"].(json.Number); ok {
+			if id, err := authorID.Int64(); err == nil {
+				if user, found := users[id]; found {
+					post["author_name"] = user.Username
+				}
+			}
+		}
 	}
 
-	return resp.Users, nil
+	return json.Marshal(payload)
 }
 
 // forwardToTimelineService forwards all timeline-related requests to the timeline service
@@ -251,9 +596,10 @@ func (g *Gateway) forwardToTimelineService(w http.ResponseWriter, r *http.Reques
 		body = bytes.NewReader(bodyBytes)
 	}
 
-	// Create the forwarding request
-	client := &http.Client{Timeout: 30 * time.Second}
-	req, err := http.NewRequest(r.Method, targetURL, body)
+	// Create the forwarding request, cancelled if the client goes away
+	ctx, cancel := context.WithTimeout(r.Context(), g.timelineTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, r.Method, targetURL, body)
 	if err != nil {
 		log.Printf("Failed to create request to timeline service: %v", err)
 		writeErrorResponse(w, "Internal server error", http.StatusInternalServerError)
@@ -268,7 +614,7 @@ func (g *Gateway) forwardToTimelineService(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Forward the request
-	resp, err := client.Do(req)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		log.Printf("Failed to forward request to timeline service: %v", err)
 		writeErrorResponse(w, "Failed to communicate with timeline service", http.StatusServiceUnavailable)
@@ -276,6 +622,40 @@ func (g *Gateway) forwardToTimelineService(w http.ResponseWriter, r *http.Reques
 	}
 	defer resp.Body.Close()
 
+	// Resolve missing author names at the edge for successful timeline reads
+	if r.Method == http.MethodGet && resp.StatusCode == http.StatusOK && g.grpcClient != nil {
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			log.Printf("Failed to read timeline service response: %v", err)
+			writeErrorResponse(w, "Failed to read timeline service response", http.StatusBadGateway)
+			return
+		}
+
+		enrichCtx, enrichCancel := context.WithTimeout(r.Context(), g.userTimeout)
+		enriched, err := g.enrichTimelineAuthors(enrichCtx, respBody)
+		enrichCancel()
+		if err != nil {
+			// Fall back to the original payload rather than failing the request
+			log.Printf("Failed to enrich timeline authors: %v", err)
+		} else {
+			respBody = enriched
+		}
+
+		// Copy response headers, dropping Content-Length since the body may have changed
+		for name, headers := range resp.Header {
+			if name == "Content-Length" {
+				continue
+			}
+			for _, h := range headers {
+				w.Header().Add(name, h)
+			}
+		}
+
+		w.WriteHeader(resp.StatusCode)
+		w.Write(respBody)
+		return
+	}
+
 	// Copy response headers
 	for name, headers := range resp.Header {
 		for _, h := range headers {
@@ -288,15 +668,37 @@ func (g *Gateway) forwardToTimelineService(w http.ResponseWriter, r *http.Reques
 	io.Copy(w, resp.Body)
 }
 
-func healthHandler(w http.ResponseWriter, r *http.Request) {
+func (g *Gateway) healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"status":    "healthy",
-		"service":   "web-service",
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "healthy",
+		"service":    "web-service",
+		"version":    version,
+		"uptime":     time.Since(startTime).Round(time.Second).String(),
+		"timestamp":  time.Now().UTC().Format(time.RFC3339),
+		"user_cache": g.userCache.Stats(),
 	})
 }
 
+// validatePaginationParams checks that page and limit, when present, are non-negative integers
+// Range clamping is left to the user-service so existing callers keep working
+func validatePaginationParams(query url.Values) error {
+	for _, param := range []string{"page", "limit"} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be an integer", param)
+		}
+		if n < 0 {
+			return fmt.Errorf("%s must not be negative", param)
+		}
+	}
+	return nil
+}
+
 func writeErrorResponse(w http.ResponseWriter, message string, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -324,3 +726,30 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intVal, err := strconv.Atoi(value); err == nil && intVal > 0 {
+			return intVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
@@ -140,6 +140,9 @@ module "ecs" {
   post_service_url       = var.post_service_url
   post_service_grpc_host = var.post_service_grpc_host
   
+  # Web service needs to know where social-graph-service is
+  social_graph_service_grpc_host = var.social_graph_service_grpc_host
+  
   # Web service needs to know where timeline-service is
   timeline_service_url   = var.timeline_service_url
 
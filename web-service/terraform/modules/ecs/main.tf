@@ -55,6 +55,10 @@ resource "aws_ecs_task_definition" "app" {
           name  = "POST_SERVICE_GRPC_HOST"
           value = var.post_service_grpc_host
         },
+        {
+          name  = "SOCIAL_GRAPH_SERVICE_GRPC_HOST"
+          value = var.social_graph_service_grpc_host
+        },
         {
           name  = "TIMELINE_SERVICE_URL"
           value = var.timeline_service_url
@@ -87,6 +87,13 @@ variable "post_service_grpc_host" {
   default     = ""
 }
 
+# Social Graph Service gRPC endpoint
+variable "social_graph_service_grpc_host" {
+  type        = string
+  description = "gRPC endpoint for social-graph-service (host:port)"
+  default     = ""
+}
+
 # Web service specific: URL to communicate with timeline-service
 variable "timeline_service_url" {
   type        = string
@@ -103,6 +103,13 @@ variable "post_service_grpc_host" {
   default     = "post-service-grpc:50053"
 }
 
+# Social Graph Service gRPC endpoint
+variable "social_graph_service_grpc_host" {
+  description = "gRPC endpoint for social-graph-service (host:port)"
+  type        = string
+  default     = "social-graph-service-grpc:50052"
+}
+
 # Timeline Service URL (internal communication)
 variable "timeline_service_url" {
   description = "Internal URL for timeline-service communication"